@@ -0,0 +1,177 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kalmhq/kalm/api/log"
+	"github.com/kalmhq/kalm/api/resources"
+	"github.com/kalmhq/kalm/api/server"
+	"github.com/kalmhq/kalm/controller/api/v1alpha1"
+	"github.com/kalmhq/kalm/controller/controllers"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+	coreV1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// The activator receives requests to components scaled to zero, the original destination is in the target header.
+// It wakes the component up, waits until the component has ready endpoints and forwards the request to it.
+
+const (
+	readyTimeout      = 2 * time.Minute
+	readyPollInterval = 500 * time.Millisecond
+)
+
+var logger *zap.Logger
+var resourceManager *resources.ResourceManager
+
+// requests to the same component only wake it up once
+var wakingMut = &sync.Mutex{}
+
+type activatorTarget struct {
+	host      string
+	name      string
+	namespace string
+}
+
+// parseTarget parses the target header, e.g. web.default.svc.cluster.local:8080
+func parseTarget(target string) (*activatorTarget, error) {
+	host := target
+
+	if colon := strings.LastIndexByte(target, ':'); colon >= 0 {
+		host = target[:colon]
+	}
+
+	parts := strings.Split(strings.TrimSuffix(host, ".svc.cluster.local"), ".")
+
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return nil, fmt.Errorf("invalid target %s", target)
+	}
+
+	return &activatorTarget{
+		host:      target,
+		name:      parts[0],
+		namespace: parts[1],
+	}, nil
+}
+
+func wakeUpComponent(target *activatorTarget) error {
+	wakingMut.Lock()
+	defer wakingMut.Unlock()
+
+	var component v1alpha1.Component
+
+	if err := resourceManager.Get(target.namespace, target.name, &component); err != nil {
+		return err
+	}
+
+	// only components with scale to zero can be woken up through the activator
+	if component.Spec.ScaleToZero == nil {
+		return fmt.Errorf("component %s/%s can't be scaled to zero", target.namespace, target.name)
+	}
+
+	if _, scaledToZero := component.Annotations[v1alpha1.KalmAnnoScaledToZeroAt]; !scaledToZero {
+		return nil
+	}
+
+	// routes keep sending requests to the activator until the controller sees available replicas and removes waking-at
+	now := time.Now().UTC().Format(time.RFC3339)
+	copied := component.DeepCopy()
+	delete(copied.Annotations, v1alpha1.KalmAnnoScaledToZeroAt)
+	copied.Annotations[v1alpha1.KalmAnnoWakingAt] = now
+	copied.Annotations[v1alpha1.KalmAnnoWokenAt] = now
+
+	if err := resourceManager.Patch(copied, client.MergeFrom(&component)); err != nil {
+		return err
+	}
+
+	logger.Info("component is woken up", zap.String("ns", target.namespace), zap.String("name", target.name))
+
+	return nil
+}
+
+func isComponentReady(target *activatorTarget) bool {
+	var endpoints coreV1.Endpoints
+
+	if err := resourceManager.Get(target.namespace, target.name, &endpoints); err != nil {
+		return false
+	}
+
+	for _, subset := range endpoints.Subsets {
+		if len(subset.Addresses) > 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+func waitForComponentReady(c echo.Context, target *activatorTarget) bool {
+	timeout := time.After(readyTimeout)
+	ticker := time.NewTicker(readyPollInterval)
+	defer ticker.Stop()
+
+	for {
+		if isComponentReady(target) {
+			return true
+		}
+
+		select {
+		case <-c.Request().Context().Done():
+			return false
+		case <-timeout:
+			return false
+		case <-ticker.C:
+		}
+	}
+}
+
+func handleActivate(c echo.Context) error {
+	target, err := parseTarget(c.Request().Header.Get(controllers.KALM_ACTIVATOR_TARGET_HEADER))
+
+	if err != nil {
+		return c.String(http.StatusBadRequest, err.Error())
+	}
+
+	if err := wakeUpComponent(target); err != nil {
+		logger.Error("wake up component failed", zap.String("target", target.host), zap.Error(err))
+		return c.String(http.StatusBadGateway, err.Error())
+	}
+
+	if !waitForComponentReady(c, target) {
+		return c.String(http.StatusGatewayTimeout, fmt.Sprintf("component %s/%s is not ready in time", target.namespace, target.name))
+	}
+
+	c.Request().Header.Del(controllers.KALM_ACTIVATOR_TARGET_HEADER)
+
+	proxy := httputil.NewSingleHostReverseProxy(&url.URL{Scheme: "http", Host: target.host})
+	proxy.ServeHTTP(c.Response(), c.Request())
+
+	return nil
+}
+
+func main() {
+	logger = log.NewLogger(false)
+
+	cfg, err := rest.InClusterConfig()
+
+	if err != nil {
+		panic(err)
+	}
+
+	resourceManager = resources.NewResourceManager(cfg, logger)
+
+	e := server.NewEchoInstance()
+	e.Any("/*", handleActivate)
+
+	if err := e.Start("0.0.0.0:3003"); err != nil {
+		panic(err)
+	}
+}
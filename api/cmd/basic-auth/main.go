@@ -0,0 +1,156 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/kalmhq/kalm/api/log"
+	"github.com/kalmhq/kalm/api/resources"
+	"github.com/kalmhq/kalm/api/server"
+	"github.com/kalmhq/kalm/controller/api/v1alpha1"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
+	coreV1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/rest"
+)
+
+// The basic auth component checks credentials of requests to routes with basic auth for the ingress gateway.
+// Only secrets used by basic auth of routes can be checked, so it can't be used to guess passwords of other secrets.
+// Credentials which passed the check are cached, bcrypt is too slow to be run on every request.
+
+const cacheTTL = 30 * time.Second
+
+var logger *zap.Logger
+var resourceManager *resources.ResourceManager
+
+var mut = &sync.Mutex{}
+
+// secrets used by routes, "namespace/name" -> true
+var usedSecrets map[string]bool
+var usedSecretsLoadedAt time.Time
+
+// hash of the secret version and credentials -> expiration of the passed check
+var passedChecks = make(map[string]time.Time)
+
+func isSecretUsedByRoutes(namespace, name string) (bool, error) {
+	mut.Lock()
+	defer mut.Unlock()
+
+	if time.Since(usedSecretsLoadedAt) > cacheTTL {
+		var routes v1alpha1.HttpRouteList
+
+		if err := resourceManager.List(&routes); err != nil {
+			return false, err
+		}
+
+		usedSecrets = make(map[string]bool)
+
+		for _, route := range routes.Items {
+			if route.Spec.AccessControl != nil && route.Spec.AccessControl.BasicAuth != nil {
+				basicAuth := route.Spec.AccessControl.BasicAuth
+				usedSecrets[basicAuth.SecretNamespace+"/"+basicAuth.SecretName] = true
+			}
+		}
+
+		usedSecretsLoadedAt = time.Now()
+	}
+
+	return usedSecrets[namespace+"/"+name], nil
+}
+
+func getCheckKey(secret *coreV1.Secret, user, password string) string {
+	sum := sha256.Sum256([]byte(string(secret.UID) + "\n" + secret.ResourceVersion + "\n" + user + "\n" + password))
+	return hex.EncodeToString(sum[:])
+}
+
+func isPassedRecently(key string) bool {
+	mut.Lock()
+	defer mut.Unlock()
+
+	now := time.Now()
+
+	for k, expiration := range passedChecks {
+		if now.After(expiration) {
+			delete(passedChecks, k)
+		}
+	}
+
+	_, exist := passedChecks[key]
+
+	return exist
+}
+
+func recordPassedCheck(key string) {
+	mut.Lock()
+	defer mut.Unlock()
+
+	passedChecks[key] = time.Now().Add(cacheTTL)
+}
+
+func handleCheck(c echo.Context) error {
+	namespace := c.Param("namespace")
+	name := c.Param("name")
+
+	used, err := isSecretUsedByRoutes(namespace, name)
+
+	if err != nil {
+		logger.Error("list routes failed", zap.Error(err))
+		return c.NoContent(http.StatusInternalServerError)
+	}
+
+	if !used {
+		return c.NoContent(http.StatusForbidden)
+	}
+
+	user, password, ok := c.Request().BasicAuth()
+
+	if !ok {
+		return c.NoContent(http.StatusUnauthorized)
+	}
+
+	var secret coreV1.Secret
+
+	if err := resourceManager.Get(namespace, name, &secret); err != nil {
+		logger.Error("get secret failed", zap.String("ns", namespace), zap.String("name", name), zap.Error(err))
+		return c.NoContent(http.StatusInternalServerError)
+	}
+
+	key := getCheckKey(&secret, user, password)
+
+	if isPassedRecently(key) {
+		return c.NoContent(http.StatusOK)
+	}
+
+	hash, exist := secret.Data[user]
+
+	if !exist || bcrypt.CompareHashAndPassword(hash, []byte(password)) != nil {
+		return c.NoContent(http.StatusUnauthorized)
+	}
+
+	recordPassedCheck(key)
+
+	return c.NoContent(http.StatusOK)
+}
+
+func main() {
+	logger = log.NewLogger(false)
+
+	cfg, err := rest.InClusterConfig()
+
+	if err != nil {
+		panic(err)
+	}
+
+	resourceManager = resources.NewResourceManager(cfg, logger)
+
+	e := server.NewEchoInstance()
+	e.GET("/check/:namespace/:name", handleCheck)
+
+	if err := e.Start("0.0.0.0:3004"); err != nil {
+		panic(err)
+	}
+}
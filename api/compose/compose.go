@@ -0,0 +1,578 @@
+// Package compose converts docker-compose files into components and routes of a Kalm application.
+//
+// Services become server components, ports and expose become ports, environment becomes env vars,
+// named volumes become pvc volumes, depends_on becomes startAfterComponents and healthcheck becomes probes.
+// Keys which can't be converted are reported instead of failing the conversion.
+package compose
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/kalmhq/kalm/controller/api/v1alpha1"
+	"gopkg.in/yaml.v3"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var DefaultVolumeSize = resource.MustParse("1Gi")
+
+type Options struct {
+	// the application the components are created in, it's used by destinations of routes
+	Application string
+
+	// if not empty, each service with published ports gets a route on <component>.<RouteDomain>,
+	// which sends requests to the first published port
+	RouteDomain string
+
+	// size of pvc volumes of named volumes, DefaultVolumeSize if nil
+	VolumeSize *resource.Quantity
+}
+
+type Result struct {
+	Components []*v1alpha1.Component `json:"components"`
+	HttpRoutes []*v1alpha1.HttpRoute `json:"httpRoutes"`
+
+	// keys of the compose file which are not converted, e.g. services.web.build
+	Unsupported []string `json:"unsupported"`
+}
+
+type converter struct {
+	options Options
+	result  *Result
+
+	// named volumes declared in the top-level volumes
+}
+
+// Convert parses the compose file, components are sorted by the name of their services.
+func Convert(data []byte, options Options) (*Result, error) {
+	var file map[string]interface{}
+
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("invalid compose file: %s", err)
+	}
+
+	services, ok := file["services"].(map[string]interface{})
+
+	if !ok || len(services) == 0 {
+		return nil, fmt.Errorf("no services in the compose file")
+	}
+
+	if options.VolumeSize == nil {
+		options.VolumeSize = &DefaultVolumeSize
+	}
+
+	c := &converter{
+		options: options,
+		result:  &Result{Unsupported: []string{}},
+	}
+
+	for _, key := range sortedKeys(file) {
+		switch key {
+		case "version", "services":
+		case "volumes":
+			if err := c.convertNamedVolumes(file[key]); err != nil {
+				return nil, err
+			}
+		default:
+			c.unsupported(key)
+		}
+	}
+
+	for _, name := range sortedKeys(services) {
+		service, ok := services[name].(map[string]interface{})
+
+		if !ok {
+			return nil, fmt.Errorf("services.%s should be a map", name)
+		}
+
+		if err := c.convertService(name, service); err != nil {
+			return nil, err
+		}
+	}
+
+	return c.result, nil
+}
+
+// ComponentName turns a service name into a valid component name, e.g. my_web => my-web
+func ComponentName(service string) string {
+	return strings.NewReplacer("_", "-", ".", "-").Replace(strings.ToLower(service))
+}
+
+func (c *converter) unsupported(path string) {
+	// extension fields are for compose files themselves
+	if strings.HasPrefix(path[strings.LastIndexByte(path, '.')+1:], "x-") {
+		return
+	}
+
+	c.result.Unsupported = append(c.result.Unsupported, path)
+}
+
+func (c *converter) convertNamedVolumes(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+
+	volumes, ok := value.(map[string]interface{})
+
+	if !ok {
+		return fmt.Errorf("volumes should be a map")
+	}
+
+	for _, name := range sortedKeys(volumes) {
+		// driver, external etc. are decided by the storage class of the cluster
+		if config, ok := volumes[name].(map[string]interface{}); ok {
+			for _, key := range sortedKeys(config) {
+				c.unsupported(fmt.Sprintf("volumes.%s.%s", name, key))
+			}
+		}
+	}
+
+	return nil
+}
+
+func (c *converter) convertService(name string, service map[string]interface{}) error {
+	path := "services." + name
+
+	component := &v1alpha1.Component{
+		ObjectMeta: metaV1.ObjectMeta{
+			Name:      ComponentName(name),
+			Namespace: c.options.Application,
+		},
+		Spec: v1alpha1.ComponentSpec{
+			WorkloadType: v1alpha1.WorkloadTypeServer,
+		},
+	}
+
+	// published ports are accessible out of the application
+	var publishedPorts []uint32
+
+	for _, key := range sortedKeys(service) {
+		value := service[key]
+		keyPath := path + "." + key
+
+		var err error
+
+		switch key {
+		case "image":
+			component.Spec.Image = toString(value)
+		case "command":
+			component.Spec.Args, err = convertCommand(value)
+		case "environment":
+			component.Spec.Env, err = c.convertEnvironment(keyPath, value)
+		case "ports":
+			publishedPorts, err = c.convertPorts(component, keyPath, value)
+		case "expose":
+			err = c.convertExpose(component, keyPath, value)
+		case "volumes":
+			component.Spec.Volumes, err = c.convertVolumes(keyPath, value)
+		case "depends_on":
+			component.Spec.StartAfterComponents, err = convertDependsOn(value)
+		case "healthcheck":
+			err = c.convertHealthcheck(component, keyPath, value)
+		case "deploy":
+			err = c.convertDeploy(component, keyPath, value)
+		case "restart":
+			// pods of server components are always restarted
+			if restart := toString(value); restart != "always" && restart != "unless-stopped" {
+				c.unsupported(keyPath)
+			}
+		default:
+			c.unsupported(keyPath)
+		}
+
+		if err != nil {
+			return fmt.Errorf("invalid %s: %s", keyPath, err)
+		}
+	}
+
+	if component.Spec.Image == "" {
+		return fmt.Errorf("%s.image is required, building images is not supported", path)
+	}
+
+	c.result.Components = append(c.result.Components, component)
+
+	if c.options.RouteDomain != "" && len(publishedPorts) > 0 {
+		c.result.HttpRoutes = append(c.result.HttpRoutes, c.buildHttpRoute(component, publishedPorts[0]))
+	}
+
+	return nil
+}
+
+func (c *converter) buildHttpRoute(component *v1alpha1.Component, port uint32) *v1alpha1.HttpRoute {
+	return &v1alpha1.HttpRoute{
+		ObjectMeta: metaV1.ObjectMeta{
+			// routes are not namespaced
+			Name: fmt.Sprintf("%s-%s", c.options.Application, component.Name),
+		},
+		Spec: v1alpha1.HttpRouteSpec{
+			Hosts:   []string{fmt.Sprintf("%s.%s", component.Name, c.options.RouteDomain)},
+			Paths:   []string{"/"},
+			Schemes: []v1alpha1.HttpRouteScheme{"http", "https"},
+			Methods: []v1alpha1.HttpRouteMethod{
+				"GET", "HEAD", "POST", "PUT", "PATCH", "DELETE", "OPTIONS", "TRACE", "CONNECT",
+			},
+			Destinations: []v1alpha1.HttpRouteDestination{
+				{
+					Host:   fmt.Sprintf("%s.%s.svc.cluster.local:%d", component.Name, c.options.Application, port),
+					Weight: 1,
+				},
+			},
+		},
+	}
+}
+
+// convertCommand converts the command of the service into args of the container,
+// the command only replaces the CMD of the image, the ENTRYPOINT is kept.
+func convertCommand(value interface{}) ([]string, error) {
+	switch command := value.(type) {
+	case string:
+		return splitCommand(command)
+	case []interface{}:
+		args := make([]string, 0, len(command))
+
+		for _, part := range command {
+			args = append(args, toString(part))
+		}
+
+		return args, nil
+	default:
+		return nil, fmt.Errorf("should be a string or a list")
+	}
+}
+
+// splitCommand splits a string command into words like a shell does, without expanding anything.
+func splitCommand(command string) ([]string, error) {
+	var args []string
+	var word strings.Builder
+	var quote rune
+	inWord, escaped := false, false
+
+	for _, ch := range command {
+		switch {
+		case escaped:
+			word.WriteRune(ch)
+			escaped = false
+		case ch == '\\' && quote != '\'':
+			escaped, inWord = true, true
+		case quote != 0:
+			if ch == quote {
+				quote = 0
+			} else {
+				word.WriteRune(ch)
+			}
+		case ch == '"' || ch == '\'':
+			quote, inWord = ch, true
+		case unicode.IsSpace(ch):
+			if inWord {
+				args = append(args, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(ch)
+			inWord = true
+		}
+	}
+
+	if quote != 0 || escaped {
+		return nil, fmt.Errorf("unterminated quote or escape")
+	}
+
+	if inWord {
+		args = append(args, word.String())
+	}
+
+	return args, nil
+}
+
+// convertEnvironment converts both the map and the list syntax.
+// Values taken from the shell running compose are not supported.
+func (c *converter) convertEnvironment(path string, value interface{}) ([]v1alpha1.EnvVar, error) {
+	var envs []v1alpha1.EnvVar
+
+	add := func(name string, value interface{}, hasValue bool) {
+		if !hasValue {
+			c.unsupported(path + "." + name)
+			return
+		}
+
+		envs = append(envs, v1alpha1.EnvVar{
+			Name:  name,
+			Value: toString(value),
+			Type:  v1alpha1.EnvVarTypeStatic,
+		})
+	}
+
+	switch environment := value.(type) {
+	case map[string]interface{}:
+		for _, name := range sortedKeys(environment) {
+			add(name, environment[name], environment[name] != nil)
+		}
+	case []interface{}:
+		for _, item := range environment {
+			parts := strings.SplitN(toString(item), "=", 2)
+
+			if len(parts) == 2 {
+				add(parts[0], parts[1], true)
+			} else {
+				add(parts[0], nil, false)
+			}
+		}
+	default:
+		return nil, fmt.Errorf("should be a map or a list")
+	}
+
+	return envs, nil
+}
+
+// parsePort parses the short syntax of ports, e.g. "8080", "80:8080", "127.0.0.1:80:8080/udp"
+func parsePort(spec string) (containerPort uint32, published bool, protocol string, err error) {
+	protocol = "tcp"
+
+	if slash := strings.IndexByte(spec, '/'); slash >= 0 {
+		protocol = spec[slash+1:]
+		spec = spec[:slash]
+	}
+
+	parts := strings.Split(spec, ":")
+
+	if strings.Contains(parts[len(parts)-1], "-") {
+		return 0, false, "", fmt.Errorf("port ranges are not supported")
+	}
+
+	port, err := strconv.ParseUint(parts[len(parts)-1], 10, 16)
+
+	if err != nil || port == 0 {
+		return 0, false, "", fmt.Errorf("invalid port %s", spec)
+	}
+
+	return uint32(port), len(parts) > 1, protocol, nil
+}
+
+func addPort(component *v1alpha1.Component, containerPort uint32, protocol string) {
+	for _, port := range component.Spec.Ports {
+		if port.ContainerPort == containerPort {
+			return
+		}
+	}
+
+	portProtocol := v1alpha1.PortProtocolTCP
+
+	if protocol == "udp" {
+		portProtocol = v1alpha1.PortProtocolUDP
+	}
+
+	// other services connect to the container port in compose, so the service port is the same
+	component.Spec.Ports = append(component.Spec.Ports, v1alpha1.Port{
+		ContainerPort: containerPort,
+		ServicePort:   containerPort,
+		Protocol:      portProtocol,
+	})
+}
+
+// convertPorts converts both the short and the long syntax, and returns container ports which are published.
+func (c *converter) convertPorts(component *v1alpha1.Component, path string, value interface{}) ([]uint32, error) {
+	ports, ok := value.([]interface{})
+
+	if !ok {
+		return nil, fmt.Errorf("should be a list")
+	}
+
+	var publishedPorts []uint32
+
+	for i, item := range ports {
+		var containerPort uint32
+		var published bool
+		protocol := "tcp"
+
+		if long, ok := item.(map[string]interface{}); ok {
+			port, err := strconv.ParseUint(toString(long["target"]), 10, 16)
+
+			if err != nil || port == 0 {
+				return nil, fmt.Errorf("invalid target of port %d", i)
+			}
+
+			containerPort = uint32(port)
+			published = long["published"] != nil
+
+			if long["protocol"] != nil {
+				protocol = toString(long["protocol"])
+			}
+
+			for _, key := range sortedKeys(long) {
+				if key != "target" && key != "published" && key != "protocol" {
+					c.unsupported(fmt.Sprintf("%s[%d].%s", path, i, key))
+				}
+			}
+		} else {
+			var err error
+
+			if containerPort, published, protocol, err = parsePort(toString(item)); err != nil {
+				return nil, err
+			}
+		}
+
+		addPort(component, containerPort, protocol)
+
+		if published && protocol != "udp" {
+			publishedPorts = append(publishedPorts, containerPort)
+		}
+	}
+
+	return publishedPorts, nil
+}
+
+func (c *converter) convertExpose(component *v1alpha1.Component, path string, value interface{}) error {
+	ports, ok := value.([]interface{})
+
+	if !ok {
+		return fmt.Errorf("should be a list")
+	}
+
+	for _, item := range ports {
+		containerPort, _, protocol, err := parsePort(toString(item))
+
+		if err != nil {
+			return err
+		}
+
+		addPort(component, containerPort, protocol)
+	}
+
+	return nil
+}
+
+// convertVolumes converts named volumes to pvc volumes and anonymous volumes to temporary volumes.
+// Bind mounts of host paths are not supported.
+func (c *converter) convertVolumes(path string, value interface{}) ([]v1alpha1.Volume, error) {
+	items, ok := value.([]interface{})
+
+	if !ok {
+		return nil, fmt.Errorf("should be a list")
+	}
+
+	var volumes []v1alpha1.Volume
+
+	for i, item := range items {
+		var volumeType, source, target string
+
+		if long, ok := item.(map[string]interface{}); ok {
+			volumeType = toString(long["type"])
+			source = toString(long["source"])
+			target = toString(long["target"])
+		} else {
+			parts := strings.Split(toString(item), ":")
+			target = parts[0]
+
+			if len(parts) > 1 {
+				source = parts[0]
+				target = parts[1]
+			}
+
+			volumeType = "volume"
+
+			if strings.HasPrefix(source, ".") || strings.HasPrefix(source, "/") || strings.HasPrefix(source, "~") {
+				volumeType = "bind"
+			}
+		}
+
+		if target == "" {
+			return nil, fmt.Errorf("volume %d has no target", i)
+		}
+
+		switch {
+		case volumeType == "tmpfs":
+			volumes = append(volumes, v1alpha1.Volume{Path: target, Type: v1alpha1.VolumeTypeTemporaryMemory})
+		case volumeType == "volume" && source == "":
+			volumes = append(volumes, v1alpha1.Volume{Path: target, Type: v1alpha1.VolumeTypeTemporaryDisk})
+		case volumeType == "volume":
+			// components using the same named volume share the pvc
+			volumes = append(volumes, v1alpha1.Volume{
+				Path: target,
+				Type: v1alpha1.VolumeTypePersistentVolumeClaim,
+				Size: c.options.VolumeSize.DeepCopy(),
+				PVC:  ComponentName(source),
+			})
+		default:
+			c.unsupported(fmt.Sprintf("%s[%d]", path, i))
+		}
+	}
+
+	return volumes, nil
+}
+
+// convertDependsOn converts both the list and the map syntax, conditions are ignored
+// as components are started after their dependencies are available.
+func convertDependsOn(value interface{}) ([]string, error) {
+	var names []string
+
+	switch dependsOn := value.(type) {
+	case []interface{}:
+		for _, name := range dependsOn {
+			names = append(names, toString(name))
+		}
+	case map[string]interface{}:
+		names = sortedKeys(dependsOn)
+	default:
+		return nil, fmt.Errorf("should be a list or a map")
+	}
+
+	for i := range names {
+		names[i] = ComponentName(names[i])
+	}
+
+	return names, nil
+}
+
+func (c *converter) convertDeploy(component *v1alpha1.Component, path string, value interface{}) error {
+	deploy, ok := value.(map[string]interface{})
+
+	if !ok {
+		return fmt.Errorf("should be a map")
+	}
+
+	for _, key := range sortedKeys(deploy) {
+		if key != "replicas" {
+			c.unsupported(path + "." + key)
+			continue
+		}
+
+		replicas, err := strconv.ParseInt(toString(deploy[key]), 10, 32)
+
+		if err != nil || replicas < 0 {
+			return fmt.Errorf("invalid replicas")
+		}
+
+		r := int32(replicas)
+		component.Spec.Replicas = &r
+	}
+
+	return nil
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+
+	for key := range m {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
+
+func toString(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	default:
+		return fmt.Sprint(v)
+	}
+}
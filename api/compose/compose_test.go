@@ -0,0 +1,201 @@
+package compose
+
+import (
+	"testing"
+
+	"github.com/kalmhq/kalm/controller/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+)
+
+const testComposeFile = `
+version: "3.8"
+services:
+  web_app:
+    image: example/web:v1
+    command: ["npm", "run", "start"]
+    ports:
+      - "80:3000"
+      - target: 9229
+        protocol: tcp
+    expose:
+      - "3000"
+    environment:
+      NODE_ENV: production
+      PORT: 3000
+      SECRET:
+    volumes:
+      - uploads:/app/uploads
+      - ./src:/app/src
+      - /tmp/cache
+    depends_on:
+      - db
+    healthcheck:
+      test: ["CMD", "curl", "-f", "http://localhost:3000"]
+      interval: 1m30s
+      timeout: 10s
+      retries: 3
+      start_period: 40s
+    deploy:
+      replicas: 2
+      resources:
+        limits:
+          cpus: "0.5"
+    restart: always
+  db:
+    image: postgres:12
+    environment:
+      - POSTGRES_PASSWORD=secret
+      - POSTGRES_USER
+    volumes:
+      - type: volume
+        source: db_data
+        target: /var/lib/postgresql/data
+    healthcheck:
+      test: pg_isready
+    build: ./db
+    x-custom: true
+volumes:
+  uploads:
+  db_data:
+    driver: local
+networks:
+  default:
+`
+
+func TestConvert(t *testing.T) {
+	result, err := Convert([]byte(testComposeFile), Options{Application: "shop", RouteDomain: "example.com"})
+
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	assert.Equal(t, []string{
+		"networks",
+		"volumes.db_data.driver",
+		"services.db.build",
+		"services.db.environment.POSTGRES_USER",
+		"services.web_app.deploy.resources",
+		"services.web_app.environment.SECRET",
+		"services.web_app.volumes[1]",
+	}, result.Unsupported)
+
+	assert.Len(t, result.Components, 2)
+
+	db := result.Components[0]
+	assert.Equal(t, "db", db.Name)
+	assert.Equal(t, "shop", db.Namespace)
+	assert.Equal(t, []v1alpha1.EnvVar{{Name: "POSTGRES_PASSWORD", Value: "secret", Type: v1alpha1.EnvVarTypeStatic}}, db.Spec.Env)
+	assert.Equal(t, "db-data", db.Spec.Volumes[0].PVC)
+	assert.Equal(t, []string{"sh", "-c", "pg_isready"}, db.Spec.ReadinessProbe.Exec.Command)
+	assert.Empty(t, db.Spec.Ports)
+
+	web := result.Components[1]
+	assert.Equal(t, "web-app", web.Name)
+	assert.Equal(t, v1alpha1.WorkloadTypeServer, web.Spec.WorkloadType)
+	assert.Equal(t, "example/web:v1", web.Spec.Image)
+	assert.Equal(t, "", web.Spec.Command)
+	assert.Equal(t, []string{"npm", "run", "start"}, web.Spec.Args)
+	assert.Equal(t, int32(2), *web.Spec.Replicas)
+	assert.Equal(t, []string{"db"}, web.Spec.StartAfterComponents)
+	assert.Equal(t, []v1alpha1.Port{
+		{ContainerPort: 3000, ServicePort: 3000, Protocol: v1alpha1.PortProtocolTCP},
+		{ContainerPort: 9229, ServicePort: 9229, Protocol: v1alpha1.PortProtocolTCP},
+	}, web.Spec.Ports)
+	assert.Equal(t, []v1alpha1.EnvVar{
+		{Name: "NODE_ENV", Value: "production", Type: v1alpha1.EnvVarTypeStatic},
+		{Name: "PORT", Value: "3000", Type: v1alpha1.EnvVarTypeStatic},
+	}, web.Spec.Env)
+
+	assert.Len(t, web.Spec.Volumes, 2)
+	assert.Equal(t, v1alpha1.VolumeTypePersistentVolumeClaim, web.Spec.Volumes[0].Type)
+	assert.Equal(t, "uploads", web.Spec.Volumes[0].PVC)
+	assert.Equal(t, "1Gi", web.Spec.Volumes[0].Size.String())
+	assert.Equal(t, v1alpha1.VolumeTypeTemporaryDisk, web.Spec.Volumes[1].Type)
+	assert.Equal(t, "/tmp/cache", web.Spec.Volumes[1].Path)
+
+	probe := web.Spec.LivenessProbe
+	assert.Equal(t, []string{"curl", "-f", "http://localhost:3000"}, probe.Exec.Command)
+	assert.Equal(t, int32(90), probe.PeriodSeconds)
+	assert.Equal(t, int32(10), probe.TimeoutSeconds)
+	assert.Equal(t, int32(3), probe.FailureThreshold)
+	assert.Equal(t, int32(40), probe.InitialDelaySeconds)
+	assert.Equal(t, probe, web.Spec.ReadinessProbe)
+
+	// only the published port of web is routed
+	assert.Len(t, result.HttpRoutes, 1)
+	route := result.HttpRoutes[0]
+	assert.Equal(t, "shop-web-app", route.Name)
+	assert.Equal(t, []string{"web-app.example.com"}, route.Spec.Hosts)
+	assert.Equal(t, "web-app.shop.svc.cluster.local:3000", route.Spec.Destinations[0].Host)
+}
+
+func TestConvertWithoutRoutes(t *testing.T) {
+	result, err := Convert([]byte(testComposeFile), Options{Application: "shop"})
+	assert.Nil(t, err)
+	assert.Empty(t, result.HttpRoutes)
+}
+
+func TestConvertInvalidFiles(t *testing.T) {
+	_, err := Convert([]byte("version: '3'"), Options{})
+	assert.EqualError(t, err, "no services in the compose file")
+
+	_, err = Convert([]byte("services:\n  web:\n    build: .\n"), Options{})
+	assert.EqualError(t, err, "services.web.image is required, building images is not supported")
+
+	_, err = Convert([]byte("services:\n  web:\n    image: web\n    ports: ['3000-3005:3000-3005']\n"), Options{})
+	assert.EqualError(t, err, "invalid services.web.ports: port ranges are not supported")
+
+	_, err = Convert([]byte("services: ["), Options{})
+	assert.NotNil(t, err)
+}
+
+func TestParsePort(t *testing.T) {
+	tests := []struct {
+		spec      string
+		port      uint32
+		published bool
+		protocol  string
+	}{
+		{"3000", 3000, false, "tcp"},
+		{"8080:80", 80, true, "tcp"},
+		{"127.0.0.1:8080:80", 80, true, "tcp"},
+		{"127.0.0.1::80", 80, true, "tcp"},
+		{"53:53/udp", 53, true, "udp"},
+	}
+
+	for _, test := range tests {
+		port, published, protocol, err := parsePort(test.spec)
+		assert.Nil(t, err, test.spec)
+		assert.Equal(t, test.port, port, test.spec)
+		assert.Equal(t, test.published, published, test.spec)
+		assert.Equal(t, test.protocol, protocol, test.spec)
+	}
+
+	_, _, _, err := parsePort("web")
+	assert.NotNil(t, err)
+}
+
+func TestConvertCommand(t *testing.T) {
+	tests := []struct {
+		command interface{}
+		args    []string
+	}{
+		{"npm run start", []string{"npm", "run", "start"}},
+		{"  sh -c 'echo $HOME && sleep 1' ", []string{"sh", "-c", "echo $HOME && sleep 1"}},
+		{`echo "a \"b\"" c\ d`, []string{"echo", `a "b"`, "c d"}},
+		{`echo ""`, []string{"echo", ""}},
+		{[]interface{}{"echo", "a b", 1}, []string{"echo", "a b", "1"}},
+	}
+
+	for _, test := range tests {
+		args, err := convertCommand(test.command)
+		assert.Nil(t, err, test.command)
+		assert.Equal(t, test.args, args, test.command)
+	}
+
+	_, err := convertCommand("echo 'a")
+	assert.NotNil(t, err)
+
+	_, err = convertCommand(map[string]interface{}{})
+	assert.NotNil(t, err)
+}
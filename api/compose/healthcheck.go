@@ -0,0 +1,132 @@
+package compose
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kalmhq/kalm/controller/api/v1alpha1"
+	coreV1 "k8s.io/api/core/v1"
+)
+
+// convertHealthcheck converts the healthcheck to both the readiness and the liveness probe,
+// as compose uses it to decide whether the container is ready and restarts unhealthy ones.
+func (c *converter) convertHealthcheck(component *v1alpha1.Component, path string, value interface{}) error {
+	healthcheck, ok := value.(map[string]interface{})
+
+	if !ok {
+		return fmt.Errorf("should be a map")
+	}
+
+	if disable, _ := healthcheck["disable"].(bool); disable {
+		return nil
+	}
+
+	command, err := convertHealthcheckTest(healthcheck["test"])
+
+	if err != nil {
+		return err
+	}
+
+	// the healthcheck of the image is disabled
+	if command == nil {
+		return nil
+	}
+
+	probe := &coreV1.Probe{
+		Handler: coreV1.Handler{
+			Exec: &coreV1.ExecAction{Command: command},
+		},
+	}
+
+	for _, key := range sortedKeys(healthcheck) {
+		var seconds int32
+
+		switch key {
+		case "test", "disable":
+			continue
+		case "retries":
+			retries, err := strconv.ParseInt(toString(healthcheck[key]), 10, 32)
+
+			if err != nil || retries < 1 {
+				return fmt.Errorf("invalid retries")
+			}
+
+			probe.FailureThreshold = int32(retries)
+			continue
+		case "interval", "timeout", "start_period":
+			if seconds, err = parseDurationSeconds(toString(healthcheck[key])); err != nil {
+				return fmt.Errorf("invalid %s: %s", key, err)
+			}
+		default:
+			c.unsupported(path + "." + key)
+			continue
+		}
+
+		switch key {
+		case "interval":
+			probe.PeriodSeconds = seconds
+		case "timeout":
+			probe.TimeoutSeconds = seconds
+		case "start_period":
+			probe.InitialDelaySeconds = seconds
+		}
+	}
+
+	component.Spec.ReadinessProbe = probe
+	component.Spec.LivenessProbe = probe.DeepCopy()
+
+	return nil
+}
+
+// convertHealthcheckTest returns the command of the probe, nil if the healthcheck is disabled by NONE.
+func convertHealthcheckTest(value interface{}) ([]string, error) {
+	switch test := value.(type) {
+	case string:
+		return []string{"sh", "-c", test}, nil
+	case []interface{}:
+		if len(test) == 0 {
+			return nil, fmt.Errorf("test is empty")
+		}
+
+		args := make([]string, 0, len(test)-1)
+		for _, arg := range test[1:] {
+			args = append(args, toString(arg))
+		}
+
+		switch toString(test[0]) {
+		case "NONE":
+			return nil, nil
+		case "CMD":
+			if len(args) == 0 {
+				return nil, fmt.Errorf("test has no command")
+			}
+
+			return args, nil
+		case "CMD-SHELL":
+			return []string{"sh", "-c", strings.Join(args, " ")}, nil
+		default:
+			return nil, fmt.Errorf("test should start with NONE, CMD or CMD-SHELL")
+		}
+	default:
+		return nil, fmt.Errorf("test should be a string or a list")
+	}
+}
+
+// parseDurationSeconds parses durations of compose, e.g. 1m30s, probes require at least 1 second.
+func parseDurationSeconds(value string) (int32, error) {
+	duration, err := time.ParseDuration(value)
+
+	if err != nil {
+		return 0, err
+	}
+
+	seconds := int32(duration / time.Second)
+
+	if seconds < 1 {
+		seconds = 1
+	}
+
+	return seconds, nil
+}
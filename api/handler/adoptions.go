@@ -0,0 +1,102 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/kalmhq/kalm/api/errors"
+	"github.com/kalmhq/kalm/api/resources"
+	"github.com/kalmhq/kalm/controller/api/v1alpha1"
+	"github.com/labstack/echo/v4"
+)
+
+func (h *ApiHandler) InstallAdoptionHandlers(e *echo.Group) {
+	e.GET("/applications/:name/adoptions", h.handleListAdoptionCandidates)
+	e.POST("/applications/:name/adoptions", h.handleAdoptWorkload)
+}
+
+// handleListAdoptionCandidates returns workloads of the application not managed by kalm,
+// with the components which would adopt them and fields of workloads that are not kept.
+func (h *ApiHandler) handleListAdoptionCandidates(c echo.Context) error {
+	currentUser := getCurrentUser(c)
+	application := c.Param("name")
+	h.MustCanView(currentUser, application, "components/*")
+
+	if err := h.mustBeKalmEnabled(application); err != nil {
+		return err
+	}
+
+	candidates, err := h.resourceManager.GetAdoptionCandidates(application)
+
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(200, candidates)
+}
+
+// handleAdoptWorkload creates the component of a workload, the controller takes ownership of the workload
+// and its service without restarting pods.
+func (h *ApiHandler) handleAdoptWorkload(c echo.Context) error {
+	currentUser := getCurrentUser(c)
+	application := c.Param("name")
+
+	var req resources.AdoptionRequest
+
+	if err := c.Bind(&req); err != nil {
+		return err
+	}
+
+	h.MustCanEdit(currentUser, application, "components/"+req.Name)
+
+	if err := h.mustBeKalmEnabled(application); err != nil {
+		return err
+	}
+
+	candidate, err := h.resourceManager.GetAdoptionCandidate(application, req.Kind, req.Name)
+
+	if err != nil {
+		return err
+	}
+
+	if candidate.Conflict != "" {
+		return errors.NewBadRequest(candidate.Conflict)
+	}
+
+	// adopted volumes and secrets must be accessible by the user, as for created components
+	if err := h.checkPermissionOnVolume(currentUser, candidate.Component.Volumes); err != nil {
+		return err
+	}
+
+	if err := h.checkPermissionOnSecrets(currentUser, getCrdComponent(candidate.Component)); err != nil {
+		return err
+	}
+
+	changedBy, changedVia := getComponentChangeCause(c)
+	component, err := h.resourceManager.AdoptWorkload(candidate, changedBy, changedVia)
+
+	if err != nil {
+		return err
+	}
+
+	res, err := h.componentResponse(component)
+
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusCreated, res)
+}
+
+func (h *ApiHandler) mustBeKalmEnabled(application string) error {
+	namespace, err := h.resourceManager.GetNamespace(application)
+
+	if err != nil {
+		return err
+	}
+
+	if !v1alpha1.IsNamespaceKalmEnabled(*namespace) {
+		return errors.NewBadRequest("application " + application + " is not managed by kalm")
+	}
+
+	return nil
+}
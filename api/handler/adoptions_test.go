@@ -0,0 +1,121 @@
+package handler
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/kalmhq/kalm/api/resources"
+	"github.com/kalmhq/kalm/controller/api/v1alpha1"
+	"github.com/kalmhq/kalm/controller/controllers"
+	"github.com/stretchr/testify/suite"
+	appsV1 "k8s.io/api/apps/v1"
+	coreV1 "k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type AdoptionHandlerTestSuite struct {
+	WithControllerTestSuite
+}
+
+func (suite *AdoptionHandlerTestSuite) SetupSuite() {
+	suite.WithControllerTestSuite.SetupSuite()
+
+	suite.Nil(suite.Create(&coreV1.Namespace{
+		ObjectMeta: metaV1.ObjectMeta{
+			Name:   "test-adoption",
+			Labels: map[string]string{controllers.KalmEnableLabelName: controllers.KalmEnableLabelValue},
+		},
+	}))
+
+	labels := map[string]string{"app": "legacy"}
+
+	suite.Nil(suite.Create(&appsV1.Deployment{
+		ObjectMeta: metaV1.ObjectMeta{Name: "legacy", Namespace: "test-adoption"},
+		Spec: appsV1.DeploymentSpec{
+			Selector: &metaV1.LabelSelector{MatchLabels: labels},
+			Template: coreV1.PodTemplateSpec{
+				ObjectMeta: metaV1.ObjectMeta{Labels: labels},
+				Spec: coreV1.PodSpec{
+					Containers: []coreV1.Container{
+						{Name: "legacy", Image: "nginx", Ports: []coreV1.ContainerPort{{ContainerPort: 80}}},
+					},
+				},
+			},
+		},
+	}))
+}
+
+func (suite *AdoptionHandlerTestSuite) TestAdoptWorkload() {
+	suite.DoTestRequest(&TestRequestContext{
+		Roles: []string{
+			GetViewerRoleOfNamespace("test-adoption"),
+		},
+		Method: http.MethodGet,
+		Path:   "/v1alpha1/applications/test-adoption/adoptions",
+		TestWithoutRoles: func(rec *ResponseRecorder) {
+			suite.IsUnauthorizedError(rec, "view")
+		},
+		TestWithRoles: func(rec *ResponseRecorder) {
+			var res []resources.AdoptionCandidate
+			rec.BodyAsJSON(&res)
+
+			suite.Equal(200, rec.Code)
+			suite.Len(res, 1)
+			suite.Equal(resources.AdoptionKindDeployment, res[0].Kind)
+			suite.Equal("nginx", res[0].Component.Image)
+			suite.Empty(res[0].Conflict)
+		},
+	})
+
+	suite.DoTestRequest(&TestRequestContext{
+		Roles: []string{
+			GetEditorRoleOfNamespace("test-adoption"),
+		},
+		Method: http.MethodPost,
+		Path:   "/v1alpha1/applications/test-adoption/adoptions",
+		Body:   resources.AdoptionRequest{Kind: resources.AdoptionKindDeployment, Name: "legacy"},
+		TestWithoutRoles: func(rec *ResponseRecorder) {
+			suite.IsUnauthorizedError(rec, "edit")
+		},
+		TestWithRoles: func(rec *ResponseRecorder) {
+			suite.Equal(201, rec.Code)
+
+			var component v1alpha1.Component
+			suite.Nil(suite.Get("test-adoption", "legacy", &component))
+			suite.Equal("1", component.Annotations[v1alpha1.KalmAnnoAdoptedGeneration])
+			suite.Equal(uint32(80), component.Spec.Ports[0].ContainerPort)
+		},
+	})
+
+	// the component exists now
+	suite.DoTestRequest(&TestRequestContext{
+		Roles: []string{
+			GetEditorRoleOfNamespace("test-adoption"),
+		},
+		Method: http.MethodPost,
+		Path:   "/v1alpha1/applications/test-adoption/adoptions",
+		Body:   resources.AdoptionRequest{Kind: resources.AdoptionKindDeployment, Name: "legacy"},
+		TestWithRoles: func(rec *ResponseRecorder) {
+			suite.Equal(400, rec.Code)
+		},
+	})
+}
+
+func (suite *AdoptionHandlerTestSuite) TestNotKalmEnabled() {
+	suite.ensureNamespaceExist("test-adoption-disabled")
+
+	suite.DoTestRequest(&TestRequestContext{
+		Roles: []string{
+			GetViewerRoleOfNamespace("test-adoption-disabled"),
+		},
+		Method: http.MethodGet,
+		Path:   "/v1alpha1/applications/test-adoption-disabled/adoptions",
+		TestWithRoles: func(rec *ResponseRecorder) {
+			suite.Equal(400, rec.Code)
+		},
+	})
+}
+
+func TestAdoptionHandlerTestSuite(t *testing.T) {
+	suite.Run(t, new(AdoptionHandlerTestSuite))
+}
@@ -5,6 +5,7 @@ import (
 
 	"github.com/kalmhq/kalm/api/errors"
 	"github.com/kalmhq/kalm/api/resources"
+	"github.com/kalmhq/kalm/controller/api/v1alpha1"
 	"github.com/kalmhq/kalm/controller/controllers"
 	"github.com/labstack/echo/v4"
 	coreV1 "k8s.io/api/core/v1"
@@ -19,6 +20,12 @@ func (h *ApiHandler) InstallApplicationsHandlers(e *echo.Group) {
 	e.POST("/applications", h.handleCreateApplication)
 	e.GET("/applications/:name", h.handleGetApplicationDetails, h.setApplicationIntoContext)
 	e.DELETE("/applications/:name", h.handleDeleteApplication, h.setApplicationIntoContext)
+	e.PUT("/applications/:name/quota", h.handleUpdateApplicationQuota, h.setApplicationIntoContext)
+	e.DELETE("/applications/:name/quota", h.handleDeleteApplicationQuota, h.setApplicationIntoContext)
+	e.POST("/applications/:name/suspend", h.handleSuspendApplication, h.setApplicationIntoContext)
+	e.POST("/applications/:name/resume", h.handleResumeApplication, h.setApplicationIntoContext)
+	e.PUT("/applications/:name/maintenance", h.handleUpdateApplicationMaintenance, h.setApplicationIntoContext)
+	e.DELETE("/applications/:name/maintenance", h.handleDeleteApplicationMaintenance, h.setApplicationIntoContext)
 }
 
 // middlewares
@@ -114,8 +121,117 @@ func (h *ApiHandler) handleDeleteApplication(c echo.Context) error {
 	return c.NoContent(http.StatusNoContent)
 }
 
+// quota can only be changed by cluster editors, otherwise editors of an application can raise its own quota
+func (h *ApiHandler) handleUpdateApplicationQuota(c echo.Context) error {
+	h.MustCanEditCluster(getCurrentUser(c))
+
+	var quota v1alpha1.ApplicationQuota
+
+	if err := c.Bind(&quota); err != nil {
+		return err
+	}
+
+	if errs := quota.Validate(); len(errs) > 0 {
+		return errors.NewBadRequest(errs.Error())
+	}
+
+	return h.updateApplicationQuota(c, &quota)
+}
+
+func (h *ApiHandler) handleDeleteApplicationQuota(c echo.Context) error {
+	h.MustCanEditCluster(getCurrentUser(c))
+
+	return h.updateApplicationQuota(c, nil)
+}
+
+func (h *ApiHandler) updateApplicationQuota(c echo.Context, quota *v1alpha1.ApplicationQuota) error {
+	namespace, err := h.resourceManager.UpdateApplicationQuota(h.getApplicationFromContext(c), quota)
+
+	if err != nil {
+		return err
+	}
+
+	res, err := h.resourceManager.BuildApplicationDetails(namespace)
+
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(200, res)
+}
+
+func (h *ApiHandler) handleSuspendApplication(c echo.Context) error {
+	return h.updateApplicationSuspended(c, true)
+}
+
+func (h *ApiHandler) handleResumeApplication(c echo.Context) error {
+	return h.updateApplicationSuspended(c, false)
+}
+
+func (h *ApiHandler) updateApplicationSuspended(c echo.Context, suspended bool) error {
+	namespace := h.getApplicationFromContext(c)
+	h.MustCanEdit(getCurrentUser(c), namespace.Name, "applications/"+namespace.Name)
+
+	namespace, err := h.resourceManager.UpdateApplicationSuspended(namespace, suspended)
+
+	if err != nil {
+		return err
+	}
+
+	res, err := h.resourceManager.BuildApplicationDetails(namespace)
+
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(200, res)
+}
+
+func (h *ApiHandler) handleUpdateApplicationMaintenance(c echo.Context) error {
+	h.mustCanEditApplication(c)
+
+	var maintenance v1alpha1.ApplicationMaintenance
+
+	if err := c.Bind(&maintenance); err != nil {
+		return err
+	}
+
+	if errs := maintenance.Validate(); len(errs) > 0 {
+		return errors.NewBadRequest(errs.Error())
+	}
+
+	return h.updateApplicationMaintenance(c, &maintenance)
+}
+
+func (h *ApiHandler) handleDeleteApplicationMaintenance(c echo.Context) error {
+	h.mustCanEditApplication(c)
+
+	return h.updateApplicationMaintenance(c, nil)
+}
+
+func (h *ApiHandler) updateApplicationMaintenance(c echo.Context, maintenance *v1alpha1.ApplicationMaintenance) error {
+	namespace, err := h.resourceManager.UpdateApplicationMaintenance(h.getApplicationFromContext(c), maintenance)
+
+	if err != nil {
+		return err
+	}
+
+	res, err := h.resourceManager.BuildApplicationDetails(namespace)
+
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(200, res)
+}
+
 // helper
 
+func (h *ApiHandler) mustCanEditApplication(c echo.Context) {
+	namespace := h.getApplicationFromContext(c)
+	h.MustCanEdit(getCurrentUser(c), namespace.Name, "applications/"+namespace.Name)
+}
+
 func bindKalmNamespaceFromRequestBody(c echo.Context) (*coreV1.Namespace, error) {
 	var ns resources.Application
 
@@ -132,5 +248,15 @@ func bindKalmNamespaceFromRequestBody(c echo.Context) (*coreV1.Namespace, error)
 		},
 	}
 
+	if ns.Quota != nil {
+		if errs := ns.Quota.Validate(); len(errs) > 0 {
+			return nil, errors.NewBadRequest(errs.Error())
+		}
+
+		if err := v1alpha1.SetApplicationQuota(&coreV1Namespace, ns.Quota); err != nil {
+			return nil, err
+		}
+	}
+
 	return &coreV1Namespace, nil
 }
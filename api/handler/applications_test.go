@@ -149,6 +149,207 @@ func (suite *ApplicationsHandlerTestSuite) TestDeleteApplication() {
 	})
 }
 
+func (suite *ApplicationsHandlerTestSuite) TestApplicationQuota() {
+	suite.DoTestRequest(&TestRequestContext{
+		Roles: []string{
+			GetClusterEditorRole(),
+		},
+		Method: http.MethodPost,
+		Path:   "/v1alpha1/applications",
+		Body:   `{"name": "test-quota", "quota": {"cpu": "2", "pods": 10}}`,
+		TestWithRoles: func(rec *ResponseRecorder) {
+			var res resources.ApplicationDetails
+			rec.BodyAsJSON(&res)
+
+			suite.Equal(201, rec.Code)
+			suite.Equal("2", res.Quota.CPU.String())
+			suite.Equal(int64(10), *res.Quota.Pods)
+		},
+	})
+
+	// only cluster editors can change the quota
+	suite.DoTestRequest(&TestRequestContext{
+		Roles: []string{
+			GetClusterEditorRole(),
+		},
+		Method: http.MethodPut,
+		Path:   "/v1alpha1/applications/test-quota/quota",
+		Body:   `{"memory": "4Gi", "routes": 5}`,
+		TestWithoutRoles: func(rec *ResponseRecorder) {
+			suite.IsUnauthorizedError(rec, "edit")
+		},
+		TestWithRoles: func(rec *ResponseRecorder) {
+			var res resources.ApplicationDetails
+			rec.BodyAsJSON(&res)
+
+			suite.Equal(200, rec.Code)
+			suite.Nil(res.Quota.CPU)
+			suite.Equal("4Gi", res.Quota.Memory.String())
+			suite.Equal(int64(5), *res.Quota.Routes)
+		},
+	})
+
+	suite.DoTestRequest(&TestRequestContext{
+		Roles: []string{
+			GetClusterEditorRole(),
+		},
+		Method: http.MethodPut,
+		Path:   "/v1alpha1/applications/test-quota/quota",
+		Body:   `{"pods": -1}`,
+		TestWithRoles: func(rec *ResponseRecorder) {
+			suite.Equal(400, rec.Code)
+		},
+	})
+
+	suite.DoTestRequest(&TestRequestContext{
+		Roles: []string{
+			GetClusterEditorRole(),
+		},
+		Method: http.MethodDelete,
+		Path:   "/v1alpha1/applications/test-quota/quota",
+		TestWithoutRoles: func(rec *ResponseRecorder) {
+			suite.IsUnauthorizedError(rec, "edit")
+		},
+		TestWithRoles: func(rec *ResponseRecorder) {
+			var res resources.ApplicationDetails
+			rec.BodyAsJSON(&res)
+
+			suite.Equal(200, rec.Code)
+			suite.Nil(res.Quota)
+			suite.Nil(res.QuotaUsage)
+		},
+	})
+}
+
+func (suite *ApplicationsHandlerTestSuite) TestSuspendAndResumeApplication() {
+	suite.ensureNamespaceExist("test-suspend")
+
+	suite.DoTestRequest(&TestRequestContext{
+		Roles: []string{
+			GetEditorRoleOfNamespace("test-suspend"),
+		},
+		Method: http.MethodPost,
+		Path:   "/v1alpha1/applications/test-suspend/suspend",
+		TestWithoutRoles: func(rec *ResponseRecorder) {
+			suite.IsUnauthorizedError(rec, "edit")
+		},
+		TestWithRoles: func(rec *ResponseRecorder) {
+			var res resources.ApplicationDetails
+			rec.BodyAsJSON(&res)
+
+			suite.Equal(200, rec.Code)
+			suite.True(res.Suspended)
+		},
+	})
+
+	suite.DoTestRequest(&TestRequestContext{
+		Roles: []string{
+			GetEditorRoleOfNamespace("test-suspend"),
+		},
+		Method: http.MethodPost,
+		Path:   "/v1alpha1/applications/test-suspend/resume",
+		TestWithoutRoles: func(rec *ResponseRecorder) {
+			suite.IsUnauthorizedError(rec, "edit")
+		},
+		TestWithRoles: func(rec *ResponseRecorder) {
+			var res resources.ApplicationDetails
+			rec.BodyAsJSON(&res)
+
+			suite.Equal(200, rec.Code)
+			suite.False(res.Suspended)
+		},
+	})
+}
+
 func TestApplicationsHandlerTestSuite(t *testing.T) {
 	suite.Run(t, new(ApplicationsHandlerTestSuite))
 }
+
+func (suite *ApplicationsHandlerTestSuite) TestUpdateApplicationMaintenance() {
+	suite.ensureNamespaceExist("test-maintenance")
+
+	suite.DoTestRequest(&TestRequestContext{
+		Roles: []string{
+			GetEditorRoleOfNamespace("test-maintenance"),
+		},
+		Method: http.MethodPut,
+		Path:   "/v1alpha1/applications/test-maintenance/maintenance",
+		Body: map[string]interface{}{
+			"response": map[string]interface{}{
+				"status":      503,
+				"contentType": "application/json",
+				"body":        `{"message":"under maintenance"}`,
+			},
+			"bypassIPs": []string{"10.0.0.1"},
+		},
+		TestWithoutRoles: func(rec *ResponseRecorder) {
+			suite.IsUnauthorizedError(rec, "edit")
+		},
+		TestWithRoles: func(rec *ResponseRecorder) {
+			var res resources.ApplicationDetails
+			rec.BodyAsJSON(&res)
+
+			suite.Equal(200, rec.Code)
+			suite.NotNil(res.Maintenance)
+			suite.Equal(503, res.Maintenance.Response.Status)
+			suite.Equal([]string{"10.0.0.1"}, res.Maintenance.BypassIPs)
+		},
+	})
+
+	suite.DoTestRequest(&TestRequestContext{
+		Roles: []string{
+			GetEditorRoleOfNamespace("test-maintenance"),
+		},
+		Method: http.MethodPut,
+		Path:   "/v1alpha1/applications/test-maintenance/maintenance",
+		Body: map[string]interface{}{
+			"response":  map[string]interface{}{"status": 503},
+			"bypassIPs": []string{"not-an-ip"},
+		},
+		TestWithoutRoles: func(rec *ResponseRecorder) {
+			suite.IsUnauthorizedError(rec, "edit")
+		},
+		TestWithRoles: func(rec *ResponseRecorder) {
+			suite.Equal(400, rec.Code)
+		},
+	})
+
+	// files of other applications can't be published through routes of the application
+	suite.DoTestRequest(&TestRequestContext{
+		Roles: []string{
+			GetEditorRoleOfNamespace("test-maintenance"),
+		},
+		Method: http.MethodPut,
+		Path:   "/v1alpha1/applications/test-maintenance/maintenance",
+		Body: map[string]interface{}{
+			"response": map[string]interface{}{
+				"status":   503,
+				"bodyFile": map[string]interface{}{"namespace": "other", "path": "/secret.html"},
+			},
+		},
+		TestWithoutRoles: func(rec *ResponseRecorder) {
+			suite.IsUnauthorizedError(rec, "edit")
+		},
+		TestWithRoles: func(rec *ResponseRecorder) {
+			suite.Equal(400, rec.Code)
+		},
+	})
+
+	suite.DoTestRequest(&TestRequestContext{
+		Roles: []string{
+			GetEditorRoleOfNamespace("test-maintenance"),
+		},
+		Method: http.MethodDelete,
+		Path:   "/v1alpha1/applications/test-maintenance/maintenance",
+		TestWithoutRoles: func(rec *ResponseRecorder) {
+			suite.IsUnauthorizedError(rec, "edit")
+		},
+		TestWithRoles: func(rec *ResponseRecorder) {
+			var res resources.ApplicationDetails
+			rec.BodyAsJSON(&res)
+
+			suite.Equal(200, rec.Code)
+			suite.Nil(res.Maintenance)
+		},
+	})
+}
@@ -0,0 +1,184 @@
+package handler
+
+import (
+	"strconv"
+
+	"github.com/kalmhq/kalm/api/auth"
+	"github.com/kalmhq/kalm/api/resources"
+	"github.com/kalmhq/kalm/controller/api/v1alpha1"
+	"github.com/labstack/echo/v4"
+	"k8s.io/apimachinery/pkg/api/errors"
+)
+
+func (h *ApiHandler) InstallComponentRevisionsHandlers(e *echo.Group) {
+	e.GET("/applications/:applicationName/components/:name/revisions", h.handleListComponentRevisions)
+	e.GET("/applications/:applicationName/components/:name/revisions/:revision", h.handleGetComponentRevision)
+	e.GET("/applications/:applicationName/components/:name/revisions/:revision/diff", h.handleDiffComponentRevision)
+	e.POST("/applications/:applicationName/components/:name/revisions/:revision/rollback", h.handleRollbackComponentRevision)
+}
+
+// getComponentChangeCause returns who is changing the component and how.
+// Requests with a bearer token are made by access tokens, others come from the dashboard.
+func getComponentChangeCause(c echo.Context) (changedBy string, changedVia string) {
+	currentUser := getCurrentUser(c)
+
+	changedBy = currentUser.Email
+	if currentUser.Impersonation != "" {
+		changedBy = currentUser.Email + " as " + currentUser.Impersonation
+	}
+
+	if auth.ExtractTokenFromHeader(c.Request().Header.Get(echo.HeaderAuthorization)) != "" {
+		return changedBy, v1alpha1.ComponentChangedViaToken
+	}
+
+	return changedBy, v1alpha1.ComponentChangedViaUI
+}
+
+func getRevisionParam(c echo.Context, name string) (int64, error) {
+	revision, err := strconv.ParseInt(c.Param(name), 10, 64)
+
+	if err != nil {
+		return 0, errors.NewBadRequest("invalid revision: " + c.Param(name))
+	}
+
+	return revision, nil
+}
+
+func (h *ApiHandler) handleListComponentRevisions(c echo.Context) error {
+	h.MustCanView(getCurrentUser(c), c.Param("applicationName"), "components/"+c.Param("name"))
+
+	component, err := h.resourceManager.GetComponent(c.Param("applicationName"), c.Param("name"))
+
+	if err != nil {
+		return err
+	}
+
+	revisions, err := h.resourceManager.GetComponentRevisions(component)
+
+	if err != nil {
+		return err
+	}
+
+	// specs are only returned when getting a single revision
+	for _, revision := range revisions {
+		revision.Spec = nil
+	}
+
+	return c.JSON(200, revisions)
+}
+
+func (h *ApiHandler) handleGetComponentRevision(c echo.Context) error {
+	h.MustCanView(getCurrentUser(c), c.Param("applicationName"), "components/"+c.Param("name"))
+
+	revisionNumber, err := getRevisionParam(c, "revision")
+
+	if err != nil {
+		return err
+	}
+
+	component, err := h.resourceManager.GetComponent(c.Param("applicationName"), c.Param("name"))
+
+	if err != nil {
+		return err
+	}
+
+	revision, err := h.resourceManager.GetComponentRevision(component, revisionNumber)
+
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(200, revision)
+}
+
+// diff from the revision to the current spec, or to the revision in "to" query param
+func (h *ApiHandler) handleDiffComponentRevision(c echo.Context) error {
+	h.MustCanView(getCurrentUser(c), c.Param("applicationName"), "components/"+c.Param("name"))
+
+	revisionNumber, err := getRevisionParam(c, "revision")
+
+	if err != nil {
+		return err
+	}
+
+	component, err := h.resourceManager.GetComponent(c.Param("applicationName"), c.Param("name"))
+
+	if err != nil {
+		return err
+	}
+
+	from, err := h.resourceManager.GetComponentRevision(component, revisionNumber)
+
+	if err != nil {
+		return err
+	}
+
+	to := &component.Spec
+
+	if c.QueryParam("to") != "" {
+		toRevisionNumber, err := strconv.ParseInt(c.QueryParam("to"), 10, 64)
+
+		if err != nil {
+			return errors.NewBadRequest("invalid revision: " + c.QueryParam("to"))
+		}
+
+		toRevision, err := h.resourceManager.GetComponentRevision(component, toRevisionNumber)
+
+		if err != nil {
+			return err
+		}
+
+		to = toRevision.Spec
+	}
+
+	diff, err := resources.DiffComponentSpecs(from.Spec, to)
+
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(200, diff)
+}
+
+func (h *ApiHandler) handleRollbackComponentRevision(c echo.Context) error {
+	currentUser := getCurrentUser(c)
+	h.MustCanEdit(currentUser, c.Param("applicationName"), "components/"+c.Param("name"))
+
+	revisionNumber, err := getRevisionParam(c, "revision")
+
+	if err != nil {
+		return err
+	}
+
+	component, err := h.resourceManager.GetComponent(c.Param("applicationName"), c.Param("name"))
+
+	if err != nil {
+		return err
+	}
+
+	revision, err := h.resourceManager.GetComponentRevision(component, revisionNumber)
+
+	if err != nil {
+		return err
+	}
+
+	component.Spec = *revision.Spec
+
+	if err := h.checkPermissionOnSecrets(currentUser, component); err != nil {
+		return err
+	}
+
+	changedBy, changedVia := getComponentChangeCause(c)
+
+	if err := h.resourceManager.ApplyComponentSpec(component, changedBy, changedVia); err != nil {
+		return err
+	}
+
+	res, err := h.componentResponse(component)
+
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(200, res)
+}
@@ -0,0 +1,137 @@
+package handler
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/kalmhq/kalm/api/resources"
+	"github.com/kalmhq/kalm/controller/api/v1alpha1"
+	"github.com/kalmhq/kalm/controller/controllers"
+	"github.com/stretchr/testify/suite"
+	appsV1 "k8s.io/api/apps/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+type ComponentRevisionsHandlerTestSuite struct {
+	WithControllerTestSuite
+}
+
+func (suite *ComponentRevisionsHandlerTestSuite) SetupSuite() {
+	suite.WithControllerTestSuite.SetupSuite()
+	suite.ensureNamespaceExist("test-revisions")
+}
+
+// revisions are created by the component controller, which is not running in handler tests
+func (suite *ComponentRevisionsHandlerTestSuite) createRevision(component *v1alpha1.Component, spec v1alpha1.ComponentSpec, revision int64) {
+	data, err := controllers.ComponentRevisionData(&spec)
+	suite.Nil(err)
+
+	isController := true
+	suite.Nil(suite.Create(&appsV1.ControllerRevision{
+		ObjectMeta: metaV1.ObjectMeta{
+			Name:      controllers.ComponentRevisionName(component.Name, data),
+			Namespace: component.Namespace,
+			Labels:    map[string]string{v1alpha1.KalmLabelComponentKey: component.Name},
+			Annotations: map[string]string{
+				v1alpha1.KalmAnnoComponentChangedBy:  "foo@example.com",
+				v1alpha1.KalmAnnoComponentChangedVia: v1alpha1.ComponentChangedViaWebhook,
+			},
+			OwnerReferences: []metaV1.OwnerReference{
+				{
+					APIVersion: "core.kalm.dev/v1alpha1",
+					Kind:       "Component",
+					Name:       component.Name,
+					UID:        component.UID,
+					Controller: &isController,
+				},
+			},
+		},
+		Data:     runtime.RawExtension{Raw: data},
+		Revision: revision,
+	}))
+}
+
+func (suite *ComponentRevisionsHandlerTestSuite) TestComponentRevisions() {
+	component := &v1alpha1.Component{
+		ObjectMeta: metaV1.ObjectMeta{
+			Name:      "web",
+			Namespace: "test-revisions",
+		},
+		Spec: v1alpha1.ComponentSpec{
+			Image: "web:v2",
+		},
+	}
+	suite.Nil(suite.Create(component))
+
+	oldSpec := component.Spec
+	oldSpec.Image = "web:v1"
+
+	suite.createRevision(component, oldSpec, 1)
+	suite.createRevision(component, component.Spec, 2)
+
+	suite.DoTestRequest(&TestRequestContext{
+		Roles: []string{
+			GetViewerRoleOfNamespace("test-revisions"),
+		},
+		Method: http.MethodGet,
+		Path:   "/v1alpha1/applications/test-revisions/components/web/revisions",
+		TestWithoutRoles: func(rec *ResponseRecorder) {
+			suite.IsUnauthorizedError(rec)
+		},
+		TestWithRoles: func(rec *ResponseRecorder) {
+			var res []resources.ComponentRevision
+			rec.BodyAsJSON(&res)
+			suite.Equal(200, rec.Code)
+			suite.Len(res, 2)
+			suite.Equal(int64(2), res[0].Revision)
+			suite.True(res[0].IsCurrent)
+			suite.Equal("web:v1", res[1].Image)
+			suite.Equal("foo@example.com", res[1].ChangedBy)
+			suite.Equal(v1alpha1.ComponentChangedViaWebhook, res[1].ChangedVia)
+		},
+	})
+
+	suite.DoTestRequest(&TestRequestContext{
+		Roles: []string{
+			GetViewerRoleOfNamespace("test-revisions"),
+		},
+		Method: http.MethodGet,
+		Path:   "/v1alpha1/applications/test-revisions/components/web/revisions/1/diff",
+		TestWithoutRoles: func(rec *ResponseRecorder) {
+			suite.IsUnauthorizedError(rec)
+		},
+		TestWithRoles: func(rec *ResponseRecorder) {
+			var res []resources.ComponentSpecDiff
+			rec.BodyAsJSON(&res)
+			suite.Equal(200, rec.Code)
+			suite.Len(res, 1)
+			suite.Equal(".image", res[0].Path)
+			suite.Equal("web:v1", res[0].From)
+			suite.Equal("web:v2", res[0].To)
+		},
+	})
+
+	suite.DoTestRequest(&TestRequestContext{
+		Roles: []string{
+			GetEditorRoleOfNamespace("test-revisions"),
+		},
+		Method: http.MethodPost,
+		Path:   "/v1alpha1/applications/test-revisions/components/web/revisions/1/rollback",
+		TestWithoutRoles: func(rec *ResponseRecorder) {
+			suite.IsUnauthorizedError(rec)
+		},
+		TestWithRoles: func(rec *ResponseRecorder) {
+			suite.Equal(200, rec.Code)
+
+			var rolledBack v1alpha1.Component
+			suite.Nil(suite.Get("test-revisions", "web", &rolledBack))
+			suite.Equal("web:v1", rolledBack.Spec.Image)
+			suite.Equal(v1alpha1.ComponentChangedViaToken, rolledBack.Annotations[v1alpha1.KalmAnnoComponentChangedVia])
+		},
+	})
+}
+
+func TestComponentRevisionsHandlerTestSuite(t *testing.T) {
+	suite.Run(t, new(ComponentRevisionsHandlerTestSuite))
+}
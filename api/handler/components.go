@@ -6,9 +6,11 @@ import (
 	client2 "github.com/kalmhq/kalm/api/client"
 	"github.com/kalmhq/kalm/api/resources"
 	"github.com/kalmhq/kalm/controller/api/v1alpha1"
+	"github.com/kalmhq/kalm/controller/controllers"
 	"github.com/labstack/echo/v4"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/rand"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
@@ -19,6 +21,10 @@ func (h *ApiHandler) InstallComponentsHandlers(e *echo.Group) {
 	e.DELETE("/applications/:applicationName/components/:name", h.handleDeleteComponent)
 	e.POST("/applications/:applicationName/components", h.handleCreateComponent)
 	e.POST("/applications/:applicationName/components/:name/jobs", h.handleTriggerJob)
+	e.GET("/applications/:applicationName/components/:name/jobs", h.handleListJobRuns)
+	e.GET("/applications/:applicationName/components/:name/executions", h.handleListCronJobExecutions)
+	e.POST("/applications/:applicationName/components/:name/suspend", h.handleSuspendCronJob)
+	e.POST("/applications/:applicationName/components/:name/resume", h.handleResumeCronJob)
 }
 
 func (h *ApiHandler) handleListComponents(c echo.Context) error {
@@ -54,8 +60,12 @@ func (h *ApiHandler) handleTriggerJob(c echo.Context) error {
 		return err
 	}
 
+	if component.Spec.WorkloadType == v1alpha1.WorkloadTypeJob {
+		return h.triggerJobRun(c, &component)
+	}
+
 	if component.Spec.WorkloadType != v1alpha1.WorkloadTypeCronjob {
-		return errors.NewBadRequest("component is not a cronjob")
+		return errors.NewBadRequest("component is not a cronjob or job")
 	}
 
 	component.TypeMeta = metaV1.TypeMeta{
@@ -72,6 +82,137 @@ func (h *ApiHandler) handleTriggerJob(c echo.Context) error {
 	return c.NoContent(http.StatusNoContent)
 }
 
+type TriggerJobRunRequest struct {
+	Env  []v1alpha1.EnvVar `json:"env"`
+	Args []string          `json:"args"`
+}
+
+type TriggerJobRunResponse struct {
+	ID      string `json:"id"`
+	JobName string `json:"jobName"`
+}
+
+func (h *ApiHandler) triggerJobRun(c echo.Context, component *v1alpha1.Component) error {
+	var req TriggerJobRunRequest
+
+	// the body is optional, the run uses envs and args of the component by default
+	if c.Request().ContentLength > 0 {
+		if err := c.Bind(&req); err != nil {
+			return err
+		}
+	}
+
+	run := v1alpha1.JobRunRequest{
+		ID:   rand.String(8),
+		Env:  req.Env,
+		Args: req.Args,
+	}
+
+	// the run may refer to secrets which the user can't view
+	if err := h.checkPermissionOnSecrets(getCurrentUser(c), &v1alpha1.Component{
+		ObjectMeta: component.ObjectMeta,
+		Spec:       v1alpha1.ComponentSpec{Env: run.Env},
+	}); err != nil {
+		return err
+	}
+
+	if err := h.resourceManager.TriggerJobRun(component, run); err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusCreated, &TriggerJobRunResponse{
+		ID:      run.ID,
+		JobName: controllers.JobRunName(component.Name, run.ID),
+	})
+}
+
+func (h *ApiHandler) handleListJobRuns(c echo.Context) error {
+	h.MustCanView(getCurrentUser(c), c.Param("applicationName"), "components/"+c.Param("name"))
+
+	component, err := h.resourceManager.GetComponent(c.Param("applicationName"), c.Param("name"))
+
+	if err != nil {
+		return err
+	}
+
+	runs, err := h.resourceManager.GetJobRuns(component)
+
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(200, runs)
+}
+
+func (h *ApiHandler) getCronJobComponent(c echo.Context) (*v1alpha1.Component, error) {
+	component, err := h.resourceManager.GetComponent(c.Param("applicationName"), c.Param("name"))
+
+	if err != nil {
+		return nil, err
+	}
+
+	if component.Spec.WorkloadType != v1alpha1.WorkloadTypeCronjob {
+		return nil, errors.NewBadRequest("component is not a cronjob")
+	}
+
+	return component, nil
+}
+
+func (h *ApiHandler) handleListCronJobExecutions(c echo.Context) error {
+	h.MustCanView(getCurrentUser(c), c.Param("applicationName"), "components/"+c.Param("name"))
+
+	component, err := h.getCronJobComponent(c)
+
+	if err != nil {
+		return err
+	}
+
+	executions, err := h.resourceManager.GetCronJobExecutions(component)
+
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(200, executions)
+}
+
+func (h *ApiHandler) handleSuspendCronJob(c echo.Context) error {
+	return h.setCronJobSuspended(c, true)
+}
+
+func (h *ApiHandler) handleResumeCronJob(c echo.Context) error {
+	return h.setCronJobSuspended(c, false)
+}
+
+func (h *ApiHandler) setCronJobSuspended(c echo.Context, suspended bool) error {
+	h.MustCanEdit(getCurrentUser(c), c.Param("applicationName"), "components/"+c.Param("name"))
+
+	component, err := h.getCronJobComponent(c)
+
+	if err != nil {
+		return err
+	}
+
+	changedBy, changedVia := getComponentChangeCause(c)
+
+	if err := h.resourceManager.SetCronJobSuspended(component, suspended, changedBy, changedVia); err != nil {
+		return err
+	}
+
+	// the component is patched through a copy, reload it for the response
+	if err := h.resourceManager.Get(component.Namespace, component.Name, component); err != nil {
+		return err
+	}
+
+	res, err := h.componentResponse(component)
+
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(200, res)
+}
+
 func (h *ApiHandler) handleCreateComponent(c echo.Context) error {
 	currentUser := getCurrentUser(c)
 	h.MustCanEdit(currentUser, c.Param("applicationName"), "components/*")
@@ -89,6 +230,13 @@ func (h *ApiHandler) handleCreateComponent(c echo.Context) error {
 		return err
 	}
 
+	if err := h.checkPermissionOnSecrets(currentUser, crdComponent); err != nil {
+		return err
+	}
+
+	changedBy, changedVia := getComponentChangeCause(c)
+	resources.SetComponentChangeCause(crdComponent, changedBy, changedVia)
+
 	if err := h.resourceManager.Create(crdComponent); err != nil {
 		return err
 	}
@@ -129,7 +277,13 @@ func (h *ApiHandler) handleUpdateComponent(c echo.Context) error {
 
 	crdComponent := getCrdComponent(component)
 
-	if err := h.resourceManager.Apply(crdComponent); err != nil {
+	if err := h.checkPermissionOnSecrets(currentUser, crdComponent); err != nil {
+		return err
+	}
+
+	changedBy, changedVia := getComponentChangeCause(c)
+
+	if err := h.resourceManager.ApplyComponentSpec(crdComponent, changedBy, changedVia); err != nil {
 		return err
 	}
 
@@ -225,6 +379,30 @@ func (h *ApiHandler) checkPermissionOnVolume(c *client2.ClientInfo, vols []v1alp
 	return nil
 }
 
+// the component can read the secret once it's deployed, so the caller should be able to read it too
+func (h *ApiHandler) checkPermissionOnSecrets(c *client2.ClientInfo, component *v1alpha1.Component) error {
+	for _, name := range resources.GetSecretsReferredByComponent(&component.Spec) {
+		if !h.clientManager.CanView(c, component.Namespace, "secrets/"+name) {
+			return resources.NoObjectViewerRoleError(component.Namespace, "secrets/"+name)
+		}
+	}
+
+	// values of secret shared envs are injected into the component too
+	sharedEnvSecrets, err := h.resourceManager.GetSharedEnvSecretsReferredByComponent(component.Namespace, &component.Spec)
+
+	if err != nil {
+		return err
+	}
+
+	for _, name := range sharedEnvSecrets {
+		if !h.clientManager.CanView(c, component.Namespace, "secrets/"+name) {
+			return resources.NoObjectViewerRoleError(component.Namespace, "secrets/"+name)
+		}
+	}
+
+	return nil
+}
+
 func getCrdComponent(component *resources.Component) *v1alpha1.Component {
 	crdComponent := &v1alpha1.Component{
 		TypeMeta: metaV1.TypeMeta{
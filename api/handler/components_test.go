@@ -338,3 +338,135 @@ func (suite *ComponentTestSuite) TestCreateComponentWithResourceRequirements() {
 		},
 	})
 }
+
+func (suite *ComponentTestSuite) TestTriggerJobRun() {
+	component := v1alpha1.Component{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      "job-with-runs",
+			Namespace: suite.namespace,
+		},
+		Spec: v1alpha1.ComponentSpec{
+			Image:        "foo",
+			WorkloadType: v1alpha1.WorkloadTypeJob,
+		},
+	}
+	suite.Nil(suite.Create(&component))
+
+	var runID string
+
+	suite.DoTestRequest(&TestRequestContext{
+		Roles: []string{
+			GetEditorRoleOfNamespace(suite.namespace),
+		},
+		Namespace: suite.namespace,
+		Method:    http.MethodPost,
+		Path:      fmt.Sprintf("/v1alpha1/applications/%s/components/%s/jobs", suite.namespace, component.Name),
+		Body: TriggerJobRunRequest{
+			Env:  []v1alpha1.EnvVar{{Name: "FOO", Value: "bar"}},
+			Args: []string{"--dry-run"},
+		},
+		TestWithoutRoles: func(rec *ResponseRecorder) {
+			suite.IsUnauthorizedError(rec)
+		},
+		TestWithRoles: func(rec *ResponseRecorder) {
+			var res TriggerJobRunResponse
+			rec.BodyAsJSON(&res)
+			suite.Equal(201, rec.Code)
+			suite.NotEmpty(res.ID)
+			suite.Equal(component.Name+"-"+res.ID, res.JobName)
+			runID = res.ID
+		},
+	})
+
+	suite.Nil(suite.Get(suite.namespace, component.Name, &component))
+	suite.Len(component.Spec.PendingJobRuns, 1)
+	suite.Equal(runID, component.Spec.PendingJobRuns[0].ID)
+	suite.Equal([]string{"--dry-run"}, component.Spec.PendingJobRuns[0].Args)
+
+	suite.DoTestRequest(&TestRequestContext{
+		Roles: []string{
+			GetViewerRoleOfNamespace(suite.namespace),
+		},
+		Namespace: suite.namespace,
+		Method:    http.MethodGet,
+		Path:      fmt.Sprintf("/v1alpha1/applications/%s/components/%s/jobs", suite.namespace, component.Name),
+		TestWithoutRoles: func(rec *ResponseRecorder) {
+			suite.IsUnauthorizedError(rec)
+		},
+		TestWithRoles: func(rec *ResponseRecorder) {
+			var runs []resources.JobRun
+			rec.BodyAsJSON(&runs)
+			suite.Equal(200, rec.Code)
+		},
+	})
+}
+
+func (suite *ComponentTestSuite) TestSuspendAndResumeCronJob() {
+	component := v1alpha1.Component{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      "cronjob-to-suspend",
+			Namespace: suite.namespace,
+		},
+		Spec: v1alpha1.ComponentSpec{
+			Image:        "foo",
+			WorkloadType: v1alpha1.WorkloadTypeCronjob,
+			Schedule:     "0 2 * * *",
+		},
+	}
+	suite.Nil(suite.Create(&component))
+
+	suite.DoTestRequest(&TestRequestContext{
+		Roles: []string{
+			GetEditorRoleOfNamespace(suite.namespace),
+		},
+		Namespace: suite.namespace,
+		Method:    http.MethodPost,
+		Path:      fmt.Sprintf("/v1alpha1/applications/%s/components/%s/suspend", suite.namespace, component.Name),
+		TestWithoutRoles: func(rec *ResponseRecorder) {
+			suite.IsUnauthorizedError(rec)
+		},
+		TestWithRoles: func(rec *ResponseRecorder) {
+			var res resources.Component
+			rec.BodyAsJSON(&res)
+			suite.Equal(200, rec.Code)
+			suite.True(res.CronJob.Suspend)
+		},
+	})
+
+	suite.DoTestRequest(&TestRequestContext{
+		Roles: []string{
+			GetViewerRoleOfNamespace(suite.namespace),
+		},
+		Namespace: suite.namespace,
+		Method:    http.MethodGet,
+		Path:      fmt.Sprintf("/v1alpha1/applications/%s/components/%s/executions", suite.namespace, component.Name),
+		TestWithoutRoles: func(rec *ResponseRecorder) {
+			suite.IsUnauthorizedError(rec)
+		},
+		TestWithRoles: func(rec *ResponseRecorder) {
+			var res resources.CronJobExecutions
+			rec.BodyAsJSON(&res)
+			suite.Equal(200, rec.Code)
+			suite.True(res.Suspended)
+			suite.Equal(int64(0), res.NextScheduleTimestamp)
+		},
+	})
+
+	suite.DoTestRequest(&TestRequestContext{
+		Roles: []string{
+			GetEditorRoleOfNamespace(suite.namespace),
+		},
+		Namespace: suite.namespace,
+		Method:    http.MethodPost,
+		Path:      fmt.Sprintf("/v1alpha1/applications/%s/components/%s/resume", suite.namespace, component.Name),
+		TestWithoutRoles: func(rec *ResponseRecorder) {
+			suite.IsUnauthorizedError(rec)
+		},
+		TestWithRoles: func(rec *ResponseRecorder) {
+			var res resources.Component
+			rec.BodyAsJSON(&res)
+			suite.Equal(200, rec.Code)
+			suite.False(res.CronJob.Suspend)
+		},
+	})
+}
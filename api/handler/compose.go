@@ -0,0 +1,75 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/kalmhq/kalm/api/compose"
+	"github.com/kalmhq/kalm/api/errors"
+	"github.com/kalmhq/kalm/api/resources"
+	"github.com/labstack/echo/v4"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+func (h *ApiHandler) InstallComposeHandlers(e *echo.Group) {
+	e.POST("/applications/:name/compose", h.handleImportCompose)
+}
+
+type ImportComposeRequest struct {
+	// content of the docker-compose.yml
+	Compose string `json:"compose"`
+
+	// if not empty, services with published ports get a route on <component>.<routeDomain>
+	RouteDomain string `json:"routeDomain"`
+
+	// only returns the converted components and routes without creating them
+	DryRun bool `json:"dryRun"`
+}
+
+// handleImportCompose converts a compose file into components of the application,
+// the application is created if it doesn't exist.
+func (h *ApiHandler) handleImportCompose(c echo.Context) error {
+	currentUser := getCurrentUser(c)
+	application := c.Param("name")
+	h.MustCanEdit(currentUser, application, "components/*")
+
+	var req ImportComposeRequest
+
+	if err := c.Bind(&req); err != nil {
+		return err
+	}
+
+	result, err := compose.Convert([]byte(req.Compose), compose.Options{
+		Application: application,
+		RouteDomain: req.RouteDomain,
+	})
+
+	if err != nil {
+		return errors.NewBadRequest(err.Error())
+	}
+
+	res := resources.BuildComposeImportResult(result)
+
+	for _, route := range res.HttpRoutes {
+		if !h.clientManager.CanOperateHttpRoute(currentUser, "edit", route) {
+			return resources.InsufficientPermissionsError
+		}
+	}
+
+	if req.DryRun {
+		return c.JSON(200, res)
+	}
+
+	if _, err := h.resourceManager.GetNamespace(application); k8sErrors.IsNotFound(err) {
+		h.MustCanEdit(currentUser, "*", "applications/*")
+	} else if err != nil {
+		return err
+	}
+
+	changedBy, changedVia := getComponentChangeCause(c)
+
+	if err := h.resourceManager.ImportCompose(application, result, changedBy, changedVia); err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusCreated, res)
+}
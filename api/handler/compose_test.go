@@ -0,0 +1,96 @@
+package handler
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/kalmhq/kalm/api/resources"
+	"github.com/kalmhq/kalm/controller/api/v1alpha1"
+	"github.com/stretchr/testify/suite"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const testComposeFile = `
+services:
+  web:
+    image: nginx
+    ports:
+      - "80:80"
+    build: .
+`
+
+type ComposeHandlerTestSuite struct {
+	WithControllerTestSuite
+}
+
+func (suite *ComposeHandlerTestSuite) SetupSuite() {
+	suite.WithControllerTestSuite.SetupSuite()
+	suite.ensureNamespaceExist("test-compose")
+}
+
+func (suite *ComposeHandlerTestSuite) TestImportCompose() {
+	suite.DoTestRequest(&TestRequestContext{
+		Roles: []string{
+			GetEditorRoleOfNamespace("test-compose"),
+		},
+		Method: http.MethodPost,
+		Path:   "/v1alpha1/applications/test-compose/compose",
+		Body:   ImportComposeRequest{Compose: testComposeFile, DryRun: true},
+		TestWithoutRoles: func(rec *ResponseRecorder) {
+			suite.IsUnauthorizedError(rec, "edit")
+		},
+		TestWithRoles: func(rec *ResponseRecorder) {
+			var res resources.ComposeImportResult
+			rec.BodyAsJSON(&res)
+
+			suite.Equal(200, rec.Code)
+			suite.Equal([]string{"services.web.build"}, res.Unsupported)
+			suite.Len(res.Components, 1)
+
+			// nothing is created in a dry run
+			var componentList v1alpha1.ComponentList
+			suite.Nil(suite.List(&componentList, client.InNamespace("test-compose")))
+			suite.Empty(componentList.Items)
+		},
+	})
+
+	suite.DoTestRequest(&TestRequestContext{
+		Roles: []string{
+			GetEditorRoleOfNamespace("test-compose"),
+		},
+		Method: http.MethodPost,
+		Path:   "/v1alpha1/applications/test-compose/compose",
+		Body:   ImportComposeRequest{Compose: testComposeFile},
+		TestWithoutRoles: func(rec *ResponseRecorder) {
+			suite.IsUnauthorizedError(rec, "edit")
+		},
+		TestWithRoles: func(rec *ResponseRecorder) {
+			var res resources.ComposeImportResult
+			rec.BodyAsJSON(&res)
+
+			suite.Equal(201, rec.Code)
+			suite.Empty(res.HttpRoutes)
+
+			var component v1alpha1.Component
+			suite.Nil(suite.Get("test-compose", "web", &component))
+			suite.Equal("nginx", component.Spec.Image)
+			suite.Equal(uint32(80), component.Spec.Ports[0].ContainerPort)
+		},
+	})
+
+	suite.DoTestRequest(&TestRequestContext{
+		Roles: []string{
+			GetEditorRoleOfNamespace("test-compose"),
+		},
+		Method: http.MethodPost,
+		Path:   "/v1alpha1/applications/test-compose/compose",
+		Body:   ImportComposeRequest{Compose: "services: {}"},
+		TestWithRoles: func(rec *ResponseRecorder) {
+			suite.Equal(400, rec.Code)
+		},
+	})
+}
+
+func TestComposeHandlerTestSuite(t *testing.T) {
+	suite.Run(t, new(ComposeHandlerTestSuite))
+}
@@ -0,0 +1,48 @@
+package handler
+
+import (
+	"fmt"
+
+	"github.com/kalmhq/kalm/api/errors"
+	"github.com/kalmhq/kalm/api/resources"
+	"github.com/labstack/echo/v4"
+)
+
+func (h *ApiHandler) InstallExportHandlers(e *echo.Group) {
+	e.GET("/applications/:name/export", h.handleExportApplication)
+}
+
+// handleExportApplication returns objects kalm creates for the application, so it can run without kalm.
+// The format is yaml (default) or helm, which is a packaged chart.
+func (h *ApiHandler) handleExportApplication(c echo.Context) error {
+	currentUser := getCurrentUser(c)
+	application := c.Param("name")
+	h.MustCanView(currentUser, application, "components/*")
+
+	if err := h.mustBeKalmEnabled(application); err != nil {
+		return err
+	}
+
+	switch format := c.QueryParam("format"); format {
+	case resources.ExportFormatYAML, "":
+		manifests, err := h.resourceManager.ExportApplicationManifests(application)
+
+		if err != nil {
+			return err
+		}
+
+		return c.Blob(200, "application/x-yaml", manifests)
+	case resources.ExportFormatHelm:
+		chart, err := h.resourceManager.ExportApplicationHelmChart(application)
+
+		if err != nil {
+			return err
+		}
+
+		c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%s-0.1.0.tgz", application))
+
+		return c.Blob(200, "application/gzip", chart)
+	default:
+		return errors.NewBadRequest(fmt.Sprintf("unknown export format %s, should be yaml or helm", format))
+	}
+}
@@ -0,0 +1,84 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/kalmhq/kalm/controller/api/v1alpha1"
+	"github.com/kalmhq/kalm/controller/controllers"
+	"github.com/stretchr/testify/suite"
+	coreV1 "k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type ExportHandlerTestSuite struct {
+	WithControllerTestSuite
+}
+
+func (suite *ExportHandlerTestSuite) SetupSuite() {
+	suite.WithControllerTestSuite.SetupSuite()
+
+	suite.Nil(suite.Create(&coreV1.Namespace{
+		ObjectMeta: metaV1.ObjectMeta{
+			Name:   "test-export",
+			Labels: map[string]string{controllers.KalmEnableLabelName: controllers.KalmEnableLabelValue},
+		},
+	}))
+
+	suite.Nil(suite.Create(&v1alpha1.Component{
+		ObjectMeta: metaV1.ObjectMeta{Name: "web", Namespace: "test-export"},
+		Spec: v1alpha1.ComponentSpec{
+			Image: "nginx",
+			Ports: []v1alpha1.Port{{ContainerPort: 80, ServicePort: 80, Protocol: v1alpha1.PortProtocolHTTP}},
+		},
+	}))
+}
+
+func (suite *ExportHandlerTestSuite) TestExportApplication() {
+	suite.DoTestRequest(&TestRequestContext{
+		Roles: []string{
+			GetViewerRoleOfNamespace("test-export"),
+		},
+		Method: http.MethodGet,
+		Path:   "/v1alpha1/applications/test-export/export",
+		TestWithoutRoles: func(rec *ResponseRecorder) {
+			suite.IsUnauthorizedError(rec, "view")
+		},
+		TestWithRoles: func(rec *ResponseRecorder) {
+			suite.Equal(200, rec.Code)
+
+			manifests := rec.BodyAsString()
+			suite.True(strings.HasPrefix(manifests, "apiVersion: v1\nkind: Namespace\n"))
+			suite.Contains(manifests, "kind: Deployment")
+			suite.Contains(manifests, "kind: Service")
+		},
+	})
+
+	suite.DoTestRequest(&TestRequestContext{
+		Roles: []string{
+			GetViewerRoleOfNamespace("test-export"),
+		},
+		Method: http.MethodGet,
+		Path:   "/v1alpha1/applications/test-export/export?format=helm",
+		TestWithRoles: func(rec *ResponseRecorder) {
+			suite.Equal(200, rec.Code)
+			suite.Equal("application/gzip", rec.Header().Get("Content-Type"))
+		},
+	})
+
+	suite.DoTestRequest(&TestRequestContext{
+		Roles: []string{
+			GetViewerRoleOfNamespace("test-export"),
+		},
+		Method: http.MethodGet,
+		Path:   "/v1alpha1/applications/test-export/export?format=json",
+		TestWithRoles: func(rec *ResponseRecorder) {
+			suite.Equal(400, rec.Code)
+		},
+	})
+}
+
+func TestExportHandlerTestSuite(t *testing.T) {
+	suite.Run(t, new(ExportHandlerTestSuite))
+}
@@ -0,0 +1,105 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/kalmhq/kalm/api/resources"
+	"github.com/kalmhq/kalm/controller/lib/files"
+	"github.com/labstack/echo/v4"
+)
+
+// Files of an application are stored in the kalm-files config map,
+// so the permissions of the config map are required to access them.
+const kalmFilesObject = "configmaps/" + files.KALM_CONFIG_MAP_NAME
+
+func (h *ApiHandler) InstallFilesHandlers(e *echo.Group) {
+	e.GET("/applications/:applicationName/files", h.handleGetFiles)
+	e.POST("/applications/:applicationName/files", h.handleCreateFile)
+	e.PUT("/applications/:applicationName/files", h.handleUpdateFile)
+	e.DELETE("/applications/:applicationName/files", h.handleDeleteFile)
+	e.POST("/applications/:applicationName/files/move", h.handleMoveFile)
+}
+
+func getFilePathParam(c echo.Context) string {
+	if path := c.QueryParam("path"); path != "" {
+		return path
+	}
+
+	return "/"
+}
+
+func (h *ApiHandler) handleGetFiles(c echo.Context) error {
+	h.MustCanView(getCurrentUser(c), c.Param("applicationName"), kalmFilesObject)
+
+	root, err := h.resourceManager.GetFileItemTree(c.Param("applicationName"), getFilePathParam(c))
+
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(200, root)
+}
+
+func (h *ApiHandler) handleCreateFile(c echo.Context) error {
+	h.MustCanEdit(getCurrentUser(c), c.Param("applicationName"), kalmFilesObject)
+
+	file := &files.File{}
+
+	if err := c.Bind(file); err != nil {
+		return err
+	}
+
+	item, err := h.resourceManager.CreateFile(c.Param("applicationName"), file)
+
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(201, item)
+}
+
+func (h *ApiHandler) handleUpdateFile(c echo.Context) error {
+	h.MustCanEdit(getCurrentUser(c), c.Param("applicationName"), kalmFilesObject)
+
+	file := &files.File{}
+
+	if err := c.Bind(file); err != nil {
+		return err
+	}
+
+	item, err := h.resourceManager.UpdateFile(c.Param("applicationName"), file)
+
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(200, item)
+}
+
+func (h *ApiHandler) handleDeleteFile(c echo.Context) error {
+	h.MustCanEdit(getCurrentUser(c), c.Param("applicationName"), kalmFilesObject)
+
+	if err := h.resourceManager.DeleteFile(c.Param("applicationName"), c.QueryParam("path")); err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (h *ApiHandler) handleMoveFile(c echo.Context) error {
+	h.MustCanEdit(getCurrentUser(c), c.Param("applicationName"), kalmFilesObject)
+
+	req := &resources.MoveFileRequest{}
+
+	if err := c.Bind(req); err != nil {
+		return err
+	}
+
+	item, err := h.resourceManager.MoveFile(c.Param("applicationName"), req.OldPath, req.NewPath)
+
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(200, item)
+}
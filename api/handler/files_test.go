@@ -0,0 +1,158 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/kalmhq/kalm/api/resources"
+	"github.com/kalmhq/kalm/controller/lib/files"
+	"github.com/stretchr/testify/suite"
+)
+
+type FilesHandlerTestSuite struct {
+	WithControllerTestSuite
+}
+
+func (suite *FilesHandlerTestSuite) SetupSuite() {
+	suite.WithControllerTestSuite.SetupSuite()
+	suite.ensureNamespaceExist("test-files")
+}
+
+func (suite *FilesHandlerTestSuite) TestFilesHandler() {
+	// create a file
+	suite.DoTestRequest(&TestRequestContext{
+		Roles: []string{
+			GetEditorRoleOfNamespace("test-files"),
+		},
+		Method: http.MethodPost,
+		Path:   "/v1alpha1/applications/test-files/files",
+		Body: files.File{
+			Path:    "/nginx/conf.d/default.conf",
+			Content: "server {}",
+		},
+		TestWithoutRoles: func(rec *ResponseRecorder) {
+			suite.IsUnauthorizedError(rec)
+		},
+		TestWithRoles: func(rec *ResponseRecorder) {
+			var item files.FileItem
+			rec.BodyAsJSON(&item)
+			suite.EqualValues(201, rec.Code)
+			suite.Equal("default.conf", item.Name)
+			suite.Equal("server {}", item.Content)
+		},
+	})
+
+	// update the file
+	suite.DoTestRequest(&TestRequestContext{
+		Roles: []string{
+			GetEditorRoleOfNamespace("test-files"),
+		},
+		Method: http.MethodPut,
+		Path:   "/v1alpha1/applications/test-files/files",
+		Body: files.File{
+			Path:    "/nginx/conf.d/default.conf",
+			Content: "server { listen 80; }",
+		},
+		TestWithoutRoles: func(rec *ResponseRecorder) {
+			suite.IsUnauthorizedError(rec)
+		},
+		TestWithRoles: func(rec *ResponseRecorder) {
+			suite.EqualValues(200, rec.Code)
+		},
+	})
+
+	// files larger than the limit are rejected
+	suite.DoTestRequest(&TestRequestContext{
+		Roles: []string{
+			GetEditorRoleOfNamespace("test-files"),
+		},
+		Method: http.MethodPut,
+		Path:   "/v1alpha1/applications/test-files/files",
+		Body: files.File{
+			Path:    "/nginx/conf.d/default.conf",
+			Content: strings.Repeat("a", files.MaxFileSize+1),
+		},
+		TestWithoutRoles: func(rec *ResponseRecorder) {
+			suite.IsUnauthorizedError(rec)
+		},
+		TestWithRoles: func(rec *ResponseRecorder) {
+			suite.EqualValues(400, rec.Code)
+		},
+	})
+
+	// move the dir
+	suite.DoTestRequest(&TestRequestContext{
+		Roles: []string{
+			GetEditorRoleOfNamespace("test-files"),
+		},
+		Method: http.MethodPost,
+		Path:   "/v1alpha1/applications/test-files/files/move",
+		Body: resources.MoveFileRequest{
+			OldPath: "/nginx",
+			NewPath: "/gateway",
+		},
+		TestWithoutRoles: func(rec *ResponseRecorder) {
+			suite.IsUnauthorizedError(rec)
+		},
+		TestWithRoles: func(rec *ResponseRecorder) {
+			var item files.FileItem
+			rec.BodyAsJSON(&item)
+			suite.EqualValues(200, rec.Code)
+			suite.Equal("/gateway", item.AbsPath)
+		},
+	})
+
+	// get the tree
+	suite.DoTestRequest(&TestRequestContext{
+		Roles: []string{
+			GetViewerRoleOfNamespace("test-files"),
+		},
+		Method: http.MethodGet,
+		Path:   "/v1alpha1/applications/test-files/files",
+		TestWithoutRoles: func(rec *ResponseRecorder) {
+			suite.IsUnauthorizedError(rec)
+		},
+		TestWithRoles: func(rec *ResponseRecorder) {
+			var root files.FileItem
+			rec.BodyAsJSON(&root)
+			suite.EqualValues(200, rec.Code)
+			suite.Equal(1, len(root.Children))
+			suite.Equal("/gateway", root.Children[0].AbsPath)
+			suite.Equal("server { listen 80; }", root.Children[0].Children[0].Children[0].Content)
+		},
+	})
+
+	// delete the dir
+	suite.DoTestRequest(&TestRequestContext{
+		Roles: []string{
+			GetEditorRoleOfNamespace("test-files"),
+		},
+		Method: http.MethodDelete,
+		Path:   "/v1alpha1/applications/test-files/files?path=/gateway",
+		TestWithoutRoles: func(rec *ResponseRecorder) {
+			suite.IsUnauthorizedError(rec)
+		},
+		TestWithRoles: func(rec *ResponseRecorder) {
+			suite.EqualValues(204, rec.Code)
+		},
+	})
+
+	suite.DoTestRequest(&TestRequestContext{
+		Roles: []string{
+			GetViewerRoleOfNamespace("test-files"),
+		},
+		Method: http.MethodGet,
+		Path:   "/v1alpha1/applications/test-files/files?path=/gateway",
+		TestWithoutRoles: func(rec *ResponseRecorder) {
+			suite.IsUnauthorizedError(rec)
+		},
+		TestWithRoles: func(rec *ResponseRecorder) {
+			suite.EqualValues(404, rec.Code)
+		},
+	})
+}
+
+func TestFilesHandlerTestSuite(t *testing.T) {
+	suite.Run(t, new(FilesHandlerTestSuite))
+}
@@ -61,6 +61,13 @@ func (h *ApiHandler) InstallMainRoutes(e *echo.Echo) {
 
 	h.InstallApplicationsHandlers(gv1Alpha1WithAuth)
 	h.InstallComponentsHandlers(gv1Alpha1WithAuth)
+	h.InstallComponentRevisionsHandlers(gv1Alpha1WithAuth)
+	h.InstallSharedEnvHandlers(gv1Alpha1WithAuth)
+	h.InstallFilesHandlers(gv1Alpha1WithAuth)
+	h.InstallResourceDefaultsHandlers(gv1Alpha1WithAuth)
+	h.InstallComposeHandlers(gv1Alpha1WithAuth)
+	h.InstallAdoptionHandlers(gv1Alpha1WithAuth)
+	h.InstallExportHandlers(gv1Alpha1WithAuth)
 	h.InstallRegistriesHandlers(gv1Alpha1WithAuth)
 	h.InstallDomainHandlers(gv1Alpha1WithAuth)
 
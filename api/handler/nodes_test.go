@@ -29,6 +29,9 @@ func (suite *NodesHandlerTestSuite) TestNodesHandler() {
 			PodCIDR:    "10.56.2.0/24",
 			PodCIDRs:   []string{"10.56.2.0/24"},
 			ProviderID: "test-node-provider-id",
+			Taints: []v1.Taint{
+				{Key: "lifecycle", Value: "spot", Effect: v1.TaintEffectNoSchedule},
+			},
 		},
 	}
 	err := suite.Create(&node)
@@ -50,6 +53,7 @@ func (suite *NodesHandlerTestSuite) TestNodesHandler() {
 			suite.EqualValues(200, rec.Code)
 			suite.Equal(1, len(nodeList.Nodes))
 			suite.Equal("test-node", nodeList.Nodes[0].Name)
+			suite.Equal(node.Spec.Taints, nodeList.Nodes[0].Taints)
 		},
 	})
 
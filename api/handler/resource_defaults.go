@@ -0,0 +1,145 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/kalmhq/kalm/api/errors"
+	"github.com/kalmhq/kalm/controller/api/v1alpha1"
+	"github.com/labstack/echo/v4"
+	"k8s.io/apimachinery/pkg/api/equality"
+)
+
+func (h *ApiHandler) InstallResourceDefaultsHandlers(e *echo.Group) {
+	e.GET("/applications/:name/resourcedefaults", h.handleGetApplicationResourceDefaults, h.setApplicationIntoContext)
+	e.PUT("/applications/:name/resourcedefaults", h.handleUpdateApplicationResourceDefaults, h.setApplicationIntoContext)
+	e.DELETE("/applications/:name/resourcedefaults", h.handleDeleteApplicationResourceDefaults, h.setApplicationIntoContext)
+
+	e.GET("/resourcedefaults", h.handleGetClusterResourceDefaults)
+	e.PUT("/resourcedefaults", h.handleUpdateClusterResourceDefaults)
+	e.DELETE("/resourcedefaults", h.handleDeleteClusterResourceDefaults)
+}
+
+func bindResourceDefaults(c echo.Context) (*v1alpha1.ResourceDefaults, error) {
+	var defaults v1alpha1.ResourceDefaults
+
+	if err := c.Bind(&defaults); err != nil {
+		return nil, err
+	}
+
+	if errs := defaults.Validate(); len(errs) > 0 {
+		return nil, errors.NewBadRequest(errs.Error())
+	}
+
+	return &defaults, nil
+}
+
+func (h *ApiHandler) handleGetApplicationResourceDefaults(c echo.Context) error {
+	namespace := h.getApplicationFromContext(c)
+	h.MustCanView(getCurrentUser(c), namespace.Name, "applications/"+namespace.Name)
+
+	defaults, err := v1alpha1.GetApplicationResourceDefaults(namespace)
+
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(200, defaults)
+}
+
+func (h *ApiHandler) handleUpdateApplicationResourceDefaults(c echo.Context) error {
+	namespace := h.getApplicationFromContext(c)
+	h.MustCanEdit(getCurrentUser(c), namespace.Name, "applications/"+namespace.Name)
+
+	defaults, err := bindResourceDefaults(c)
+
+	if err != nil {
+		return err
+	}
+
+	res, err := h.updateApplicationResourceDefaults(c, defaults)
+
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(200, res)
+}
+
+func (h *ApiHandler) handleDeleteApplicationResourceDefaults(c echo.Context) error {
+	namespace := h.getApplicationFromContext(c)
+	h.MustCanEdit(getCurrentUser(c), namespace.Name, "applications/"+namespace.Name)
+
+	if _, err := h.updateApplicationResourceDefaults(c, nil); err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// max limits can only be changed by cluster editors, otherwise editors of an application can lift their own limits
+func (h *ApiHandler) updateApplicationResourceDefaults(c echo.Context, defaults *v1alpha1.ResourceDefaults) (*v1alpha1.ResourceDefaults, error) {
+	namespace := h.getApplicationFromContext(c)
+
+	old, err := v1alpha1.GetApplicationResourceDefaults(namespace)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if old == nil {
+		old = &v1alpha1.ResourceDefaults{}
+	}
+
+	current := defaults
+
+	if current == nil {
+		current = &v1alpha1.ResourceDefaults{}
+	}
+
+	if !equality.Semantic.DeepEqual(old.MaxContainerLimits, current.MaxContainerLimits) ||
+		!equality.Semantic.DeepEqual(old.MaxIstioLimits, current.MaxIstioLimits) {
+		h.MustCanEditCluster(getCurrentUser(c))
+	}
+
+	return h.resourceManager.UpdateApplicationResourceDefaults(namespace, defaults)
+}
+
+func (h *ApiHandler) handleGetClusterResourceDefaults(c echo.Context) error {
+	h.MustCanViewCluster(getCurrentUser(c))
+
+	defaults, err := h.resourceManager.GetClusterResourceDefaults()
+
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(200, defaults)
+}
+
+func (h *ApiHandler) handleUpdateClusterResourceDefaults(c echo.Context) error {
+	h.MustCanEditCluster(getCurrentUser(c))
+
+	defaults, err := bindResourceDefaults(c)
+
+	if err != nil {
+		return err
+	}
+
+	res, err := h.resourceManager.UpdateClusterResourceDefaults(defaults)
+
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(200, res)
+}
+
+func (h *ApiHandler) handleDeleteClusterResourceDefaults(c echo.Context) error {
+	h.MustCanEditCluster(getCurrentUser(c))
+
+	if _, err := h.resourceManager.UpdateClusterResourceDefaults(nil); err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
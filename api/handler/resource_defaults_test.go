@@ -0,0 +1,155 @@
+package handler
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/kalmhq/kalm/controller/api/v1alpha1"
+	"github.com/stretchr/testify/suite"
+	coreV1 "k8s.io/api/core/v1"
+)
+
+type ResourceDefaultsHandlerTestSuite struct {
+	WithControllerTestSuite
+}
+
+func (suite *ResourceDefaultsHandlerTestSuite) SetupSuite() {
+	suite.WithControllerTestSuite.SetupSuite()
+	suite.ensureNamespaceExist("test-resource-defaults")
+}
+
+func (suite *ResourceDefaultsHandlerTestSuite) TestApplicationResourceDefaults() {
+	suite.DoTestRequest(&TestRequestContext{
+		Roles: []string{
+			GetEditorRoleOfNamespace("test-resource-defaults"),
+		},
+		Method: http.MethodPut,
+		Path:   "/v1alpha1/applications/test-resource-defaults/resourcedefaults",
+		Body:   `{"container": {"limits": {"cpu": "100m", "memory": "64Mi"}}}`,
+		TestWithoutRoles: func(rec *ResponseRecorder) {
+			suite.IsUnauthorizedError(rec, "edit")
+		},
+		TestWithRoles: func(rec *ResponseRecorder) {
+			var res v1alpha1.ResourceDefaults
+			rec.BodyAsJSON(&res)
+
+			suite.Equal(200, rec.Code)
+			cpu := res.Container.Limits[coreV1.ResourceCPU]
+			suite.Equal("100m", cpu.String())
+		},
+	})
+
+	// max limits can only be changed by cluster editors
+	suite.DoTestRequest(&TestRequestContext{
+		Roles: []string{
+			GetEditorRoleOfNamespace("test-resource-defaults"),
+		},
+		Method: http.MethodPut,
+		Path:   "/v1alpha1/applications/test-resource-defaults/resourcedefaults",
+		Body:   `{"maxContainerLimits": {"cpu": "4"}}`,
+		TestWithRoles: func(rec *ResponseRecorder) {
+			suite.IsUnauthorizedError(rec, "edit")
+		},
+	})
+
+	suite.DoTestRequest(&TestRequestContext{
+		Roles: []string{
+			GetClusterEditorRole(),
+		},
+		Method: http.MethodPut,
+		Path:   "/v1alpha1/applications/test-resource-defaults/resourcedefaults",
+		Body:   `{"container": {"limits": {"cpu": "2"}}, "maxContainerLimits": {"cpu": "1"}}`,
+		TestWithRoles: func(rec *ResponseRecorder) {
+			suite.Equal(400, rec.Code)
+		},
+	})
+
+	suite.DoTestRequest(&TestRequestContext{
+		Roles: []string{
+			GetViewerRoleOfNamespace("test-resource-defaults"),
+		},
+		Method: http.MethodGet,
+		Path:   "/v1alpha1/applications/test-resource-defaults/resourcedefaults",
+		TestWithoutRoles: func(rec *ResponseRecorder) {
+			suite.IsUnauthorizedError(rec, "view")
+		},
+		TestWithRoles: func(rec *ResponseRecorder) {
+			var res v1alpha1.ResourceDefaults
+			rec.BodyAsJSON(&res)
+
+			suite.Equal(200, rec.Code)
+			memory := res.Container.Limits[coreV1.ResourceMemory]
+			suite.Equal("64Mi", memory.String())
+		},
+	})
+
+	suite.DoTestRequest(&TestRequestContext{
+		Roles: []string{
+			GetEditorRoleOfNamespace("test-resource-defaults"),
+		},
+		Method: http.MethodDelete,
+		Path:   "/v1alpha1/applications/test-resource-defaults/resourcedefaults",
+		TestWithoutRoles: func(rec *ResponseRecorder) {
+			suite.IsUnauthorizedError(rec, "edit")
+		},
+		TestWithRoles: func(rec *ResponseRecorder) {
+			suite.Equal(http.StatusNoContent, rec.Code)
+		},
+	})
+}
+
+func (suite *ResourceDefaultsHandlerTestSuite) TestClusterResourceDefaults() {
+	suite.ensureNamespaceExist(v1alpha1.KalmSystemNamespace)
+
+	suite.DoTestRequest(&TestRequestContext{
+		Roles: []string{
+			GetClusterEditorRole(),
+		},
+		Method: http.MethodPut,
+		Path:   "/v1alpha1/resourcedefaults",
+		Body:   `{"istio": {"requests": {"cpu": "50m"}}, "maxIstioLimits": {"memory": "256Mi"}}`,
+		TestWithoutRoles: func(rec *ResponseRecorder) {
+			suite.IsUnauthorizedError(rec, "edit")
+		},
+		TestWithRoles: func(rec *ResponseRecorder) {
+			suite.Equal(200, rec.Code)
+		},
+	})
+
+	suite.DoTestRequest(&TestRequestContext{
+		Roles: []string{
+			GetClusterViewerRole(),
+		},
+		Method: http.MethodGet,
+		Path:   "/v1alpha1/resourcedefaults",
+		TestWithoutRoles: func(rec *ResponseRecorder) {
+			suite.IsUnauthorizedError(rec, "view")
+		},
+		TestWithRoles: func(rec *ResponseRecorder) {
+			var res v1alpha1.ResourceDefaults
+			rec.BodyAsJSON(&res)
+
+			suite.Equal(200, rec.Code)
+			memory := res.MaxIstioLimits[coreV1.ResourceMemory]
+			suite.Equal("256Mi", memory.String())
+		},
+	})
+
+	suite.DoTestRequest(&TestRequestContext{
+		Roles: []string{
+			GetClusterEditorRole(),
+		},
+		Method: http.MethodDelete,
+		Path:   "/v1alpha1/resourcedefaults",
+		TestWithoutRoles: func(rec *ResponseRecorder) {
+			suite.IsUnauthorizedError(rec, "edit")
+		},
+		TestWithRoles: func(rec *ResponseRecorder) {
+			suite.Equal(http.StatusNoContent, rec.Code)
+		},
+	})
+}
+
+func TestResourceDefaultsHandlerTestSuite(t *testing.T) {
+	suite.Run(t, new(ResourceDefaultsHandlerTestSuite))
+}
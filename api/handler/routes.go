@@ -3,6 +3,7 @@ package handler
 import (
 	"fmt"
 
+	"github.com/kalmhq/kalm/api/client"
 	"github.com/kalmhq/kalm/api/resources"
 	"github.com/labstack/echo/v4"
 )
@@ -37,6 +38,8 @@ func (h *ApiHandler) handleCreateRoute(c echo.Context) (err error) {
 		return resources.InsufficientPermissionsError
 	}
 
+	h.mustCanViewBodyFile(currentUser, route)
+
 	if route, err = h.resourceManager.CreateHttpRoute(route); err != nil {
 		return err
 	}
@@ -61,6 +64,8 @@ func (h *ApiHandler) handleUpdateRoute(c echo.Context) (err error) {
 		return resources.InsufficientPermissionsError
 	}
 
+	h.mustCanViewBodyFile(currentUser, route)
+
 	if route, err = h.resourceManager.UpdateHttpRoute(route); err != nil {
 		return err
 	}
@@ -85,6 +90,13 @@ func (h *ApiHandler) handleDeleteRoute(c echo.Context) (err error) {
 	return c.NoContent(200)
 }
 
+// the body file of the direct response is published by the route, so it requires permission to view files of its application
+func (h *ApiHandler) mustCanViewBodyFile(user *client.ClientInfo, route *resources.HttpRoute) {
+	if response := route.DirectResponse; response != nil && response.BodyFile != nil {
+		h.MustCanView(user, response.BodyFile.Namespace, kalmFilesObject)
+	}
+}
+
 func getHttpRouteFromContext(c echo.Context) (*resources.HttpRoute, error) {
 	var route resources.HttpRoute
 
@@ -0,0 +1,90 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/kalmhq/kalm/api/resources"
+	"github.com/labstack/echo/v4"
+)
+
+func (h *ApiHandler) InstallSharedEnvHandlers(e *echo.Group) {
+	e.GET("/applications/:applicationName/sharedenvs", h.handleListSharedEnvs)
+	e.GET("/applications/:applicationName/sharedenvs/:name", h.handleGetSharedEnv)
+	e.POST("/applications/:applicationName/sharedenvs", h.handleCreateSharedEnv)
+	e.PUT("/applications/:applicationName/sharedenvs/:name", h.handleUpdateSharedEnv)
+	e.DELETE("/applications/:applicationName/sharedenvs/:name", h.handleDeleteSharedEnv)
+}
+
+func (h *ApiHandler) handleListSharedEnvs(c echo.Context) error {
+	h.MustCanView(getCurrentUser(c), c.Param("applicationName"), "sharedenvs/*")
+
+	sharedEnvs, err := h.resourceManager.GetSharedEnvs(c.Param("applicationName"))
+
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(200, sharedEnvs)
+}
+
+func (h *ApiHandler) handleGetSharedEnv(c echo.Context) error {
+	h.MustCanView(getCurrentUser(c), c.Param("applicationName"), "sharedenvs/"+c.Param("name"))
+
+	sharedEnv, err := h.resourceManager.GetSharedEnv(c.Param("applicationName"), c.Param("name"))
+
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(200, sharedEnv)
+}
+
+func (h *ApiHandler) handleCreateSharedEnv(c echo.Context) error {
+	sharedEnv := &resources.SharedEnv{}
+
+	if err := c.Bind(sharedEnv); err != nil {
+		return err
+	}
+
+	sharedEnv.Namespace = c.Param("applicationName")
+	h.MustCanEdit(getCurrentUser(c), sharedEnv.Namespace, "sharedenvs/"+sharedEnv.Name)
+
+	sharedEnv, err := h.resourceManager.CreateSharedEnv(sharedEnv)
+
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(201, sharedEnv)
+}
+
+func (h *ApiHandler) handleUpdateSharedEnv(c echo.Context) error {
+	h.MustCanEdit(getCurrentUser(c), c.Param("applicationName"), "sharedenvs/"+c.Param("name"))
+
+	sharedEnv := &resources.SharedEnv{}
+
+	if err := c.Bind(sharedEnv); err != nil {
+		return err
+	}
+
+	sharedEnv.Namespace = c.Param("applicationName")
+	sharedEnv.Name = c.Param("name")
+
+	sharedEnv, err := h.resourceManager.UpdateSharedEnv(sharedEnv)
+
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(200, sharedEnv)
+}
+
+func (h *ApiHandler) handleDeleteSharedEnv(c echo.Context) error {
+	h.MustCanEdit(getCurrentUser(c), c.Param("applicationName"), "sharedenvs/"+c.Param("name"))
+
+	if err := h.resourceManager.DeleteSharedEnv(c.Param("applicationName"), c.Param("name")); err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
@@ -0,0 +1,131 @@
+package handler
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/kalmhq/kalm/api/resources"
+	"github.com/stretchr/testify/suite"
+	coreV1 "k8s.io/api/core/v1"
+)
+
+type SharedEnvsHandlerTestSuite struct {
+	WithControllerTestSuite
+}
+
+func (suite *SharedEnvsHandlerTestSuite) SetupSuite() {
+	suite.WithControllerTestSuite.SetupSuite()
+	suite.ensureNamespaceExist("test-shared-envs")
+}
+
+func (suite *SharedEnvsHandlerTestSuite) TestSharedEnvsHandler() {
+	// create a secret shared env
+	suite.DoTestRequest(&TestRequestContext{
+		Roles: []string{
+			GetEditorRoleOfNamespace("test-shared-envs"),
+		},
+		Method: http.MethodPost,
+		Path:   "/v1alpha1/applications/test-shared-envs/sharedenvs",
+		Body: resources.SharedEnv{
+			Name:     "credentials",
+			IsSecret: true,
+			Data: map[string]string{
+				"PASSWORD": "secret",
+			},
+		},
+		TestWithoutRoles: func(rec *ResponseRecorder) {
+			suite.IsUnauthorizedError(rec)
+		},
+		TestWithRoles: func(rec *ResponseRecorder) {
+			var res resources.SharedEnv
+			rec.BodyAsJSON(&res)
+			suite.EqualValues(201, rec.Code)
+			suite.Nil(res.Data)
+			suite.Equal([]string{"PASSWORD"}, res.Keys)
+		},
+	})
+
+	// values of a secret shared env are not returned
+	suite.DoTestRequest(&TestRequestContext{
+		Roles: []string{
+			GetViewerRoleOfNamespace("test-shared-envs"),
+		},
+		Method: http.MethodGet,
+		Path:   "/v1alpha1/applications/test-shared-envs/sharedenvs/credentials",
+		TestWithoutRoles: func(rec *ResponseRecorder) {
+			suite.IsUnauthorizedError(rec)
+		},
+		TestWithRoles: func(rec *ResponseRecorder) {
+			var res resources.SharedEnv
+			rec.BodyAsJSON(&res)
+			suite.EqualValues(200, rec.Code)
+			suite.True(res.IsSecret)
+			suite.Nil(res.Data)
+			suite.Equal([]string{"PASSWORD"}, res.Keys)
+		},
+	})
+
+	// an empty value keeps the current value
+	suite.DoTestRequest(&TestRequestContext{
+		Roles: []string{
+			GetEditorRoleOfNamespace("test-shared-envs"),
+		},
+		Method: http.MethodPut,
+		Path:   "/v1alpha1/applications/test-shared-envs/sharedenvs/credentials",
+		Body: resources.SharedEnv{
+			IsSecret: true,
+			Data: map[string]string{
+				"PASSWORD": "",
+				"USER":     "admin",
+			},
+		},
+		TestWithoutRoles: func(rec *ResponseRecorder) {
+			suite.IsUnauthorizedError(rec)
+		},
+		TestWithRoles: func(rec *ResponseRecorder) {
+			suite.EqualValues(200, rec.Code)
+
+			var secret coreV1.Secret
+			suite.Nil(suite.Get("test-shared-envs", "credentials", &secret))
+			suite.Equal("secret", string(secret.Data["PASSWORD"]))
+			suite.Equal("admin", string(secret.Data["USER"]))
+		},
+	})
+
+	// list shared envs
+	suite.DoTestRequest(&TestRequestContext{
+		Roles: []string{
+			GetViewerRoleOfNamespace("test-shared-envs"),
+		},
+		Method: http.MethodGet,
+		Path:   "/v1alpha1/applications/test-shared-envs/sharedenvs",
+		TestWithoutRoles: func(rec *ResponseRecorder) {
+			suite.IsUnauthorizedError(rec)
+		},
+		TestWithRoles: func(rec *ResponseRecorder) {
+			var res []*resources.SharedEnv
+			rec.BodyAsJSON(&res)
+			suite.Len(res, 1)
+			suite.Equal([]string{"PASSWORD", "USER"}, res[0].Keys)
+		},
+	})
+
+	// delete a shared env
+	suite.DoTestRequest(&TestRequestContext{
+		Roles: []string{
+			GetEditorRoleOfNamespace("test-shared-envs"),
+		},
+		Method: http.MethodDelete,
+		Path:   "/v1alpha1/applications/test-shared-envs/sharedenvs/credentials",
+		TestWithoutRoles: func(rec *ResponseRecorder) {
+			suite.IsUnauthorizedError(rec)
+		},
+		TestWithRoles: func(rec *ResponseRecorder) {
+			suite.EqualValues(204, rec.Code)
+		},
+	})
+}
+
+func TestSharedEnvsHandlerTestSuite(t *testing.T) {
+	suite.Run(t, new(SharedEnvsHandlerTestSuite))
+}
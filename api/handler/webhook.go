@@ -81,6 +81,7 @@ func (h *ApiHandler) handleDeployWebhookCall(c echo.Context) error {
 
 	updateTs := int(time.Now().Unix())
 	copiedComp.Annotations[controllers.AnnoLastUpdatedByWebhook] = strconv.Itoa(updateTs)
+	resources.SetComponentChangeCause(copiedComp, clientInfo.Name, v1alpha1.ComponentChangedViaWebhook)
 
 	if err := h.resourceManager.Patch(copiedComp, client.MergeFrom(crdComp)); err != nil {
 		h.logger.Info("fail updating component", zap.String("name", copiedComp.Name), zap.Int("time", updateTs))
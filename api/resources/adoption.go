@@ -0,0 +1,601 @@
+package resources
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/kalmhq/kalm/controller/api/v1alpha1"
+	appsV1 "k8s.io/api/apps/v1"
+	batchV1Beta1 "k8s.io/api/batch/v1beta1"
+	coreV1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Kinds of workloads that can be adopted
+const (
+	AdoptionKindDeployment  = "Deployment"
+	AdoptionKindStatefulSet = "StatefulSet"
+	AdoptionKindDaemonSet   = "DaemonSet"
+	AdoptionKindCronJob     = "CronJob"
+)
+
+// AdoptionCandidate is a workload not managed by kalm, with the component which would adopt it.
+type AdoptionCandidate struct {
+	Kind      string     `json:"kind"`
+	Name      string     `json:"name"`
+	Component *Component `json:"component"`
+
+	// services selecting pods of the workload, only the one with the same name as the workload is adopted
+	Services []string `json:"services"`
+
+	// fields of the workload which are not kept by the component,
+	// they are dropped once the component is changed and kalm updates the workload
+	Diff []ComponentSpecDiff `json:"diff"`
+
+	// why the workload can't be adopted, empty if it can
+	Conflict string `json:"conflict,omitempty"`
+}
+
+type AdoptionRequest struct {
+	Kind string `json:"kind"`
+	Name string `json:"name"`
+}
+
+// adoptableWorkload is what the conversion needs to know about a workload of any kind
+type adoptableWorkload struct {
+	kind           string
+	meta           metaV1.ObjectMeta
+	spec           interface{}
+	podPath        string
+	template       *coreV1.PodTemplateSpec
+	replicas       *int32
+	claimTemplates []coreV1.PersistentVolumeClaim
+}
+
+// workload fields which are decided by kalm, they are not reported in the diff
+var adoptionIgnoredPaths = []string{
+	".selector",
+	".template.metadata",
+	".jobTemplate.metadata",
+	".jobTemplate.spec.template.metadata",
+	".strategy.rollingUpdate",
+	".updateStrategy",
+	".revisionHistoryLimit",
+	".progressDeadlineSeconds",
+	".serviceName",
+	".podManagementPolicy",
+}
+
+// adoptionConverter converts a workload into a component spec and remembers which fields of the workload are kept
+type adoptionConverter struct {
+	workload *adoptableWorkload
+	services []coreV1.Service
+	pvcs     map[string]*coreV1.PersistentVolumeClaim
+	kept     []string
+}
+
+func (c *adoptionConverter) keep(path string) {
+	c.kept = append(c.kept, path)
+}
+
+func (c *adoptionConverter) isKept(path string) bool {
+	for _, prefix := range c.kept {
+		if path == prefix || strings.HasPrefix(path, prefix+".") || strings.HasPrefix(path, prefix+"[") {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (resourceManager *ResourceManager) listAdoptableWorkloads(namespace string) ([]*adoptableWorkload, error) {
+	var res []*adoptableWorkload
+
+	var deployments appsV1.DeploymentList
+	if err := resourceManager.List(&deployments, client.InNamespace(namespace)); err != nil {
+		return nil, err
+	}
+
+	for i := range deployments.Items {
+		deployment := &deployments.Items[i]
+		res = append(res, &adoptableWorkload{
+			kind:     AdoptionKindDeployment,
+			meta:     deployment.ObjectMeta,
+			spec:     deployment.Spec,
+			podPath:  ".template.spec",
+			template: &deployment.Spec.Template,
+			replicas: deployment.Spec.Replicas,
+		})
+	}
+
+	var statefulSets appsV1.StatefulSetList
+	if err := resourceManager.List(&statefulSets, client.InNamespace(namespace)); err != nil {
+		return nil, err
+	}
+
+	for i := range statefulSets.Items {
+		sts := &statefulSets.Items[i]
+		res = append(res, &adoptableWorkload{
+			kind:           AdoptionKindStatefulSet,
+			meta:           sts.ObjectMeta,
+			spec:           sts.Spec,
+			podPath:        ".template.spec",
+			template:       &sts.Spec.Template,
+			replicas:       sts.Spec.Replicas,
+			claimTemplates: sts.Spec.VolumeClaimTemplates,
+		})
+	}
+
+	var daemonSets appsV1.DaemonSetList
+	if err := resourceManager.List(&daemonSets, client.InNamespace(namespace)); err != nil {
+		return nil, err
+	}
+
+	for i := range daemonSets.Items {
+		ds := &daemonSets.Items[i]
+		res = append(res, &adoptableWorkload{
+			kind:     AdoptionKindDaemonSet,
+			meta:     ds.ObjectMeta,
+			spec:     ds.Spec,
+			podPath:  ".template.spec",
+			template: &ds.Spec.Template,
+		})
+	}
+
+	var cronJobs batchV1Beta1.CronJobList
+	if err := resourceManager.List(&cronJobs, client.InNamespace(namespace)); err != nil {
+		return nil, err
+	}
+
+	for i := range cronJobs.Items {
+		cronJob := &cronJobs.Items[i]
+		res = append(res, &adoptableWorkload{
+			kind:     AdoptionKindCronJob,
+			meta:     cronJob.ObjectMeta,
+			spec:     cronJob.Spec,
+			podPath:  ".jobTemplate.spec.template.spec",
+			template: &cronJob.Spec.JobTemplate.Spec.Template,
+		})
+	}
+
+	// workloads controlled by components or other controllers are not adoptable
+	adoptable := res[:0]
+
+	for _, workload := range res {
+		if metaV1.GetControllerOf(&workload.meta) == nil {
+			adoptable = append(adoptable, workload)
+		}
+	}
+
+	return adoptable, nil
+}
+
+// GetAdoptionCandidates returns workloads in the namespace which are not managed by kalm or other controllers.
+func (resourceManager *ResourceManager) GetAdoptionCandidates(namespace string) ([]*AdoptionCandidate, error) {
+	workloads, err := resourceManager.listAdoptableWorkloads(namespace)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var services coreV1.ServiceList
+	if err := resourceManager.List(&services, client.InNamespace(namespace)); err != nil {
+		return nil, err
+	}
+
+	var pvcList coreV1.PersistentVolumeClaimList
+	if err := resourceManager.List(&pvcList, client.InNamespace(namespace)); err != nil {
+		return nil, err
+	}
+
+	pvcs := make(map[string]*coreV1.PersistentVolumeClaim)
+	for i := range pvcList.Items {
+		pvcs[pvcList.Items[i].Name] = &pvcList.Items[i]
+	}
+
+	var components v1alpha1.ComponentList
+	if err := resourceManager.List(&components, client.InNamespace(namespace)); err != nil {
+		return nil, err
+	}
+
+	componentNames := make(map[string]bool)
+	for _, component := range components.Items {
+		componentNames[component.Name] = true
+	}
+
+	res := []*AdoptionCandidate{}
+
+	for _, workload := range workloads {
+		candidate, err := buildAdoptionCandidate(workload, services.Items, pvcs)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if componentNames[workload.meta.Name] {
+			candidate.Conflict = fmt.Sprintf("component %s already exists", workload.meta.Name)
+		}
+
+		res = append(res, candidate)
+	}
+
+	return res, nil
+}
+
+func (resourceManager *ResourceManager) GetAdoptionCandidate(namespace, kind, name string) (*AdoptionCandidate, error) {
+	candidates, err := resourceManager.GetAdoptionCandidates(namespace)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, candidate := range candidates {
+		if candidate.Kind == kind && candidate.Name == name {
+			return candidate, nil
+		}
+	}
+
+	return nil, errors.NewNotFound(schema.GroupResource{Group: "apps", Resource: strings.ToLower(kind)}, name)
+}
+
+// AdoptWorkload creates the component of the candidate, the controller takes ownership of the workload and its service.
+func (resourceManager *ResourceManager) AdoptWorkload(candidate *AdoptionCandidate, changedBy, changedVia string) (*v1alpha1.Component, error) {
+	component := &v1alpha1.Component{
+		ObjectMeta: metaV1.ObjectMeta{
+			Name:      candidate.Component.Name,
+			Namespace: candidate.Component.Namespace,
+			Annotations: map[string]string{
+				// generation of a new component is 1
+				v1alpha1.KalmAnnoAdoptedGeneration: "1",
+			},
+		},
+		Spec: *candidate.Component.ComponentSpec,
+	}
+
+	SetComponentChangeCause(component, changedBy, changedVia)
+
+	if err := resourceManager.Create(component); err != nil {
+		return nil, err
+	}
+
+	return component, nil
+}
+
+func buildAdoptionCandidate(workload *adoptableWorkload, services []coreV1.Service, pvcs map[string]*coreV1.PersistentVolumeClaim) (*AdoptionCandidate, error) {
+	c := &adoptionConverter{
+		workload: workload,
+		pvcs:     pvcs,
+		kept:     append([]string{}, adoptionIgnoredPaths...),
+	}
+
+	var serviceNames []string
+
+	for _, service := range services {
+		if len(service.Spec.Selector) == 0 {
+			continue
+		}
+
+		if labels.SelectorFromSet(service.Spec.Selector).Matches(labels.Set(workload.template.Labels)) {
+			serviceNames = append(serviceNames, service.Name)
+
+			if service.Name == workload.meta.Name {
+				c.services = append(c.services, service)
+			}
+		}
+	}
+
+	spec := c.convert()
+
+	fields, err := flattenJSONObject(workload.spec)
+
+	if err != nil {
+		return nil, err
+	}
+
+	diff := []ComponentSpecDiff{}
+
+	for path, value := range fields {
+		if !c.isKept(path) {
+			diff = append(diff, ComponentSpecDiff{Path: ".spec" + path, From: value})
+		}
+	}
+
+	sort.Slice(diff, func(i, j int) bool {
+		return diff[i].Path < diff[j].Path
+	})
+
+	return &AdoptionCandidate{
+		Kind: workload.kind,
+		Name: workload.meta.Name,
+		Component: &Component{
+			Name:          workload.meta.Name,
+			Namespace:     workload.meta.Namespace,
+			ComponentSpec: spec,
+		},
+		Services: serviceNames,
+		Diff:     diff,
+	}, nil
+}
+
+func (c *adoptionConverter) convert() *v1alpha1.ComponentSpec {
+	workload := c.workload
+	podSpec := &workload.template.Spec
+	podPath := workload.podPath
+
+	spec := &v1alpha1.ComponentSpec{
+		Replicas: workload.replicas,
+	}
+
+	c.keep(".replicas")
+
+	switch workload.kind {
+	case AdoptionKindDeployment:
+		spec.WorkloadType = v1alpha1.WorkloadTypeServer
+		spec.RestartStrategy = workload.spec.(appsV1.DeploymentSpec).Strategy.Type
+		c.keep(".strategy.type")
+	case AdoptionKindStatefulSet:
+		spec.WorkloadType = v1alpha1.WorkloadTypeStatefulSet
+	case AdoptionKindDaemonSet:
+		spec.WorkloadType = v1alpha1.WorkloadTypeDaemonSet
+	case AdoptionKindCronJob:
+		cronJob := workload.spec.(batchV1Beta1.CronJobSpec)
+		spec.WorkloadType = v1alpha1.WorkloadTypeCronjob
+		spec.Schedule = cronJob.Schedule
+		spec.RestartPolicy = podSpec.RestartPolicy
+		spec.CronJob = &v1alpha1.CronJobConfig{
+			ConcurrencyPolicy:          cronJob.ConcurrencyPolicy,
+			StartingDeadlineSeconds:    cronJob.StartingDeadlineSeconds,
+			SuccessfulJobsHistoryLimit: cronJob.SuccessfulJobsHistoryLimit,
+			FailedJobsHistoryLimit:     cronJob.FailedJobsHistoryLimit,
+		}
+
+		if cronJob.Suspend != nil {
+			spec.CronJob.Suspend = *cronJob.Suspend
+		}
+
+		c.keep(".schedule")
+		c.keep(".concurrencyPolicy")
+		c.keep(".startingDeadlineSeconds")
+		c.keep(".successfulJobsHistoryLimit")
+		c.keep(".failedJobsHistoryLimit")
+		c.keep(".suspend")
+	}
+
+	// pods of other workloads are always restarted
+	c.keep(podPath + ".restartPolicy")
+
+	spec.DnsPolicy = podSpec.DNSPolicy
+	spec.TerminationGracePeriodSeconds = podSpec.TerminationGracePeriodSeconds
+	spec.NodeSelectorLabels = podSpec.NodeSelector
+	spec.Tolerations = podSpec.Tolerations
+	spec.PriorityClassName = podSpec.PriorityClassName
+
+	for _, field := range []string{"dnsPolicy", "terminationGracePeriodSeconds", "nodeSelector", "tolerations", "priorityClassName", "schedulerName"} {
+		c.keep(podPath + "." + field)
+	}
+
+	if len(podSpec.Containers) > 0 {
+		c.convertMainContainer(spec, &podSpec.Containers[0], fmt.Sprintf("%s.containers[0]", podPath))
+	}
+
+	for i := 1; i < len(podSpec.Containers); i++ {
+		spec.Sidecars = append(spec.Sidecars, c.convertContainer(&podSpec.Containers[i], fmt.Sprintf("%s.containers[%d]", podPath, i)))
+	}
+
+	for i := range podSpec.InitContainers {
+		spec.InitContainers = append(spec.InitContainers, c.convertContainer(&podSpec.InitContainers[i], fmt.Sprintf("%s.initContainers[%d]", podPath, i)))
+	}
+
+	return spec
+}
+
+func (c *adoptionConverter) convertMainContainer(spec *v1alpha1.ComponentSpec, container *coreV1.Container, path string) {
+	spec.Image = container.Image
+	spec.Command = joinCommand(container.Command, container.Args)
+	spec.Env = c.convertEnv(container.Env, path)
+	spec.LivenessProbe = container.LivenessProbe
+	spec.ReadinessProbe = container.ReadinessProbe
+
+	if len(container.Resources.Limits) > 0 || len(container.Resources.Requests) > 0 {
+		spec.ResourceRequirements = container.Resources.DeepCopy()
+	}
+
+	for _, field := range []string{"name", "image", "command", "livenessProbe", "readinessProbe", "resources", "imagePullPolicy", "terminationMessagePath", "terminationMessagePolicy"} {
+		c.keep(path + "." + field)
+	}
+
+	// args are kept in the command, unless there is no command to run them with
+	if len(container.Command) > 0 {
+		c.keep(path + ".args")
+	}
+
+	for i, port := range container.Ports {
+		spec.Ports = append(spec.Ports, c.convertPort(port))
+		c.keep(fmt.Sprintf("%s.ports[%d]", path, i))
+	}
+
+	for i, mount := range container.VolumeMounts {
+		if volume := c.convertVolumeMount(mount); volume != nil {
+			spec.Volumes = append(spec.Volumes, *volume)
+			c.keep(fmt.Sprintf("%s.volumeMounts[%d].name", path, i))
+			c.keep(fmt.Sprintf("%s.volumeMounts[%d].mountPath", path, i))
+		}
+	}
+}
+
+func (c *adoptionConverter) convertContainer(container *coreV1.Container, path string) v1alpha1.ComponentContainer {
+	res := v1alpha1.ComponentContainer{
+		Name:    container.Name,
+		Image:   container.Image,
+		Command: container.Command,
+		Args:    container.Args,
+		Env:     c.convertEnv(container.Env, path),
+	}
+
+	if len(container.Resources.Limits) > 0 || len(container.Resources.Requests) > 0 {
+		res.ResourceRequirements = container.Resources.DeepCopy()
+	}
+
+	for _, field := range []string{"name", "image", "command", "args", "resources", "imagePullPolicy", "terminationMessagePath", "terminationMessagePolicy"} {
+		c.keep(path + "." + field)
+	}
+
+	return res
+}
+
+// convertEnv converts static values, secrets and field refs, other sources are dropped.
+func (c *adoptionConverter) convertEnv(envs []coreV1.EnvVar, path string) []v1alpha1.EnvVar {
+	var res []v1alpha1.EnvVar
+
+	for i, env := range envs {
+		var converted *v1alpha1.EnvVar
+
+		switch {
+		case env.ValueFrom == nil:
+			converted = &v1alpha1.EnvVar{Name: env.Name, Value: env.Value, Type: v1alpha1.EnvVarTypeStatic}
+		case env.ValueFrom.SecretKeyRef != nil:
+			ref := env.ValueFrom.SecretKeyRef
+			converted = &v1alpha1.EnvVar{Name: env.Name, Value: ref.Name + "/" + ref.Key, Type: v1alpha1.EnvVarTypeSecret}
+		case env.ValueFrom.FieldRef != nil:
+			converted = &v1alpha1.EnvVar{Name: env.Name, Value: env.ValueFrom.FieldRef.FieldPath, Type: v1alpha1.EnvVarTypeFieldRef}
+		}
+
+		if converted != nil {
+			res = append(res, *converted)
+			c.keep(fmt.Sprintf("%s.env[%d]", path, i))
+		}
+	}
+
+	return res
+}
+
+// convertPort uses the service port of the adopted service if any, so clients of the service are not affected.
+func (c *adoptionConverter) convertPort(containerPort coreV1.ContainerPort) v1alpha1.Port {
+	port := v1alpha1.Port{
+		ContainerPort: uint32(containerPort.ContainerPort),
+		ServicePort:   uint32(containerPort.ContainerPort),
+		Protocol:      guessPortProtocol(containerPort.Protocol, containerPort.Name),
+	}
+
+	for _, service := range c.services {
+		for _, servicePort := range service.Spec.Ports {
+			target := servicePort.TargetPort
+
+			if target.IntVal == containerPort.ContainerPort || (target.StrVal != "" && target.StrVal == containerPort.Name) {
+				port.ServicePort = uint32(servicePort.Port)
+				port.Protocol = guessPortProtocol(servicePort.Protocol, servicePort.Name, containerPort.Name)
+			}
+		}
+	}
+
+	return port
+}
+
+// guessPortProtocol follows the istio convention of port names, e.g. http-web
+func guessPortProtocol(protocol coreV1.Protocol, names ...string) v1alpha1.PortProtocol {
+	if protocol == coreV1.ProtocolUDP {
+		return v1alpha1.PortProtocolUDP
+	}
+
+	protocols := []v1alpha1.PortProtocol{
+		v1alpha1.PortProtocolGRPCWEB,
+		v1alpha1.PortProtocolGRPC,
+		v1alpha1.PortProtocolHTTP2,
+		v1alpha1.PortProtocolHTTPS,
+		v1alpha1.PortProtocolHTTP,
+		v1alpha1.PortProtocolTCP,
+	}
+
+	for _, name := range names {
+		for _, p := range protocols {
+			if name == string(p) || strings.HasPrefix(name, string(p)+"-") {
+				return p
+			}
+		}
+	}
+
+	return v1alpha1.PortProtocolTCP
+}
+
+// convertVolumeMount converts mounts of pvc, temporary and host path volumes, nil for other volumes.
+func (c *adoptionConverter) convertVolumeMount(mount coreV1.VolumeMount) *v1alpha1.Volume {
+	podSpec := &c.workload.template.Spec
+
+	for i, claimTemplate := range c.workload.claimTemplates {
+		if claimTemplate.Name != mount.Name {
+			continue
+		}
+
+		c.keep(fmt.Sprintf(".volumeClaimTemplates[%d]", i))
+
+		return &v1alpha1.Volume{
+			Path:             mount.MountPath,
+			Type:             v1alpha1.VolumeTypePersistentVolumeClaimTemplate,
+			Size:             claimTemplate.Spec.Resources.Requests[coreV1.ResourceStorage],
+			StorageClassName: claimTemplate.Spec.StorageClassName,
+		}
+	}
+
+	for i, volume := range podSpec.Volumes {
+		if volume.Name != mount.Name {
+			continue
+		}
+
+		res := &v1alpha1.Volume{Path: mount.MountPath}
+
+		switch {
+		case volume.PersistentVolumeClaim != nil:
+			res.Type = v1alpha1.VolumeTypePersistentVolumeClaim
+			res.PVC = volume.PersistentVolumeClaim.ClaimName
+
+			if pvc, exist := c.pvcs[res.PVC]; exist {
+				res.Size = pvc.Spec.Resources.Requests[coreV1.ResourceStorage]
+				res.StorageClassName = pvc.Spec.StorageClassName
+			}
+		case volume.EmptyDir != nil && volume.EmptyDir.Medium == coreV1.StorageMediumMemory:
+			res.Type = v1alpha1.VolumeTypeTemporaryMemory
+		case volume.EmptyDir != nil:
+			res.Type = v1alpha1.VolumeTypeTemporaryDisk
+		case volume.HostPath != nil:
+			res.Type = v1alpha1.VolumeTypeHostPath
+			res.HostPath = volume.HostPath.Path
+		default:
+			return nil
+		}
+
+		if volume.EmptyDir != nil && volume.EmptyDir.SizeLimit != nil {
+			res.Size = *volume.EmptyDir.SizeLimit
+		}
+
+		c.keep(fmt.Sprintf("%s.volumes[%d]", c.workload.podPath, i))
+
+		return res
+	}
+
+	return nil
+}
+
+// joinCommand joins the command and args into the command of a component, which is run by sh if it has spaces,
+// so parts are quoted for the shell.
+func joinCommand(command, args []string) string {
+	if len(command) == 0 {
+		return ""
+	}
+
+	parts := make([]string, 0, len(command)+len(args))
+
+	for _, part := range append(append([]string{}, command...), args...) {
+		if part == "" || strings.ContainsAny(part, " \t\n\"'$`\\|&;<>()*?[]{}#~") {
+			part = "'" + strings.ReplaceAll(part, "'", `'\''`) + "'"
+		}
+
+		parts = append(parts, part)
+	}
+
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,158 @@
+package resources
+
+import (
+	"testing"
+
+	"github.com/kalmhq/kalm/controller/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	appsV1 "k8s.io/api/apps/v1"
+	coreV1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func TestBuildAdoptionCandidate(t *testing.T) {
+	replicas := int32(3)
+
+	spec := appsV1.DeploymentSpec{
+		Replicas: &replicas,
+		Selector: &metaV1.LabelSelector{MatchLabels: map[string]string{"app": "web"}},
+		Strategy: appsV1.DeploymentStrategy{Type: appsV1.RecreateDeploymentStrategyType},
+		Template: coreV1.PodTemplateSpec{
+			ObjectMeta: metaV1.ObjectMeta{Labels: map[string]string{"app": "web"}},
+			Spec: coreV1.PodSpec{
+				Containers: []coreV1.Container{
+					{
+						Name:    "web",
+						Image:   "nginx:1.19",
+						Command: []string{"nginx"},
+						Args:    []string{"-g", "daemon off;"},
+						Env: []coreV1.EnvVar{
+							{Name: "MODE", Value: "production"},
+							{Name: "PASSWORD", ValueFrom: &coreV1.EnvVarSource{
+								SecretKeyRef: &coreV1.SecretKeySelector{
+									LocalObjectReference: coreV1.LocalObjectReference{Name: "db"},
+									Key:                  "password",
+								},
+							}},
+							{Name: "CONFIG", ValueFrom: &coreV1.EnvVarSource{
+								ConfigMapKeyRef: &coreV1.ConfigMapKeySelector{
+									LocalObjectReference: coreV1.LocalObjectReference{Name: "web"},
+									Key:                  "config",
+								},
+							}},
+						},
+						Ports: []coreV1.ContainerPort{
+							{Name: "http", ContainerPort: 8080},
+							{ContainerPort: 9000},
+						},
+						VolumeMounts: []coreV1.VolumeMount{
+							{Name: "data", MountPath: "/data"},
+							{Name: "config", MountPath: "/etc/nginx/conf.d"},
+						},
+					},
+					{Name: "logger", Image: "fluentd"},
+				},
+				Volumes: []coreV1.Volume{
+					{Name: "data", VolumeSource: coreV1.VolumeSource{
+						PersistentVolumeClaim: &coreV1.PersistentVolumeClaimVolumeSource{ClaimName: "web-data"},
+					}},
+					{Name: "config", VolumeSource: coreV1.VolumeSource{
+						ConfigMap: &coreV1.ConfigMapVolumeSource{LocalObjectReference: coreV1.LocalObjectReference{Name: "web"}},
+					}},
+				},
+			},
+		},
+	}
+
+	workload := &adoptableWorkload{
+		kind:     AdoptionKindDeployment,
+		meta:     metaV1.ObjectMeta{Name: "web", Namespace: "shop"},
+		spec:     spec,
+		podPath:  ".template.spec",
+		template: &spec.Template,
+		replicas: spec.Replicas,
+	}
+
+	services := []coreV1.Service{
+		{
+			ObjectMeta: metaV1.ObjectMeta{Name: "web"},
+			Spec: coreV1.ServiceSpec{
+				Selector: map[string]string{"app": "web"},
+				Ports:    []coreV1.ServicePort{{Name: "http", Port: 80, TargetPort: intstr.FromString("http")}},
+			},
+		},
+		{
+			ObjectMeta: metaV1.ObjectMeta{Name: "web-metrics"},
+			Spec:       coreV1.ServiceSpec{Selector: map[string]string{"app": "web"}},
+		},
+		{
+			ObjectMeta: metaV1.ObjectMeta{Name: "db"},
+			Spec:       coreV1.ServiceSpec{Selector: map[string]string{"app": "db"}},
+		},
+	}
+
+	pvcs := map[string]*coreV1.PersistentVolumeClaim{
+		"web-data": {
+			Spec: coreV1.PersistentVolumeClaimSpec{
+				Resources: coreV1.ResourceRequirements{
+					Requests: coreV1.ResourceList{coreV1.ResourceStorage: resource.MustParse("2Gi")},
+				},
+			},
+		},
+	}
+
+	candidate, err := buildAdoptionCandidate(workload, services, pvcs)
+
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	assert.Equal(t, []string{"web", "web-metrics"}, candidate.Services)
+
+	component := candidate.Component
+	assert.Equal(t, "web", component.Name)
+	assert.Equal(t, v1alpha1.WorkloadTypeServer, component.WorkloadType)
+	assert.Equal(t, int32(3), *component.Replicas)
+	assert.Equal(t, appsV1.RecreateDeploymentStrategyType, component.RestartStrategy)
+	assert.Equal(t, "nginx:1.19", component.Image)
+	assert.Equal(t, "nginx -g 'daemon off;'", component.Command)
+	assert.Equal(t, []v1alpha1.EnvVar{
+		{Name: "MODE", Value: "production", Type: v1alpha1.EnvVarTypeStatic},
+		{Name: "PASSWORD", Value: "db/password", Type: v1alpha1.EnvVarTypeSecret},
+	}, component.Env)
+	assert.Equal(t, []v1alpha1.Port{
+		{ContainerPort: 8080, ServicePort: 80, Protocol: v1alpha1.PortProtocolHTTP},
+		{ContainerPort: 9000, ServicePort: 9000, Protocol: v1alpha1.PortProtocolTCP},
+	}, component.ComponentSpec.Ports)
+
+	assert.Len(t, component.Volumes, 1)
+	assert.Equal(t, v1alpha1.VolumeTypePersistentVolumeClaim, component.Volumes[0].Type)
+	assert.Equal(t, "web-data", component.Volumes[0].PVC)
+	assert.Equal(t, "2Gi", component.Volumes[0].Size.String())
+
+	assert.Len(t, component.Sidecars, 1)
+	assert.Equal(t, "fluentd", component.Sidecars[0].Image)
+
+	var paths []string
+	for _, diff := range candidate.Diff {
+		paths = append(paths, diff.Path)
+	}
+
+	assert.Equal(t, []string{
+		".spec.template.spec.containers[0].env[2].name",
+		".spec.template.spec.containers[0].env[2].valueFrom.configMapKeyRef.key",
+		".spec.template.spec.containers[0].env[2].valueFrom.configMapKeyRef.name",
+		".spec.template.spec.containers[0].volumeMounts[1].mountPath",
+		".spec.template.spec.containers[0].volumeMounts[1].name",
+		".spec.template.spec.volumes[1].configMap.name",
+		".spec.template.spec.volumes[1].name",
+	}, paths)
+}
+
+func TestJoinCommand(t *testing.T) {
+	assert.Equal(t, "", joinCommand(nil, []string{"--help"}))
+	assert.Equal(t, "redis-server", joinCommand([]string{"redis-server"}, nil))
+	assert.Equal(t, `sh -c 'echo "it'\''s $HOME"'`, joinCommand([]string{"sh", "-c"}, []string{`echo "it's $HOME"`}))
+}
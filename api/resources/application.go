@@ -89,10 +89,28 @@ type PodMetrics struct {
 
 type ApplicationDetails struct {
 	*Application         `json:",inline"`
-	Metrics              MetricHistories       `json:"metrics"`
-	IstioMetricHistories *IstioMetricHistories `json:"istioMetricHistories"`
-	Roles                []string              `json:"roles"`
-	Status               string                `json:"status"` // Active or Terminating
+	Metrics              MetricHistories        `json:"metrics"`
+	IstioMetricHistories *IstioMetricHistories  `json:"istioMetricHistories"`
+	Roles                []string               `json:"roles"`
+	Status               string                 `json:"status"` // Active or Terminating
+	QuotaUsage           *ApplicationQuotaUsage `json:"quotaUsage,omitempty"`
+	Suspended            bool                   `json:"suspended"`
+	ScalingSchedules     []ComponentScaling     `json:"scalingSchedules,omitempty"`
+	// routes to the application respond with the maintenance response
+	Maintenance *v1alpha1.ApplicationMaintenance `json:"maintenance,omitempty"`
+}
+
+// ComponentScaling reports scaling schedules of a component, and the one in effect if any
+type ComponentScaling struct {
+	Component string                                   `json:"component"`
+	Schedules []v1alpha1.ScalingSchedule               `json:"schedules"`
+	Active    *v1alpha1.ComponentScalingScheduleStatus `json:"active,omitempty"`
+}
+
+// ApplicationQuotaUsage reports the resources used by the application, tracked by its ResourceQuota
+type ApplicationQuotaUsage struct {
+	Hard coreV1.ResourceList `json:"hard"`
+	Used coreV1.ResourceList `json:"used"`
 }
 
 type CreateOrUpdateApplicationRequest struct {
@@ -100,7 +118,8 @@ type CreateOrUpdateApplicationRequest struct {
 }
 
 type Application struct {
-	Name string `json:"name"`
+	Name  string                     `json:"name"`
+	Quota *v1alpha1.ApplicationQuota `json:"quota,omitempty"`
 }
 
 func (resourceManager *ResourceManager) GetNamespace(name string) (*coreV1.Namespace, error) {
@@ -168,9 +187,38 @@ func (resourceManager *ResourceManager) BuildApplicationDetails(namespace *coreV
 		}
 	}
 
+	quota, err := v1alpha1.GetApplicationQuota(namespace)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var quotaUsage *ApplicationQuotaUsage
+
+	if quota != nil {
+		quotaUsage, err = resourceManager.GetApplicationQuotaUsage(nsName)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	scalingSchedules, err := resourceManager.GetApplicationScalingSchedules(nsName)
+
+	if err != nil {
+		return nil, err
+	}
+
+	maintenance, err := v1alpha1.GetApplicationMaintenance(namespace)
+
+	if err != nil {
+		return nil, err
+	}
+
 	return &ApplicationDetails{
 		Application: &Application{
-			Name: nsName,
+			Name:  nsName,
+			Quota: quota,
 		},
 		Metrics: MetricHistories{
 			CPU:    applicationMetric.CPU,
@@ -178,9 +226,93 @@ func (resourceManager *ResourceManager) BuildApplicationDetails(namespace *coreV
 		},
 		IstioMetricHistories: istioMetricHistories,
 		Status:               string(namespace.Status.Phase),
+		QuotaUsage:           quotaUsage,
+		Suspended:            v1alpha1.IsApplicationSuspended(namespace),
+		ScalingSchedules:     scalingSchedules,
+		Maintenance:          maintenance,
+	}, nil
+}
+
+// GetApplicationScalingSchedules returns scaling schedules of components which have any.
+func (resourceManager *ResourceManager) GetApplicationScalingSchedules(namespace string) ([]ComponentScaling, error) {
+	var componentList v1alpha1.ComponentList
+
+	if err := resourceManager.List(&componentList, client.InNamespace(namespace)); err != nil {
+		return nil, err
+	}
+
+	var res []ComponentScaling
+
+	for _, component := range componentList.Items {
+		if len(component.Spec.ScalingSchedules) == 0 {
+			continue
+		}
+
+		res = append(res, ComponentScaling{
+			Component: component.Name,
+			Schedules: component.Spec.ScalingSchedules,
+			Active:    component.Status.ScalingSchedule,
+		})
+	}
+
+	return res, nil
+}
+
+// GetApplicationQuotaUsage returns nil if the ResourceQuota is not created by the controller yet.
+func (resourceManager *ResourceManager) GetApplicationQuotaUsage(namespace string) (*ApplicationQuotaUsage, error) {
+	var resourceQuota coreV1.ResourceQuota
+
+	if err := resourceManager.Get(namespace, v1alpha1.ApplicationResourceQuotaName, &resourceQuota); err != nil {
+		return nil, client.IgnoreNotFound(err)
+	}
+
+	return &ApplicationQuotaUsage{
+		Hard: resourceQuota.Status.Hard,
+		Used: resourceQuota.Status.Used,
 	}, nil
 }
 
+func (resourceManager *ResourceManager) UpdateApplicationQuota(namespace *coreV1.Namespace, quota *v1alpha1.ApplicationQuota) (*coreV1.Namespace, error) {
+	copied := namespace.DeepCopy()
+
+	if err := v1alpha1.SetApplicationQuota(copied, quota); err != nil {
+		return nil, err
+	}
+
+	if err := resourceManager.Patch(copied, client.MergeFrom(namespace)); err != nil {
+		return nil, err
+	}
+
+	return copied, nil
+}
+
+// UpdateApplicationSuspended suspends or resumes the application, components are scaled by the controller.
+func (resourceManager *ResourceManager) UpdateApplicationSuspended(namespace *coreV1.Namespace, suspended bool) (*coreV1.Namespace, error) {
+	copied := namespace.DeepCopy()
+	v1alpha1.SetApplicationSuspended(copied, suspended)
+
+	if err := resourceManager.Patch(copied, client.MergeFrom(namespace)); err != nil {
+		return nil, err
+	}
+
+	return copied, nil
+}
+
+// UpdateApplicationMaintenance starts or ends the maintenance of the application, routes are changed by the controller.
+func (resourceManager *ResourceManager) UpdateApplicationMaintenance(namespace *coreV1.Namespace, maintenance *v1alpha1.ApplicationMaintenance) (*coreV1.Namespace, error) {
+	copied := namespace.DeepCopy()
+
+	if err := v1alpha1.SetApplicationMaintenance(copied, maintenance); err != nil {
+		return nil, err
+	}
+
+	if err := resourceManager.Patch(copied, client.MergeFrom(namespace)); err != nil {
+		return nil, err
+	}
+
+	return copied, nil
+}
+
 // TODO formatters should be deleted in the feature, Use validator instead
 func formatEnvs(envs []v1alpha1.EnvVar) {
 	for i := range envs {
@@ -33,6 +33,31 @@ func (resourceManager *ResourceManager) GetComponent(namespace, name string) (*v
 	return component, nil
 }
 
+func SetComponentChangeCause(component *v1alpha1.Component, changedBy, changedVia string) {
+	if component.Annotations == nil {
+		component.Annotations = make(map[string]string)
+	}
+
+	component.Annotations[v1alpha1.KalmAnnoComponentChangedBy] = changedBy
+	component.Annotations[v1alpha1.KalmAnnoComponentChangedVia] = changedVia
+}
+
+// ApplyComponentSpec updates the spec of the component together with the change cause,
+// so the revision created by the controller is able to record who made the change.
+func (resourceManager *ResourceManager) ApplyComponentSpec(component *v1alpha1.Component, changedBy, changedVia string) error {
+	var fetched v1alpha1.Component
+
+	if err := resourceManager.Get(component.Namespace, component.Name, &fetched); err != nil {
+		return err
+	}
+
+	copied := fetched.DeepCopy()
+	copied.Spec = component.Spec
+	SetComponentChangeCause(copied, changedBy, changedVia)
+
+	return resourceManager.Patch(copied, client.MergeFrom(&fetched))
+}
+
 func (resourceManager *ResourceManager) GetComponentListChannel(namespaces string, listOptions metaV1.ListOptions) *ComponentListChannel {
 	channel := &ComponentListChannel{
 		List:  make(chan []v1alpha1.Component, 1),
@@ -63,6 +88,34 @@ type Component struct {
 	*v1alpha1.ProtectedEndpointSpec `json:"protectedEndpoint,omitempty"`
 }
 
+// GetSecretsReferredByComponent returns names of secrets referred by secret env vars and pre-injected files.
+func GetSecretsReferredByComponent(spec *v1alpha1.ComponentSpec) []string {
+	var names []string
+	seen := make(map[string]bool)
+
+	add := func(name string) {
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+
+	for _, env := range spec.GetAllEnvs() {
+		if env.Type == v1alpha1.EnvVarTypeSecret {
+			secretName, _ := v1alpha1.ParseSecretEnvValue(env)
+			add(secretName)
+		}
+	}
+
+	for _, file := range spec.PreInjectedFiles {
+		if file.SecretRef != nil {
+			add(file.SecretRef.Name)
+		}
+	}
+
+	return names
+}
+
 type CPUQuantity struct {
 	resource.Quantity
 }
@@ -205,7 +258,7 @@ func (resourceManager *ResourceManager) BuildComponentDetails(
 		Pods:                 podsStatus,
 	}
 
-	if component.Spec.WorkloadType == v1alpha1.WorkloadTypeCronjob {
+	if component.Spec.WorkloadType == v1alpha1.WorkloadTypeCronjob || component.Spec.WorkloadType == v1alpha1.WorkloadTypeJob {
 		jobs := findJobs(resources.JobList, component.Name)
 		jobsStatus := make([]JobStatus, len(jobs))
 
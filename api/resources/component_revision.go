@@ -0,0 +1,196 @@
+package resources
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/kalmhq/kalm/controller/api/v1alpha1"
+	"github.com/kalmhq/kalm/controller/controllers"
+	appsV1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+type ComponentRevision struct {
+	Name              string                  `json:"name"`
+	Revision          int64                   `json:"revision"`
+	CreationTimestamp int64                   `json:"creationTimestamp"`
+	ChangedBy         string                  `json:"changedBy"`
+	ChangedVia        string                  `json:"changedVia"`
+	Image             string                  `json:"image"`
+	IsCurrent         bool                    `json:"isCurrent"`
+	Spec              *v1alpha1.ComponentSpec `json:"spec,omitempty"`
+}
+
+type ComponentSpecDiff struct {
+	// json path of the changed field, e.g. .image or .env[0].value
+	Path string      `json:"path"`
+	From interface{} `json:"from,omitempty"`
+	To   interface{} `json:"to,omitempty"`
+}
+
+func BuildComponentRevision(revision *appsV1.ControllerRevision, currentRevisionName string) (*ComponentRevision, error) {
+	var spec v1alpha1.ComponentSpec
+
+	if err := json.Unmarshal(revision.Data.Raw, &spec); err != nil {
+		return nil, err
+	}
+
+	return &ComponentRevision{
+		Name:              revision.Name,
+		Revision:          revision.Revision,
+		CreationTimestamp: revision.CreationTimestamp.UnixNano() / int64(time.Millisecond),
+		ChangedBy:         revision.Annotations[v1alpha1.KalmAnnoComponentChangedBy],
+		ChangedVia:        revision.Annotations[v1alpha1.KalmAnnoComponentChangedVia],
+		Image:             spec.Image,
+		IsCurrent:         revision.Name == currentRevisionName,
+		Spec:              &spec,
+	}, nil
+}
+
+func currentComponentRevisionName(component *v1alpha1.Component) (string, error) {
+	data, err := controllers.ComponentRevisionData(&component.Spec)
+
+	if err != nil {
+		return "", err
+	}
+
+	return controllers.ComponentRevisionName(component.Name, data), nil
+}
+
+// GetComponentRevisions returns revisions of the component, the latest one comes first.
+func (resourceManager *ResourceManager) GetComponentRevisions(component *v1alpha1.Component) ([]*ComponentRevision, error) {
+	var revisionList appsV1.ControllerRevisionList
+
+	if err := resourceManager.List(
+		&revisionList,
+		client.InNamespace(component.Namespace),
+		client.MatchingLabels{v1alpha1.KalmLabelComponentKey: component.Name},
+	); err != nil {
+		return nil, err
+	}
+
+	currentRevisionName, err := currentComponentRevisionName(component)
+
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]*ComponentRevision, 0, len(revisionList.Items))
+
+	for i := range revisionList.Items {
+		revision := &revisionList.Items[i]
+
+		if !metaV1.IsControlledBy(revision, component) {
+			continue
+		}
+
+		componentRevision, err := BuildComponentRevision(revision, currentRevisionName)
+
+		if err != nil {
+			return nil, err
+		}
+
+		res = append(res, componentRevision)
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Revision > res[j].Revision
+	})
+
+	return res, nil
+}
+
+func (resourceManager *ResourceManager) GetComponentRevision(component *v1alpha1.Component, revision int64) (*ComponentRevision, error) {
+	revisions, err := resourceManager.GetComponentRevisions(component)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, r := range revisions {
+		if r.Revision == revision {
+			return r, nil
+		}
+	}
+
+	return nil, errors.NewNotFound(appsV1.Resource("controllerrevisions"), fmt.Sprintf("%s revision %d", component.Name, revision))
+}
+
+// DiffComponentSpecs returns changed fields from one spec to another, sorted by path.
+func DiffComponentSpecs(from, to *v1alpha1.ComponentSpec) ([]ComponentSpecDiff, error) {
+	fromFields, err := flattenJSONObject(from)
+
+	if err != nil {
+		return nil, err
+	}
+
+	toFields, err := flattenJSONObject(to)
+
+	if err != nil {
+		return nil, err
+	}
+
+	res := []ComponentSpecDiff{}
+
+	for path, fromValue := range fromFields {
+		toValue, exist := toFields[path]
+
+		if !exist {
+			res = append(res, ComponentSpecDiff{Path: path, From: fromValue})
+		} else if !reflect.DeepEqual(fromValue, toValue) {
+			res = append(res, ComponentSpecDiff{Path: path, From: fromValue, To: toValue})
+		}
+	}
+
+	for path, toValue := range toFields {
+		if _, exist := fromFields[path]; !exist {
+			res = append(res, ComponentSpecDiff{Path: path, To: toValue})
+		}
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Path < res[j].Path
+	})
+
+	return res, nil
+}
+
+// flattenJSONObject returns values of the object by their json paths
+func flattenJSONObject(object interface{}) (map[string]interface{}, error) {
+	bts, err := json.Marshal(object)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var obj interface{}
+
+	if err := json.Unmarshal(bts, &obj); err != nil {
+		return nil, err
+	}
+
+	res := make(map[string]interface{})
+	flattenJSONValue("", obj, res)
+
+	return res, nil
+}
+
+func flattenJSONValue(path string, value interface{}, res map[string]interface{}) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			flattenJSONValue(path+"."+key, child, res)
+		}
+	case []interface{}:
+		for i, child := range v {
+			flattenJSONValue(fmt.Sprintf("%s[%d]", path, i), child, res)
+		}
+	default:
+		res[path] = v
+	}
+}
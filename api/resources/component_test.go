@@ -0,0 +1,62 @@
+package resources
+
+import (
+	"testing"
+
+	"github.com/kalmhq/kalm/controller/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSecretsReferredByComponent(t *testing.T) {
+	spec := v1alpha1.ComponentSpec{
+		Env: []v1alpha1.EnvVar{
+			{Name: "FOO", Value: "foo"},
+			{Name: "PASSWORD", Value: "db/password", Type: v1alpha1.EnvVarTypeSecret},
+			{Name: "USER", Value: "db/user", Type: v1alpha1.EnvVarTypeSecret},
+		},
+		PreInjectedFiles: []v1alpha1.PreInjectFile{
+			{MountPath: "/etc/foo", Content: "foo"},
+			{MountPath: "/etc/tls.key", SecretRef: &v1alpha1.SecretKeyReference{Name: "tls", Key: "tls.key"}},
+		},
+		Sidecars: []v1alpha1.ComponentContainer{
+			{
+				Name: "proxy",
+				Env: []v1alpha1.EnvVar{
+					{Name: "CREDENTIALS", Value: "cloud-sql/credentials", Type: v1alpha1.EnvVarTypeSecret},
+				},
+			},
+		},
+	}
+
+	assert.Equal(t, []string{"db", "cloud-sql", "tls"}, GetSecretsReferredByComponent(&spec))
+}
+
+func TestDiffComponentSpecs(t *testing.T) {
+	from := &v1alpha1.ComponentSpec{
+		Image: "foo:v1",
+		Env: []v1alpha1.EnvVar{
+			{Name: "FOO", Value: "foo"},
+		},
+	}
+
+	to := &v1alpha1.ComponentSpec{
+		Image: "foo:v2",
+		Env: []v1alpha1.EnvVar{
+			{Name: "FOO", Value: "bar"},
+			{Name: "BAR", Value: "bar"},
+		},
+	}
+
+	diff, err := DiffComponentSpecs(from, to)
+	assert.Nil(t, err)
+	assert.Equal(t, []ComponentSpecDiff{
+		{Path: ".env[0].value", From: "foo", To: "bar"},
+		{Path: ".env[1].name", To: "BAR"},
+		{Path: ".env[1].value", To: "bar"},
+		{Path: ".image", From: "foo:v1", To: "foo:v2"},
+	}, diff)
+
+	diff, err = DiffComponentSpecs(to, to)
+	assert.Nil(t, err)
+	assert.Len(t, diff, 0)
+}
@@ -0,0 +1,73 @@
+package resources
+
+import (
+	"github.com/kalmhq/kalm/api/compose"
+	"github.com/kalmhq/kalm/controller/controllers"
+	coreV1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type ComposeImportResult struct {
+	Components  []Component  `json:"components"`
+	HttpRoutes  []*HttpRoute `json:"httpRoutes"`
+	Unsupported []string     `json:"unsupported"`
+}
+
+func BuildComposeImportResult(result *compose.Result) *ComposeImportResult {
+	res := &ComposeImportResult{
+		Components:  make([]Component, 0, len(result.Components)),
+		HttpRoutes:  make([]*HttpRoute, 0, len(result.HttpRoutes)),
+		Unsupported: result.Unsupported,
+	}
+
+	for _, component := range result.Components {
+		res.Components = append(res.Components, Component{
+			Name:          component.Name,
+			Namespace:     component.Namespace,
+			ComponentSpec: &component.Spec,
+		})
+	}
+
+	for _, route := range result.HttpRoutes {
+		res.HttpRoutes = append(res.HttpRoutes, BuildHttpRouteFromResource(route))
+	}
+
+	return res
+}
+
+// ImportCompose creates the application if it doesn't exist yet, then creates the converted components and routes.
+func (resourceManager *ResourceManager) ImportCompose(application string, result *compose.Result, changedBy, changedVia string) error {
+	if _, err := resourceManager.GetNamespace(application); err != nil {
+		if !errors.IsNotFound(err) {
+			return err
+		}
+
+		if err := resourceManager.CreateNamespace(&coreV1.Namespace{
+			ObjectMeta: metaV1.ObjectMeta{
+				Name: application,
+				Labels: map[string]string{
+					controllers.KalmEnableLabelName: controllers.KalmEnableLabelValue,
+				},
+			},
+		}); err != nil {
+			return err
+		}
+	}
+
+	for _, component := range result.Components {
+		SetComponentChangeCause(component, changedBy, changedVia)
+
+		if err := resourceManager.Create(component); err != nil {
+			return err
+		}
+	}
+
+	for _, route := range result.HttpRoutes {
+		if err := resourceManager.Create(route); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,89 @@
+package resources
+
+import (
+	"time"
+
+	"github.com/kalmhq/kalm/controller/api/v1alpha1"
+	"github.com/robfig/cron"
+	batchv1beta1 "k8s.io/api/batch/v1beta1"
+	"k8s.io/apimachinery/pkg/api/errors"
+)
+
+type CronJobExecutions struct {
+	Schedule              string    `json:"schedule"`
+	TimeZone              string    `json:"timeZone,omitempty"`
+	Suspended             bool      `json:"suspended"`
+	LastScheduleTimestamp int64     `json:"lastScheduleTimestamp,omitempty"`
+	NextScheduleTimestamp int64     `json:"nextScheduleTimestamp,omitempty"`
+	Executions            []*JobRun `json:"executions"`
+}
+
+// getNextScheduleTime returns zero time if the schedule is suspended or invalid.
+func getNextScheduleTime(component *v1alpha1.Component, now time.Time) time.Time {
+	config := component.Spec.CronJob
+
+	if config != nil && config.Suspend {
+		return time.Time{}
+	}
+
+	schedule, err := cron.ParseStandard(component.Spec.Schedule)
+	if err != nil {
+		return time.Time{}
+	}
+
+	location := time.UTC
+	if config != nil && config.TimeZone != "" {
+		if location, err = time.LoadLocation(config.TimeZone); err != nil {
+			return time.Time{}
+		}
+	}
+
+	return schedule.Next(now.In(location))
+}
+
+func (resourceManager *ResourceManager) GetCronJobExecutions(component *v1alpha1.Component) (*CronJobExecutions, error) {
+	runs, err := resourceManager.GetJobRuns(component)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &CronJobExecutions{
+		Schedule:   component.Spec.Schedule,
+		Executions: runs,
+	}
+
+	if config := component.Spec.CronJob; config != nil {
+		res.TimeZone = config.TimeZone
+		res.Suspended = config.Suspend
+	}
+
+	if next := getNextScheduleTime(component, time.Now()); !next.IsZero() {
+		res.NextScheduleTimestamp = next.UnixNano() / int64(time.Millisecond)
+	}
+
+	var cronJob batchv1beta1.CronJob
+	err = resourceManager.Get(component.Namespace, component.Name, &cronJob)
+
+	if err != nil && !errors.IsNotFound(err) {
+		return nil, err
+	}
+
+	if err == nil && cronJob.Status.LastScheduleTime != nil {
+		res.LastScheduleTimestamp = cronJob.Status.LastScheduleTime.UnixNano() / int64(time.Millisecond)
+	}
+
+	return res, nil
+}
+
+// SetCronJobSuspended suspends or resumes the schedule of the cronjob component.
+func (resourceManager *ResourceManager) SetCronJobSuspended(component *v1alpha1.Component, suspended bool, changedBy, changedVia string) error {
+	copied := component.DeepCopy()
+
+	if copied.Spec.CronJob == nil {
+		copied.Spec.CronJob = &v1alpha1.CronJobConfig{}
+	}
+
+	copied.Spec.CronJob.Suspend = suspended
+
+	return resourceManager.ApplyComponentSpec(copied, changedBy, changedVia)
+}
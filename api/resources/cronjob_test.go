@@ -0,0 +1,29 @@
+package resources
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kalmhq/kalm/controller/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetNextScheduleTime(t *testing.T) {
+	now := time.Date(2020, 10, 1, 20, 0, 0, 0, time.UTC)
+
+	component := &v1alpha1.Component{
+		Spec: v1alpha1.ComponentSpec{
+			WorkloadType: v1alpha1.WorkloadTypeCronjob,
+			Schedule:     "0 2 * * *",
+		},
+	}
+
+	assert.Equal(t, time.Date(2020, 10, 2, 2, 0, 0, 0, time.UTC), getNextScheduleTime(component, now).UTC())
+
+	// 02:00 in Shanghai is 18:00 UTC of the previous day
+	component.Spec.CronJob = &v1alpha1.CronJobConfig{TimeZone: "Asia/Shanghai"}
+	assert.Equal(t, time.Date(2020, 10, 2, 18, 0, 0, 0, time.UTC), getNextScheduleTime(component, now).UTC())
+
+	component.Spec.CronJob.Suspend = true
+	assert.True(t, getNextScheduleTime(component, now).IsZero())
+}
@@ -0,0 +1,307 @@
+package resources
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/kalmhq/kalm/controller/api/v1alpha1"
+	"github.com/kalmhq/kalm/controller/controllers"
+	"gopkg.in/yaml.v3"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+const (
+	ExportFormatYAML = "yaml"
+	ExportFormatHelm = "helm"
+)
+
+// exportValuePlaceholder marks a value which is replaced with a template of the helm chart,
+// it is a plain scalar in yaml so the template is not quoted.
+const exportValuePlaceholder = "__KALM_EXPORT_VALUE_%d__"
+
+// ExportedComponentValues are values of a component which can be changed when installing the helm chart
+type ExportedComponentValues struct {
+	Image    string `yaml:"image"`
+	Replicas *int64 `yaml:"replicas,omitempty"`
+}
+
+// ExportApplicationManifests returns objects kalm creates for the application as multi-document yaml
+func (resourceManager *ResourceManager) ExportApplicationManifests(name string) ([]byte, error) {
+	objects, err := resourceManager.exportApplicationObjects(name)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+
+	for i, obj := range objects {
+		if i > 0 {
+			buf.WriteString("---\n")
+		}
+
+		bts, err := marshalExportedYAML(obj)
+
+		if err != nil {
+			return nil, err
+		}
+
+		buf.Write(bts)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// ExportApplicationHelmChart returns the application as a packaged helm chart,
+// images and replicas of components are values of the chart.
+// The namespace is not part of the chart, it's created by helm install --create-namespace.
+func (resourceManager *ResourceManager) ExportApplicationHelmChart(name string) ([]byte, error) {
+	objects, err := resourceManager.exportApplicationObjects(name)
+
+	if err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]*ExportedComponentValues)
+	files := make(map[string][]byte)
+	var fileNames []string
+
+	for _, obj := range objects {
+		kind, _ := obj["kind"].(string)
+
+		if kind == "Namespace" {
+			continue
+		}
+
+		templates := parameterizeExportedObject(obj, values)
+
+		bts, err := marshalExportedYAML(obj)
+
+		if err != nil {
+			return nil, err
+		}
+
+		content := escapeHelmTemplate(string(bts))
+		for i, template := range templates {
+			content = strings.Replace(content, fmt.Sprintf(exportValuePlaceholder, i), template, 1)
+		}
+
+		metadata, _ := obj["metadata"].(map[string]interface{})
+		fileName := fmt.Sprintf("templates/%s-%s.yaml", strings.ToLower(kind), metadata["name"])
+		fileNames = append(fileNames, fileName)
+		files[fileName] = []byte(content)
+	}
+
+	chart, err := marshalExportedYAML(map[string]interface{}{
+		"apiVersion":  "v2",
+		"name":        name,
+		"description": fmt.Sprintf("Application %s exported from kalm", name),
+		"type":        "application",
+		"version":     "0.1.0",
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	valuesFile, err := marshalExportedYAML(map[string]interface{}{"components": values})
+
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	gzipWriter := gzip.NewWriter(&buf)
+	tarWriter := tar.NewWriter(gzipWriter)
+	now := time.Now()
+
+	writeFile := func(fileName string, content []byte) error {
+		if err := tarWriter.WriteHeader(&tar.Header{
+			Name:    name + "/" + fileName,
+			Mode:    0644,
+			Size:    int64(len(content)),
+			ModTime: now,
+		}); err != nil {
+			return err
+		}
+
+		_, err := tarWriter.Write(content)
+		return err
+	}
+
+	if err := writeFile("Chart.yaml", chart); err != nil {
+		return nil, err
+	}
+
+	if err := writeFile("values.yaml", valuesFile); err != nil {
+		return nil, err
+	}
+
+	for _, fileName := range fileNames {
+		if err := writeFile(fileName, files[fileName]); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tarWriter.Close(); err != nil {
+		return nil, err
+	}
+
+	if err := gzipWriter.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// exportApplicationObjects renders objects of the application by the controller and converts them into json objects
+func (resourceManager *ResourceManager) exportApplicationObjects(name string) ([]map[string]interface{}, error) {
+	objects, err := controllers.ExportApplication(resourceManager.ctx, resourceManager.Client, name)
+
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]map[string]interface{}, 0, len(objects))
+
+	for _, obj := range objects {
+		exported, err := toExportedObject(obj)
+
+		if err != nil {
+			return nil, err
+		}
+
+		res = append(res, exported)
+	}
+
+	return res, nil
+}
+
+func toExportedObject(obj runtime.Object) (map[string]interface{}, error) {
+	bts, err := json.Marshal(obj)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var res map[string]interface{}
+
+	if err := json.Unmarshal(bts, &res); err != nil {
+		return nil, err
+	}
+
+	delete(res, "status")
+	removeNullFields(res)
+
+	return res, nil
+}
+
+// removeNullFields removes fields which are null in json, e.g. the zero creationTimestamp of pod templates
+func removeNullFields(value interface{}) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			if child == nil {
+				delete(v, key)
+			} else {
+				removeNullFields(child)
+			}
+		}
+	case []interface{}:
+		for _, child := range v {
+			removeNullFields(child)
+		}
+	}
+}
+
+// escapeHelmTemplate keeps template delimiters in manifests, e.g. in env values or files, as they are when helm renders them.
+// Only "{{" starts an action, "}}" outside of actions is plain text.
+func escapeHelmTemplate(content string) string {
+	return strings.ReplaceAll(content, "{{", `{{ "{{" }}`)
+}
+
+// parameterizeExportedObject replaces the image and replicas of the component workload with placeholders,
+// values of the component are collected and templates of placeholders are returned in order.
+func parameterizeExportedObject(obj map[string]interface{}, values map[string]*ExportedComponentValues) []string {
+	metadata, _ := obj["metadata"].(map[string]interface{})
+	labels, _ := metadata["labels"].(map[string]interface{})
+	component, _ := labels[v1alpha1.KalmLabelComponentKey].(string)
+	spec, _ := obj["spec"].(map[string]interface{})
+
+	if component == "" || spec == nil {
+		return nil
+	}
+
+	var podSpecPath []string
+
+	switch obj["kind"] {
+	case "Deployment", "StatefulSet", "DaemonSet", "Job":
+		podSpecPath = []string{"template", "spec"}
+	case "CronJob":
+		podSpecPath = []string{"jobTemplate", "spec", "template", "spec"}
+	default:
+		return nil
+	}
+
+	podSpec := spec
+	for _, key := range podSpecPath {
+		podSpec, _ = podSpec[key].(map[string]interface{})
+	}
+
+	containers, _ := podSpec["containers"].([]interface{})
+
+	var mainContainer map[string]interface{}
+	for _, c := range containers {
+		if container, ok := c.(map[string]interface{}); ok && container["name"] == component {
+			mainContainer = container
+		}
+	}
+
+	if mainContainer == nil {
+		return nil
+	}
+
+	componentValues := values[component]
+
+	if componentValues == nil {
+		componentValues = &ExportedComponentValues{}
+		values[component] = componentValues
+	}
+
+	valuesRef := fmt.Sprintf("(index .Values.components %q)", component)
+
+	componentValues.Image, _ = mainContainer["image"].(string)
+	mainContainer["image"] = fmt.Sprintf(exportValuePlaceholder, 0)
+	templates := []string{fmt.Sprintf("{{ %s.image | quote }}", valuesRef)}
+
+	if replicas, ok := spec["replicas"].(float64); ok {
+		value := int64(replicas)
+		componentValues.Replicas = &value
+		spec["replicas"] = fmt.Sprintf(exportValuePlaceholder, 1)
+		templates = append(templates, fmt.Sprintf("{{ %s.replicas }}", valuesRef))
+	}
+
+	return templates
+}
+
+func marshalExportedYAML(value interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+
+	if err := encoder.Encode(value); err != nil {
+		return nil, err
+	}
+
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,99 @@
+package resources
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+	"text/template"
+
+	"github.com/stretchr/testify/assert"
+	appsV1 "k8s.io/api/apps/v1"
+	coreV1 "k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestParameterizeExportedObject(t *testing.T) {
+	replicas := int32(2)
+
+	deployment := &appsV1.Deployment{
+		TypeMeta: metaV1.TypeMeta{Kind: "Deployment", APIVersion: "apps/v1"},
+		ObjectMeta: metaV1.ObjectMeta{
+			Name:      "web",
+			Namespace: "shop",
+			Labels:    map[string]string{"kalm-component": "web"},
+		},
+		Spec: appsV1.DeploymentSpec{
+			Replicas: &replicas,
+			Template: coreV1.PodTemplateSpec{
+				Spec: coreV1.PodSpec{
+					Containers: []coreV1.Container{
+						{Name: "web", Image: "nginx:1.19"},
+						{Name: "logger", Image: "fluentd"},
+					},
+				},
+			},
+		},
+	}
+
+	obj, err := toExportedObject(deployment)
+
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	values := make(map[string]*ExportedComponentValues)
+	templates := parameterizeExportedObject(obj, values)
+
+	assert.Equal(t, "nginx:1.19", values["web"].Image)
+	assert.Equal(t, int64(2), *values["web"].Replicas)
+	assert.Equal(t, []string{
+		`{{ (index .Values.components "web").image | quote }}`,
+		`{{ (index .Values.components "web").replicas }}`,
+	}, templates)
+
+	bts, err := marshalExportedYAML(obj)
+
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	content := string(bts)
+	assert.NotContains(t, content, "creationTimestamp")
+	assert.NotContains(t, content, "status")
+	assert.Contains(t, content, fmt.Sprintf("replicas: "+exportValuePlaceholder, 1))
+	assert.Contains(t, content, fmt.Sprintf("image: "+exportValuePlaceholder, 0))
+	assert.Contains(t, content, "image: fluentd")
+	assert.True(t, strings.HasPrefix(content, "apiVersion: apps/v1\nkind: Deployment\n"))
+}
+
+func TestParameterizeExportedObjectWithoutComponent(t *testing.T) {
+	obj, err := toExportedObject(&coreV1.Service{
+		TypeMeta:   metaV1.TypeMeta{Kind: "Service", APIVersion: "v1"},
+		ObjectMeta: metaV1.ObjectMeta{Name: "web", Labels: map[string]string{"kalm-component": "web"}},
+	})
+
+	assert.Nil(t, err)
+
+	values := make(map[string]*ExportedComponentValues)
+	assert.Nil(t, parameterizeExportedObject(obj, values))
+	assert.Empty(t, values)
+}
+
+func TestEscapeHelmTemplate(t *testing.T) {
+	content := "value: '{{ .Values.foo }} {{- bar -}} }}'\nimage: " + fmt.Sprintf(exportValuePlaceholder, 0) + "\n"
+	escaped := escapeHelmTemplate(content)
+
+	// helm renders templates with text/template, the content is kept as it is
+	tmpl, err := template.New("manifest").Parse(escaped)
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	var buf bytes.Buffer
+	assert.Nil(t, tmpl.Execute(&buf, nil))
+	assert.Equal(t, content, buf.String())
+
+	// placeholders are not affected
+	assert.Contains(t, escaped, fmt.Sprintf(exportValuePlaceholder, 0))
+}
@@ -0,0 +1,206 @@
+package resources
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/kalmhq/kalm/controller/lib/files"
+	coreV1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+)
+
+type MoveFileRequest struct {
+	OldPath string `json:"oldPath"`
+	NewPath string `json:"newPath"`
+}
+
+// getKalmFilesConfigMap returns the kalm-files config map of the namespace.
+// If it doesn't exist yet, an empty one with the root dir is returned without being created.
+func (resourceManager *ResourceManager) getKalmFilesConfigMap(namespace string) (*coreV1.ConfigMap, bool, error) {
+	var configMap coreV1.ConfigMap
+
+	if err := resourceManager.Get(namespace, files.KALM_CONFIG_MAP_NAME, &configMap); err != nil {
+		if !errors.IsNotFound(err) {
+			return nil, false, err
+		}
+
+		return &coreV1.ConfigMap{
+			ObjectMeta: metaV1.ObjectMeta{
+				Name:      files.KALM_CONFIG_MAP_NAME,
+				Namespace: namespace,
+			},
+			Data: map[string]string{
+				files.KALM_SLASH_REPLACER: files.KALM_PERSISTENT_DIR_PLACEHOLDER,
+			},
+		}, false, nil
+	}
+
+	return &configMap, true, nil
+}
+
+// updateKalmFiles applies the change to the kalm-files config map, retries if there is a conflict.
+// Errors returned by the change and the size check are reported as bad requests.
+func (resourceManager *ResourceManager) updateKalmFiles(namespace string, change func(configMap *coreV1.ConfigMap) error) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		configMap, exist, err := resourceManager.getKalmFilesConfigMap(namespace)
+
+		if err != nil {
+			return err
+		}
+
+		if err := change(configMap); err != nil {
+			return errors.NewBadRequest(err.Error())
+		}
+
+		files.CleanUpConfigMap(configMap)
+
+		if err := files.CheckSize(configMap); err != nil {
+			return errors.NewBadRequest(err.Error())
+		}
+
+		if exist {
+			return resourceManager.Update(configMap)
+		}
+
+		return resourceManager.Create(configMap)
+	})
+}
+
+func validateFilePath(path string) error {
+	if err := files.ValidatePath(path); err != nil {
+		return errors.NewBadRequest(err.Error())
+	}
+
+	if path == "/" {
+		return errors.NewBadRequest("can't change the root dir")
+	}
+
+	return nil
+}
+
+func (resourceManager *ResourceManager) GetFileItemTree(namespace, path string) (*files.FileItem, error) {
+	if err := files.ValidatePath(path); err != nil {
+		return nil, errors.NewBadRequest(err.Error())
+	}
+
+	configMap, _, err := resourceManager.getKalmFilesConfigMap(namespace)
+
+	if err != nil {
+		return nil, err
+	}
+
+	root, err := files.GetFileItemTree(configMap, path)
+
+	if err != nil {
+		return nil, errors.NewNotFound(coreV1.Resource("files"), path)
+	}
+
+	return root, nil
+}
+
+func (resourceManager *ResourceManager) CreateFile(namespace string, file *files.File) (*files.FileItem, error) {
+	if err := validateFilePath(file.Path); err != nil {
+		return nil, err
+	}
+
+	err := resourceManager.updateKalmFiles(namespace, func(configMap *coreV1.ConfigMap) error {
+		return files.AddFile(configMap, file)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return resourceManager.GetFileItemTree(namespace, file.Path)
+}
+
+func (resourceManager *ResourceManager) UpdateFile(namespace string, file *files.File) (*files.FileItem, error) {
+	if err := validateFilePath(file.Path); err != nil {
+		return nil, err
+	}
+
+	err := resourceManager.updateKalmFiles(namespace, func(configMap *coreV1.ConfigMap) error {
+		return files.UpdateFile(configMap, file)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return resourceManager.GetFileItemTree(namespace, file.Path)
+}
+
+func (resourceManager *ResourceManager) DeleteFile(namespace, path string) error {
+	if err := validateFilePath(path); err != nil {
+		return err
+	}
+
+	return resourceManager.updateKalmFiles(namespace, func(configMap *coreV1.ConfigMap) error {
+		return files.DeleteFile(configMap, &files.File{Path: path})
+	})
+}
+
+// MoveFile moves a file or a dir, empty dirs created by users are moved as well.
+func (resourceManager *ResourceManager) MoveFile(namespace, oldPath, newPath string) (*files.FileItem, error) {
+	if err := validateFilePath(oldPath); err != nil {
+		return nil, err
+	}
+
+	if err := validateFilePath(newPath); err != nil {
+		return nil, err
+	}
+
+	if oldPath == newPath || strings.HasPrefix(newPath, oldPath+"/") {
+		return nil, errors.NewBadRequest(fmt.Sprintf("can't move %s to %s", oldPath, newPath))
+	}
+
+	err := resourceManager.updateKalmFiles(namespace, func(configMap *coreV1.ConfigMap) error {
+		if _, exist := configMap.Data[files.EncodeFilePath(newPath)]; exist {
+			return fmt.Errorf("%s already exists", newPath)
+		}
+
+		root, err := files.GetFileItemTree(configMap, oldPath)
+
+		if err != nil {
+			return fmt.Errorf("%s doesn't exist", oldPath)
+		}
+
+		if root.IsDir {
+			encodedOldPath := files.EncodeFilePath(oldPath)
+
+			for key, content := range configMap.Data {
+				if content != files.KALM_PERSISTENT_DIR_PLACEHOLDER {
+					continue
+				}
+
+				if key != encodedOldPath && !strings.HasPrefix(key, encodedOldPath+files.KALM_SLASH_REPLACER) {
+					continue
+				}
+
+				dirPath := newPath + strings.TrimPrefix(files.DecodeFilePath(key), oldPath)
+
+				if err := files.AddFile(configMap, &files.File{Path: dirPath, IsDir: true}); err != nil {
+					return err
+				}
+			}
+		}
+
+		if err := files.MoveFile(configMap, root, newPath); err != nil {
+			return err
+		}
+
+		if root.IsDir {
+			return files.DeleteFile(configMap, &files.File{Path: oldPath})
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return resourceManager.GetFileItemTree(namespace, newPath)
+}
@@ -1,29 +1,17 @@
 package resources
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
-	"io/ioutil"
-	"net/http"
-	"net/url"
 	"os"
 	"strconv"
 	"time"
 
 	"github.com/kalmhq/kalm/api/log"
+	"github.com/kalmhq/kalm/controller/lib/istiometric"
 	"go.uber.org/zap"
 )
 
-var istioPrometheusAPIAddress string
-
-func init() {
-	if os.Getenv("KALM_ISTIO_PROMETHEUS_API_ADDRESS") != "" {
-		istioPrometheusAPIAddress = os.Getenv("KALM_ISTIO_PROMETHEUS_API_ADDRESS")
-	} else {
-		istioPrometheusAPIAddress = "http://prometheus.istio-system:9090"
-	}
-}
-
 type IstioMetricListChannel struct {
 	// svc -> histories
 	List  chan map[string]*IstioMetricHistories
@@ -126,19 +114,11 @@ func getIstioMetricHistoriesMap(ns string) (map[string]*IstioMetricHistories, er
 	respContentChan := make(chan respContent, len(queryMap))
 
 	for k, query := range queryMap {
-		api := fmt.Sprintf("%s/api/v1/query_range?query=%s&start=%d&end=%d&step=%d",
-			istioPrometheusAPIAddress,
-			url.QueryEscape(query),
-			startAs30MinAgo,
-			now,
-			stepAs1Min,
-		)
-
-		go func(k, api string) {
-			promResp, err := queryPrometheusAPI(api)
+		go func(k, query string) {
+			promResp, err := istiometric.QueryRange(context.Background(), query, startAs30MinAgo, now, stepAs1Min)
 
 			if err != nil {
-				log.Debug("err when queryPrometheusAPI, ignored", zap.String("api", api), zap.Error(err))
+				log.Debug("err when query prometheus api, ignored", zap.String("query", query), zap.Error(err))
 			}
 
 			respContentChan <- respContent{
@@ -146,7 +126,7 @@ func getIstioMetricHistoriesMap(ns string) (map[string]*IstioMetricHistories, er
 				Resp: promResp,
 				Err:  err,
 			}
-		}(k, api)
+		}(k, query)
 	}
 
 	cnt := 0
@@ -256,39 +236,6 @@ func parseInterfaceAsTime(i interface{}) (time.Time, bool) {
 	return t, true
 }
 
-func queryPrometheusAPI(api string) (PromResponse, error) {
-	resp, err := http.Get(api)
-	if err != nil {
-		return PromResponse{}, err
-	}
-
-	var promResp PromResponse
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return PromResponse{}, err
-	}
-
-	log.Debug("prom api", zap.String("api", api), zap.Any("resp", body))
-
-	err = json.Unmarshal(body, &promResp)
-
-	if err != nil {
-		log.Error("fail to parse resp from prometheus", zap.Any("val", body), zap.Error(err))
-		return PromResponse{}, err
-	}
-
-	return promResp, nil
-}
-
-type PromResponse struct {
-	Status string `json:"status"`
-	Data   struct {
-		ResultType string             `json:"resultType"`
-		Result     []PromMatrixResult `json:"result"`
-	} `json:"data"`
-}
-
-type PromMatrixResult struct {
-	Metric map[string]string `json:"metric"`
-	Values [][]interface{}   `json:"values,string"`
-}
+// the prometheus client is shared with the controller, which analyzes canary rollouts with istio metrics
+type PromResponse = istiometric.PromResponse
+type PromMatrixResult = istiometric.PromMatrixResult
@@ -1,8 +1,17 @@
 package resources
 
 import (
+	"fmt"
+	"net/url"
+	"sort"
+	"time"
+
+	"github.com/kalmhq/kalm/controller/api/v1alpha1"
 	batchv1 "k8s.io/api/batch/v1"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
@@ -75,3 +84,177 @@ func (resourceManager *ResourceManager) GetJobListChannel(opts ...client.ListOpt
 
 	return channel
 }
+
+const (
+	JobRunStatusPending   = "Pending"
+	JobRunStatusRunning   = "Running"
+	JobRunStatusSucceeded = "Succeeded"
+	JobRunStatusFailed    = "Failed"
+)
+
+// JobRun is a run of a job component.
+type JobRun struct {
+	ID                  string   `json:"id"`
+	Name                string   `json:"name"`
+	Status              string   `json:"status"`
+	Active              int32    `json:"active"`
+	Succeeded           int32    `json:"succeeded"`
+	Failed              int32    `json:"failed"`
+	CreationTimestamp   int64    `json:"creationTimestamp"`
+	StartTimestamp      int64    `json:"startTimestamp,omitempty"`
+	CompletionTimestamp int64    `json:"completionTimestamp,omitempty"`
+	DurationSeconds     int64    `json:"durationSeconds"`
+	Pods                []string `json:"pods"`
+	// the page of the dashboard to view logs of the last pod of the run
+	LogsURL string `json:"logsURL,omitempty"`
+}
+
+func getJobCondition(job *batchv1.Job, condType batchv1.JobConditionType) *batchv1.JobCondition {
+	for i := range job.Status.Conditions {
+		if job.Status.Conditions[i].Type == condType && job.Status.Conditions[i].Status == v1.ConditionTrue {
+			return &job.Status.Conditions[i]
+		}
+	}
+
+	return nil
+}
+
+func getJobRunLogsURL(namespace, podName, containerName string) string {
+	query := url.Values{}
+	query.Add("active", podName)
+	query.Add("active", containerName)
+	query.Set("namespace", namespace)
+
+	return fmt.Sprintf("/applications/%s/logs?%s", namespace, query.Encode())
+}
+
+// BuildJobRun builds the run from the job and its pods, which are sorted by creation time.
+func BuildJobRun(job *batchv1.Job, componentName string, pods []v1.Pod, now time.Time) *JobRun {
+	run := &JobRun{
+		ID:                job.Labels[v1alpha1.KalmLabelJobRunIDKey],
+		Name:              job.Name,
+		Status:            JobRunStatusPending,
+		Active:            job.Status.Active,
+		Succeeded:         job.Status.Succeeded,
+		Failed:            job.Status.Failed,
+		CreationTimestamp: job.CreationTimestamp.UnixNano() / int64(time.Millisecond),
+		Pods:              []string{},
+	}
+
+	var finishedAt *metaV1.Time
+
+	if getJobCondition(job, batchv1.JobComplete) != nil {
+		run.Status = JobRunStatusSucceeded
+		finishedAt = job.Status.CompletionTime
+	} else if cond := getJobCondition(job, batchv1.JobFailed); cond != nil {
+		run.Status = JobRunStatusFailed
+		finishedAt = &cond.LastTransitionTime
+	} else if job.Status.StartTime != nil {
+		run.Status = JobRunStatusRunning
+	}
+
+	if job.Status.StartTime != nil {
+		run.StartTimestamp = job.Status.StartTime.UnixNano() / int64(time.Millisecond)
+
+		end := now
+		if finishedAt != nil {
+			run.CompletionTimestamp = finishedAt.UnixNano() / int64(time.Millisecond)
+			end = finishedAt.Time
+		}
+
+		run.DurationSeconds = int64(end.Sub(job.Status.StartTime.Time) / time.Second)
+	}
+
+	for _, pod := range pods {
+		run.Pods = append(run.Pods, pod.Name)
+	}
+
+	if len(pods) > 0 {
+		run.LogsURL = getJobRunLogsURL(job.Namespace, pods[len(pods)-1].Name, componentName)
+	}
+
+	return run
+}
+
+func isJobOfComponent(job *batchv1.Job, componentName string) bool {
+	if job.Labels[v1alpha1.KalmLabelComponentKey] == componentName {
+		return true
+	}
+
+	// jobs created by the cronjob before the job template is labeled
+	for _, ownerRef := range job.OwnerReferences {
+		if ownerRef.Kind == "CronJob" && ownerRef.Name == componentName {
+			return true
+		}
+	}
+
+	return false
+}
+
+// GetJobRuns returns runs of the component, the latest first.
+// Jobs of a cronjob, including manually triggered ones, are included.
+func (resourceManager *ResourceManager) GetJobRuns(component *v1alpha1.Component) ([]*JobRun, error) {
+	labels := client.MatchingLabels{v1alpha1.KalmLabelComponentKey: component.Name}
+
+	var allJobs batchv1.JobList
+	if err := resourceManager.List(&allJobs, client.InNamespace(component.Namespace)); err != nil {
+		return nil, err
+	}
+
+	var jobList batchv1.JobList
+	for i := range allJobs.Items {
+		if isJobOfComponent(&allJobs.Items[i], component.Name) {
+			jobList.Items = append(jobList.Items, allJobs.Items[i])
+		}
+	}
+
+	var podList v1.PodList
+	if err := resourceManager.List(&podList, client.InNamespace(component.Namespace), labels); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(podList.Items, func(i, j int) bool {
+		return podList.Items[i].CreationTimestamp.Before(&podList.Items[j].CreationTimestamp)
+	})
+
+	// the label is added to pods by the job controller
+	podsOfJobs := make(map[string][]v1.Pod)
+	for _, pod := range podList.Items {
+		if jobName := pod.Labels["job-name"]; jobName != "" {
+			podsOfJobs[jobName] = append(podsOfJobs[jobName], pod)
+		}
+	}
+
+	sort.Slice(jobList.Items, func(i, j int) bool {
+		return jobList.Items[j].CreationTimestamp.Before(&jobList.Items[i].CreationTimestamp)
+	})
+
+	now := time.Now()
+	runs := make([]*JobRun, 0, len(jobList.Items))
+
+	for i := range jobList.Items {
+		job := &jobList.Items[i]
+		runs = append(runs, BuildJobRun(job, component.Name, podsOfJobs[job.Name], now))
+	}
+
+	return runs, nil
+}
+
+// TriggerJobRun adds a run to the job component, the job of the run is created by the controller.
+func (resourceManager *ResourceManager) TriggerJobRun(component *v1alpha1.Component, run v1alpha1.JobRunRequest) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		copied := component.DeepCopy()
+		copied.Spec.PendingJobRuns = append(copied.Spec.PendingJobRuns, run)
+
+		err := resourceManager.Patch(copied, client.MergeFromWithOptions(component, client.MergeFromWithOptimisticLock{}))
+
+		// the controller may be cleaning created runs at the same time
+		if errors.IsConflict(err) {
+			if getErr := resourceManager.Get(component.Namespace, component.Name, component); getErr != nil {
+				return getErr
+			}
+		}
+
+		return err
+	})
+}
@@ -0,0 +1,59 @@
+package resources
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kalmhq/kalm/controller/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	batchv1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestBuildJobRun(t *testing.T) {
+	now := time.Now()
+	startTime := metaV1.NewTime(now.Add(-time.Minute))
+
+	job := &batchv1.Job{
+		ObjectMeta: metaV1.ObjectMeta{
+			Name:      "migrate-abc",
+			Namespace: "default",
+			Labels:    map[string]string{v1alpha1.KalmLabelJobRunIDKey: "abc"},
+		},
+		Status: batchv1.JobStatus{
+			StartTime: &startTime,
+			Active:    1,
+		},
+	}
+
+	pods := []v1.Pod{
+		{ObjectMeta: metaV1.ObjectMeta{Name: "migrate-abc-1"}},
+		{ObjectMeta: metaV1.ObjectMeta{Name: "migrate-abc-2"}},
+	}
+
+	run := BuildJobRun(job, "migrate", pods, now)
+	assert.Equal(t, "abc", run.ID)
+	assert.Equal(t, JobRunStatusRunning, run.Status)
+	assert.Equal(t, int64(60), run.DurationSeconds)
+	assert.Equal(t, []string{"migrate-abc-1", "migrate-abc-2"}, run.Pods)
+	assert.Equal(t, "/applications/default/logs?active=migrate-abc-2&active=migrate&namespace=default", run.LogsURL)
+
+	failedAt := metaV1.NewTime(now.Add(-30 * time.Second))
+	job.Status.Active = 0
+	job.Status.Failed = 1
+	job.Status.Conditions = []batchv1.JobCondition{
+		{Type: batchv1.JobFailed, Status: v1.ConditionTrue, LastTransitionTime: failedAt},
+	}
+
+	run = BuildJobRun(job, "migrate", nil, now)
+	assert.Equal(t, JobRunStatusFailed, run.Status)
+	assert.Equal(t, int64(30), run.DurationSeconds)
+	assert.Equal(t, "", run.LogsURL)
+
+	job.Status.StartTime = nil
+	job.Status.Conditions = nil
+	run = BuildJobRun(job, "migrate", nil, now)
+	assert.Equal(t, JobRunStatusPending, run.Status)
+	assert.Equal(t, int64(0), run.DurationSeconds)
+}
@@ -29,6 +29,8 @@ type Node struct {
 	Labels             map[string]string  `json:"labels"`
 	Annotations        map[string]string  `json:"annotations"`
 	Status             coreV1.NodeStatus  `json:"status"`
+	Taints             []coreV1.Taint     `json:"taints"`
+	Unschedulable      bool               `json:"unschedulable"`
 	StatusTexts        []string           `json:"statusTexts"`
 	Metrics            MetricHistories    `json:"metrics"`
 	Roles              []string           `json:"roles"`
@@ -149,6 +151,8 @@ func (resourceManager *ResourceManager) BuildNodeResponse(node *coreV1.Node) *No
 		Labels:             node.Labels,
 		Annotations:        node.Annotations,
 		Status:             node.Status,
+		Taints:             node.Spec.Taints,
+		Unschedulable:      node.Spec.Unschedulable,
 		Metrics:            histories.Nodes[node.Name],
 		Roles:              findNodeRoles(node),
 		CreationTimestamp:  node.CreationTimestamp.UnixNano() / int64(time.Millisecond),
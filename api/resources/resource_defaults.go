@@ -0,0 +1,94 @@
+package resources
+
+import (
+	"encoding/json"
+
+	"github.com/kalmhq/kalm/controller/api/v1alpha1"
+	coreV1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func (resourceManager *ResourceManager) UpdateApplicationResourceDefaults(namespace *coreV1.Namespace, defaults *v1alpha1.ResourceDefaults) (*v1alpha1.ResourceDefaults, error) {
+	copied := namespace.DeepCopy()
+
+	if err := v1alpha1.SetApplicationResourceDefaults(copied, defaults); err != nil {
+		return nil, err
+	}
+
+	if err := resourceManager.Patch(copied, client.MergeFrom(namespace)); err != nil {
+		return nil, err
+	}
+
+	return defaults, nil
+}
+
+func (resourceManager *ResourceManager) getClusterResourceDefaultsConfigMap() (*coreV1.ConfigMap, bool, error) {
+	var configMap coreV1.ConfigMap
+
+	if err := resourceManager.Get(v1alpha1.KalmSystemNamespace, v1alpha1.ClusterResourceDefaultsConfigMapName, &configMap); err != nil {
+		if !errors.IsNotFound(err) {
+			return nil, false, err
+		}
+
+		return &coreV1.ConfigMap{
+			ObjectMeta: metaV1.ObjectMeta{
+				Namespace: v1alpha1.KalmSystemNamespace,
+				Name:      v1alpha1.ClusterResourceDefaultsConfigMapName,
+			},
+		}, false, nil
+	}
+
+	return &configMap, true, nil
+}
+
+// GetClusterResourceDefaults returns nil if the cluster has no defaults.
+func (resourceManager *ResourceManager) GetClusterResourceDefaults() (*v1alpha1.ResourceDefaults, error) {
+	configMap, _, err := resourceManager.getClusterResourceDefaultsConfigMap()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return v1alpha1.GetClusterResourceDefaults(configMap)
+}
+
+// UpdateClusterResourceDefaults stores the defaults in the config map, nil defaults are removed.
+func (resourceManager *ResourceManager) UpdateClusterResourceDefaults(defaults *v1alpha1.ResourceDefaults) (*v1alpha1.ResourceDefaults, error) {
+	configMap, exist, err := resourceManager.getClusterResourceDefaultsConfigMap()
+
+	if err != nil {
+		return nil, err
+	}
+
+	if defaults == nil {
+		if !exist {
+			return nil, nil
+		}
+
+		return nil, resourceManager.Delete(configMap)
+	}
+
+	bts, err := json.Marshal(defaults)
+
+	if err != nil {
+		return nil, err
+	}
+
+	configMap.Data = map[string]string{
+		v1alpha1.ClusterResourceDefaultsKey: string(bts),
+	}
+
+	if exist {
+		err = resourceManager.Update(configMap)
+	} else {
+		err = resourceManager.Create(configMap)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return defaults, nil
+}
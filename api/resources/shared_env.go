@@ -0,0 +1,274 @@
+package resources
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/kalmhq/kalm/controller/api/v1alpha1"
+	coreV1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// SharedEnv is a set of env vars shared by components of an application.
+// It's stored in a ConfigMap, or in a Secret if the values are sensitive.
+type SharedEnv struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+	IsSecret  bool   `json:"isSecret"`
+
+	// Values of a secret shared env are never returned.
+	// When updating a secret shared env, an empty value keeps the current value of the key.
+	Data map[string]string `json:"data,omitempty"`
+	Keys []string          `json:"keys"`
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+
+	for k := range m {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+	return keys
+}
+
+func BuildSharedEnvFromConfigMap(configMap *coreV1.ConfigMap) *SharedEnv {
+	return &SharedEnv{
+		Name:      configMap.Name,
+		Namespace: configMap.Namespace,
+		Data:      configMap.Data,
+		Keys:      sortedKeys(configMap.Data),
+	}
+}
+
+func BuildSharedEnvFromSecret(secret *coreV1.Secret) *SharedEnv {
+	keys := make([]string, 0, len(secret.Data))
+
+	for k := range secret.Data {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	return &SharedEnv{
+		Name:      secret.Name,
+		Namespace: secret.Namespace,
+		IsSecret:  true,
+		Keys:      keys,
+	}
+}
+
+func sharedEnvLabels() map[string]string {
+	return map[string]string{
+		v1alpha1.KalmLabelSharedEnvKey: "true",
+	}
+}
+
+func (resourceManager *ResourceManager) GetSharedEnvs(namespace string) ([]*SharedEnv, error) {
+	var configMaps coreV1.ConfigMapList
+	if err := resourceManager.List(&configMaps, client.InNamespace(namespace), client.MatchingLabels(sharedEnvLabels())); err != nil {
+		return nil, err
+	}
+
+	var secrets coreV1.SecretList
+	if err := resourceManager.List(&secrets, client.InNamespace(namespace), client.MatchingLabels(sharedEnvLabels())); err != nil {
+		return nil, err
+	}
+
+	res := make([]*SharedEnv, 0, len(configMaps.Items)+len(secrets.Items))
+
+	for i := range configMaps.Items {
+		res = append(res, BuildSharedEnvFromConfigMap(&configMaps.Items[i]))
+	}
+
+	for i := range secrets.Items {
+		res = append(res, BuildSharedEnvFromSecret(&secrets.Items[i]))
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Name < res[j].Name
+	})
+
+	return res, nil
+}
+
+// getSharedEnvObjects returns the ConfigMap or the Secret of the shared env, nil if it doesn't exist.
+func (resourceManager *ResourceManager) getSharedEnvObjects(namespace, name string) (*coreV1.ConfigMap, *coreV1.Secret, error) {
+	var configMap coreV1.ConfigMap
+	if err := resourceManager.Get(namespace, name, &configMap); err != nil {
+		if !errors.IsNotFound(err) {
+			return nil, nil, err
+		}
+	} else if configMap.Labels[v1alpha1.KalmLabelSharedEnvKey] == "true" {
+		return &configMap, nil, nil
+	}
+
+	var secret coreV1.Secret
+	if err := resourceManager.Get(namespace, name, &secret); err != nil {
+		if !errors.IsNotFound(err) {
+			return nil, nil, err
+		}
+	} else if secret.Labels[v1alpha1.KalmLabelSharedEnvKey] == "true" {
+		return nil, &secret, nil
+	}
+
+	return nil, nil, nil
+}
+
+// GetSharedEnvSecretsReferredByComponent returns names of shared envs of the component which are Secrets.
+// Shared envs which don't exist are skipped, the component can't be deployed until they are created.
+func (resourceManager *ResourceManager) GetSharedEnvSecretsReferredByComponent(namespace string, spec *v1alpha1.ComponentSpec) ([]string, error) {
+	var names []string
+	seen := make(map[string]bool)
+
+	for _, env := range spec.GetAllEnvs() {
+		if env.Type != v1alpha1.EnvVarTypeExternal {
+			continue
+		}
+
+		name, _ := v1alpha1.ParseExternalEnvValue(env)
+
+		if seen[name] {
+			continue
+		}
+
+		seen[name] = true
+
+		_, secret, err := resourceManager.getSharedEnvObjects(namespace, name)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if secret != nil {
+			names = append(names, name)
+		}
+	}
+
+	return names, nil
+}
+
+func (resourceManager *ResourceManager) GetSharedEnv(namespace, name string) (*SharedEnv, error) {
+	configMap, secret, err := resourceManager.getSharedEnvObjects(namespace, name)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if configMap != nil {
+		return BuildSharedEnvFromConfigMap(configMap), nil
+	}
+
+	if secret != nil {
+		return BuildSharedEnvFromSecret(secret), nil
+	}
+
+	return nil, errors.NewNotFound(coreV1.Resource("sharedenvs"), name)
+}
+
+func (resourceManager *ResourceManager) CreateSharedEnv(sharedEnv *SharedEnv) (*SharedEnv, error) {
+	objectMeta := metaV1.ObjectMeta{
+		Name:      sharedEnv.Name,
+		Namespace: sharedEnv.Namespace,
+		Labels:    sharedEnvLabels(),
+	}
+
+	if sharedEnv.IsSecret {
+		// Data instead of StringData, keys of the response are built from it
+		data := make(map[string][]byte, len(sharedEnv.Data))
+
+		for k, v := range sharedEnv.Data {
+			data[k] = []byte(v)
+		}
+
+		secret := &coreV1.Secret{
+			ObjectMeta: objectMeta,
+			Data:       data,
+		}
+
+		if err := resourceManager.Create(secret); err != nil {
+			return nil, err
+		}
+
+		return BuildSharedEnvFromSecret(secret), nil
+	}
+
+	configMap := &coreV1.ConfigMap{
+		ObjectMeta: objectMeta,
+		Data:       sharedEnv.Data,
+	}
+
+	if err := resourceManager.Create(configMap); err != nil {
+		return nil, err
+	}
+
+	return BuildSharedEnvFromConfigMap(configMap), nil
+}
+
+func (resourceManager *ResourceManager) UpdateSharedEnv(sharedEnv *SharedEnv) (*SharedEnv, error) {
+	configMap, secret, err := resourceManager.getSharedEnvObjects(sharedEnv.Namespace, sharedEnv.Name)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if configMap == nil && secret == nil {
+		return nil, errors.NewNotFound(coreV1.Resource("sharedenvs"), sharedEnv.Name)
+	}
+
+	if (secret != nil) != sharedEnv.IsSecret {
+		return nil, errors.NewBadRequest(fmt.Sprintf("can't change type of shared env %s", sharedEnv.Name))
+	}
+
+	if configMap != nil {
+		configMap.Data = sharedEnv.Data
+
+		if err := resourceManager.Update(configMap); err != nil {
+			return nil, err
+		}
+
+		return BuildSharedEnvFromConfigMap(configMap), nil
+	}
+
+	data := make(map[string][]byte, len(sharedEnv.Data))
+
+	for k, v := range sharedEnv.Data {
+		if v == "" {
+			if current, exist := secret.Data[k]; exist {
+				data[k] = current
+				continue
+			}
+		}
+
+		data[k] = []byte(v)
+	}
+
+	secret.Data = data
+
+	if err := resourceManager.Update(secret); err != nil {
+		return nil, err
+	}
+
+	return BuildSharedEnvFromSecret(secret), nil
+}
+
+func (resourceManager *ResourceManager) DeleteSharedEnv(namespace, name string) error {
+	configMap, secret, err := resourceManager.getSharedEnvObjects(namespace, name)
+
+	if err != nil {
+		return err
+	}
+
+	if configMap != nil {
+		return resourceManager.Delete(configMap)
+	}
+
+	if secret != nil {
+		return resourceManager.Delete(secret)
+	}
+
+	return errors.NewNotFound(coreV1.Resource("sharedenvs"), name)
+}
@@ -0,0 +1,93 @@
+package v1alpha1
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+// the maintenance of an application is stored as json in this annotation of its namespace
+const KalmAnnoApplicationMaintenance = "core.kalm.dev/maintenance"
+
+// ApplicationMaintenance swaps routes to the application with the response.
+// Requests from bypass IPs or with any of the bypass headers are still sent to components.
+type ApplicationMaintenance struct {
+	Response HttpRouteDirectResponse `json:"response"`
+
+	// client IPs seen by the ingress gateway
+	BypassIPs []string `json:"bypassIPs,omitempty"`
+
+	// header -> exact value
+	BypassHeaders map[string]string `json:"bypassHeaders,omitempty"`
+}
+
+// GetApplicationMaintenance returns the maintenance of the application, nil if the application is not in maintenance.
+func GetApplicationMaintenance(namespace *v1.Namespace) (*ApplicationMaintenance, error) {
+	value, exist := namespace.Annotations[KalmAnnoApplicationMaintenance]
+
+	if !exist || value == "" {
+		return nil, nil
+	}
+
+	var maintenance ApplicationMaintenance
+	if err := json.Unmarshal([]byte(value), &maintenance); err != nil {
+		return nil, fmt.Errorf("invalid maintenance of application %s: %s", namespace.Name, err)
+	}
+
+	return &maintenance, nil
+}
+
+// SetApplicationMaintenance stores the maintenance in the annotation of the namespace, nil ends the maintenance.
+func SetApplicationMaintenance(namespace *v1.Namespace, maintenance *ApplicationMaintenance) error {
+	if maintenance == nil {
+		delete(namespace.Annotations, KalmAnnoApplicationMaintenance)
+		return nil
+	}
+
+	bts, err := json.Marshal(maintenance)
+	if err != nil {
+		return err
+	}
+
+	if namespace.Annotations == nil {
+		namespace.Annotations = make(map[string]string)
+	}
+
+	namespace.Annotations[KalmAnnoApplicationMaintenance] = string(bts)
+
+	return nil
+}
+
+func (m *ApplicationMaintenance) Validate() (rst KalmValidateErrorList) {
+	rst = append(rst, validateDirectResponse(&m.Response, ".response", false)...)
+
+	// files of other applications can't be published through routes of the application
+	if m.Response.BodyFile != nil && m.Response.BodyFile.Namespace != "" {
+		rst = append(rst, KalmValidateError{
+			Err:  "the file is read from the application in maintenance, namespace can't be set",
+			Path: ".response.bodyFile.namespace",
+		})
+	}
+
+	for i, ip := range m.BypassIPs {
+		if net.ParseIP(ip) == nil {
+			rst = append(rst, KalmValidateError{
+				Err:  "invalid ip: " + ip,
+				Path: fmt.Sprintf(".bypassIPs[%d]", i),
+			})
+		}
+	}
+
+	for _, header := range sortedKeys(m.BypassHeaders) {
+		if header == "" || m.BypassHeaders[header] == "" {
+			rst = append(rst, KalmValidateError{
+				Err:  "header and value can't be blank",
+				Path: ".bypassHeaders." + header,
+			})
+		}
+	}
+
+	return rst
+}
@@ -0,0 +1,37 @@
+package v1alpha1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+)
+
+func TestApplicationMaintenance(t *testing.T) {
+	namespace := &v1.Namespace{}
+
+	maintenance, err := GetApplicationMaintenance(namespace)
+	assert.Nil(t, err)
+	assert.Nil(t, maintenance)
+
+	maintenance = &ApplicationMaintenance{
+		Response:      HttpRouteDirectResponse{Status: 503, BodyFile: &HttpRouteBodyFile{Path: "/maintenance.html"}},
+		BypassIPs:     []string{"10.0.0.1", "::1"},
+		BypassHeaders: map[string]string{"x-bypass": "secret"},
+	}
+
+	assert.Empty(t, maintenance.Validate())
+	assert.Nil(t, SetApplicationMaintenance(namespace, maintenance))
+
+	stored, err := GetApplicationMaintenance(namespace)
+	assert.Nil(t, err)
+	assert.Equal(t, maintenance, stored)
+
+	assert.Nil(t, SetApplicationMaintenance(namespace, nil))
+	assert.NotContains(t, namespace.Annotations, KalmAnnoApplicationMaintenance)
+
+	maintenance.BypassIPs = []string{"10.0.0.0/8"}
+	maintenance.BypassHeaders = map[string]string{"x-bypass": ""}
+	maintenance.Response.BodyFile.Namespace = "other"
+	assert.Len(t, maintenance.Validate(), 3)
+}
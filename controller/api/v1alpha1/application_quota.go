@@ -0,0 +1,356 @@
+package v1alpha1
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// the quota of an application is stored as json in this annotation of its namespace
+	KalmAnnoApplicationQuota = "core.kalm.dev/quota"
+
+	// ResourceQuota & LimitRange created by the namespace controller from the application quota
+	ApplicationResourceQuotaName = "kalm-application-quota"
+	ApplicationLimitRangeName    = "kalm-application-limits"
+
+	ResourceHttpRoutesCount v1.ResourceName = "count/httproutes.core.kalm.dev"
+)
+
+// Containers without limits are limited by the LimitRange of the application,
+// otherwise they can't be created once cpu or memory of the application is limited.
+var (
+	DefaultQuotaContainerCPULimit    = resource.MustParse("200m")
+	DefaultQuotaContainerMemoryLimit = resource.MustParse("128Mi")
+)
+
+// ApplicationQuota limits the resources that can be used by all components of an application.
+// Nil fields are not limited.
+type ApplicationQuota struct {
+	// sum of cpu limits of all pods
+	CPU *resource.Quantity `json:"cpu,omitempty"`
+
+	// sum of memory limits of all pods
+	Memory *resource.Quantity `json:"memory,omitempty"`
+
+	// sum of the size of all persistent volumes
+	Storage *resource.Quantity `json:"storage,omitempty"`
+
+	Pods    *int64 `json:"pods,omitempty"`
+	Routes  *int64 `json:"routes,omitempty"`
+	Volumes *int64 `json:"volumes,omitempty"`
+}
+
+// ApplicationResourceUsage is the resources required by components, computed from their spec.
+type ApplicationResourceUsage struct {
+	CPU     resource.Quantity `json:"cpu"`
+	Memory  resource.Quantity `json:"memory"`
+	Storage resource.Quantity `json:"storage"`
+	Pods    int64             `json:"pods"`
+	Volumes int64             `json:"volumes"`
+}
+
+// GetApplicationQuota returns the quota of the application, nil if the application is not limited.
+func GetApplicationQuota(namespace *v1.Namespace) (*ApplicationQuota, error) {
+	value, exist := namespace.Annotations[KalmAnnoApplicationQuota]
+
+	if !exist || value == "" {
+		return nil, nil
+	}
+
+	var quota ApplicationQuota
+	if err := json.Unmarshal([]byte(value), &quota); err != nil {
+		return nil, fmt.Errorf("invalid quota of application %s: %s", namespace.Name, err)
+	}
+
+	return &quota, nil
+}
+
+// SetApplicationQuota stores the quota in the annotation of the namespace, a nil quota removes the limits.
+func SetApplicationQuota(namespace *v1.Namespace, quota *ApplicationQuota) error {
+	if quota == nil {
+		delete(namespace.Annotations, KalmAnnoApplicationQuota)
+		return nil
+	}
+
+	bts, err := json.Marshal(quota)
+	if err != nil {
+		return err
+	}
+
+	if namespace.Annotations == nil {
+		namespace.Annotations = make(map[string]string)
+	}
+
+	namespace.Annotations[KalmAnnoApplicationQuota] = string(bts)
+
+	return nil
+}
+
+func (q *ApplicationQuota) Validate() (rst KalmValidateErrorList) {
+	quantities := map[string]*resource.Quantity{
+		"cpu":     q.CPU,
+		"memory":  q.Memory,
+		"storage": q.Storage,
+	}
+
+	for name, quantity := range quantities {
+		if quantity != nil && quantity.Sign() < 0 {
+			rst = append(rst, KalmValidateError{Err: isNegativeErrorMsg, Path: "." + name})
+		}
+	}
+
+	counts := map[string]*int64{
+		"pods":    q.Pods,
+		"routes":  q.Routes,
+		"volumes": q.Volumes,
+	}
+
+	for name, count := range counts {
+		if count != nil && *count < 0 {
+			rst = append(rst, KalmValidateError{Err: isNegativeErrorMsg, Path: "." + name})
+		}
+	}
+
+	return rst
+}
+
+// ResourceList returns the hard limits of the ResourceQuota.
+func (q *ApplicationQuota) ResourceList() v1.ResourceList {
+	hard := v1.ResourceList{}
+
+	if q.CPU != nil {
+		hard[v1.ResourceLimitsCPU] = *q.CPU
+	}
+
+	if q.Memory != nil {
+		hard[v1.ResourceLimitsMemory] = *q.Memory
+	}
+
+	if q.Storage != nil {
+		hard[v1.ResourceRequestsStorage] = *q.Storage
+	}
+
+	if q.Pods != nil {
+		hard[v1.ResourcePods] = *resource.NewQuantity(*q.Pods, resource.DecimalSI)
+	}
+
+	if q.Routes != nil {
+		hard[ResourceHttpRoutesCount] = *resource.NewQuantity(*q.Routes, resource.DecimalSI)
+	}
+
+	if q.Volumes != nil {
+		hard[v1.ResourcePersistentVolumeClaims] = *resource.NewQuantity(*q.Volumes, resource.DecimalSI)
+	}
+
+	return hard
+}
+
+// Check returns the reasons why the usage doesn't fit in the quota, empty if it fits.
+func (q *ApplicationQuota) Check(usage ApplicationResourceUsage) []string {
+	var res []string
+
+	exceeded := func(name string, used, hard resource.Quantity) {
+		res = append(res, fmt.Sprintf("%s %s exceeds the quota %s", name, used.String(), hard.String()))
+	}
+
+	if q.CPU != nil && usage.CPU.Cmp(*q.CPU) > 0 {
+		exceeded("cpu", usage.CPU, *q.CPU)
+	}
+
+	if q.Memory != nil && usage.Memory.Cmp(*q.Memory) > 0 {
+		exceeded("memory", usage.Memory, *q.Memory)
+	}
+
+	if q.Storage != nil && usage.Storage.Cmp(*q.Storage) > 0 {
+		exceeded("storage", usage.Storage, *q.Storage)
+	}
+
+	if q.Pods != nil && usage.Pods > *q.Pods {
+		res = append(res, fmt.Sprintf("pods %d exceeds the quota %d", usage.Pods, *q.Pods))
+	}
+
+	if q.Volumes != nil && usage.Volumes > *q.Volumes {
+		res = append(res, fmt.Sprintf("volumes %d exceeds the quota %d", usage.Volumes, *q.Volumes))
+	}
+
+	return res
+}
+
+func (u *ApplicationResourceUsage) Add(other ApplicationResourceUsage) {
+	u.CPU.Add(other.CPU)
+	u.Memory.Add(other.Memory)
+	u.Storage.Add(other.Storage)
+	u.Pods += other.Pods
+	u.Volumes += other.Volumes
+}
+
+func getContainerLimit(requirements *v1.ResourceRequirements, name v1.ResourceName, defaultLimit resource.Quantity) resource.Quantity {
+	if requirements != nil {
+		if limit, exist := requirements.Limits[name]; exist {
+			return limit.DeepCopy()
+		}
+	}
+
+	return defaultLimit.DeepCopy()
+}
+
+// GetMaxPods returns the max number of pods the component can run.
+// Pods of a daemonset depend on nodes of the cluster, they are not counted.
+func (r *Component) GetMaxPods() int64 {
+	if r.Labels[KalmLabelKeyExceedingQuota] == "true" || r.Labels[KalmLabelKeySuspended] == "true" {
+		return 0
+	}
+
+	switch r.Spec.WorkloadType {
+	case WorkloadTypeDaemonSet:
+		return 0
+	case WorkloadTypeCronjob, WorkloadTypeJob:
+		if r.Spec.Job != nil && r.Spec.Job.Parallelism != nil {
+			return int64(*r.Spec.Job.Parallelism)
+		}
+
+		return 1
+	}
+
+	if r.Spec.AutoScaling != nil {
+		return int64(r.Spec.AutoScaling.MaxReplicas)
+	}
+
+	if r.Spec.Replicas == nil {
+		return 1
+	}
+
+	return int64(*r.Spec.Replicas)
+}
+
+// GetResourceUsage returns the resources required by the component at its max scale.
+// Containers without limits are counted with the default limits of the LimitRange.
+func (r *Component) GetResourceUsage() ApplicationResourceUsage {
+	pods := r.GetMaxPods()
+
+	usage := ApplicationResourceUsage{
+		Pods: pods,
+	}
+
+	cpu := getContainerLimit(r.Spec.ResourceRequirements, v1.ResourceCPU, DefaultQuotaContainerCPULimit)
+	memory := getContainerLimit(r.Spec.ResourceRequirements, v1.ResourceMemory, DefaultQuotaContainerMemoryLimit)
+
+	for _, container := range r.Spec.Sidecars {
+		cpu.Add(getContainerLimit(container.ResourceRequirements, v1.ResourceCPU, DefaultQuotaContainerCPULimit))
+		memory.Add(getContainerLimit(container.ResourceRequirements, v1.ResourceMemory, DefaultQuotaContainerMemoryLimit))
+	}
+
+	// limits of the sidecar are decided by istio if they are not set
+	if r.Spec.IstioResourceRequirements != nil {
+		if limit, exist := r.Spec.IstioResourceRequirements.Limits[v1.ResourceCPU]; exist {
+			cpu.Add(limit)
+		}
+
+		if limit, exist := r.Spec.IstioResourceRequirements.Limits[v1.ResourceMemory]; exist {
+			memory.Add(limit)
+		}
+	}
+
+	// init containers run one by one before other containers, only the largest one matters
+	for _, container := range r.Spec.InitContainers {
+		if limit := getContainerLimit(container.ResourceRequirements, v1.ResourceCPU, DefaultQuotaContainerCPULimit); limit.Cmp(cpu) > 0 {
+			cpu = limit
+		}
+
+		if limit := getContainerLimit(container.ResourceRequirements, v1.ResourceMemory, DefaultQuotaContainerMemoryLimit); limit.Cmp(memory) > 0 {
+			memory = limit
+		}
+	}
+
+	for i := int64(0); i < pods; i++ {
+		usage.CPU.Add(cpu)
+		usage.Memory.Add(memory)
+	}
+
+	for _, vol := range r.Spec.Volumes {
+		switch vol.Type {
+		case VolumeTypePersistentVolumeClaim:
+			usage.Volumes++
+			usage.Storage.Add(vol.Size)
+		case VolumeTypePersistentVolumeClaimTemplate:
+			// each pod of a statefulset has its own volume
+			for i := int64(0); i < pods; i++ {
+				usage.Volumes++
+				usage.Storage.Add(vol.Size)
+			}
+		}
+	}
+
+	return usage
+}
+
+// IsLargerThan returns true if any resource is more than the other usage.
+func (u ApplicationResourceUsage) IsLargerThan(other ApplicationResourceUsage) bool {
+	return u.CPU.Cmp(other.CPU) > 0 ||
+		u.Memory.Cmp(other.Memory) > 0 ||
+		u.Storage.Cmp(other.Storage) > 0 ||
+		u.Pods > other.Pods ||
+		u.Volumes > other.Volumes
+}
+
+// check if the component still fits in the quota of its application after it's saved,
+// changes that don't require more resources are always allowed, otherwise components already exceeding the quota
+// can't even be scaled down.
+func (r *Component) validateQuota(old *Component) KalmValidateErrorList {
+	if webhookClient == nil || IsKalmSystemNamespace(r.Namespace) {
+		return nil
+	}
+
+	if old != nil && !r.GetResourceUsage().IsLargerThan(old.GetResourceUsage()) {
+		return nil
+	}
+
+	var namespace v1.Namespace
+	if err := webhookClient.Get(context.Background(), client.ObjectKey{Name: r.Namespace}, &namespace); err != nil {
+		componentlog.Error(err, "fail to get namespace to check quota", "ns", r.Namespace)
+		return KalmValidateErrorList{{Err: "fail to get the application: " + err.Error(), Path: ".metadata.namespace"}}
+	}
+
+	quota, err := GetApplicationQuota(&namespace)
+	if err != nil {
+		return KalmValidateErrorList{{Err: err.Error(), Path: ".metadata.namespace"}}
+	}
+
+	if quota == nil {
+		return nil
+	}
+
+	var componentList ComponentList
+	if err := webhookClient.List(context.Background(), &componentList, client.InNamespace(r.Namespace)); err != nil {
+		componentlog.Error(err, "fail to list components to check quota", "ns", r.Namespace)
+		return KalmValidateErrorList{{Err: "fail to list components of the application: " + err.Error(), Path: ".spec"}}
+	}
+
+	return validateQuotaOfComponent(quota, r, componentList.Items)
+}
+
+func validateQuotaOfComponent(quota *ApplicationQuota, component *Component, components []Component) (rst KalmValidateErrorList) {
+	usage := component.GetResourceUsage()
+
+	for i := range components {
+		if components[i].Name == component.Name {
+			continue
+		}
+
+		usage.Add(components[i].GetResourceUsage())
+	}
+
+	for _, msg := range quota.Check(usage) {
+		rst = append(rst, KalmValidateError{
+			Err:  fmt.Sprintf("application %s is out of quota, %s", component.Namespace, msg),
+			Path: ".spec",
+		})
+	}
+
+	return rst
+}
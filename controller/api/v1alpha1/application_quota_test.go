@@ -0,0 +1,135 @@
+package v1alpha1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestApplicationQuotaAnnotation(t *testing.T) {
+	ns := &v1.Namespace{ObjectMeta: metaV1.ObjectMeta{Name: "app"}}
+
+	quota, err := GetApplicationQuota(ns)
+	assert.Nil(t, err)
+	assert.Nil(t, quota)
+
+	cpu := resource.MustParse("2")
+	pods := int64(10)
+	assert.Nil(t, SetApplicationQuota(ns, &ApplicationQuota{CPU: &cpu, Pods: &pods}))
+
+	quota, err = GetApplicationQuota(ns)
+	assert.Nil(t, err)
+	assert.Equal(t, "2", quota.CPU.String())
+	assert.Equal(t, int64(10), *quota.Pods)
+	assert.Nil(t, quota.Memory)
+
+	hard := quota.ResourceList()
+	assert.Len(t, hard, 2)
+	hardCPU := hard[v1.ResourceLimitsCPU]
+	hardPods := hard[v1.ResourcePods]
+	assert.Equal(t, "2", hardCPU.String())
+	assert.Equal(t, "10", hardPods.String())
+
+	assert.Nil(t, SetApplicationQuota(ns, nil))
+	quota, err = GetApplicationQuota(ns)
+	assert.Nil(t, err)
+	assert.Nil(t, quota)
+}
+
+func TestComponentResourceUsage(t *testing.T) {
+	replicas := int32(3)
+
+	component := Component{
+		ObjectMeta: metaV1.ObjectMeta{Name: "db", Namespace: "app"},
+		Spec: ComponentSpec{
+			WorkloadType: WorkloadTypeStatefulSet,
+			Replicas:     &replicas,
+			ResourceRequirements: &v1.ResourceRequirements{
+				Limits: v1.ResourceList{
+					v1.ResourceCPU: resource.MustParse("500m"),
+				},
+			},
+			Sidecars: []ComponentContainer{{Name: "exporter", Image: "exporter"}},
+			Volumes: []Volume{
+				{Type: VolumeTypePersistentVolumeClaimTemplate, Path: "/data", Size: resource.MustParse("1Gi")},
+			},
+		},
+	}
+
+	usage := component.GetResourceUsage()
+	assert.Equal(t, int64(3), usage.Pods)
+	assert.Equal(t, "2100m", usage.CPU.String())
+	assert.Equal(t, "768Mi", usage.Memory.String())
+	assert.Equal(t, "3Gi", usage.Storage.String())
+	assert.Equal(t, int64(3), usage.Volumes)
+
+	// the defaults are not changed by the usage
+	assert.Equal(t, "200m", DefaultQuotaContainerCPULimit.String())
+
+	component.Labels = map[string]string{KalmLabelKeyExceedingQuota: "true"}
+	usage = component.GetResourceUsage()
+	assert.Equal(t, int64(0), usage.Pods)
+	assert.True(t, usage.CPU.IsZero())
+}
+
+func TestValidateQuotaOfComponent(t *testing.T) {
+	cpu := resource.MustParse("1")
+	pods := int64(4)
+	quota := &ApplicationQuota{CPU: &cpu, Pods: &pods}
+
+	replicas := int32(2)
+	newComponent := func(name string) Component {
+		return Component{
+			ObjectMeta: metaV1.ObjectMeta{Name: name, Namespace: "app"},
+			Spec:       ComponentSpec{WorkloadType: WorkloadTypeServer, Replicas: &replicas},
+		}
+	}
+
+	web := newComponent("web")
+	api := newComponent("api")
+
+	// 2 * 200m + 2 * 200m
+	assert.Nil(t, validateQuotaOfComponent(quota, &web, []Component{web, api}))
+
+	replicas = 3
+	errs := validateQuotaOfComponent(quota, &web, []Component{web, api})
+	assert.Len(t, errs, 2)
+	assert.Equal(t, "application app is out of quota, cpu 1200m exceeds the quota 1", errs[0].Err)
+	assert.Equal(t, "application app is out of quota, pods 6 exceeds the quota 4", errs[1].Err)
+}
+
+func TestValidateQuotaOfUpdatedComponent(t *testing.T) {
+	ns := &v1.Namespace{ObjectMeta: metaV1.ObjectMeta{Name: "app"}}
+	cpu := resource.MustParse("1")
+	assert.Nil(t, SetApplicationQuota(ns, &ApplicationQuota{CPU: &cpu}))
+
+	replicas := int32(7)
+	web := Component{
+		ObjectMeta: metaV1.ObjectMeta{Name: "web", Namespace: "app"},
+		Spec:       ComponentSpec{WorkloadType: WorkloadTypeServer, Replicas: &replicas},
+	}
+
+	scheme := runtime.NewScheme()
+	assert.Nil(t, clientgoscheme.AddToScheme(scheme))
+	assert.Nil(t, AddToScheme(scheme))
+
+	webhookClient = fake.NewFakeClientWithScheme(scheme, ns, web.DeepCopy())
+	defer func() { webhookClient = nil }()
+
+	// 7 * 200m
+	assert.Len(t, web.validateQuota(nil), 1)
+
+	// components exceeding the quota can still be scaled down
+	scaledDown := web.DeepCopy()
+	fewerReplicas := int32(6)
+	scaledDown.Spec.Replicas = &fewerReplicas
+	assert.Nil(t, scaledDown.validateQuota(&web))
+
+	assert.Len(t, web.validateQuota(scaledDown), 1)
+}
@@ -0,0 +1,34 @@
+package v1alpha1
+
+import (
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+// when an application is suspended, this annotation of its namespace records the time
+const KalmAnnoApplicationSuspendedAt = "core.kalm.dev/suspended-at"
+
+// IsApplicationSuspended returns true if components of the application are scaled down on purpose.
+func IsApplicationSuspended(namespace *v1.Namespace) bool {
+	_, exist := namespace.Annotations[KalmAnnoApplicationSuspendedAt]
+	return exist
+}
+
+// SetApplicationSuspended suspends or resumes the application, the time of an existing suspension is kept.
+func SetApplicationSuspended(namespace *v1.Namespace, suspended bool) {
+	if !suspended {
+		delete(namespace.Annotations, KalmAnnoApplicationSuspendedAt)
+		return
+	}
+
+	if IsApplicationSuspended(namespace) {
+		return
+	}
+
+	if namespace.Annotations == nil {
+		namespace.Annotations = make(map[string]string)
+	}
+
+	namespace.Annotations[KalmAnnoApplicationSuspendedAt] = time.Now().UTC().Format(time.RFC3339)
+}
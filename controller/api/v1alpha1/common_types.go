@@ -1,6 +1,8 @@
 package v1alpha1
 
 import (
+	"strings"
+
 	rbacV1 "k8s.io/api/rbac/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 )
@@ -16,12 +18,17 @@ const (
 	EnvVarTypeLinked   EnvVarType = "linked"
 	EnvVarTypeFieldRef EnvVarType = "fieldref"
 	EnvVarTypeBuiltin  EnvVarType = "builtin"
+	EnvVarTypeSecret   EnvVarType = "secret"
 
 	EnvVarBuiltinHost      string = "host"
 	EnvVarBuiltinPodName   string = "podName"
 	EnvVarBuiltinNamespace string = "namespace"
 )
 
+// ConfigMaps and Secrets with this label set to "true" are shared env sets of the application.
+// Env vars of type external refer to them.
+const KalmLabelSharedEnvKey = "kalm-shared-env"
+
 // +kubebuilder:validation:Enum=http;https;http2;grpc;grpc-web;tcp;udp;unknown
 type PortProtocol string
 
@@ -42,9 +49,12 @@ type EnvVar struct {
 	// +kubebuilder:validation:MinLength=1
 	Name string `json:"name"`
 
+	// For type external, value is "<shared env set name>/<key>",
+	// for type secret, value is "<secret name>/<key>".
+	// The key can be omitted if it's the same as the name of the env var.
 	Value string `json:"value,omitempty"`
 
-	// +kubebuilder:validation:Enum=static;external;linked;fieldref;builtin
+	// +kubebuilder:validation:Enum=static;external;linked;fieldref;builtin;secret
 	Type EnvVarType `json:"type,omitempty"`
 
 	Prefix string `json:"prefix,omitempty"`
@@ -52,6 +62,26 @@ type EnvVar struct {
 	Suffix string `json:"suffix,omitempty"`
 }
 
+// ParseExternalEnvValue returns the shared env set name and the key an external env var refers to.
+func ParseExternalEnvValue(env EnvVar) (setName string, key string) {
+	return parseEnvValueReference(env)
+}
+
+// ParseSecretEnvValue returns the secret name and the key a secret env var refers to.
+func ParseSecretEnvValue(env EnvVar) (secretName string, key string) {
+	return parseEnvValueReference(env)
+}
+
+func parseEnvValueReference(env EnvVar) (string, string) {
+	parts := strings.SplitN(env.Value, "/", 2)
+
+	if len(parts) == 2 {
+		return parts[0], parts[1]
+	}
+
+	return parts[0], env.Name
+}
+
 type Port struct {
 	// +kubebuilder:validation:Maximum=65535
 	// +kubebuilder:validation:Minimum=1
@@ -66,7 +96,7 @@ type Port struct {
 	Protocol PortProtocol `json:"protocol"`
 }
 
-// +kubebuilder:validation:Enum=emptyDirMemory;emptyDir;pvc;pvcTemplate;hostpath
+// +kubebuilder:validation:Enum=emptyDirMemory;emptyDir;pvc;pvcTemplate;hostpath;file
 type VolumeType string
 
 const (
@@ -77,6 +107,9 @@ const (
 	VolumeTypePersistentVolumeClaimTemplate VolumeType = "pvcTemplate"
 
 	VolumeTypeHostPath VolumeType = "hostpath"
+
+	// mount a file or a dir of the kalm-files config map in the same namespace
+	VolumeTypeFile VolumeType = "file"
 )
 
 type Volume struct {
@@ -86,6 +119,10 @@ type Volume struct {
 	// +optional
 	HostPath string `json:"hostPath,omitempty"`
 
+	// for Type: file, the path of a file or a dir in the kalm-files config map
+	// +optional
+	FilePath string `json:"filePath,omitempty"`
+
 	// If we need to create this volume first, the size of the volume
 	Size resource.Quantity `json:"size"`
 
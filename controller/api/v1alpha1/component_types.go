@@ -16,23 +16,131 @@ limitations under the License.
 package v1alpha1
 
 import (
+	"strconv"
+
 	apps1 "k8s.io/api/apps/v1"
+	batchv1beta1 "k8s.io/api/batch/v1beta1"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 const (
 	KalmLabelNamespaceKey = "kalm-namespace"
 
 	KalmLabelComponentKey        = "kalm-component"
+	KalmLabelJobRunIDKey         = "kalm-job-run-id"
 	KalmLabelKeyExceedingQuota   = "kalm-exceeding-quota"
+	KalmLabelKeySuspended        = "kalm-suspended"
 	KalmLabelKeyOriginalReplicas = "kalm-original-replicas"
 )
 
-type PreInjectFile struct {
-	// the content of the file
+// Who and how the spec of a component is changed for the last time.
+// They are recorded in the revision history of the component.
+const (
+	KalmAnnoComponentChangedBy  = "core.kalm.dev/changed-by"
+	KalmAnnoComponentChangedVia = "core.kalm.dev/changed-via"
+
+	ComponentChangedViaUI         = "ui"
+	ComponentChangedViaWebhook    = "webhook"
+	ComponentChangedViaToken      = "token"
+	ComponentChangedViaController = "controller"
+)
+
+const DefaultComponentRevisionHistoryLimit int32 = 10
+
+// State of a component with scaleToZero. The controller sets scaled-to-zero-at once the component is idle,
+// the activator replaces it with waking-at and sets woken-at when a request arrives.
+// Routes keep sending requests to the activator until the controller removes waking-at once replicas are available.
+const (
+	KalmAnnoScaledToZeroAt = "core.kalm.dev/scaled-to-zero-at"
+	KalmAnnoWakingAt       = "core.kalm.dev/waking-at"
+	KalmAnnoWokenAt        = "core.kalm.dev/woken-at"
+)
+
+// A component created to adopt an existing workload of the same name has this annotation with its generation at creation.
+// The controller only takes ownership of the workload and its service until the component is changed,
+// so the pods are not restarted by the adoption.
+const KalmAnnoAdoptedGeneration = "core.kalm.dev/adopted-generation"
+
+// IsComponentAdopted returns true if the component is created from an existing workload.
+func IsComponentAdopted(component *Component) bool {
+	_, exist := component.Annotations[KalmAnnoAdoptedGeneration]
+	return exist
+}
+
+// IsComponentAdoptionPending returns true if the component is not changed since it adopted the workload.
+func IsComponentAdoptionPending(component *Component) bool {
+	generation, exist := component.Annotations[KalmAnnoAdoptedGeneration]
+	return exist && generation == strconv.FormatInt(component.Generation, 10)
+}
+
+// ScaleToZeroConfig scales a server component to zero after it receives no requests for a while.
+// Requests from HttpRoutes to the idle component are held by the activator,
+// which scales the component up and forwards them once it's ready.
+type ScaleToZeroConfig struct {
+	// The component is scaled to zero if istio reports no requests to it in this window.
+	// +kubebuilder:validation:Minimum=60
+	IdleSeconds int32 `json:"idleSeconds"`
+}
+
+// ScalingSchedule overrides replicas of the component in a recurring time window,
+// e.g. 0 replicas from "0 20 * * *" to "0 7 * * *" stops the component at night.
+type ScalingSchedule struct {
+	// Cron expression of when the window starts.
+	// +kubebuilder:validation:MinLength=1
+	Start string `json:"start"`
+
+	// Cron expression of when the window ends.
+	// +kubebuilder:validation:MinLength=1
+	End string `json:"end"`
+
+	// Replicas of the component in the window, the HorizontalPodAutoscaler is paused meanwhile.
+	// +kubebuilder:validation:Minimum=0
+	Replicas int32 `json:"replicas"`
+
+	// IANA time zone of start and end, e.g. Asia/Shanghai. Defaults to UTC.
+	// +optional
+	TimeZone string `json:"timeZone,omitempty"`
+}
+
+// IsComponentScaledToZero returns true if the component is scaled to zero because it's idle.
+func IsComponentScaledToZero(component *Component) bool {
+	if component.Spec.ScaleToZero == nil {
+		return false
+	}
+
+	_, exist := component.Annotations[KalmAnnoScaledToZeroAt]
+
+	return exist
+}
+
+// IsComponentWaking returns true if the component is scaled up by the activator but has no available replicas yet.
+func IsComponentWaking(component *Component) bool {
+	if component.Spec.ScaleToZero == nil {
+		return false
+	}
+
+	_, exist := component.Annotations[KalmAnnoWakingAt]
+
+	return exist
+}
+
+type SecretKeyReference struct {
+	// name of the secret in the same namespace of the component
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+
 	// +kubebuilder:validation:MinLength=1
-	Content string `json:"content"`
+	Key string `json:"key"`
+}
+
+type PreInjectFile struct {
+	// the content of the file, required if secretRef is not set
+	Content string `json:"content,omitempty"`
+
+	// read the content from a key of a secret instead of storing it in the component
+	SecretRef *SecretKeyReference `json:"secretRef,omitempty"`
 
 	// To support binary content, it allows set base64 encoded data into `Content` field
 	// and set this flag to `true`. Binary data will be restored instead of plain string in `Content`.
@@ -46,6 +154,151 @@ type PreInjectFile struct {
 	Runnable bool `json:"runnable"`
 }
 
+type AutoScalingConfig struct {
+	// +kubebuilder:validation:Minimum=1
+	MinReplicas int32 `json:"minReplicas"`
+
+	// +kubebuilder:validation:Minimum=1
+	MaxReplicas int32 `json:"maxReplicas"`
+
+	// target average cpu utilization in percentage of the cpu request
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	TargetCPUUtilizationPercentage *int32 `json:"targetCPUUtilizationPercentage,omitempty"`
+
+	// target average memory utilization in percentage of the memory request
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	TargetMemoryUtilizationPercentage *int32 `json:"targetMemoryUtilizationPercentage,omitempty"`
+
+	// target average requests per second of each pod, reported by istio.
+	// It requires a custom metrics adapter that exposes the istio_requests_per_second pod metric.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	TargetIstioRequestsPerSecond *int32 `json:"targetIstioRequestsPerSecond,omitempty"`
+}
+
+type ContainerVolumeMount struct {
+	// path of a volume in spec.volumes of the component
+	// +kubebuilder:validation:MinLength=1
+	Volume string `json:"volume"`
+
+	// where to mount the volume in the container, defaults to the path of the volume
+	// +optional
+	MountPath string `json:"mountPath,omitempty"`
+
+	ReadOnly bool `json:"readOnly,omitempty"`
+}
+
+// ComponentContainer is an extra container running beside the main container of the component,
+// e.g. a log shipper, a proxy or a migration step.
+type ComponentContainer struct {
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+
+	// +kubebuilder:validation:MinLength=1
+	Image string `json:"image"`
+
+	Command []string `json:"command,omitempty"`
+
+	Args []string `json:"args,omitempty"`
+
+	Env []EnvVar `json:"env,omitempty"`
+
+	// volumes of the component shared with the main container
+	VolumeMounts []ContainerVolumeMount `json:"volumeMounts,omitempty"`
+
+	// +optional
+	ResourceRequirements *v1.ResourceRequirements `json:"resourceRequirements,omitempty"`
+}
+
+type RolloutStrategyType string
+
+const (
+	// Traffic is shifted to the new version step by step
+	RolloutStrategyCanary RolloutStrategyType = "canary"
+	// The new version is fully deployed beside the old one, then all traffic is switched at once
+	RolloutStrategyBlueGreen RolloutStrategyType = "blueGreen"
+)
+
+// RolloutStrategy rolls out a new image as a second versioned workload.
+// Traffic is split between the stable and canary subsets of the component service by istio,
+// and the new version is promoted or aborted automatically according to its istio metrics.
+type RolloutStrategy struct {
+	// +kubebuilder:validation:Enum=canary;blueGreen
+	Type RolloutStrategyType `json:"type"`
+
+	// Traffic weights in percentage sent to the new version in each step, in ascending order, e.g. [10, 50].
+	// Only for canary, a blue/green rollout has a single step of 100.
+	// +optional
+	Steps []int32 `json:"steps,omitempty"`
+
+	// How long the traffic of each step is observed before analysis.
+	// +kubebuilder:validation:Minimum=60
+	StepDurationSeconds int32 `json:"stepDurationSeconds"`
+
+	// The rollout is aborted if the 5xx response rate of the new version is higher than this percentage.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=100
+	// +optional
+	MaxErrorRatePercentage *int32 `json:"maxErrorRatePercentage,omitempty"`
+
+	// The rollout is aborted if the P99 latency of the new version is higher than this value.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	MaxLatencyMilliseconds *int32 `json:"maxLatencyMilliseconds,omitempty"`
+}
+
+// GetSteps returns traffic weights of all steps of the rollout.
+func (s *RolloutStrategy) GetSteps() []int32 {
+	if s.Type == RolloutStrategyBlueGreen {
+		return []int32{100}
+	}
+
+	return s.Steps
+}
+
+// NodeAffinity decides the nodes pods of the component can be or prefer to be scheduled to,
+// in addition to nodeSelectorLabels.
+type NodeAffinity struct {
+	// Nodes must match all these expressions.
+	// +optional
+	Required []v1.NodeSelectorRequirement `json:"required,omitempty"`
+
+	// +optional
+	Preferred []PreferredNodeAffinity `json:"preferred,omitempty"`
+}
+
+// PreferredNodeAffinity adds its weight to the score of nodes matching all the expressions.
+type PreferredNodeAffinity struct {
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=100
+	Weight      int32                        `json:"weight"`
+	Expressions []v1.NodeSelectorRequirement `json:"expressions"`
+}
+
+type TopologySpreadType string
+
+const (
+	TopologySpreadZone TopologySpreadType = "zone"
+	TopologySpreadHost TopologySpreadType = "host"
+)
+
+// TopologySpread spreads pods of the component evenly across zones or hosts.
+type TopologySpread struct {
+	// +kubebuilder:validation:Enum=zone;host
+	Topology TopologySpreadType `json:"topology"`
+
+	// The max difference between numbers of pods in any two zones or hosts, defaults to 1.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	MaxSkew int32 `json:"maxSkew,omitempty"`
+
+	// Pods are still scheduled if the constraint can't be satisfied, instead of staying pending.
+	// +optional
+	ScheduleAnyway bool `json:"scheduleAnyway,omitempty"`
+}
+
 // ComponentSpec defines the desired state of Component
 type ComponentSpec struct {
 	// labels will add to pods
@@ -62,23 +315,65 @@ type ComponentSpec struct {
 
 	Replicas *int32 `json:"replicas,omitempty"`
 
+	// Only for server workload. When it's set, replicas of the deployment is managed by a HorizontalPodAutoscaler
+	// and spec.replicas is ignored unless it's 0.
+	// +optional
+	AutoScaling *AutoScalingConfig `json:"autoScaling,omitempty"`
+
+	// Only for server workload. When it's set, the component is scaled to zero while it's idle,
+	// and scaled up again by the next request through an HttpRoute.
+	// +optional
+	ScaleToZero *ScaleToZeroConfig `json:"scaleToZero,omitempty"`
+
+	// Only for server and statefulset workload. The first schedule in effect decides replicas of the component.
+	// +optional
+	ScalingSchedules []ScalingSchedule `json:"scalingSchedules,omitempty"`
+
 	NodeSelectorLabels map[string]string `json:"nodeSelectorLabels,omitempty"`
 	PreferNotCoLocated bool              `json:"preferNotCoLocated,omitempty"`
 
+	// +optional
+	NodeAffinity *NodeAffinity `json:"nodeAffinity,omitempty"`
+
+	// +optional
+	Tolerations []v1.Toleration `json:"tolerations,omitempty"`
+
+	// +optional
+	PriorityClassName string `json:"priorityClassName,omitempty"`
+
+	// +optional
+	TopologySpread []TopologySpread `json:"topologySpread,omitempty"`
+
+	// The minimum number (e.g. 2) or percentage (e.g. "50%") of pods that must stay available
+	// during voluntary disruptions such as node drains. A PodDisruptionBudget is created if it's set.
+	// Only for server and statefulset workloads.
+	// +optional
+	MinAvailable *intstr.IntOrString `json:"minAvailable,omitempty"`
+
+	// The workload of the component won't be created until all these components in the same application are available.
+	// An existing workload is not affected.
 	StartAfterComponents []string `json:"startAfterComponents,omitempty"`
 
 	Command string `json:"command,omitempty"`
 
+	// Args replace the CMD of the image, they are ignored if the command is run by a shell.
+	// +optional
+	Args []string `json:"args,omitempty"`
+
 	// +optional
 	EnableHeadlessService bool `json:"enableHeadlessService,omitempty"`
 
 	Ports []Port `json:"ports,omitempty"`
 
-	// +kubebuilder:validation:Enum=server;cronjob;statefulset;daemonset
+	// +kubebuilder:validation:Enum=server;cronjob;statefulset;daemonset;job
 	WorkloadType WorkloadType `json:"workloadType,omitempty"`
 
 	Schedule string `json:"schedule,omitempty"`
 
+	// Only for cronjob workload.
+	// +optional
+	CronJob *CronJobConfig `json:"cronJob,omitempty"`
+
 	// +k8s:openapi-gen=true
 	// +optional
 	LivenessProbe *v1.Probe `json:"livenessProbe,omitempty"`
@@ -110,20 +405,272 @@ type ComponentSpec struct {
 
 	PreInjectedFiles []PreInjectFile `json:"preInjectedFiles,omitempty"`
 
+	// Run in order before the main container starts, e.g. database migrations.
+	// +optional
+	InitContainers []ComponentContainer `json:"initContainers,omitempty"`
+
+	// Run beside the main container in the same pod.
+	// +optional
+	Sidecars []ComponentContainer `json:"sidecars,omitempty"`
+
+	// Only for server workload. When it's set, a new image is rolled out progressively instead of
+	// updating pods of the deployment directly. Other changes of the spec are applied to all pods immediately.
+	// +optional
+	RolloutStrategy *RolloutStrategy `json:"rolloutStrategy,omitempty"`
+
+	// The number of old revisions to keep for rollback, defaults to 10.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	RevisionHistoryLimit *int32 `json:"revisionHistoryLimit,omitempty"`
+
 	// This is only meaningful if this component is a cronjob workload.
 	// Controller should immediately trigger a job and set its value to false if it's true.
 	ImmediateTrigger bool `json:"immediateTrigger,omitempty"`
+
+	// Only for job workload.
+	// +optional
+	Job *JobConfig `json:"job,omitempty"`
+
+	// Runs of a job workload waiting to be created.
+	// Controller creates a job for each of them and removes them from the list.
+	// +optional
+	PendingJobRuns []JobRunRequest `json:"pendingJobRuns,omitempty"`
+}
+
+type CronJobConfig struct {
+	// Defaults to Allow.
+	// +kubebuilder:validation:Enum=Allow;Forbid;Replace
+	// +optional
+	ConcurrencyPolicy batchv1beta1.ConcurrencyPolicy `json:"concurrencyPolicy,omitempty"`
+
+	// Later executions are not scheduled if it's true, running jobs are not affected.
+	// +optional
+	Suspend bool `json:"suspend,omitempty"`
+
+	// An execution is skipped if it can't be started in this many seconds after its scheduled time.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	StartingDeadlineSeconds *int64 `json:"startingDeadlineSeconds,omitempty"`
+
+	// Defaults to 3.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	SuccessfulJobsHistoryLimit *int32 `json:"successfulJobsHistoryLimit,omitempty"`
+
+	// Defaults to 5.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	FailedJobsHistoryLimit *int32 `json:"failedJobsHistoryLimit,omitempty"`
+
+	// IANA time zone of the schedule, e.g. Asia/Shanghai. The time zone of the kube-controller-manager is used if it's empty.
+	// It's passed to the cronjob controller as the CRON_TZ prefix of the schedule, so it's rejected before kubernetes 1.21.
+	// +optional
+	TimeZone string `json:"timeZone,omitempty"`
+}
+
+type JobConfig struct {
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	Completions *int32 `json:"completions,omitempty"`
+
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	Parallelism *int32 `json:"parallelism,omitempty"`
+
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	BackoffLimit *int32 `json:"backoffLimit,omitempty"`
+
+	// Finished jobs are deleted after this many seconds, they are kept if it's not set.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	TTLSecondsAfterFinished *int32 `json:"ttlSecondsAfterFinished,omitempty"`
+}
+
+// JobRunRequest is a run of a job workload with its own overrides.
+type JobRunRequest struct {
+	ID string `json:"id"`
+
+	// Envs are merged into envs of the main container by name.
+	// +optional
+	Env []EnvVar `json:"env,omitempty"`
+
+	// Replaces args of the main container if it's not empty.
+	// +optional
+	Args []string `json:"args,omitempty"`
+}
+
+type ComponentConditionType string
+
+const (
+	// Progressing is true while the workload is rolling out the latest spec of the component
+	ComponentConditionProgressing ComponentConditionType = "Progressing"
+	// Available is true when all desired replicas of the workload are available
+	ComponentConditionAvailable ComponentConditionType = "Available"
+	// ExceedingQuota is true when the component is scaled down because its application is exceeding quota
+	ComponentConditionExceedingQuota ComponentConditionType = "ExceedingQuota"
+	// Suspended is true when the component is scaled down because its application is suspended
+	ComponentConditionSuspended ComponentConditionType = "Suspended"
+	// PluginError is true when one of the component plugins failed during the last reconcile
+	ComponentConditionPluginError ComponentConditionType = "PluginError"
+	// DependenciesReady is false when the workload is held back until components in startAfterComponents are available
+	ComponentConditionDependenciesReady ComponentConditionType = "DependenciesReady"
+)
+
+type ComponentCondition struct {
+	// Type of the condition, one of ('Progressing', 'Available', 'ExceedingQuota', 'Suspended', 'PluginError').
+	Type ComponentConditionType `json:"type"`
+
+	// Status of the condition, one of ('True', 'False', 'Unknown').
+	Status v1.ConditionStatus `json:"status"`
+
+	// Last time the condition transitioned from one status to another.
+	// +optional
+	LastTransitionTime metav1.Time `json:"lastTransitionTime,omitempty"`
+
+	// Reason is a brief machine readable explanation for the condition's last
+	// transition.
+	// +optional
+	Reason string `json:"reason,omitempty"`
+
+	// Message is a human readable description of the details of the last
+	// transition, complementing reason.
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+type RolloutPhase string
+
+const (
+	RolloutPhaseProgressing RolloutPhase = "Progressing"
+	// All steps passed, the stable workload is being updated to the new image
+	RolloutPhasePromoting RolloutPhase = "Promoting"
+	RolloutPhaseSucceeded RolloutPhase = "Succeeded"
+	// Analysis of a step failed, all traffic is sent back to the stable version.
+	// The same image won't be rolled out again until the image of the component is changed.
+	RolloutPhaseAborted RolloutPhase = "Aborted"
+)
+
+type RolloutStepPhase string
+
+const (
+	RolloutStepPhasePending RolloutStepPhase = "Pending"
+	RolloutStepPhaseRunning RolloutStepPhase = "Running"
+	RolloutStepPhasePassed  RolloutStepPhase = "Passed"
+	RolloutStepPhaseFailed  RolloutStepPhase = "Failed"
+)
+
+type RolloutStepStatus struct {
+	// traffic weight in percentage of the new version
+	Weight int32 `json:"weight"`
+
+	Phase RolloutStepPhase `json:"phase"`
+
+	// +optional
+	StartedAt *metav1.Time `json:"startedAt,omitempty"`
+
+	// +optional
+	FinishedAt *metav1.Time `json:"finishedAt,omitempty"`
+
+	// 5xx response rate in percentage of the new version during the step, e.g. "0.52"
+	// +optional
+	ErrorRatePercentage string `json:"errorRatePercentage,omitempty"`
+
+	// P99 latency of the new version during the step, e.g. "120.00"
+	// +optional
+	LatencyMilliseconds string `json:"latencyMilliseconds,omitempty"`
+
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+type ComponentRolloutStatus struct {
+	Type RolloutStrategyType `json:"type"`
+
+	Phase RolloutPhase `json:"phase"`
+
+	// image of the current stable version
+	StableImage string `json:"stableImage"`
+
+	// image being rolled out
+	CanaryImage string `json:"canaryImage"`
+
+	// traffic weight in percentage currently sent to the new version
+	CanaryWeight int32 `json:"canaryWeight"`
+
+	// index of the current step
+	CurrentStep int32 `json:"currentStep"`
+
+	Steps []RolloutStepStatus `json:"steps,omitempty"`
+
+	// +optional
+	StartedAt *metav1.Time `json:"startedAt,omitempty"`
+
+	// +optional
+	FinishedAt *metav1.Time `json:"finishedAt,omitempty"`
+
+	// +optional
+	Message string `json:"message,omitempty"`
 }
 
 // ComponentStatus defines the observed state of Component
 type ComponentStatus struct {
-	// INSERT ADDITIONAL STATUS FIELD - define observed state of cluster
-	// Important: Run "make" to regenerate code after modifying this file
+	// The generation of the component spec that has been reconciled by the controller.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Desired number of pods of the workload.
+	// +optional
+	Replicas int32 `json:"replicas"`
+
+	// +optional
+	ReadyReplicas int32 `json:"readyReplicas"`
+
+	// Number of pods running the latest pod template of the workload.
+	// +optional
+	UpdatedReplicas int32 `json:"updatedReplicas"`
+
+	// +optional
+	AvailableReplicas int32 `json:"availableReplicas"`
+
+	// The image of the main container of the last completed rollout.
+	// +optional
+	Image string `json:"image,omitempty"`
+
+	// +optional
+	LastSuccessfulRolloutTime *metav1.Time `json:"lastSuccessfulRolloutTime,omitempty"`
+
+	// +optional
+	Conditions []ComponentCondition `json:"conditions,omitempty"`
+
+	// The last progressive rollout of the component, if rolloutStrategy is set.
+	// +optional
+	Rollout *ComponentRolloutStatus `json:"rollout,omitempty"`
+
+	// The id of the last run created for a job workload.
+	// +optional
+	LastJobRunID string `json:"lastJobRunID,omitempty"`
+
+	// The scaling schedule in effect, nil if replicas are not overridden by any schedule.
+	// +optional
+	ScalingSchedule *ComponentScalingScheduleStatus `json:"scalingSchedule,omitempty"`
+}
+
+type ComponentScalingScheduleStatus struct {
+	Replicas int32 `json:"replicas"`
+
+	// When the window of the schedule ends.
+	// +optional
+	Until *metav1.Time `json:"until,omitempty"`
 }
 
 // +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
 // +kubebuilder:printcolumn:name="Workload",type="string",JSONPath=".spec.workloadType"
 // +kubebuilder:printcolumn:name="Image",type="string",JSONPath=".spec.image"
+// +kubebuilder:printcolumn:name="Ready",type="integer",JSONPath=".status.readyReplicas"
+// +kubebuilder:printcolumn:name="Desired",type="integer",JSONPath=".status.replicas"
+// +kubebuilder:printcolumn:name="Available",type="string",JSONPath=`.status.conditions[?(@.type=="Available")].status`
 // +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
 
 // Component is the Schema for the components API
@@ -147,3 +694,42 @@ type ComponentList struct {
 func init() {
 	SchemeBuilder.Register(&Component{}, &ComponentList{})
 }
+
+func GetComponentCondition(status ComponentStatus, conditionType ComponentConditionType) *ComponentCondition {
+	for i := range status.Conditions {
+		if status.Conditions[i].Type == conditionType {
+			return &status.Conditions[i]
+		}
+	}
+
+	return nil
+}
+
+func IsComponentAvailable(component Component) bool {
+	cond := GetComponentCondition(component.Status, ComponentConditionAvailable)
+	return cond != nil && cond.Status == v1.ConditionTrue
+}
+
+// GetAllEnvs returns envs of the main container, init containers, sidecars and pending job runs.
+func (spec *ComponentSpec) GetAllEnvs() []EnvVar {
+	envs := append([]EnvVar{}, spec.Env...)
+
+	for _, run := range spec.PendingJobRuns {
+		envs = append(envs, run.Env...)
+	}
+
+	for _, container := range spec.InitContainers {
+		envs = append(envs, container.Env...)
+	}
+
+	for _, container := range spec.Sidecars {
+		envs = append(envs, container.Env...)
+	}
+
+	return envs
+}
+
+// IsRolloutActive returns true if traffic may be sent to the new version of the rollout.
+func IsRolloutActive(status *ComponentRolloutStatus) bool {
+	return status != nil && (status.Phase == RolloutPhaseProgressing || status.Phase == RolloutPhasePromoting)
+}
@@ -18,15 +18,22 @@ package v1alpha1
 import (
 	"fmt"
 	"math/rand"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/kalmhq/kalm/controller/lib/files"
 	"github.com/robfig/cron"
+	batchv1beta1 "k8s.io/api/batch/v1beta1"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	apimachineryval "k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/apimachinery/pkg/util/validation/field"
+	utilversion "k8s.io/apimachinery/pkg/util/version"
+	"k8s.io/client-go/discovery"
 	ctrl "sigs.k8s.io/controller-runtime"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
@@ -35,7 +42,20 @@ import (
 // log is for logging in this package.
 var componentlog = logf.Log.WithName("component-webhook")
 
+// the cronjob controller accepts the CRON_TZ prefix of schedules since kubernetes 1.21
+var cronJobTimeZoneSupported bool
+
 func (r *Component) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	if discoveryClient, err := discovery.NewDiscoveryClientForConfig(mgr.GetConfig()); err != nil {
+		componentlog.Error(err, "unable to create the discovery client")
+	} else if info, err := discoveryClient.ServerVersion(); err != nil {
+		componentlog.Error(err, "unable to get the version of the cluster")
+	} else if v, err := utilversion.ParseGeneric(info.GitVersion); err != nil {
+		componentlog.Error(err, "unable to parse the version of the cluster", "version", info.GitVersion)
+	} else {
+		cronJobTimeZoneSupported = v.AtLeast(utilversion.MustParseGeneric("1.21"))
+	}
+
 	return ctrl.NewWebhookManagedBy(mgr).
 		For(r).
 		Complete()
@@ -97,11 +117,17 @@ func (r *Component) Default() {
 	}
 
 	if !IsKalmSystemNamespace(r.Namespace) {
-		// set default resourceRequirement & limits
-		// r.setupResourceRequirementIfAbsent()
+		defaults, err := loadResourceDefaults(r.Namespace)
 
-		// set for istio proxy
-		// r.setupIstioResourceRequirementIfAbsent()
+		if err != nil {
+			componentlog.Error(err, "fail to load resource defaults, ignored", "ns", r.Namespace)
+		} else if defaults != nil {
+			// set default resourceRequirement & limits
+			r.setupResourceRequirementIfAbsent(defaults)
+
+			// set for istio proxy
+			r.setupIstioResourceRequirementIfAbsent(defaults)
+		}
 	}
 }
 
@@ -113,7 +139,11 @@ var _ webhook.Validator = &Component{}
 func (r *Component) ValidateCreate() error {
 	componentlog.Info("validate create", "ns", r.Namespace, "name", r.Name)
 
-	if errList := r.validate(); len(errList) > 0 {
+	errList := r.validate()
+	errList = append(errList, r.validateChange(nil)...)
+	errList = append(errList, r.validateQuota(nil)...)
+
+	if len(errList) > 0 {
 		componentlog.Error(errList, "validate fail")
 		return error(errList)
 	}
@@ -149,6 +179,11 @@ func (r *Component) ValidateUpdate(old runtime.Object) error {
 	commonValidateErr := r.validate()
 	volErrList = append(volErrList, commonValidateErr...)
 
+	if oldComponent, ok := old.(*Component); ok {
+		volErrList = append(volErrList, r.validateChange(oldComponent)...)
+		volErrList = append(volErrList, r.validateQuota(oldComponent)...)
+	}
+
 	if len(volErrList) > 0 {
 		return error(volErrList)
 	}
@@ -215,12 +250,22 @@ func (r *Component) validate() KalmValidateErrorList {
 
 	rst = append(rst, r.validateEnvVarList()...)
 	rst = append(rst, validateLabels(r.Spec.NodeSelectorLabels, ".spec.nodeSelectorLabels")...)
+	rst = append(rst, r.validateScheduling()...)
 	rst = append(rst, r.validateScheduleOfComponentIfIsCronJob()...)
+	rst = append(rst, r.validateJob()...)
+	rst = append(rst, r.validateAutoScaling()...)
+	rst = append(rst, r.validateRolloutStrategy()...)
+	rst = append(rst, r.validateScaleToZero()...)
+	rst = append(rst, r.validateScalingSchedules()...)
+	rst = append(rst, r.validateDisruptionBudget()...)
+	rst = append(rst, r.validateTopologySpread()...)
 	rst = append(rst, r.validateProbes()...)
 	rst = append(rst, r.validateResRequirement()...)
 	rst = append(rst, r.validateVolumesOfComponent()...)
 	rst = append(rst, r.validateRunnerPermission()...)
 	rst = append(rst, r.validatePreInjectedFiles()...)
+	rst = append(rst, r.validateExtraContainers()...)
+	rst = append(rst, r.validateDependencies()...)
 
 	if len(rst) == 0 {
 		return nil
@@ -288,6 +333,25 @@ func (r *Component) validateVolumesOfComponent() (rst KalmValidateErrorList) {
 				})
 			}
 		}
+
+		if vol.Type == VolumeTypeFile {
+			if vol.FilePath == "" {
+				rst = append(rst, KalmValidateError{
+					Err:  "must set filePath for this volume",
+					Path: fmt.Sprintf(".spec.volumes[%d].filePath", i),
+				})
+			} else if err := files.ValidatePath(vol.FilePath); err != nil {
+				rst = append(rst, KalmValidateError{
+					Err:  err.Error(),
+					Path: fmt.Sprintf(".spec.volumes[%d].filePath", i),
+				})
+			}
+		} else if vol.FilePath != "" {
+			rst = append(rst, KalmValidateError{
+				Err:  fmt.Sprintf("filePath is only allowed for volume type %s", VolumeTypeFile),
+				Path: fmt.Sprintf(".spec.volumes[%d].filePath", i),
+			})
+		}
 	}
 
 	// sts use volType: pvcTemplate instead pvc
@@ -343,7 +407,7 @@ func (r *Component) validateVolumesOfComponent() (rst KalmValidateErrorList) {
 
 func (r *Component) isStatelessWorkload() bool {
 	switch r.Spec.WorkloadType {
-	case WorkloadTypeServer, WorkloadTypeDaemonSet, WorkloadTypeCronjob:
+	case WorkloadTypeServer, WorkloadTypeDaemonSet, WorkloadTypeCronjob, WorkloadTypeJob:
 		return true
 	default:
 		return false
@@ -352,20 +416,577 @@ func (r *Component) isStatelessWorkload() bool {
 
 func (r *Component) validateScheduleOfComponentIfIsCronJob() (rst KalmValidateErrorList) {
 	if r.Spec.WorkloadType != WorkloadTypeCronjob {
-		return nil
+		if r.Spec.CronJob != nil {
+			rst = append(rst, KalmValidateError{
+				Err:  "cronjob config is only supported by cronjob workload",
+				Path: ".spec.cronJob",
+			})
+		}
+
+		return
 	}
 
-	_, err := cron.ParseStandard(r.Spec.Schedule)
-	if err != nil {
+	// the time zone is set in cronJob.timeZone instead
+	if strings.HasPrefix(r.Spec.Schedule, "CRON_TZ=") || strings.HasPrefix(r.Spec.Schedule, "TZ=") {
+		rst = append(rst, KalmValidateError{
+			Err:  "use cronJob.timeZone to set the time zone of the schedule",
+			Path: ".spec.schedule",
+		})
+	} else if _, err := cron.ParseStandard(r.Spec.Schedule); err != nil {
 		rst = append(rst, KalmValidateError{
 			Err:  err.Error(),
 			Path: ".spec.schedule",
 		})
 	}
 
+	config := r.Spec.CronJob
+	if config == nil {
+		return
+	}
+
+	switch config.ConcurrencyPolicy {
+	case "", batchv1beta1.AllowConcurrent, batchv1beta1.ForbidConcurrent, batchv1beta1.ReplaceConcurrent:
+	default:
+		rst = append(rst, KalmValidateError{
+			Err:  "should be one of Allow, Forbid and Replace",
+			Path: ".spec.cronJob.concurrencyPolicy",
+		})
+	}
+
+	if config.StartingDeadlineSeconds != nil && *config.StartingDeadlineSeconds < 0 {
+		rst = append(rst, KalmValidateError{
+			Err:  "should not be negative",
+			Path: ".spec.cronJob.startingDeadlineSeconds",
+		})
+	}
+
+	if config.SuccessfulJobsHistoryLimit != nil && *config.SuccessfulJobsHistoryLimit < 0 {
+		rst = append(rst, KalmValidateError{
+			Err:  "should not be negative",
+			Path: ".spec.cronJob.successfulJobsHistoryLimit",
+		})
+	}
+
+	if config.FailedJobsHistoryLimit != nil && *config.FailedJobsHistoryLimit < 0 {
+		rst = append(rst, KalmValidateError{
+			Err:  "should not be negative",
+			Path: ".spec.cronJob.failedJobsHistoryLimit",
+		})
+	}
+
+	// "Local" is the time zone of the webhook, which may differ from the cronjob controller
+	if config.TimeZone == "" {
+	} else if !cronJobTimeZoneSupported {
+		rst = append(rst, KalmValidateError{
+			Err:  "time zones of cronjobs require kubernetes 1.21 or later",
+			Path: ".spec.cronJob.timeZone",
+		})
+	} else if config.TimeZone == "Local" {
+		rst = append(rst, KalmValidateError{
+			Err:  "should be an IANA time zone, e.g. Asia/Shanghai",
+			Path: ".spec.cronJob.timeZone",
+		})
+	} else if _, err := time.LoadLocation(config.TimeZone); err != nil {
+		rst = append(rst, KalmValidateError{
+			Err:  "unknown time zone: " + config.TimeZone,
+			Path: ".spec.cronJob.timeZone",
+		})
+	}
+
 	return
 }
 
+func (r *Component) validateJob() (rst KalmValidateErrorList) {
+	if r.Spec.WorkloadType != WorkloadTypeJob {
+		if r.Spec.Job != nil {
+			rst = append(rst, KalmValidateError{
+				Err:  "job config is only supported by job workload",
+				Path: ".spec.job",
+			})
+		}
+
+		if len(r.Spec.PendingJobRuns) > 0 {
+			rst = append(rst, KalmValidateError{
+				Err:  "job runs are only supported by job workload",
+				Path: ".spec.pendingJobRuns",
+			})
+		}
+
+		return rst
+	}
+
+	if config := r.Spec.Job; config != nil {
+		if config.Completions != nil && *config.Completions < 1 {
+			rst = append(rst, KalmValidateError{
+				Err:  "should be at least 1",
+				Path: ".spec.job.completions",
+			})
+		}
+
+		if config.Parallelism != nil && *config.Parallelism < 1 {
+			rst = append(rst, KalmValidateError{
+				Err:  "should be at least 1",
+				Path: ".spec.job.parallelism",
+			})
+		}
+
+		if config.BackoffLimit != nil && *config.BackoffLimit < 0 {
+			rst = append(rst, KalmValidateError{
+				Err:  "should not be negative",
+				Path: ".spec.job.backoffLimit",
+			})
+		}
+
+		if config.TTLSecondsAfterFinished != nil && *config.TTLSecondsAfterFinished < 0 {
+			rst = append(rst, KalmValidateError{
+				Err:  "should not be negative",
+				Path: ".spec.job.ttlSecondsAfterFinished",
+			})
+		}
+	}
+
+	runIDs := make(map[string]bool)
+
+	for i, run := range r.Spec.PendingJobRuns {
+		path := fmt.Sprintf(".spec.pendingJobRuns[%d]", i)
+
+		// the job name is used as a label value of its pods
+		for _, msg := range apimachineryval.IsDNS1123Label(r.Name + "-" + run.ID) {
+			rst = append(rst, KalmValidateError{
+				Err:  msg,
+				Path: path + ".id",
+			})
+		}
+
+		if runIDs[run.ID] {
+			rst = append(rst, KalmValidateError{
+				Err:  "duplicate run id: " + run.ID,
+				Path: path + ".id",
+			})
+		}
+
+		runIDs[run.ID] = true
+
+		rst = append(rst, validateEnvVars(run.Env, path+".env")...)
+	}
+
+	return rst
+}
+
+func (r *Component) validateAutoScaling() (rst KalmValidateErrorList) {
+	autoScaling := r.Spec.AutoScaling
+	if autoScaling == nil {
+		return nil
+	}
+
+	if r.Spec.WorkloadType != WorkloadTypeServer {
+		rst = append(rst, KalmValidateError{
+			Err:  "auto scaling is only supported by server workload",
+			Path: ".spec.autoScaling",
+		})
+	}
+
+	if autoScaling.MinReplicas < 1 {
+		rst = append(rst, KalmValidateError{
+			Err:  "should be at least 1",
+			Path: ".spec.autoScaling.minReplicas",
+		})
+	}
+
+	if autoScaling.MaxReplicas < autoScaling.MinReplicas {
+		rst = append(rst, KalmValidateError{
+			Err:  "should not be less than minReplicas",
+			Path: ".spec.autoScaling.maxReplicas",
+		})
+	}
+
+	if autoScaling.TargetCPUUtilizationPercentage == nil &&
+		autoScaling.TargetMemoryUtilizationPercentage == nil &&
+		autoScaling.TargetIstioRequestsPerSecond == nil {
+		rst = append(rst, KalmValidateError{
+			Err:  "at least one target should be set",
+			Path: ".spec.autoScaling",
+		})
+	}
+
+	return rst
+}
+
+func (r *Component) validateScaleToZero() (rst KalmValidateErrorList) {
+	if r.Spec.ScaleToZero == nil {
+		return nil
+	}
+
+	if r.Spec.WorkloadType != WorkloadTypeServer {
+		rst = append(rst, KalmValidateError{
+			Err:  "scale to zero is only supported by server workload",
+			Path: ".spec.scaleToZero",
+		})
+	}
+
+	// requests are measured and held through the service of the component
+	if len(r.Spec.Ports) == 0 {
+		rst = append(rst, KalmValidateError{
+			Err:  "scale to zero requires at least one port",
+			Path: ".spec.scaleToZero",
+		})
+	}
+
+	if r.Spec.ScaleToZero.IdleSeconds < 60 {
+		rst = append(rst, KalmValidateError{
+			Err:  "should be at least 60",
+			Path: ".spec.scaleToZero.idleSeconds",
+		})
+	}
+
+	return rst
+}
+
+func (r *Component) validateScalingSchedules() (rst KalmValidateErrorList) {
+	if len(r.Spec.ScalingSchedules) == 0 {
+		return nil
+	}
+
+	if r.Spec.WorkloadType != WorkloadTypeServer && r.Spec.WorkloadType != WorkloadTypeStatefulSet {
+		rst = append(rst, KalmValidateError{
+			Err:  "scaling schedules are only supported by server and statefulset workload",
+			Path: ".spec.scalingSchedules",
+		})
+	}
+
+	for i, schedule := range r.Spec.ScalingSchedules {
+		path := fmt.Sprintf(".spec.scalingSchedules[%d]", i)
+
+		if _, err := cron.ParseStandard(schedule.Start); err != nil {
+			rst = append(rst, KalmValidateError{
+				Err:  "invalid cron expression: " + err.Error(),
+				Path: path + ".start",
+			})
+		}
+
+		if _, err := cron.ParseStandard(schedule.End); err != nil {
+			rst = append(rst, KalmValidateError{
+				Err:  "invalid cron expression: " + err.Error(),
+				Path: path + ".end",
+			})
+		} else if schedule.End == schedule.Start {
+			rst = append(rst, KalmValidateError{
+				Err:  "should be different from start",
+				Path: path + ".end",
+			})
+		}
+
+		if schedule.Replicas < 0 {
+			rst = append(rst, KalmValidateError{
+				Err:  "should not be negative",
+				Path: path + ".replicas",
+			})
+		}
+
+		// "Local" is the time zone of the webhook, which may differ from the controller
+		if schedule.TimeZone == "Local" {
+			rst = append(rst, KalmValidateError{
+				Err:  "should be an IANA time zone, e.g. Asia/Shanghai",
+				Path: path + ".timeZone",
+			})
+		} else if _, err := time.LoadLocation(schedule.TimeZone); err != nil {
+			rst = append(rst, KalmValidateError{
+				Err:  "unknown time zone: " + schedule.TimeZone,
+				Path: path + ".timeZone",
+			})
+		}
+	}
+
+	return rst
+}
+
+func (r *Component) validateRolloutStrategy() (rst KalmValidateErrorList) {
+	strategy := r.Spec.RolloutStrategy
+	if strategy == nil {
+		return nil
+	}
+
+	if r.Spec.WorkloadType != WorkloadTypeServer {
+		rst = append(rst, KalmValidateError{
+			Err:  "rollout strategy is only supported by server workload",
+			Path: ".spec.rolloutStrategy",
+		})
+	}
+
+	// traffic is split by istio through the service of the component
+	if len(r.Spec.Ports) == 0 {
+		rst = append(rst, KalmValidateError{
+			Err:  "rollout strategy requires at least one port",
+			Path: ".spec.rolloutStrategy",
+		})
+	}
+
+	switch strategy.Type {
+	case RolloutStrategyBlueGreen:
+		if len(strategy.Steps) > 0 {
+			rst = append(rst, KalmValidateError{
+				Err:  "steps are not supported by blue/green rollout",
+				Path: ".spec.rolloutStrategy.steps",
+			})
+		}
+	case RolloutStrategyCanary:
+		if len(strategy.Steps) == 0 {
+			rst = append(rst, KalmValidateError{
+				Err:  "at least one step is required",
+				Path: ".spec.rolloutStrategy.steps",
+			})
+		}
+
+		for i, weight := range strategy.Steps {
+			if weight < 1 || weight > 100 {
+				rst = append(rst, KalmValidateError{
+					Err:  "should be between 1 and 100",
+					Path: fmt.Sprintf(".spec.rolloutStrategy.steps[%d]", i),
+				})
+			} else if i > 0 && weight <= strategy.Steps[i-1] {
+				rst = append(rst, KalmValidateError{
+					Err:  "should be greater than the weight of the previous step",
+					Path: fmt.Sprintf(".spec.rolloutStrategy.steps[%d]", i),
+				})
+			}
+		}
+	}
+
+	return rst
+}
+
+func (r *Component) validateScheduling() (rst KalmValidateErrorList) {
+	if r.Spec.NodeAffinity != nil {
+		for i, expression := range r.Spec.NodeAffinity.Required {
+			rst = append(rst, validateNodeSelectorRequirement(expression, fmt.Sprintf(".spec.nodeAffinity.required[%d]", i))...)
+		}
+
+		for i, preferred := range r.Spec.NodeAffinity.Preferred {
+			path := fmt.Sprintf(".spec.nodeAffinity.preferred[%d]", i)
+
+			if preferred.Weight < 1 || preferred.Weight > 100 {
+				rst = append(rst, KalmValidateError{
+					Err:  "should be between 1 and 100",
+					Path: path + ".weight",
+				})
+			}
+
+			if len(preferred.Expressions) == 0 {
+				rst = append(rst, KalmValidateError{
+					Err:  "at least one expression is required",
+					Path: path + ".expressions",
+				})
+			}
+
+			for j, expression := range preferred.Expressions {
+				rst = append(rst, validateNodeSelectorRequirement(expression, fmt.Sprintf("%s.expressions[%d]", path, j))...)
+			}
+		}
+	}
+
+	for i, toleration := range r.Spec.Tolerations {
+		rst = append(rst, validateToleration(toleration, fmt.Sprintf(".spec.tolerations[%d]", i))...)
+	}
+
+	if r.Spec.PriorityClassName != "" {
+		for _, msg := range apimachineryval.IsDNS1123Subdomain(r.Spec.PriorityClassName) {
+			rst = append(rst, KalmValidateError{
+				Err:  msg,
+				Path: ".spec.priorityClassName",
+			})
+		}
+	}
+
+	return rst
+}
+
+func validateNodeSelectorRequirement(expression v1.NodeSelectorRequirement, path string) (rst KalmValidateErrorList) {
+	for _, msg := range apimachineryval.IsQualifiedName(expression.Key) {
+		rst = append(rst, KalmValidateError{
+			Err:  msg,
+			Path: path + ".key",
+		})
+	}
+
+	switch expression.Operator {
+	case v1.NodeSelectorOpIn, v1.NodeSelectorOpNotIn:
+		if len(expression.Values) == 0 {
+			rst = append(rst, KalmValidateError{
+				Err:  "values are required by operator " + string(expression.Operator),
+				Path: path + ".values",
+			})
+		}
+	case v1.NodeSelectorOpExists, v1.NodeSelectorOpDoesNotExist:
+		if len(expression.Values) > 0 {
+			rst = append(rst, KalmValidateError{
+				Err:  "values must be empty for operator " + string(expression.Operator),
+				Path: path + ".values",
+			})
+		}
+	case v1.NodeSelectorOpGt, v1.NodeSelectorOpLt:
+		if len(expression.Values) != 1 {
+			rst = append(rst, KalmValidateError{
+				Err:  "exactly one value is required by operator " + string(expression.Operator),
+				Path: path + ".values",
+			})
+		} else if _, err := strconv.ParseInt(expression.Values[0], 10, 64); err != nil {
+			rst = append(rst, KalmValidateError{
+				Err:  "value should be an integer for operator " + string(expression.Operator),
+				Path: path + ".values",
+			})
+		}
+	default:
+		rst = append(rst, KalmValidateError{
+			Err:  "unknown operator: " + string(expression.Operator),
+			Path: path + ".operator",
+		})
+	}
+
+	return rst
+}
+
+func validateToleration(toleration v1.Toleration, path string) (rst KalmValidateErrorList) {
+	if toleration.Key == "" {
+		// an empty key with Exists tolerates everything
+		if toleration.Operator != v1.TolerationOpExists {
+			rst = append(rst, KalmValidateError{
+				Err:  "operator must be Exists when key is empty",
+				Path: path + ".operator",
+			})
+		}
+	} else {
+		for _, msg := range apimachineryval.IsQualifiedName(toleration.Key) {
+			rst = append(rst, KalmValidateError{
+				Err:  msg,
+				Path: path + ".key",
+			})
+		}
+	}
+
+	switch toleration.Operator {
+	case v1.TolerationOpEqual, "":
+		for _, msg := range apimachineryval.IsValidLabelValue(toleration.Value) {
+			rst = append(rst, KalmValidateError{
+				Err:  msg,
+				Path: path + ".value",
+			})
+		}
+	case v1.TolerationOpExists:
+		if toleration.Value != "" {
+			rst = append(rst, KalmValidateError{
+				Err:  "value must be empty when operator is Exists",
+				Path: path + ".value",
+			})
+		}
+	default:
+		rst = append(rst, KalmValidateError{
+			Err:  "unknown operator: " + string(toleration.Operator),
+			Path: path + ".operator",
+		})
+	}
+
+	switch toleration.Effect {
+	case v1.TaintEffectNoSchedule, v1.TaintEffectPreferNoSchedule, v1.TaintEffectNoExecute, "":
+	default:
+		rst = append(rst, KalmValidateError{
+			Err:  "unknown effect: " + string(toleration.Effect),
+			Path: path + ".effect",
+		})
+	}
+
+	if toleration.TolerationSeconds != nil && toleration.Effect != v1.TaintEffectNoExecute {
+		rst = append(rst, KalmValidateError{
+			Err:  "tolerationSeconds is only supported by effect NoExecute",
+			Path: path + ".tolerationSeconds",
+		})
+	}
+
+	return rst
+}
+
+// validateDisruptionBudget rejects a minAvailable that would block all evictions,
+// e.g. node drains would hang forever. Scaling schedules can reduce replicas, so their fewest replicas are checked too.
+func (r *Component) validateDisruptionBudget() (rst KalmValidateErrorList) {
+	minAvailable := r.Spec.MinAvailable
+	if minAvailable == nil {
+		return nil
+	}
+
+	if r.Spec.WorkloadType != WorkloadTypeServer && r.Spec.WorkloadType != WorkloadTypeStatefulSet {
+		return append(rst, KalmValidateError{
+			Err:  "minAvailable is only supported by server and statefulset workloads",
+			Path: ".spec.minAvailable",
+		})
+	}
+
+	var replicas int32 = 1
+	if r.Spec.AutoScaling != nil {
+		replicas = r.Spec.AutoScaling.MinReplicas
+	} else if r.Spec.Replicas != nil {
+		replicas = *r.Spec.Replicas
+	}
+
+	// pods are stopped in windows with 0 replicas, there is nothing to evict
+	for _, schedule := range r.Spec.ScalingSchedules {
+		if schedule.Replicas > 0 && (replicas == 0 || schedule.Replicas < replicas) {
+			replicas = schedule.Replicas
+		}
+	}
+
+	value, err := intstr.GetValueFromIntOrPercent(minAvailable, int(replicas), true)
+	if err != nil {
+		return append(rst, KalmValidateError{
+			Err:  err.Error(),
+			Path: ".spec.minAvailable",
+		})
+	}
+
+	if value < 0 {
+		return append(rst, KalmValidateError{
+			Err:  "should not be negative",
+			Path: ".spec.minAvailable",
+		})
+	}
+
+	if replicas > 0 && value >= int(replicas) {
+		rst = append(rst, KalmValidateError{
+			Err:  fmt.Sprintf("minAvailable %s blocks all evictions of %d replicas", minAvailable.String(), replicas),
+			Path: ".spec.minAvailable",
+		})
+	}
+
+	return rst
+}
+
+func (r *Component) validateTopologySpread() (rst KalmValidateErrorList) {
+	topologies := make(map[TopologySpreadType]bool)
+
+	for i, spread := range r.Spec.TopologySpread {
+		if spread.Topology != TopologySpreadZone && spread.Topology != TopologySpreadHost {
+			rst = append(rst, KalmValidateError{
+				Err:  "topology should be zone or host",
+				Path: fmt.Sprintf(".spec.topologySpread[%d].topology", i),
+			})
+		} else if topologies[spread.Topology] {
+			rst = append(rst, KalmValidateError{
+				Err:  "duplicate topology: " + string(spread.Topology),
+				Path: fmt.Sprintf(".spec.topologySpread[%d].topology", i),
+			})
+		}
+
+		if spread.MaxSkew < 0 {
+			rst = append(rst, KalmValidateError{
+				Err:  "should not be negative",
+				Path: fmt.Sprintf(".spec.topologySpread[%d].maxSkew", i),
+			})
+		}
+
+		topologies[spread.Topology] = true
+	}
+
+	return rst
+}
+
 func validateLabels(labels map[string]string, fieldPath string) (rst KalmValidateErrorList) {
 	if valid, errList := isValidLabels(labels, field.NewPath(fieldPath)); !valid {
 		return toKalmValidateErrors(errList)
@@ -393,25 +1014,110 @@ func (r *Component) validateProbes() (rst KalmValidateErrorList) {
 }
 
 func (r *Component) validateEnvVarList() (rst KalmValidateErrorList) {
-	if len(r.Spec.Env) == 0 {
-		return nil
-	}
+	return validateEnvVars(r.Spec.Env, ".spec.env")
+}
 
-	for i, env := range r.Spec.Env {
+func validateEnvVars(envs []EnvVar, path string) (rst KalmValidateErrorList) {
+	for i, env := range envs {
 		errs := apimachineryval.IsCIdentifier(env.Name)
 		for _, err := range errs {
 			rst = append(rst, KalmValidateError{
 				Err:  err,
-				Path: fmt.Sprintf(".spec.env[%d]", i),
+				Path: fmt.Sprintf("%s[%d]", path, i),
 			})
 		}
+
+		if env.Type == EnvVarTypeExternal {
+			setName, key := ParseExternalEnvValue(env)
+
+			errs = append(apimachineryval.IsDNS1123Subdomain(setName), apimachineryval.IsConfigMapKey(key)...)
+			for _, err := range errs {
+				rst = append(rst, KalmValidateError{
+					Err:  "invalid shared env reference: " + err,
+					Path: fmt.Sprintf("%s[%d].value", path, i),
+				})
+			}
+		}
+
+		if env.Type == EnvVarTypeSecret {
+			secretName, key := ParseSecretEnvValue(env)
+
+			errs = append(apimachineryval.IsDNS1123Subdomain(secretName), apimachineryval.IsConfigMapKey(key)...)
+			for _, err := range errs {
+				rst = append(rst, KalmValidateError{
+					Err:  "invalid secret reference: " + err,
+					Path: fmt.Sprintf("%s[%d].value", path, i),
+				})
+			}
+		}
 	}
 
 	return rst
 }
 
 func (r *Component) validateResRequirement() (rst KalmValidateErrorList) {
-	resRequirement := r.Spec.ResourceRequirements
+	return validateResourceRequirements(r.Spec.ResourceRequirements, "spec.resourceRequirements")
+}
+
+// validateChange runs checks against the resource defaults of the application the component belongs to.
+// Components already exceeding max limits can still be updated, as long as the change doesn't make it worse.
+func (r *Component) validateChange(old *Component) (rst KalmValidateErrorList) {
+	if IsKalmSystemNamespace(r.Namespace) {
+		return nil
+	}
+
+	return r.validateMaxLimits(old)
+}
+
+func (r *Component) validateMaxLimits(old *Component) KalmValidateErrorList {
+	if old != nil &&
+		equality.Semantic.DeepEqual(old.Spec.ResourceRequirements, r.Spec.ResourceRequirements) &&
+		equality.Semantic.DeepEqual(old.Spec.IstioResourceRequirements, r.Spec.IstioResourceRequirements) &&
+		equality.Semantic.DeepEqual(getExtraContainerResourceRequirements(old), getExtraContainerResourceRequirements(r)) {
+		return nil
+	}
+
+	defaults, err := loadResourceDefaults(r.Namespace)
+
+	if err != nil {
+		componentlog.Error(err, "fail to load resource defaults", "ns", r.Namespace)
+		return KalmValidateErrorList{{Err: "fail to load max limits: " + err.Error(), Path: "spec.resourceRequirements"}}
+	}
+
+	if defaults == nil {
+		return nil
+	}
+
+	var rst KalmValidateErrorList
+	rst = append(rst, validateMaxLimits(r.Spec.ResourceRequirements, defaults.MaxContainerLimits, "spec.resourceRequirements")...)
+	rst = append(rst, validateMaxLimits(r.Spec.IstioResourceRequirements, defaults.MaxIstioLimits, "spec.istioResourceRequirements")...)
+
+	for i, container := range r.Spec.InitContainers {
+		rst = append(rst, validateMaxLimits(container.ResourceRequirements, defaults.MaxContainerLimits, fmt.Sprintf("spec.initContainers[%d].resourceRequirements", i))...)
+	}
+
+	for i, container := range r.Spec.Sidecars {
+		rst = append(rst, validateMaxLimits(container.ResourceRequirements, defaults.MaxContainerLimits, fmt.Sprintf("spec.sidecars[%d].resourceRequirements", i))...)
+	}
+
+	return rst
+}
+
+func getExtraContainerResourceRequirements(component *Component) []*v1.ResourceRequirements {
+	var rst []*v1.ResourceRequirements
+
+	for _, container := range component.Spec.InitContainers {
+		rst = append(rst, container.ResourceRequirements)
+	}
+
+	for _, container := range component.Spec.Sidecars {
+		rst = append(rst, container.ResourceRequirements)
+	}
+
+	return rst
+}
+
+func validateResourceRequirements(resRequirement *v1.ResourceRequirements, path string) (rst KalmValidateErrorList) {
 	if resRequirement == nil {
 		return nil
 	}
@@ -422,13 +1128,13 @@ func (r *Component) validateResRequirement() (rst KalmValidateErrorList) {
 
 		if limit, exist := resRequirement.Limits[resName]; exist {
 
-			fldPath := field.NewPath("spec.resourceRequirements.limits." + string(resName))
+			fldPath := field.NewPath(path + ".limits." + string(resName))
 			errList := ValidateResourceQuantityValue(limit, fldPath, isIntegerRes)
 			rst = append(rst, toKalmValidateErrors(errList)...)
 		}
 
 		if request, exist := resRequirement.Requests[resName]; exist {
-			fldPath := field.NewPath("spec.resourceRequirements.requests." + string(resName))
+			fldPath := field.NewPath(path + ".requests." + string(resName))
 			errList := ValidateResourceQuantityValue(request, fldPath, isIntegerRes)
 			rst = append(rst, toKalmValidateErrors(errList)...)
 		}
@@ -446,8 +1152,112 @@ func (r *Component) validatePreInjectedFiles() (rst KalmValidateErrorList) {
 				Path: fmt.Sprintf(".spec.preInjectedFiles[%d]", i),
 			})
 		}
+
+		if preInjectFile.SecretRef == nil {
+			if preInjectFile.Content == "" {
+				rst = append(rst, KalmValidateError{
+					Err:  "content or secretRef is required",
+					Path: fmt.Sprintf(".spec.preInjectedFiles[%d].content", i),
+				})
+			}
+
+			continue
+		}
+
+		if preInjectFile.Content != "" {
+			rst = append(rst, KalmValidateError{
+				Err:  "content and secretRef can't be set at the same time",
+				Path: fmt.Sprintf(".spec.preInjectedFiles[%d].content", i),
+			})
+		}
+
+		errs := append(
+			apimachineryval.IsDNS1123Subdomain(preInjectFile.SecretRef.Name),
+			apimachineryval.IsConfigMapKey(preInjectFile.SecretRef.Key)...,
+		)
+		for _, err := range errs {
+			rst = append(rst, KalmValidateError{
+				Err:  "invalid secret reference: " + err,
+				Path: fmt.Sprintf(".spec.preInjectedFiles[%d].secretRef", i),
+			})
+		}
+	}
+
+	return rst
+}
+
+// names of containers added by kalm or istio
+var reservedContainerNames = map[string]bool{
+	"inject-files": true,
+	"istio-proxy":  true,
+	"istio-init":   true,
+}
+
+func (r *Component) validateExtraContainers() (rst KalmValidateErrorList) {
+	// the main container is named after the component
+	usedNames := map[string]bool{r.Name: true}
+
+	volumePaths := make(map[string]bool)
+	for _, vol := range r.Spec.Volumes {
+		volumePaths[vol.Path] = true
+	}
+
+	validateContainers := func(containers []ComponentContainer, path string) {
+		for i, container := range containers {
+			containerPath := fmt.Sprintf("%s[%d]", path, i)
+
+			for _, err := range apimachineryval.IsDNS1123Label(container.Name) {
+				rst = append(rst, KalmValidateError{
+					Err:  err,
+					Path: containerPath + ".name",
+				})
+			}
+
+			if reservedContainerNames[container.Name] {
+				rst = append(rst, KalmValidateError{
+					Err:  fmt.Sprintf("container name %s is reserved", container.Name),
+					Path: containerPath + ".name",
+				})
+			} else if usedNames[container.Name] {
+				rst = append(rst, KalmValidateError{
+					Err:  fmt.Sprintf("container name %s is already used", container.Name),
+					Path: containerPath + ".name",
+				})
+			}
+
+			usedNames[container.Name] = true
+
+			if container.Image == "" {
+				rst = append(rst, KalmValidateError{
+					Err:  "image is required",
+					Path: containerPath + ".image",
+				})
+			}
+
+			rst = append(rst, validateEnvVars(container.Env, containerPath+".env")...)
+			rst = append(rst, validateResourceRequirements(container.ResourceRequirements, containerPath+".resourceRequirements")...)
+
+			for j, volumeMount := range container.VolumeMounts {
+				if !volumePaths[volumeMount.Volume] {
+					rst = append(rst, KalmValidateError{
+						Err:  fmt.Sprintf("volume %s is not found in spec.volumes", volumeMount.Volume),
+						Path: fmt.Sprintf("%s.volumeMounts[%d].volume", containerPath, j),
+					})
+				}
+
+				if volumeMount.MountPath != "" && !strings.HasPrefix(volumeMount.MountPath, "/") {
+					rst = append(rst, KalmValidateError{
+						Err:  "should start with: /",
+						Path: fmt.Sprintf("%s.volumeMounts[%d].mountPath", containerPath, j),
+					})
+				}
+			}
+		}
 	}
 
+	validateContainers(r.Spec.InitContainers, ".spec.initContainers")
+	validateContainers(r.Spec.Sidecars, ".spec.sidecars")
+
 	return rst
 }
 
@@ -469,7 +1279,9 @@ func (r *Component) validateRunnerPermission() (rst KalmValidateErrorList) {
 	return rst
 }
 
-func fillResourceRequirementIfAbsent(requirements *v1.ResourceRequirements, cpu, mem resource.Quantity) *v1.ResourceRequirements {
+// fillResourceRequirementIfAbsent sets the default requests & limits of resources absent in the requirements.
+// A limit is never smaller than the request, and resources with a max limit are always limited.
+func fillResourceRequirementIfAbsent(requirements, defaults *v1.ResourceRequirements, maxLimits v1.ResourceList) *v1.ResourceRequirements {
 	var rst *v1.ResourceRequirements
 	if requirements == nil {
 		rst = &v1.ResourceRequirements{}
@@ -477,6 +1289,10 @@ func fillResourceRequirementIfAbsent(requirements *v1.ResourceRequirements, cpu,
 		rst = requirements.DeepCopy()
 	}
 
+	if defaults == nil {
+		defaults = &v1.ResourceRequirements{}
+	}
+
 	if rst.Limits == nil {
 		rst.Limits = make(map[v1.ResourceName]resource.Quantity)
 	}
@@ -489,53 +1305,58 @@ func fillResourceRequirementIfAbsent(requirements *v1.ResourceRequirements, cpu,
 
 	// Limits
 
-	// if request exist, set limit same as request
-	if _, exist := limits[v1.ResourceCPU]; !exist {
-		if req, exist := requests[v1.ResourceCPU]; exist {
-			limits[v1.ResourceCPU] = req
-		} else {
-			limits[v1.ResourceCPU] = cpu
+	for name, limit := range defaults.Limits {
+		if _, exist := limits[name]; exist {
+			continue
 		}
-	}
 
-	if _, exist := limits[v1.ResourceMemory]; !exist {
-		if req, exist := requests[v1.ResourceMemory]; exist {
-			limits[v1.ResourceMemory] = req
+		// if request is larger, set limit same as request
+		if req, exist := requests[name]; exist && req.Cmp(limit) > 0 {
+			limits[name] = req
 		} else {
-			limits[v1.ResourceMemory] = mem
+			limits[name] = limit
 		}
 	}
 
-	rst.Limits = limits
+	for name, max := range maxLimits {
+		if _, exist := limits[name]; !exist {
+			limits[name] = max
+		}
+	}
 
 	// Requests
 
-	// start with really low request here
-	if _, exist := requests[v1.ResourceCPU]; !exist {
-		requests[v1.ResourceCPU] = resource.MustParse("1m")
-	}
-	if _, exist := requests[v1.ResourceMemory]; !exist {
-		requests[v1.ResourceMemory] = resource.MustParse("1Mi")
+	for name, req := range defaults.Requests {
+		if _, exist := requests[name]; exist {
+			continue
+		}
+
+		if limit, exist := limits[name]; exist && req.Cmp(limit) > 0 {
+			requests[name] = limit
+		} else {
+			requests[name] = req
+		}
 	}
-	rst.Requests = requests
 
-	return rst
-}
+	if len(limits) == 0 {
+		rst.Limits = nil
+	}
 
-func (r *Component) setupResourceRequirementIfAbsent() {
-	defaultCPULimit := resource.MustParse("200m")
-	defaultMemoryLimit := resource.MustParse("128Mi")
+	if len(requests) == 0 {
+		rst.Requests = nil
+	}
 
-	filledResRequirement := fillResourceRequirementIfAbsent(r.Spec.ResourceRequirements, defaultCPULimit, defaultMemoryLimit)
+	if rst.Limits == nil && rst.Requests == nil {
+		return requirements
+	}
 
-	r.Spec.ResourceRequirements = filledResRequirement
+	return rst
 }
 
-func (r *Component) setupIstioResourceRequirementIfAbsent() {
-	defaultCPULimit := resource.MustParse("100m")
-	defaultMemoryLimit := resource.MustParse("128Mi")
-
-	filledResRequirement := fillResourceRequirementIfAbsent(r.Spec.IstioResourceRequirements, defaultCPULimit, defaultMemoryLimit)
+func (r *Component) setupResourceRequirementIfAbsent(defaults *ResourceDefaults) {
+	r.Spec.ResourceRequirements = fillResourceRequirementIfAbsent(r.Spec.ResourceRequirements, defaults.Container, defaults.MaxContainerLimits)
+}
 
-	r.Spec.IstioResourceRequirements = filledResRequirement
+func (r *Component) setupIstioResourceRequirementIfAbsent(defaults *ResourceDefaults) {
+	r.Spec.IstioResourceRequirements = fillResourceRequirementIfAbsent(r.Spec.IstioResourceRequirements, defaults.Istio, defaults.MaxIstioLimits)
 }
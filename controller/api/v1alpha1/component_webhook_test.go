@@ -5,7 +5,10 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	batchv1beta1 "k8s.io/api/batch/v1beta1"
+	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	ctrl "sigs.k8s.io/controller-runtime"
 )
 
@@ -218,3 +221,571 @@ func TestSTSForbiddenUpdatePersistVol(t *testing.T) {
 	assert.NotNil(t, err)
 	assert.Contains(t, err.Error(), "should not update volume of type: pvcTemplate")
 }
+
+func TestComponentExternalEnv(t *testing.T) {
+	component := Component{
+		ObjectMeta: ctrl.ObjectMeta{
+			Namespace: "kalm-system",
+			Name:      "kalm-comp-env",
+		},
+		Spec: ComponentSpec{
+			Image: fmt.Sprintf("%s:%s", "foo", "bar"),
+			Env: []EnvVar{
+				{
+					Name:  "DATABASE_URL",
+					Value: "database",
+					Type:  EnvVarTypeExternal,
+				},
+				{
+					Name:  "REDIS_URL",
+					Value: "cache/REDIS_MASTER_URL",
+					Type:  EnvVarTypeExternal,
+				},
+			},
+		},
+	}
+	component.Default()
+
+	assert.Nil(t, component.validate())
+
+	setName, key := ParseExternalEnvValue(component.Spec.Env[0])
+	assert.Equal(t, "database", setName)
+	assert.Equal(t, "DATABASE_URL", key)
+
+	setName, key = ParseExternalEnvValue(component.Spec.Env[1])
+	assert.Equal(t, "cache", setName)
+	assert.Equal(t, "REDIS_MASTER_URL", key)
+
+	component.Spec.Env[1].Value = "Cache/"
+	errs := component.validate()
+	assert.Len(t, errs, 2)
+	assert.Equal(t, ".spec.env[1].value", errs[0].Path)
+}
+
+func TestComponentSecretEnvAndFiles(t *testing.T) {
+	component := Component{
+		ObjectMeta: ctrl.ObjectMeta{
+			Namespace: "kalm-system",
+			Name:      "kalm-comp-secret",
+		},
+		Spec: ComponentSpec{
+			Image: fmt.Sprintf("%s:%s", "foo", "bar"),
+			Env: []EnvVar{
+				{
+					Name:  "PASSWORD",
+					Value: "db-credentials/password",
+					Type:  EnvVarTypeSecret,
+				},
+			},
+			PreInjectedFiles: []PreInjectFile{
+				{
+					MountPath: "/etc/tls/tls.key",
+					SecretRef: &SecretKeyReference{
+						Name: "tls",
+						Key:  "tls.key",
+					},
+				},
+			},
+		},
+	}
+	component.Default()
+
+	assert.Nil(t, component.validate())
+
+	secretName, key := ParseSecretEnvValue(component.Spec.Env[0])
+	assert.Equal(t, "db-credentials", secretName)
+	assert.Equal(t, "password", key)
+
+	component.Spec.PreInjectedFiles[0].Content = "foo"
+	errs := component.validate()
+	assert.Len(t, errs, 1)
+	assert.Equal(t, ".spec.preInjectedFiles[0].content", errs[0].Path)
+
+	component.Spec.PreInjectedFiles[0].Content = ""
+	component.Spec.PreInjectedFiles[0].SecretRef = nil
+	errs = component.validate()
+	assert.Len(t, errs, 1)
+	assert.Equal(t, ".spec.preInjectedFiles[0].content", errs[0].Path)
+}
+
+func TestComponentAutoScaling(t *testing.T) {
+	targetCPU := int32(80)
+
+	component := Component{
+		ObjectMeta: ctrl.ObjectMeta{
+			Namespace: "kalm-system",
+			Name:      "kalm-comp-hpa",
+		},
+		Spec: ComponentSpec{
+			Image: fmt.Sprintf("%s:%s", "foo", "bar"),
+			AutoScaling: &AutoScalingConfig{
+				MinReplicas:                    2,
+				MaxReplicas:                    5,
+				TargetCPUUtilizationPercentage: &targetCPU,
+			},
+		},
+	}
+	component.Default()
+
+	assert.Nil(t, component.validate())
+
+	component.Spec.AutoScaling.MaxReplicas = 1
+	errs := component.validate()
+	assert.Len(t, errs, 1)
+	assert.Equal(t, ".spec.autoScaling.maxReplicas", errs[0].Path)
+
+	component.Spec.AutoScaling.MaxReplicas = 5
+	component.Spec.AutoScaling.TargetCPUUtilizationPercentage = nil
+	errs = component.validate()
+	assert.Len(t, errs, 1)
+	assert.Equal(t, ".spec.autoScaling", errs[0].Path)
+
+	component.Spec.AutoScaling.TargetCPUUtilizationPercentage = &targetCPU
+	component.Spec.WorkloadType = WorkloadTypeDaemonSet
+	errs = component.validate()
+	assert.Len(t, errs, 1)
+	assert.Equal(t, ".spec.autoScaling", errs[0].Path)
+}
+
+func TestComponentExtraContainers(t *testing.T) {
+	component := Component{
+		ObjectMeta: ctrl.ObjectMeta{
+			Namespace: "kalm-system",
+			Name:      "kalm-comp-containers",
+		},
+		Spec: ComponentSpec{
+			Image: fmt.Sprintf("%s:%s", "foo", "bar"),
+			Volumes: []Volume{
+				{
+					Path: "/var/log/app",
+					Size: resource.MustParse("1Gi"),
+					Type: VolumeTypeTemporaryDisk,
+				},
+			},
+			InitContainers: []ComponentContainer{
+				{
+					Name:    "migrate",
+					Image:   "foo:bar",
+					Command: []string{"./migrate"},
+				},
+			},
+			Sidecars: []ComponentContainer{
+				{
+					Name:  "log-shipper",
+					Image: "fluent-bit",
+					Env: []EnvVar{
+						{Name: "TOKEN", Value: "logging/token", Type: EnvVarTypeSecret},
+					},
+					VolumeMounts: []ContainerVolumeMount{
+						{Volume: "/var/log/app", ReadOnly: true},
+					},
+				},
+			},
+		},
+	}
+	component.Default()
+
+	assert.Nil(t, component.validate())
+
+	component.Spec.Sidecars[0].Name = "migrate"
+	errs := component.validate()
+	assert.Len(t, errs, 1)
+	assert.Equal(t, ".spec.sidecars[0].name", errs[0].Path)
+
+	component.Spec.Sidecars[0].Name = "istio-proxy"
+	errs = component.validate()
+	assert.Len(t, errs, 1)
+	assert.Equal(t, ".spec.sidecars[0].name", errs[0].Path)
+
+	component.Spec.Sidecars[0].Name = "log-shipper"
+	component.Spec.Sidecars[0].VolumeMounts[0].Volume = "/data"
+	errs = component.validate()
+	assert.Len(t, errs, 1)
+	assert.Equal(t, ".spec.sidecars[0].volumeMounts[0].volume", errs[0].Path)
+
+	component.Spec.Sidecars[0].VolumeMounts[0].Volume = "/var/log/app"
+	component.Spec.InitContainers[0].Env = []EnvVar{{Name: "DB", Value: "Invalid_Secret/password", Type: EnvVarTypeSecret}}
+	errs = component.validate()
+	assert.Len(t, errs, 1)
+	assert.Equal(t, ".spec.initContainers[0].env[0].value", errs[0].Path)
+}
+
+func TestComponentRolloutStrategy(t *testing.T) {
+	component := Component{
+		ObjectMeta: ctrl.ObjectMeta{
+			Namespace: "kalm-system",
+			Name:      "kalm-comp-rollout",
+		},
+		Spec: ComponentSpec{
+			Image: fmt.Sprintf("%s:%s", "foo", "bar"),
+			Ports: []Port{
+				{Protocol: PortProtocolHTTP, ContainerPort: 8080},
+			},
+			RolloutStrategy: &RolloutStrategy{
+				Type:                RolloutStrategyCanary,
+				Steps:               []int32{10, 50},
+				StepDurationSeconds: 60,
+			},
+		},
+	}
+	component.Default()
+
+	assert.Nil(t, component.validate())
+
+	component.Spec.RolloutStrategy.Steps = []int32{50, 10}
+	errs := component.validate()
+	assert.Len(t, errs, 1)
+	assert.Equal(t, ".spec.rolloutStrategy.steps[1]", errs[0].Path)
+
+	component.Spec.RolloutStrategy.Type = RolloutStrategyBlueGreen
+	errs = component.validate()
+	assert.Len(t, errs, 1)
+	assert.Equal(t, ".spec.rolloutStrategy.steps", errs[0].Path)
+
+	component.Spec.RolloutStrategy.Steps = nil
+	assert.Nil(t, component.validate())
+	assert.Equal(t, []int32{100}, component.Spec.RolloutStrategy.GetSteps())
+
+	component.Spec.Ports = nil
+	errs = component.validate()
+	assert.Len(t, errs, 1)
+	assert.Equal(t, ".spec.rolloutStrategy", errs[0].Path)
+}
+
+func TestComponentScaleToZero(t *testing.T) {
+	component := Component{
+		ObjectMeta: ctrl.ObjectMeta{
+			Namespace: "kalm-system",
+			Name:      "kalm-comp-scale-to-zero",
+		},
+		Spec: ComponentSpec{
+			Image: fmt.Sprintf("%s:%s", "foo", "bar"),
+			Ports: []Port{
+				{Protocol: PortProtocolHTTP, ContainerPort: 8080},
+			},
+			ScaleToZero: &ScaleToZeroConfig{
+				IdleSeconds: 600,
+			},
+		},
+	}
+	component.Default()
+
+	assert.Nil(t, component.validate())
+	assert.False(t, IsComponentScaledToZero(&component))
+
+	component.Annotations = map[string]string{KalmAnnoScaledToZeroAt: "2020-10-01T00:00:00Z"}
+	assert.True(t, IsComponentScaledToZero(&component))
+
+	component.Spec.ScaleToZero.IdleSeconds = 10
+	errs := component.validate()
+	assert.Len(t, errs, 1)
+	assert.Equal(t, ".spec.scaleToZero.idleSeconds", errs[0].Path)
+
+	component.Spec.ScaleToZero.IdleSeconds = 600
+	component.Spec.WorkloadType = WorkloadTypeDaemonSet
+	errs = component.validate()
+	assert.Len(t, errs, 1)
+	assert.Equal(t, ".spec.scaleToZero", errs[0].Path)
+}
+
+func TestComponentScalingSchedules(t *testing.T) {
+	component := Component{
+		ObjectMeta: ctrl.ObjectMeta{
+			Namespace: "kalm-system",
+			Name:      "kalm-comp-scaling-schedules",
+		},
+		Spec: ComponentSpec{
+			Image: fmt.Sprintf("%s:%s", "foo", "bar"),
+			ScalingSchedules: []ScalingSchedule{
+				{Start: "0 20 * * *", End: "0 7 * * *", Replicas: 0, TimeZone: "Asia/Shanghai"},
+			},
+		},
+	}
+	component.Default()
+
+	assert.Nil(t, component.validate())
+
+	component.Spec.ScalingSchedules = append(component.Spec.ScalingSchedules, ScalingSchedule{
+		Start: "0 0 * * 6", End: "foo", Replicas: 1, TimeZone: "Mars/Olympus",
+	})
+	errs := component.validate()
+	assert.Len(t, errs, 2)
+	assert.Equal(t, ".spec.scalingSchedules[1].end", errs[0].Path)
+	assert.Equal(t, ".spec.scalingSchedules[1].timeZone", errs[1].Path)
+
+	component.Spec.ScalingSchedules = component.Spec.ScalingSchedules[:1]
+	component.Spec.WorkloadType = WorkloadTypeCronjob
+	component.Spec.Schedule = "* * * * *"
+	errs = component.validate()
+	assert.Len(t, errs, 1)
+	assert.Equal(t, ".spec.scalingSchedules", errs[0].Path)
+}
+
+func TestComponentDisruptionBudget(t *testing.T) {
+	replicas := int32(3)
+	minAvailable := intstr.FromInt(2)
+
+	component := Component{
+		ObjectMeta: ctrl.ObjectMeta{
+			Namespace: "kalm-system",
+			Name:      "kalm-comp-pdb",
+		},
+		Spec: ComponentSpec{
+			Image:        fmt.Sprintf("%s:%s", "foo", "bar"),
+			Replicas:     &replicas,
+			MinAvailable: &minAvailable,
+			TopologySpread: []TopologySpread{
+				{Topology: TopologySpreadZone},
+				{Topology: TopologySpreadHost, MaxSkew: 2, ScheduleAnyway: true},
+			},
+		},
+	}
+	component.Default()
+
+	assert.Nil(t, component.validate())
+
+	minAvailable = intstr.FromString("50%")
+	assert.Nil(t, component.validate())
+
+	// all 3 replicas are required to be available
+	minAvailable = intstr.FromInt(3)
+	errs := component.validate()
+	assert.Len(t, errs, 1)
+	assert.Equal(t, ".spec.minAvailable", errs[0].Path)
+
+	minAvailable = intstr.FromString("90%")
+	errs = component.validate()
+	assert.Len(t, errs, 1)
+	assert.Equal(t, ".spec.minAvailable", errs[0].Path)
+
+	minAvailable = intstr.FromString("foo")
+	errs = component.validate()
+	assert.Len(t, errs, 1)
+
+	// the min replicas of autoscaling is used
+	minAvailable = intstr.FromInt(2)
+	targetCPU := int32(80)
+	component.Spec.AutoScaling = &AutoScalingConfig{MinReplicas: 2, MaxReplicas: 5, TargetCPUUtilizationPercentage: &targetCPU}
+	errs = component.validate()
+	assert.Len(t, errs, 1)
+	assert.Equal(t, ".spec.minAvailable", errs[0].Path)
+	component.Spec.AutoScaling = nil
+
+	// the fewest replicas of scaling schedules are used, stopped windows are ignored
+	component.Spec.ScalingSchedules = []ScalingSchedule{
+		{Start: "0 20 * * *", End: "0 7 * * *", Replicas: 0},
+	}
+	assert.Nil(t, component.validate())
+
+	component.Spec.ScalingSchedules = append(component.Spec.ScalingSchedules, ScalingSchedule{
+		Start: "0 12 * * *", End: "0 13 * * *", Replicas: 2,
+	})
+	errs = component.validate()
+	assert.Len(t, errs, 1)
+	assert.Equal(t, ".spec.minAvailable", errs[0].Path)
+	component.Spec.ScalingSchedules = nil
+
+	component.Spec.WorkloadType = WorkloadTypeDaemonSet
+	errs = component.validate()
+	assert.Len(t, errs, 1)
+	assert.Equal(t, ".spec.minAvailable", errs[0].Path)
+
+	component.Spec.WorkloadType = WorkloadTypeServer
+	component.Spec.TopologySpread = append(component.Spec.TopologySpread, TopologySpread{Topology: TopologySpreadZone})
+	errs = component.validate()
+	assert.Len(t, errs, 1)
+	assert.Equal(t, ".spec.topologySpread[2].topology", errs[0].Path)
+}
+
+func TestComponentScheduling(t *testing.T) {
+	tolerationSeconds := int64(60)
+
+	component := Component{
+		ObjectMeta: ctrl.ObjectMeta{
+			Namespace: "kalm-system",
+			Name:      "kalm-comp-scheduling",
+		},
+		Spec: ComponentSpec{
+			Image: fmt.Sprintf("%s:%s", "foo", "bar"),
+			NodeAffinity: &NodeAffinity{
+				Required: []v1.NodeSelectorRequirement{
+					{Key: "pool", Operator: v1.NodeSelectorOpIn, Values: []string{"spot"}},
+				},
+				Preferred: []PreferredNodeAffinity{
+					{
+						Weight: 10,
+						Expressions: []v1.NodeSelectorRequirement{
+							{Key: "gpu", Operator: v1.NodeSelectorOpDoesNotExist},
+						},
+					},
+				},
+			},
+			Tolerations: []v1.Toleration{
+				{Key: "spot", Operator: v1.TolerationOpEqual, Value: "true", Effect: v1.TaintEffectNoSchedule},
+				{Key: "unreachable", Operator: v1.TolerationOpExists, Effect: v1.TaintEffectNoExecute, TolerationSeconds: &tolerationSeconds},
+			},
+			PriorityClassName: "batch-low",
+		},
+	}
+	component.Default()
+
+	assert.Nil(t, component.validate())
+
+	component.Spec.NodeAffinity.Required[0].Values = nil
+	component.Spec.NodeAffinity.Preferred[0].Weight = 0
+	errs := component.validate()
+	assert.Len(t, errs, 2)
+	assert.Equal(t, ".spec.nodeAffinity.required[0].values", errs[0].Path)
+	assert.Equal(t, ".spec.nodeAffinity.preferred[0].weight", errs[1].Path)
+
+	component.Spec.NodeAffinity.Required[0] = v1.NodeSelectorRequirement{Key: "cpus", Operator: v1.NodeSelectorOpGt, Values: []string{"four"}}
+	component.Spec.NodeAffinity.Preferred[0].Weight = 10
+	errs = component.validate()
+	assert.Len(t, errs, 1)
+	assert.Equal(t, ".spec.nodeAffinity.required[0].values", errs[0].Path)
+	component.Spec.NodeAffinity = nil
+
+	component.Spec.Tolerations[0].Operator = v1.TolerationOpExists
+	component.Spec.Tolerations[1].Effect = v1.TaintEffectNoSchedule
+	errs = component.validate()
+	assert.Len(t, errs, 2)
+	assert.Equal(t, ".spec.tolerations[0].value", errs[0].Path)
+	assert.Equal(t, ".spec.tolerations[1].tolerationSeconds", errs[1].Path)
+
+	component.Spec.Tolerations = []v1.Toleration{{Operator: v1.TolerationOpEqual}}
+	errs = component.validate()
+	assert.Len(t, errs, 1)
+	assert.Equal(t, ".spec.tolerations[0].operator", errs[0].Path)
+
+	component.Spec.Tolerations = nil
+	component.Spec.PriorityClassName = "Batch_Low"
+	errs = component.validate()
+	assert.Len(t, errs, 1)
+	assert.Equal(t, ".spec.priorityClassName", errs[0].Path)
+}
+
+func TestComponentJob(t *testing.T) {
+	parallelism := int32(2)
+
+	component := Component{
+		ObjectMeta: ctrl.ObjectMeta{
+			Namespace: "kalm-system",
+			Name:      "kalm-comp-job",
+		},
+		Spec: ComponentSpec{
+			Image:        fmt.Sprintf("%s:%s", "foo", "bar"),
+			WorkloadType: WorkloadTypeJob,
+			Job: &JobConfig{
+				Parallelism: &parallelism,
+			},
+			PendingJobRuns: []JobRunRequest{
+				{ID: "abc", Env: []EnvVar{{Name: "FOO", Value: "bar"}}, Args: []string{"--dry-run"}},
+			},
+		},
+	}
+	component.Default()
+
+	assert.Nil(t, component.validate())
+
+	parallelism = 0
+	component.Spec.PendingJobRuns = append(component.Spec.PendingJobRuns, JobRunRequest{ID: "abc"}, JobRunRequest{ID: "Invalid_ID"})
+	errs := component.validate()
+	assert.Len(t, errs, 3)
+	assert.Equal(t, ".spec.job.parallelism", errs[0].Path)
+	assert.Equal(t, ".spec.pendingJobRuns[1].id", errs[1].Path)
+	assert.Equal(t, ".spec.pendingJobRuns[2].id", errs[2].Path)
+
+	component.Spec.WorkloadType = WorkloadTypeServer
+	errs = component.validate()
+	assert.Len(t, errs, 2)
+	assert.Equal(t, ".spec.job", errs[0].Path)
+	assert.Equal(t, ".spec.pendingJobRuns", errs[1].Path)
+}
+
+func TestComponentCronJobConfig(t *testing.T) {
+	cronJobTimeZoneSupported = true
+	defer func() { cronJobTimeZoneSupported = false }()
+
+	historyLimit := int32(10)
+
+	component := Component{
+		ObjectMeta: ctrl.ObjectMeta{
+			Namespace: "kalm-system",
+			Name:      "kalm-comp-cronjob",
+		},
+		Spec: ComponentSpec{
+			Image:        fmt.Sprintf("%s:%s", "foo", "bar"),
+			WorkloadType: WorkloadTypeCronjob,
+			Schedule:     "0 2 * * *",
+			CronJob: &CronJobConfig{
+				ConcurrencyPolicy:          batchv1beta1.ForbidConcurrent,
+				SuccessfulJobsHistoryLimit: &historyLimit,
+				TimeZone:                   "Asia/Shanghai",
+			},
+		},
+	}
+	component.Default()
+
+	assert.Nil(t, component.validate())
+
+	component.Spec.CronJob.TimeZone = "Mars/Olympus_Mons"
+	component.Spec.CronJob.ConcurrencyPolicy = "Never"
+	historyLimit = -1
+	errs := component.validate()
+	assert.Len(t, errs, 3)
+	assert.Equal(t, ".spec.cronJob.concurrencyPolicy", errs[0].Path)
+	assert.Equal(t, ".spec.cronJob.successfulJobsHistoryLimit", errs[1].Path)
+	assert.Equal(t, ".spec.cronJob.timeZone", errs[2].Path)
+
+	// clusters before 1.21 reject the CRON_TZ prefix
+	cronJobTimeZoneSupported = false
+	component.Spec.CronJob.TimeZone = "Asia/Shanghai"
+	errs = component.validate()
+	assert.Len(t, errs, 3)
+	assert.Equal(t, "time zones of cronjobs require kubernetes 1.21 or later", errs[2].Err)
+
+	component.Spec.CronJob = &CronJobConfig{}
+	component.Spec.Schedule = "CRON_TZ=Asia/Shanghai 0 2 * * *"
+	errs = component.validate()
+	assert.Len(t, errs, 1)
+	assert.Equal(t, ".spec.schedule", errs[0].Path)
+
+	component.Spec.Schedule = "0 2 * * *"
+	component.Spec.WorkloadType = WorkloadTypeServer
+	errs = component.validate()
+	assert.Len(t, errs, 1)
+	assert.Equal(t, ".spec.cronJob", errs[0].Path)
+}
+
+func TestComponentFileVolume(t *testing.T) {
+	component := Component{
+		ObjectMeta: ctrl.ObjectMeta{
+			Namespace: "kalm-system",
+			Name:      "kalm-comp-file-volume",
+		},
+		Spec: ComponentSpec{
+			Image: fmt.Sprintf("%s:%s", "foo", "bar"),
+			Volumes: []Volume{
+				{Type: VolumeTypeFile, Path: "/etc/nginx/conf.d", FilePath: "/nginx/conf.d"},
+			},
+		},
+	}
+	component.Default()
+
+	assert.Nil(t, component.validate())
+
+	component.Spec.Volumes[0].FilePath = ""
+	errs := component.validate()
+	assert.Len(t, errs, 1)
+	assert.Equal(t, ".spec.volumes[0].filePath", errs[0].Path)
+
+	component.Spec.Volumes[0].FilePath = "/nginx/../conf.d"
+	errs = component.validate()
+	assert.Len(t, errs, 1)
+	assert.Equal(t, ".spec.volumes[0].filePath", errs[0].Path)
+
+	component.Spec.Volumes[0] = Volume{Type: VolumeTypeTemporaryDisk, Path: "/tmp", Size: resource.MustParse("1Gi"), FilePath: "/nginx"}
+	errs = component.validate()
+	assert.Len(t, errs, 1)
+	assert.Equal(t, ".spec.volumes[0].filePath", errs[0].Path)
+}
@@ -8,6 +8,7 @@ const (
 
 	// Event Reason
 	ReasonExceedingQuota = "ExceedingQuota"
+	ReasonSuspended      = "Suspended"
 	ReasonReschedule     = "ReSchedule"
 
 	ACMEServerName = "acme-server"
@@ -97,9 +97,123 @@ type HttpRouteCORS struct {
 	MaxAgeSeconds    *int     `json:"maxAgeSeconds,omitempty"`
 }
 
+// HttpRouteRateLimit is enforced by each replica of the ingress gateway with a token bucket,
+// all requests to the route share the bucket.
+type HttpRouteRateLimit struct {
+	// requests allowed in each interval
+	// +kubebuilder:validation:Minimum=1
+	Requests int `json:"requests"`
+
+	// +kubebuilder:validation:Minimum=1
+	IntervalSeconds int `json:"intervalSeconds"`
+
+	// extra requests allowed in a burst, on top of requests of an interval
+	// +kubebuilder:validation:Minimum=0
+	Burst int `json:"burst,omitempty"`
+
+	// status of rejected requests, 429 if not set
+	// +kubebuilder:validation:Minimum=400
+	// +kubebuilder:validation:Maximum=599
+	ResponseStatus int `json:"responseStatus,omitempty"`
+}
+
 // +kubebuilder:validation:Enum=GET;HEAD;POST;PUT;PATCH;DELETE;OPTIONS;TRACE;CONNECT
 type AllowMethod string
 
+// HttpRouteRewrite rewrites requests before they are sent to destinations,
+// only one of uri and uriRegex can be set.
+type HttpRouteRewrite struct {
+	// replace the matched path prefix with the uri
+	Uri string `json:"uri,omitempty"`
+	// rewrite the path with a regular expression
+	UriRegex *HttpRouteRegexRewrite `json:"uriRegex,omitempty"`
+	// replace the host header
+	Authority string `json:"authority,omitempty"`
+}
+
+type HttpRouteRegexRewrite struct {
+	// RE2 regular expression matching the full path
+	Pattern string `json:"pattern"`
+	// substitution of the path, capture groups can be referenced with \1, \2 etc
+	Substitution string `json:"substitution"`
+}
+
+// HttpRouteRedirect responds with a redirect instead of sending requests to destinations
+type HttpRouteRedirect struct {
+	// the host of the redirect location, the request host is kept if it's blank
+	Host string `json:"host,omitempty"`
+	// the path of the redirect location, it replaces the matched path prefix if preservePath is true
+	Path string `json:"path,omitempty"`
+
+	// +kubebuilder:validation:Enum=301;302;307;308
+	// 301 by default
+	StatusCode int `json:"statusCode,omitempty"`
+
+	PreservePath  bool `json:"preservePath,omitempty"`
+	PreserveQuery bool `json:"preserveQuery,omitempty"`
+}
+
+// HttpRouteAccessControl limits clients of the route at the ingress gateway.
+// Client IPs are computed by envoy from x-forwarded-for with the trusted hops of the ingress gateway.
+// Behind a L4 load balancer, client IPs are only kept if the ingress gateway service uses externalTrafficPolicy Local,
+// which isn't the default since it affects all traffic of the cluster, see the overlay in istiocontrolplane.yaml of the operator.
+// Load balancers which replace client IPs need the proxy protocol of the gateway instead.
+type HttpRouteAccessControl struct {
+	// CIDRs or IPs of clients which can access the route, all clients can if it's empty
+	AllowedIPs []string `json:"allowedIPs,omitempty"`
+	// CIDRs or IPs of clients which can't access the route, even if they are allowed
+	DeniedIPs []string `json:"deniedIPs,omitempty"`
+
+	BasicAuth *HttpRouteBasicAuth `json:"basicAuth,omitempty"`
+}
+
+// HttpRouteBasicAuth requires http basic auth with users in the secret,
+// each key of the secret is a user name and the value is the bcrypt hash of the password.
+type HttpRouteBasicAuth struct {
+	SecretNamespace string `json:"secretNamespace"`
+	SecretName      string `json:"secretName"`
+	Realm           string `json:"realm,omitempty"`
+}
+
+// envoy refuses direct responses with larger bodies
+const MaxDirectResponseBodySize = 4096
+
+// HttpRouteDirectResponse responds with the body instead of sending requests to destinations
+type HttpRouteDirectResponse struct {
+	// +kubebuilder:validation:Minimum=200
+	// +kubebuilder:validation:Maximum=599
+	Status int `json:"status"`
+
+	ContentType string `json:"contentType,omitempty"`
+	Body        string `json:"body,omitempty"`
+
+	// read the body from kalm files of an application instead
+	BodyFile *HttpRouteBodyFile `json:"bodyFile,omitempty"`
+}
+
+type HttpRouteBodyFile struct {
+	// the application of the file, maintenance responses always read files of the application in maintenance
+	Namespace string `json:"namespace,omitempty"`
+	Path      string `json:"path"`
+}
+
+// HttpRouteHeaderOperations are applied in order of set, add and remove
+type HttpRouteHeaderOperations struct {
+	// overwrite the header with the value
+	Set map[string]string `json:"set,omitempty"`
+	// append the value to the header
+	Add map[string]string `json:"add,omitempty"`
+	// remove the header
+	Remove []string `json:"remove,omitempty"`
+}
+
+type HttpRouteHeaders struct {
+	// operations on headers of requests before they are sent to destinations
+	Request *HttpRouteHeaderOperations `json:"request,omitempty"`
+	// operations on headers of responses before they are returned to clients
+	Response *HttpRouteHeaderOperations `json:"response,omitempty"`
+}
+
 // +kubebuilder:validation:Enum=GET;HEAD;POST;PUT;PATCH;DELETE;OPTIONS;TRACE;CONNECT
 type HttpRouteMethod string
 
@@ -126,8 +240,8 @@ type HttpRouteSpec struct {
 
 	Conditions []HttpRouteCondition `json:"conditions,omitempty"`
 
-	// +kubebuilder:validation:MinItems=1
-	Destinations []HttpRouteDestination `json:"destinations"`
+	// destinations are required unless the route is a redirect or a direct response
+	Destinations []HttpRouteDestination `json:"destinations,omitempty"`
 
 	HttpRedirectToHttps bool `json:"httpRedirectToHttps,omitempty"`
 
@@ -138,6 +252,17 @@ type HttpRouteSpec struct {
 	Fault  *HttpRouteFault  `json:"fault,omitempty"`
 	Delay  *HttpRouteDelay  `json:"delay,omitempty"`
 	CORS   *HttpRouteCORS   `json:"cors,omitempty"`
+
+	RateLimit *HttpRouteRateLimit `json:"rateLimit,omitempty"`
+
+	Headers *HttpRouteHeaders `json:"headers,omitempty"`
+
+	Rewrite  *HttpRouteRewrite  `json:"rewrite,omitempty"`
+	Redirect *HttpRouteRedirect `json:"redirect,omitempty"`
+
+	DirectResponse *HttpRouteDirectResponse `json:"directResponse,omitempty"`
+
+	AccessControl *HttpRouteAccessControl `json:"accessControl,omitempty"`
 }
 
 type HttpRouteDestinationStatus struct {
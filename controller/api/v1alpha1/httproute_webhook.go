@@ -17,10 +17,15 @@ package v1alpha1
 
 import (
 	"fmt"
+	"net"
+	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 
+	"github.com/kalmhq/kalm/controller/lib/files"
 	"github.com/kalmhq/kalm/controller/validation"
+	apimachineryvalidation "k8s.io/apimachinery/pkg/api/validation"
 	"k8s.io/apimachinery/pkg/runtime"
 	ctrl "sigs.k8s.io/controller-runtime"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
@@ -124,6 +129,15 @@ func (r *HttpRoute) validate() error {
 		}
 	}
 
+	rst = append(rst, r.validateRouteAction()...)
+
+	rst = append(rst, validateAccessControl(r.Spec.AccessControl)...)
+
+	if r.Spec.Headers != nil {
+		rst = append(rst, validateHeaderOperations(r.Spec.Headers.Request, "spec.headers.request")...)
+		rst = append(rst, validateHeaderOperations(r.Spec.Headers.Response, "spec.headers.response")...)
+	}
+
 	if len(rst) == 0 {
 		return nil
 	}
@@ -131,6 +145,319 @@ func (r *HttpRoute) validate() error {
 	return rst
 }
 
+func (r *HttpRoute) validateRouteAction() (rst KalmValidateErrorList) {
+	spec := &r.Spec
+
+	if rewrite := spec.Rewrite; rewrite != nil {
+		if spec.StripPath {
+			rst = append(rst, KalmValidateError{
+				Err:  "rewrite can't be used with stripPath",
+				Path: "spec.rewrite",
+			})
+		}
+
+		if rewrite.Uri != "" && rewrite.UriRegex != nil {
+			rst = append(rst, KalmValidateError{
+				Err:  "only one of uri and uriRegex can be set",
+				Path: "spec.rewrite.uriRegex",
+			})
+		}
+
+		if rewrite.Uri != "" && !isValidPath(rewrite.Uri) {
+			rst = append(rst, KalmValidateError{
+				Err:  "invalid uri, should start with: /",
+				Path: "spec.rewrite.uri",
+			})
+		}
+
+		if rewrite.UriRegex != nil {
+			if _, err := regexp.Compile(rewrite.UriRegex.Pattern); err != nil {
+				rst = append(rst, KalmValidateError{
+					Err:  "invalid regular expression: " + err.Error(),
+					Path: "spec.rewrite.uriRegex.pattern",
+				})
+			}
+		}
+
+		if rewrite.Authority != "" && !isValidDestinationHost(rewrite.Authority) {
+			rst = append(rst, KalmValidateError{
+				Err:  "invalid authority:" + rewrite.Authority,
+				Path: "spec.rewrite.authority",
+			})
+		}
+	}
+
+	if spec.DirectResponse != nil {
+		if spec.Redirect != nil {
+			rst = append(rst, KalmValidateError{
+				Err:  "only one of redirect and directResponse can be set",
+				Path: "spec.directResponse",
+			})
+		}
+
+		if len(spec.Destinations) > 0 {
+			rst = append(rst, KalmValidateError{
+				Err:  "direct response route can't have destinations",
+				Path: "spec.destinations",
+			})
+		}
+
+		if spec.Rewrite != nil || spec.StripPath || spec.Mirror != nil {
+			rst = append(rst, KalmValidateError{
+				Err:  "direct response route can't rewrite or mirror requests",
+				Path: "spec.directResponse",
+			})
+		}
+
+		rst = append(rst, validateDirectResponse(spec.DirectResponse, "spec.directResponse", true)...)
+
+		return rst
+	}
+
+	redirect := spec.Redirect
+
+	if redirect == nil {
+		if len(spec.Destinations) == 0 {
+			rst = append(rst, KalmValidateError{
+				Err:  "destinations are required unless the route is a redirect or a direct response",
+				Path: "spec.destinations",
+			})
+		}
+
+		return rst
+	}
+
+	if len(spec.Destinations) > 0 {
+		rst = append(rst, KalmValidateError{
+			Err:  "redirect route can't have destinations",
+			Path: "spec.destinations",
+		})
+	}
+
+	if spec.Rewrite != nil || spec.StripPath || spec.Mirror != nil {
+		rst = append(rst, KalmValidateError{
+			Err:  "redirect route can't rewrite or mirror requests",
+			Path: "spec.redirect",
+		})
+	}
+
+	if redirect.Host == "" && redirect.Path == "" && redirect.PreservePath {
+		rst = append(rst, KalmValidateError{
+			Err:  "redirect to the same location",
+			Path: "spec.redirect",
+		})
+	}
+
+	if redirect.Host != "" && !isValidDestinationHost(redirect.Host) {
+		rst = append(rst, KalmValidateError{
+			Err:  "invalid redirect host:" + redirect.Host,
+			Path: "spec.redirect.host",
+		})
+	}
+
+	if redirect.Path != "" && !isValidPath(redirect.Path) {
+		rst = append(rst, KalmValidateError{
+			Err:  "invalid path, should start with: /",
+			Path: "spec.redirect.path",
+		})
+	}
+
+	return rst
+}
+
+func validateAccessControl(accessControl *HttpRouteAccessControl) (rst KalmValidateErrorList) {
+	if accessControl == nil {
+		return nil
+	}
+
+	for i, ip := range accessControl.AllowedIPs {
+		if !isValidIPOrCIDR(ip) {
+			rst = append(rst, KalmValidateError{
+				Err:  "invalid ip or cidr: " + ip,
+				Path: fmt.Sprintf("spec.accessControl.allowedIPs[%d]", i),
+			})
+		}
+	}
+
+	for i, ip := range accessControl.DeniedIPs {
+		if !isValidIPOrCIDR(ip) {
+			rst = append(rst, KalmValidateError{
+				Err:  "invalid ip or cidr: " + ip,
+				Path: fmt.Sprintf("spec.accessControl.deniedIPs[%d]", i),
+			})
+		}
+	}
+
+	basicAuth := accessControl.BasicAuth
+
+	if basicAuth == nil {
+		return rst
+	}
+
+	if errs := apimachineryvalidation.ValidateNamespaceName(basicAuth.SecretNamespace, false); len(errs) > 0 {
+		rst = append(rst, KalmValidateError{
+			Err:  "invalid namespace: " + strings.Join(errs, ", "),
+			Path: "spec.accessControl.basicAuth.secretNamespace",
+		})
+	}
+
+	if errs := apimachineryvalidation.NameIsDNSSubdomain(basicAuth.SecretName, false); len(errs) > 0 {
+		rst = append(rst, KalmValidateError{
+			Err:  "invalid secret name: " + strings.Join(errs, ", "),
+			Path: "spec.accessControl.basicAuth.secretName",
+		})
+	}
+
+	// the realm is quoted in the www-authenticate header
+	if strings.ContainsAny(basicAuth.Realm, "\"\\\r\n") {
+		rst = append(rst, KalmValidateError{
+			Err:  "realm can't contain quotes, backslashes or line breaks",
+			Path: "spec.accessControl.basicAuth.realm",
+		})
+	}
+
+	return rst
+}
+
+func isValidIPOrCIDR(ip string) bool {
+	if _, _, err := net.ParseCIDR(ip); err == nil {
+		return true
+	}
+
+	return net.ParseIP(ip) != nil
+}
+
+// validateDirectResponse validates the response of a route or an application in maintenance,
+// the namespace of the body file is only required by routes.
+func validateDirectResponse(response *HttpRouteDirectResponse, path string, requireFileNamespace bool) (rst KalmValidateErrorList) {
+	if response.Status < 200 || response.Status > 599 {
+		rst = append(rst, KalmValidateError{
+			Err:  "status should be between 200 and 599",
+			Path: path + ".status",
+		})
+	}
+
+	if len(response.Body) > MaxDirectResponseBodySize {
+		rst = append(rst, KalmValidateError{
+			Err:  fmt.Sprintf("body can't be larger than %d bytes", MaxDirectResponseBodySize),
+			Path: path + ".body",
+		})
+	}
+
+	bodyFile := response.BodyFile
+
+	if bodyFile == nil {
+		return rst
+	}
+
+	if response.Body != "" {
+		rst = append(rst, KalmValidateError{
+			Err:  "only one of body and bodyFile can be set",
+			Path: path + ".bodyFile",
+		})
+	}
+
+	if requireFileNamespace && bodyFile.Namespace == "" {
+		rst = append(rst, KalmValidateError{
+			Err:  "namespace of the file is required",
+			Path: path + ".bodyFile.namespace",
+		})
+	}
+
+	if err := files.ValidatePath(bodyFile.Path); err != nil {
+		rst = append(rst, KalmValidateError{
+			Err:  err.Error(),
+			Path: path + ".bodyFile.path",
+		})
+	}
+
+	return rst
+}
+
+// headers used by kalm sso, routes and the activator
+const (
+	KalmSSOUserinfoHeader                 = "kalm-sso-userinfo"
+	KalmSSOSetCookiePayloadHeader         = "kalm-set-cookie"
+	KalmRouteHeader                       = "kalm-route"
+	KalmAllowToPassIfHasBearerTokenHeader = "allow-to-pass-if-has-bearer-token"
+	KalmAuthEmailHeader                   = "kalm-auth-email"
+	KalmActivatorTargetHeader             = "kalm-activator-target"
+)
+
+// DangerousHeaders are removed from requests of clients by routes, and can't be changed by routes
+var DangerousHeaders = []string{
+	KalmSSOUserinfoHeader,
+	KalmAllowToPassIfHasBearerTokenHeader,
+	KalmRouteHeader,
+	KalmSSOSetCookiePayloadHeader,
+	KalmAuthEmailHeader,
+	KalmActivatorTargetHeader,
+}
+
+const reservedHeaderPrefix = "kalm-sso-"
+
+func isReservedHeader(header string) bool {
+	header = strings.ToLower(header)
+
+	if strings.HasPrefix(header, reservedHeaderPrefix) {
+		return true
+	}
+
+	for _, reserved := range DangerousHeaders {
+		if header == reserved {
+			return true
+		}
+	}
+
+	return false
+}
+
+func validateHeaderOperations(operations *HttpRouteHeaderOperations, path string) (rst KalmValidateErrorList) {
+	if operations == nil {
+		return nil
+	}
+
+	validateHeader := func(header, headerPath string) {
+		if header == "" {
+			rst = append(rst, KalmValidateError{
+				Err:  "header can't be blank",
+				Path: headerPath,
+			})
+		} else if isReservedHeader(header) {
+			rst = append(rst, KalmValidateError{
+				Err:  "header is reserved by kalm: " + header,
+				Path: headerPath,
+			})
+		}
+	}
+
+	for _, header := range sortedKeys(operations.Set) {
+		validateHeader(header, fmt.Sprintf("%s.set.%s", path, header))
+	}
+
+	for _, header := range sortedKeys(operations.Add) {
+		validateHeader(header, fmt.Sprintf("%s.add.%s", path, header))
+	}
+
+	for i, header := range operations.Remove {
+		validateHeader(header, fmt.Sprintf("%s.remove[%d]", path, i))
+	}
+
+	return rst
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+
+	for key := range m {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
+
 func isValidDestinationHost(host string) bool {
 	host = stripIfHasPort(host)
 	return validation.ValidateFQDN(host) == nil
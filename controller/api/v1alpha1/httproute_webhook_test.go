@@ -34,6 +34,42 @@ func TestHttpRoute_Validate(t *testing.T) {
 
 	route.Default()
 	assert.Nil(t, route.validate())
+
+	route.Spec.RateLimit = &HttpRouteRateLimit{Requests: 10, IntervalSeconds: 1}
+	assert.Nil(t, route.validate())
+}
+
+func TestHttpRoute_validateHeaders(t *testing.T) {
+	route := HttpRoute{
+		Spec: HttpRouteSpec{
+			Hosts: []string{"xip.io"},
+			Paths: []string{"/"},
+			Destinations: []HttpRouteDestination{
+				{Host: "server-v1", Weight: 1},
+			},
+			Headers: &HttpRouteHeaders{
+				Request: &HttpRouteHeaderOperations{
+					Set:    map[string]string{"X-Forwarded-Prefix": "/api"},
+					Remove: []string{"x-internal"},
+				},
+				Response: &HttpRouteHeaderOperations{
+					Add: map[string]string{"Strict-Transport-Security": "max-age=31536000"},
+				},
+			},
+		},
+	}
+
+	assert.Nil(t, route.validate())
+
+	route.Spec.Headers.Request.Remove = append(route.Spec.Headers.Request.Remove, "Kalm-Route")
+	route.Spec.Headers.Response.Set = map[string]string{"kalm-sso-userinfo": ""}
+
+	err := route.validate()
+	if assert.NotNil(t, err) {
+		assert.Len(t, err.(KalmValidateErrorList), 2)
+		assert.Equal(t, "spec.headers.request.remove[1]", err.(KalmValidateErrorList)[0].Path)
+		assert.Equal(t, "spec.headers.response.set.kalm-sso-userinfo", err.(KalmValidateErrorList)[1].Path)
+	}
 }
 
 func TestHttpRoute_isValidRouteHost(t *testing.T) {
@@ -62,3 +98,99 @@ func TestHttpRoute_isValidDestinationHost(t *testing.T) {
 		assert.True(t, isValidDestinationHost(h))
 	}
 }
+
+func TestHttpRoute_validateRedirect(t *testing.T) {
+	route := HttpRoute{
+		Spec: HttpRouteSpec{
+			Hosts: []string{"xip.io"},
+			Paths: []string{"/old"},
+			Redirect: &HttpRouteRedirect{
+				Host:          "example.com",
+				Path:          "/new",
+				StatusCode:    308,
+				PreservePath:  true,
+				PreserveQuery: true,
+			},
+		},
+	}
+
+	assert.Nil(t, route.validate())
+
+	route.Spec.Destinations = []HttpRouteDestination{{Host: "server-v1", Weight: 1}}
+	assert.NotNil(t, route.validate())
+
+	route.Spec.Redirect = nil
+	route.Spec.Rewrite = &HttpRouteRewrite{
+		UriRegex: &HttpRouteRegexRewrite{Pattern: "^/old/(.*)$", Substitution: "/new/\\1"},
+	}
+	assert.Nil(t, route.validate())
+
+	route.Spec.Rewrite.Uri = "/new"
+	assert.NotNil(t, route.validate())
+
+	route.Spec.Rewrite = &HttpRouteRewrite{UriRegex: &HttpRouteRegexRewrite{Pattern: "(", Substitution: "/"}}
+	assert.NotNil(t, route.validate())
+
+	route.Spec.Rewrite = nil
+	route.Spec.Destinations = nil
+	assert.NotNil(t, route.validate())
+}
+
+func TestHttpRoute_validateDirectResponse(t *testing.T) {
+	route := HttpRoute{
+		Spec: HttpRouteSpec{
+			Hosts: []string{"xip.io"},
+			Paths: []string{"/"},
+			DirectResponse: &HttpRouteDirectResponse{
+				Status:      503,
+				ContentType: "text/html",
+				BodyFile:    &HttpRouteBodyFile{Namespace: "shop", Path: "/maintenance.html"},
+			},
+		},
+	}
+
+	assert.Nil(t, route.validate())
+
+	route.Spec.DirectResponse.Body = "down"
+	assert.NotNil(t, route.validate())
+
+	route.Spec.DirectResponse.BodyFile = nil
+	route.Spec.Destinations = []HttpRouteDestination{{Host: "server-v1", Weight: 1}}
+	assert.NotNil(t, route.validate())
+
+	route.Spec.Destinations = nil
+	route.Spec.DirectResponse.Status = 100
+	assert.NotNil(t, route.validate())
+}
+
+func TestHttpRoute_validateAccessControl(t *testing.T) {
+	route := HttpRoute{
+		Spec: HttpRouteSpec{
+			Hosts: []string{"xip.io"},
+			Paths: []string{"/"},
+			Destinations: []HttpRouteDestination{
+				{Host: "server-v1", Weight: 1},
+			},
+			AccessControl: &HttpRouteAccessControl{
+				AllowedIPs: []string{"10.0.0.0/8", "192.168.1.1", "2001:db8::/32"},
+				DeniedIPs:  []string{"10.0.0.1"},
+				BasicAuth: &HttpRouteBasicAuth{
+					SecretNamespace: "staging",
+					SecretName:      "staging-users",
+					Realm:           "staging",
+				},
+			},
+		},
+	}
+
+	assert.Nil(t, route.validate())
+
+	route.Spec.AccessControl.AllowedIPs = []string{"10.0.0.0/33"}
+	route.Spec.AccessControl.BasicAuth.SecretName = "Invalid_Name"
+	route.Spec.AccessControl.BasicAuth.Realm = `"staging"`
+
+	err := route.validate()
+	if assert.NotNil(t, err) {
+		assert.Len(t, err.(KalmValidateErrorList), 3)
+	}
+}
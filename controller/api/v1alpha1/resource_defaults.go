@@ -0,0 +1,219 @@
+package v1alpha1
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// defaults of an application are stored as json in this annotation of its namespace
+	KalmAnnoResourceDefaults = "core.kalm.dev/resource-defaults"
+
+	// defaults of the cluster are stored as json in this config map in kalm-system
+	ClusterResourceDefaultsConfigMapName = "kalm-resource-defaults"
+	ClusterResourceDefaultsKey           = "defaults"
+)
+
+// ResourceDefaults are applied to components without resource requirements.
+// Defaults of an application take precedence over defaults of the cluster, resource by resource.
+type ResourceDefaults struct {
+	// default requests & limits of the main container
+	// +optional
+	Container *v1.ResourceRequirements `json:"container,omitempty"`
+
+	// default requests & limits of the istio sidecar
+	// +optional
+	Istio *v1.ResourceRequirements `json:"istio,omitempty"`
+
+	// requests & limits of the main container, init containers and sidecars can't be larger than these
+	// +optional
+	MaxContainerLimits v1.ResourceList `json:"maxContainerLimits,omitempty"`
+
+	// requests & limits of the istio sidecar can't be larger than these
+	// +optional
+	MaxIstioLimits v1.ResourceList `json:"maxIstioLimits,omitempty"`
+}
+
+func ParseResourceDefaults(value string) (*ResourceDefaults, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	var defaults ResourceDefaults
+	if err := json.Unmarshal([]byte(value), &defaults); err != nil {
+		return nil, fmt.Errorf("invalid resource defaults: %s", err)
+	}
+
+	return &defaults, nil
+}
+
+// GetApplicationResourceDefaults returns nil if the application has no defaults.
+func GetApplicationResourceDefaults(namespace *v1.Namespace) (*ResourceDefaults, error) {
+	return ParseResourceDefaults(namespace.Annotations[KalmAnnoResourceDefaults])
+}
+
+func GetClusterResourceDefaults(configMap *v1.ConfigMap) (*ResourceDefaults, error) {
+	return ParseResourceDefaults(configMap.Data[ClusterResourceDefaultsKey])
+}
+
+// SetApplicationResourceDefaults stores the defaults in the annotation of the namespace, nil defaults are removed.
+func SetApplicationResourceDefaults(namespace *v1.Namespace, defaults *ResourceDefaults) error {
+	if defaults == nil {
+		delete(namespace.Annotations, KalmAnnoResourceDefaults)
+		return nil
+	}
+
+	bts, err := json.Marshal(defaults)
+	if err != nil {
+		return err
+	}
+
+	if namespace.Annotations == nil {
+		namespace.Annotations = make(map[string]string)
+	}
+
+	namespace.Annotations[KalmAnnoResourceDefaults] = string(bts)
+
+	return nil
+}
+
+func (d *ResourceDefaults) Validate() (rst KalmValidateErrorList) {
+	rst = append(rst, validateResourceRequirements(d.Container, ".container")...)
+	rst = append(rst, validateResourceRequirements(d.Istio, ".istio")...)
+
+	for name, quantity := range d.MaxContainerLimits {
+		if quantity.Sign() < 0 {
+			rst = append(rst, KalmValidateError{Err: isNegativeErrorMsg, Path: ".maxContainerLimits." + string(name)})
+		}
+	}
+
+	for name, quantity := range d.MaxIstioLimits {
+		if quantity.Sign() < 0 {
+			rst = append(rst, KalmValidateError{Err: isNegativeErrorMsg, Path: ".maxIstioLimits." + string(name)})
+		}
+	}
+
+	rst = append(rst, validateMaxLimits(d.Container, d.MaxContainerLimits, ".container")...)
+	rst = append(rst, validateMaxLimits(d.Istio, d.MaxIstioLimits, ".istio")...)
+
+	return rst
+}
+
+func mergeResourceList(list, fallback v1.ResourceList) v1.ResourceList {
+	if len(list) == 0 && len(fallback) == 0 {
+		return nil
+	}
+
+	res := v1.ResourceList{}
+
+	for name, quantity := range fallback {
+		res[name] = quantity.DeepCopy()
+	}
+
+	for name, quantity := range list {
+		res[name] = quantity.DeepCopy()
+	}
+
+	return res
+}
+
+func mergeResourceRequirements(requirements, fallback *v1.ResourceRequirements) *v1.ResourceRequirements {
+	if requirements == nil && fallback == nil {
+		return nil
+	}
+
+	if requirements == nil {
+		return fallback.DeepCopy()
+	}
+
+	if fallback == nil {
+		return requirements.DeepCopy()
+	}
+
+	return &v1.ResourceRequirements{
+		Limits:   mergeResourceList(requirements.Limits, fallback.Limits),
+		Requests: mergeResourceList(requirements.Requests, fallback.Requests),
+	}
+}
+
+// MergeResourceDefaults merges defaults of an application with defaults of the cluster.
+func MergeResourceDefaults(application, cluster *ResourceDefaults) *ResourceDefaults {
+	if application == nil && cluster == nil {
+		return nil
+	}
+
+	if application == nil {
+		application = &ResourceDefaults{}
+	}
+
+	if cluster == nil {
+		cluster = &ResourceDefaults{}
+	}
+
+	return &ResourceDefaults{
+		Container:          mergeResourceRequirements(application.Container, cluster.Container),
+		Istio:              mergeResourceRequirements(application.Istio, cluster.Istio),
+		MaxContainerLimits: mergeResourceList(application.MaxContainerLimits, cluster.MaxContainerLimits),
+		MaxIstioLimits:     mergeResourceList(application.MaxIstioLimits, cluster.MaxIstioLimits),
+	}
+}
+
+// loadResourceDefaults returns the merged defaults of the application and the cluster, nil if there is none.
+func loadResourceDefaults(namespace string) (*ResourceDefaults, error) {
+	if webhookClient == nil {
+		return nil, nil
+	}
+
+	var ns v1.Namespace
+	if err := webhookClient.Get(context.Background(), client.ObjectKey{Name: namespace}, &ns); err != nil {
+		return nil, err
+	}
+
+	application, err := GetApplicationResourceDefaults(&ns)
+	if err != nil {
+		return nil, err
+	}
+
+	var cluster *ResourceDefaults
+	var configMap v1.ConfigMap
+
+	if err := webhookClient.Get(context.Background(), client.ObjectKey{Namespace: KalmSystemNamespace, Name: ClusterResourceDefaultsConfigMapName}, &configMap); err != nil {
+		if !errors.IsNotFound(err) {
+			return nil, err
+		}
+	} else if cluster, err = GetClusterResourceDefaults(&configMap); err != nil {
+		return nil, err
+	}
+
+	return MergeResourceDefaults(application, cluster), nil
+}
+
+// validateMaxLimits checks both requests and limits, as requests larger than limits are invalid anyway.
+func validateMaxLimits(requirements *v1.ResourceRequirements, maxLimits v1.ResourceList, path string) (rst KalmValidateErrorList) {
+	if requirements == nil {
+		return nil
+	}
+
+	for name, max := range maxLimits {
+		if limit, exist := requirements.Limits[name]; exist && limit.Cmp(max) > 0 {
+			rst = append(rst, KalmValidateError{
+				Err:  fmt.Sprintf("limit %s is larger than the max limit %s", limit.String(), max.String()),
+				Path: fmt.Sprintf("%s.limits.%s", path, name),
+			})
+		}
+
+		if request, exist := requirements.Requests[name]; exist && request.Cmp(max) > 0 {
+			rst = append(rst, KalmValidateError{
+				Err:  fmt.Sprintf("request %s is larger than the max limit %s", request.String(), max.String()),
+				Path: fmt.Sprintf("%s.requests.%s", path, name),
+			})
+		}
+	}
+
+	return rst
+}
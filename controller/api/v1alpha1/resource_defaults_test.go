@@ -0,0 +1,186 @@
+package v1alpha1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestResourceDefaultsAnnotation(t *testing.T) {
+	ns := &v1.Namespace{ObjectMeta: metaV1.ObjectMeta{Name: "app"}}
+
+	defaults, err := GetApplicationResourceDefaults(ns)
+	assert.Nil(t, err)
+	assert.Nil(t, defaults)
+
+	assert.Nil(t, SetApplicationResourceDefaults(ns, &ResourceDefaults{
+		MaxContainerLimits: v1.ResourceList{v1.ResourceCPU: resource.MustParse("1")},
+	}))
+
+	defaults, err = GetApplicationResourceDefaults(ns)
+	assert.Nil(t, err)
+	maxCPU := defaults.MaxContainerLimits[v1.ResourceCPU]
+	assert.Equal(t, "1", maxCPU.String())
+
+	assert.Nil(t, SetApplicationResourceDefaults(ns, nil))
+	defaults, err = GetApplicationResourceDefaults(ns)
+	assert.Nil(t, err)
+	assert.Nil(t, defaults)
+
+	ns.Annotations[KalmAnnoResourceDefaults] = "{"
+	_, err = GetApplicationResourceDefaults(ns)
+	assert.NotNil(t, err)
+}
+
+func TestMergeResourceDefaults(t *testing.T) {
+	assert.Nil(t, MergeResourceDefaults(nil, nil))
+
+	application := &ResourceDefaults{
+		Container: &v1.ResourceRequirements{
+			Limits: v1.ResourceList{v1.ResourceCPU: resource.MustParse("100m")},
+		},
+	}
+
+	cluster := &ResourceDefaults{
+		Container: &v1.ResourceRequirements{
+			Limits: v1.ResourceList{
+				v1.ResourceCPU:    resource.MustParse("1"),
+				v1.ResourceMemory: resource.MustParse("1Gi"),
+			},
+		},
+		MaxContainerLimits: v1.ResourceList{v1.ResourceCPU: resource.MustParse("2")},
+	}
+
+	merged := MergeResourceDefaults(application, cluster)
+
+	cpu := merged.Container.Limits[v1.ResourceCPU]
+	memory := merged.Container.Limits[v1.ResourceMemory]
+	maxCPU := merged.MaxContainerLimits[v1.ResourceCPU]
+	assert.Equal(t, "100m", cpu.String())
+	assert.Equal(t, "1Gi", memory.String())
+	assert.Equal(t, "2", maxCPU.String())
+	assert.Nil(t, merged.Istio)
+
+	// inputs are not changed
+	assert.Len(t, application.Container.Limits, 1)
+}
+
+func TestResourceDefaultsValidate(t *testing.T) {
+	valid := ResourceDefaults{
+		Container: &v1.ResourceRequirements{
+			Limits:   v1.ResourceList{v1.ResourceCPU: resource.MustParse("500m")},
+			Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse("100m")},
+		},
+		MaxContainerLimits: v1.ResourceList{v1.ResourceCPU: resource.MustParse("1")},
+	}
+	assert.Len(t, valid.Validate(), 0)
+
+	largerThanMax := ResourceDefaults{
+		Istio: &v1.ResourceRequirements{
+			Limits: v1.ResourceList{v1.ResourceMemory: resource.MustParse("1Gi")},
+		},
+		MaxIstioLimits: v1.ResourceList{v1.ResourceMemory: resource.MustParse("512Mi")},
+	}
+	errs := largerThanMax.Validate()
+	assert.Len(t, errs, 1)
+	assert.Equal(t, ".istio.limits.memory", errs[0].Path)
+
+	negative := ResourceDefaults{
+		MaxContainerLimits: v1.ResourceList{v1.ResourceCPU: resource.MustParse("-1")},
+	}
+	assert.Len(t, negative.Validate(), 1)
+}
+
+func TestFillResourceRequirementIfAbsent(t *testing.T) {
+	assert.Nil(t, fillResourceRequirementIfAbsent(nil, nil, nil))
+
+	defaults := &v1.ResourceRequirements{
+		Limits: v1.ResourceList{
+			v1.ResourceCPU:    resource.MustParse("200m"),
+			v1.ResourceMemory: resource.MustParse("128Mi"),
+		},
+		Requests: v1.ResourceList{
+			v1.ResourceCPU:    resource.MustParse("100m"),
+			v1.ResourceMemory: resource.MustParse("64Mi"),
+		},
+	}
+
+	// absent resources are filled
+	rst := fillResourceRequirementIfAbsent(nil, defaults, nil)
+	assert.True(t, rst.Limits[v1.ResourceCPU].Equal(resource.MustParse("200m")))
+	assert.True(t, rst.Requests[v1.ResourceMemory].Equal(resource.MustParse("64Mi")))
+
+	// existing resources are kept, the limit is never smaller than the request
+	rst = fillResourceRequirementIfAbsent(&v1.ResourceRequirements{
+		Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse("1")},
+		Limits:   v1.ResourceList{v1.ResourceMemory: resource.MustParse("32Mi")},
+	}, defaults, nil)
+	assert.True(t, rst.Limits[v1.ResourceCPU].Equal(resource.MustParse("1")))
+	assert.True(t, rst.Requests[v1.ResourceCPU].Equal(resource.MustParse("1")))
+	assert.True(t, rst.Limits[v1.ResourceMemory].Equal(resource.MustParse("32Mi")))
+	assert.True(t, rst.Requests[v1.ResourceMemory].Equal(resource.MustParse("32Mi")))
+
+	// resources with a max limit are always limited
+	rst = fillResourceRequirementIfAbsent(nil, nil, v1.ResourceList{v1.ResourceMemory: resource.MustParse("1Gi")})
+	assert.True(t, rst.Limits[v1.ResourceMemory].Equal(resource.MustParse("1Gi")))
+	assert.Nil(t, rst.Requests)
+}
+
+func TestValidateMaxLimits(t *testing.T) {
+	maxLimits := v1.ResourceList{v1.ResourceCPU: resource.MustParse("1")}
+
+	assert.Len(t, validateMaxLimits(nil, maxLimits, "spec.resourceRequirements"), 0)
+
+	errs := validateMaxLimits(&v1.ResourceRequirements{
+		Limits:   v1.ResourceList{v1.ResourceCPU: resource.MustParse("2")},
+		Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse("1500m")},
+	}, maxLimits, "spec.resourceRequirements")
+
+	assert.Len(t, errs, 2)
+
+	errs = validateMaxLimits(&v1.ResourceRequirements{
+		Limits: v1.ResourceList{
+			v1.ResourceCPU:    resource.MustParse("1"),
+			v1.ResourceMemory: resource.MustParse("8Gi"),
+		},
+	}, maxLimits, "spec.resourceRequirements")
+
+	assert.Len(t, errs, 0)
+}
+
+func TestValidateMaxLimitsOfExtraContainers(t *testing.T) {
+	ns := &v1.Namespace{ObjectMeta: metaV1.ObjectMeta{Name: "app"}}
+	assert.Nil(t, SetApplicationResourceDefaults(ns, &ResourceDefaults{
+		MaxContainerLimits: v1.ResourceList{v1.ResourceCPU: resource.MustParse("1")},
+	}))
+
+	scheme := runtime.NewScheme()
+	assert.Nil(t, clientgoscheme.AddToScheme(scheme))
+
+	webhookClient = fake.NewFakeClientWithScheme(scheme, ns)
+	defer func() { webhookClient = nil }()
+
+	large := &v1.ResourceRequirements{Limits: v1.ResourceList{v1.ResourceCPU: resource.MustParse("2")}}
+
+	component := &Component{
+		ObjectMeta: metaV1.ObjectMeta{Name: "web", Namespace: "app"},
+		Spec: ComponentSpec{
+			InitContainers: []ComponentContainer{{Name: "migrate", Image: "migrate", ResourceRequirements: large}},
+			Sidecars:       []ComponentContainer{{Name: "proxy", Image: "proxy", ResourceRequirements: large}},
+		},
+	}
+
+	errs := component.validateMaxLimits(nil)
+	assert.Len(t, errs, 2)
+	assert.Equal(t, "spec.initContainers[0].resourceRequirements.limits.cpu", errs[0].Path)
+	assert.Equal(t, "spec.sidecars[0].resourceRequirements.limits.cpu", errs[1].Path)
+
+	// unchanged containers exceeding the limits are still allowed
+	assert.Len(t, component.validateMaxLimits(component.DeepCopy()), 0)
+}
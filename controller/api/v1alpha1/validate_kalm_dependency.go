@@ -1,154 +1,122 @@
 package v1alpha1
 
 import (
-	"container/list"
+	"context"
 	"fmt"
+	"sort"
+	"strings"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
-// 1. check if there is any loop in dependency graph
-//func isValidateDependency(spec ComponentSpec) KalmValidateErrorList {
-//	//spec := app.Spec
-//
-//	// build graph
-//	nodeMap := buildDependencyGraph(spec)
-//	loopExist, nodesInLoop := bfsCheckIfLoopExist(nodeMap)
-//
-//	if !loopExist {
-//		return nil
-//	}
-//
-//	var errs []KalmValidateError
-//	for _, node := range nodesInLoop {
-//		errs = append(errs, KalmValidateError{
-//			Err:  "dependency loop exist",
-//			Path: getJsonPathOfWrongField(node.Name, spec),
-//		})
-//	}
-//
-//	return errs
-//}
-
-//func getJsonPathOfWrongField(name string, spec ApplicationSpec) string {
-//	idx := idxOfComponent(name, spec)
-//
-//	return fmt.Sprintf(".components[%d].dependencies", idx)
-//}
-//
-//func idxOfComponent(componentName string, spec ApplicationSpec) int {
-//	for i := range spec.Components {
-//		if componentName == spec.Components[i].Name {
-//			return i
-//		}
-//	}
-//
-//	return -1
-//}
-
-// componentName -> node
-func buildDependencyGraph(component Component) map[string]*node {
-	var nodeMap = make(map[string]*node)
-	//for _, component := range spec.Components {
-
-	if _, exist := nodeMap[component.Name]; !exist {
-		nodeMap[component.Name] = &node{
-			Name: component.Name,
-		}
+// check if there is any loop in the dependency graph of the application after the component is saved
+func (r *Component) validateDependencies() KalmValidateErrorList {
+	if len(r.Spec.StartAfterComponents) == 0 {
+		return nil
 	}
-	curNode := nodeMap[component.Name]
 
-	for _, dep := range component.Spec.StartAfterComponents {
-		if _, exist := nodeMap[dep]; !exist {
-			nodeMap[dep] = &node{
-				Name: dep,
+	components := []Component{*r}
+
+	if webhookClient != nil {
+		var componentList ComponentList
+
+		if err := webhookClient.List(context.Background(), &componentList, client.InNamespace(r.Namespace)); err != nil {
+			componentlog.Error(err, "fail to list components to check dependency loop", "ns", r.Namespace)
+			return KalmValidateErrorList{
+				{
+					Err:  "fail to list components of the application: " + err.Error(),
+					Path: ".spec.startAfterComponents",
+				},
 			}
 		}
 
-		nodeMap[dep].Refed = append(nodeMap[dep].Refed, curNode)
-		//fmt.Println("> node:", dep, "refedBy:", nodeMap[dep].Refed[0])
-	}
+		for _, component := range componentList.Items {
+			if component.Name == r.Name {
+				continue
+			}
 
-	//fmt.Println("node in depGraph:", component.Name, "dependOn:", component.StartAfterComponents)
-	//}
+			components = append(components, component)
+		}
+	}
 
-	//fmt.Println("graph size:", len(nodeMap))
-	return nodeMap
+	return isValidateDependency(r.Name, components)
 }
 
-func bfsCheckIfLoopExist(nodeMap map[string]*node) (bool, []*node) {
-	// bfs
-	var queue = list.New()
-	for _, v := range nodeMap {
-		if len(v.Refed) <= 0 {
-			queue.PushBack(v)
+// 1. check if the component is in a loop of the dependency graph
+// Components which depend on a loop, or are depended by a loop, are not members of it.
+func isValidateDependency(componentName string, components []Component) KalmValidateErrorList {
+	dependencies := buildDependencyGraph(components)
+	dependents := make(map[string][]string)
+
+	for name, deps := range dependencies {
+		for _, dep := range deps {
+			dependents[dep] = append(dependents[dep], name)
 		}
 	}
 
-	for queue.Len() > 0 {
-		size := queue.Len()
-		fmt.Println("queue size of 0-degree nodes:", size)
+	// the loop is the strongly connected component of the component,
+	// which are components reachable from the component and reaching it as well
+	reachable := reachableComponents(componentName, dependencies)
 
-		for i := 0; i < size; i++ {
-			curEle := queue.Front()
-			queue.Remove(curEle)
+	var names []string
 
-			curNode := curEle.Value.(*node)
-			for _, n := range nodeMap {
-				if existNode(n.Refed, curNode) {
-					n.Refed = removeFromSlice(n.Refed, curNode)
-				}
-			}
-
-			//delete this node from map
-			delete(nodeMap, curNode.Name)
+	for name := range reachableComponents(componentName, dependents) {
+		if reachable[name] {
+			names = append(names, name)
 		}
+	}
 
-		fmt.Println("map size after rm 0-inDegree nodes:", len(nodeMap))
+	if len(names) == 0 {
+		return nil
+	}
 
-		// recheck in-degree zero nodes
-		for _, v := range nodeMap {
-			fmt.Println("leftNode:", v)
+	sort.Strings(names)
 
-			if len(v.Refed) <= 0 {
-				queue.PushBack(v)
-			}
-		}
+	return KalmValidateErrorList{
+		{
+			Err:  fmt.Sprintf("dependency loop exists among components: %s", strings.Join(names, ", ")),
+			Path: ".spec.startAfterComponents",
+		},
 	}
+}
 
-	loopExist := len(nodeMap) > 0
+// componentName -> names of components it depends on
+func buildDependencyGraph(components []Component) map[string][]string {
+	graph := make(map[string][]string)
 
-	var nodesInLoop []*node
-	for _, node := range nodeMap {
-		nodesInLoop = append(nodesInLoop, node)
-	}
+	for _, component := range components {
+		seen := make(map[string]bool)
 
-	return loopExist, nodesInLoop
-}
+		for _, dep := range component.Spec.StartAfterComponents {
+			if seen[dep] {
+				continue
+			}
 
-func existNode(slice []*node, target *node) bool {
-	for _, node := range slice {
-		if node == target {
-			return true
+			seen[dep] = true
+			graph[component.Name] = append(graph[component.Name], dep)
 		}
 	}
 
-	return false
+	return graph
 }
 
-func removeFromSlice(slice []*node, target *node) []*node {
-	for i := 0; i < len(slice); i++ {
-		if slice[i] == target {
-			// overwrite by head, and remove without head
-			slice[i] = slice[0]
-			slice = slice[1:]
+// reachableComponents returns components reachable from the start by at least one edge,
+// the start itself is included only if it's in a loop.
+func reachableComponents(start string, graph map[string][]string) map[string]bool {
+	visited := make(map[string]bool)
+	stack := append([]string{}, graph[start]...)
+
+	for len(stack) > 0 {
+		name := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
 
-			return slice
+		if visited[name] {
+			continue
 		}
-	}
 
-	return slice
-}
+		visited[name] = true
+		stack = append(stack, graph[name]...)
+	}
 
-type node struct {
-	Name  string
-	Refed []*node
+	return visited
 }
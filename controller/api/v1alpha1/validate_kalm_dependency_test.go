@@ -1,23 +1,64 @@
 package v1alpha1
 
-//
-//func TestIsValidateDependency(t *testing.T) {
-//	appSpec := ApplicationSpec{
-//		Components: []ComponentSpec{
-//			{
-//				Name:         "a",
-//				StartAfterComponents: []string{"b"},
-//			},
-//			{
-//				Name:         "b",
-//				StartAfterComponents: []string{"a"},
-//			},
-//		},
-//	}
-//
-//	errs := isValidateDependency(appSpec)
-//	assert.Equal(t, 2, len(errs))
-//}
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+func componentWithDependencies(name string, deps ...string) Component {
+	return Component{
+		ObjectMeta: ctrl.ObjectMeta{
+			Namespace: "test",
+			Name:      name,
+		},
+		Spec: ComponentSpec{
+			StartAfterComponents: deps,
+		},
+	}
+}
+
+func TestIsValidateDependency(t *testing.T) {
+	components := []Component{
+		componentWithDependencies("a", "b"),
+		componentWithDependencies("b", "c", "c"),
+		componentWithDependencies("c"),
+	}
+
+	assert.Nil(t, isValidateDependency("a", components))
+
+	components[2] = componentWithDependencies("c", "a")
+	errs := isValidateDependency("c", components)
+	assert.Equal(t, 1, len(errs))
+	assert.Equal(t, ".spec.startAfterComponents", errs[0].Path)
+	assert.Contains(t, errs[0].Err, "a, b, c")
+
+	// depends on itself
+	errs = isValidateDependency("d", []Component{componentWithDependencies("d", "d")})
+	assert.Equal(t, 1, len(errs))
+
+	// the loop is not caused by e
+	components = append(components, componentWithDependencies("e", "f"))
+	assert.Nil(t, isValidateDependency("e", components))
+
+	// components depending on the loop, or depended by it, are not members of it
+	components = []Component{
+		componentWithDependencies("a", "b"),
+		componentWithDependencies("b", "c"),
+		componentWithDependencies("c", "a", "x"),
+		componentWithDependencies("x"),
+		componentWithDependencies("y", "a"),
+	}
+
+	assert.Nil(t, isValidateDependency("x", components))
+	assert.Nil(t, isValidateDependency("y", components))
+
+	errs = isValidateDependency("c", components)
+	assert.Equal(t, 1, len(errs))
+	assert.Equal(t, "dependency loop exists among components: a, b, c", errs[0].Err)
+}
+
 //
 //func TestKalmProbeValidator(t *testing.T) {
 //	appSpec := ApplicationSpec{
@@ -23,6 +23,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/api/rbac/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
@@ -242,6 +243,127 @@ func (in *AccessTokenStatus) DeepCopy() *AccessTokenStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ApplicationMaintenance) DeepCopyInto(out *ApplicationMaintenance) {
+	*out = *in
+	in.Response.DeepCopyInto(&out.Response)
+	if in.BypassIPs != nil {
+		in, out := &in.BypassIPs, &out.BypassIPs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.BypassHeaders != nil {
+		in, out := &in.BypassHeaders, &out.BypassHeaders
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ApplicationMaintenance.
+func (in *ApplicationMaintenance) DeepCopy() *ApplicationMaintenance {
+	if in == nil {
+		return nil
+	}
+	out := new(ApplicationMaintenance)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ApplicationQuota) DeepCopyInto(out *ApplicationQuota) {
+	*out = *in
+	if in.CPU != nil {
+		in, out := &in.CPU, &out.CPU
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.Memory != nil {
+		in, out := &in.Memory, &out.Memory
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.Storage != nil {
+		in, out := &in.Storage, &out.Storage
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.Pods != nil {
+		in, out := &in.Pods, &out.Pods
+		*out = new(int64)
+		**out = **in
+	}
+	if in.Routes != nil {
+		in, out := &in.Routes, &out.Routes
+		*out = new(int64)
+		**out = **in
+	}
+	if in.Volumes != nil {
+		in, out := &in.Volumes, &out.Volumes
+		*out = new(int64)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ApplicationQuota.
+func (in *ApplicationQuota) DeepCopy() *ApplicationQuota {
+	if in == nil {
+		return nil
+	}
+	out := new(ApplicationQuota)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ApplicationResourceUsage) DeepCopyInto(out *ApplicationResourceUsage) {
+	*out = *in
+	out.CPU = in.CPU.DeepCopy()
+	out.Memory = in.Memory.DeepCopy()
+	out.Storage = in.Storage.DeepCopy()
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ApplicationResourceUsage.
+func (in *ApplicationResourceUsage) DeepCopy() *ApplicationResourceUsage {
+	if in == nil {
+		return nil
+	}
+	out := new(ApplicationResourceUsage)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AutoScalingConfig) DeepCopyInto(out *AutoScalingConfig) {
+	*out = *in
+	if in.TargetCPUUtilizationPercentage != nil {
+		in, out := &in.TargetCPUUtilizationPercentage, &out.TargetCPUUtilizationPercentage
+		*out = new(int32)
+		**out = **in
+	}
+	if in.TargetMemoryUtilizationPercentage != nil {
+		in, out := &in.TargetMemoryUtilizationPercentage, &out.TargetMemoryUtilizationPercentage
+		*out = new(int32)
+		**out = **in
+	}
+	if in.TargetIstioRequestsPerSecond != nil {
+		in, out := &in.TargetIstioRequestsPerSecond, &out.TargetIstioRequestsPerSecond
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AutoScalingConfig.
+func (in *AutoScalingConfig) DeepCopy() *AutoScalingConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(AutoScalingConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CAForTestIssuer) DeepCopyInto(out *CAForTestIssuer) {
 	*out = *in
@@ -263,7 +385,7 @@ func (in *Component) DeepCopyInto(out *Component) {
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Component.
@@ -284,6 +406,62 @@ func (in *Component) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ComponentCondition) DeepCopyInto(out *ComponentCondition) {
+	*out = *in
+	in.LastTransitionTime.DeepCopyInto(&out.LastTransitionTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ComponentCondition.
+func (in *ComponentCondition) DeepCopy() *ComponentCondition {
+	if in == nil {
+		return nil
+	}
+	out := new(ComponentCondition)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ComponentContainer) DeepCopyInto(out *ComponentContainer) {
+	*out = *in
+	if in.Command != nil {
+		in, out := &in.Command, &out.Command
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Args != nil {
+		in, out := &in.Args, &out.Args
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Env != nil {
+		in, out := &in.Env, &out.Env
+		*out = make([]EnvVar, len(*in))
+		copy(*out, *in)
+	}
+	if in.VolumeMounts != nil {
+		in, out := &in.VolumeMounts, &out.VolumeMounts
+		*out = make([]ContainerVolumeMount, len(*in))
+		copy(*out, *in)
+	}
+	if in.ResourceRequirements != nil {
+		in, out := &in.ResourceRequirements, &out.ResourceRequirements
+		*out = new(corev1.ResourceRequirements)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ComponentContainer.
+func (in *ComponentContainer) DeepCopy() *ComponentContainer {
+	if in == nil {
+		return nil
+	}
+	out := new(ComponentContainer)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ComponentList) DeepCopyInto(out *ComponentList) {
 	*out = *in
@@ -509,6 +687,55 @@ func (in *ComponentPluginStatus) DeepCopy() *ComponentPluginStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ComponentRolloutStatus) DeepCopyInto(out *ComponentRolloutStatus) {
+	*out = *in
+	if in.Steps != nil {
+		in, out := &in.Steps, &out.Steps
+		*out = make([]RolloutStepStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.StartedAt != nil {
+		in, out := &in.StartedAt, &out.StartedAt
+		*out = (*in).DeepCopy()
+	}
+	if in.FinishedAt != nil {
+		in, out := &in.FinishedAt, &out.FinishedAt
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ComponentRolloutStatus.
+func (in *ComponentRolloutStatus) DeepCopy() *ComponentRolloutStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ComponentRolloutStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ComponentScalingScheduleStatus) DeepCopyInto(out *ComponentScalingScheduleStatus) {
+	*out = *in
+	if in.Until != nil {
+		in, out := &in.Until, &out.Until
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ComponentScalingScheduleStatus.
+func (in *ComponentScalingScheduleStatus) DeepCopy() *ComponentScalingScheduleStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ComponentScalingScheduleStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ComponentSpec) DeepCopyInto(out *ComponentSpec) {
 	*out = *in
@@ -536,6 +763,21 @@ func (in *ComponentSpec) DeepCopyInto(out *ComponentSpec) {
 		*out = new(int32)
 		**out = **in
 	}
+	if in.AutoScaling != nil {
+		in, out := &in.AutoScaling, &out.AutoScaling
+		*out = new(AutoScalingConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ScaleToZero != nil {
+		in, out := &in.ScaleToZero, &out.ScaleToZero
+		*out = new(ScaleToZeroConfig)
+		**out = **in
+	}
+	if in.ScalingSchedules != nil {
+		in, out := &in.ScalingSchedules, &out.ScalingSchedules
+		*out = make([]ScalingSchedule, len(*in))
+		copy(*out, *in)
+	}
 	if in.NodeSelectorLabels != nil {
 		in, out := &in.NodeSelectorLabels, &out.NodeSelectorLabels
 		*out = make(map[string]string, len(*in))
@@ -543,16 +785,48 @@ func (in *ComponentSpec) DeepCopyInto(out *ComponentSpec) {
 			(*out)[key] = val
 		}
 	}
+	if in.NodeAffinity != nil {
+		in, out := &in.NodeAffinity, &out.NodeAffinity
+		*out = new(NodeAffinity)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Tolerations != nil {
+		in, out := &in.Tolerations, &out.Tolerations
+		*out = make([]corev1.Toleration, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.TopologySpread != nil {
+		in, out := &in.TopologySpread, &out.TopologySpread
+		*out = make([]TopologySpread, len(*in))
+		copy(*out, *in)
+	}
+	if in.MinAvailable != nil {
+		in, out := &in.MinAvailable, &out.MinAvailable
+		*out = new(intstr.IntOrString)
+		**out = **in
+	}
 	if in.StartAfterComponents != nil {
 		in, out := &in.StartAfterComponents, &out.StartAfterComponents
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.Args != nil {
+		in, out := &in.Args, &out.Args
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	if in.Ports != nil {
 		in, out := &in.Ports, &out.Ports
 		*out = make([]Port, len(*in))
 		copy(*out, *in)
 	}
+	if in.CronJob != nil {
+		in, out := &in.CronJob, &out.CronJob
+		*out = new(CronJobConfig)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.LivenessProbe != nil {
 		in, out := &in.LivenessProbe, &out.LivenessProbe
 		*out = new(corev1.Probe)
@@ -593,7 +867,45 @@ func (in *ComponentSpec) DeepCopyInto(out *ComponentSpec) {
 	if in.PreInjectedFiles != nil {
 		in, out := &in.PreInjectedFiles, &out.PreInjectedFiles
 		*out = make([]PreInjectFile, len(*in))
-		copy(*out, *in)
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.InitContainers != nil {
+		in, out := &in.InitContainers, &out.InitContainers
+		*out = make([]ComponentContainer, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Sidecars != nil {
+		in, out := &in.Sidecars, &out.Sidecars
+		*out = make([]ComponentContainer, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.RolloutStrategy != nil {
+		in, out := &in.RolloutStrategy, &out.RolloutStrategy
+		*out = new(RolloutStrategy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.RevisionHistoryLimit != nil {
+		in, out := &in.RevisionHistoryLimit, &out.RevisionHistoryLimit
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Job != nil {
+		in, out := &in.Job, &out.Job
+		*out = new(JobConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PendingJobRuns != nil {
+		in, out := &in.PendingJobRuns, &out.PendingJobRuns
+		*out = make([]JobRunRequest, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
 	}
 }
 
@@ -610,6 +922,27 @@ func (in *ComponentSpec) DeepCopy() *ComponentSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ComponentStatus) DeepCopyInto(out *ComponentStatus) {
 	*out = *in
+	if in.LastSuccessfulRolloutTime != nil {
+		in, out := &in.LastSuccessfulRolloutTime, &out.LastSuccessfulRolloutTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]ComponentCondition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Rollout != nil {
+		in, out := &in.Rollout, &out.Rollout
+		*out = new(ComponentRolloutStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ScalingSchedule != nil {
+		in, out := &in.ScalingSchedule, &out.ScalingSchedule
+		*out = new(ComponentScalingScheduleStatus)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ComponentStatus.
@@ -759,6 +1092,51 @@ func (in *Config) DeepCopy() *Config {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ContainerVolumeMount) DeepCopyInto(out *ContainerVolumeMount) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ContainerVolumeMount.
+func (in *ContainerVolumeMount) DeepCopy() *ContainerVolumeMount {
+	if in == nil {
+		return nil
+	}
+	out := new(ContainerVolumeMount)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CronJobConfig) DeepCopyInto(out *CronJobConfig) {
+	*out = *in
+	if in.StartingDeadlineSeconds != nil {
+		in, out := &in.StartingDeadlineSeconds, &out.StartingDeadlineSeconds
+		*out = new(int64)
+		**out = **in
+	}
+	if in.SuccessfulJobsHistoryLimit != nil {
+		in, out := &in.SuccessfulJobsHistoryLimit, &out.SuccessfulJobsHistoryLimit
+		*out = new(int32)
+		**out = **in
+	}
+	if in.FailedJobsHistoryLimit != nil {
+		in, out := &in.FailedJobsHistoryLimit, &out.FailedJobsHistoryLimit
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CronJobConfig.
+func (in *CronJobConfig) DeepCopy() *CronJobConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(CronJobConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *DNS01Issuer) DeepCopyInto(out *DNS01Issuer) {
 	*out = *in
@@ -1206,6 +1584,66 @@ func (in *HttpRoute) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HttpRouteAccessControl) DeepCopyInto(out *HttpRouteAccessControl) {
+	*out = *in
+	if in.AllowedIPs != nil {
+		in, out := &in.AllowedIPs, &out.AllowedIPs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.DeniedIPs != nil {
+		in, out := &in.DeniedIPs, &out.DeniedIPs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.BasicAuth != nil {
+		in, out := &in.BasicAuth, &out.BasicAuth
+		*out = new(HttpRouteBasicAuth)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HttpRouteAccessControl.
+func (in *HttpRouteAccessControl) DeepCopy() *HttpRouteAccessControl {
+	if in == nil {
+		return nil
+	}
+	out := new(HttpRouteAccessControl)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HttpRouteBasicAuth) DeepCopyInto(out *HttpRouteBasicAuth) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HttpRouteBasicAuth.
+func (in *HttpRouteBasicAuth) DeepCopy() *HttpRouteBasicAuth {
+	if in == nil {
+		return nil
+	}
+	out := new(HttpRouteBasicAuth)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HttpRouteBodyFile) DeepCopyInto(out *HttpRouteBodyFile) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HttpRouteBodyFile.
+func (in *HttpRouteBodyFile) DeepCopy() *HttpRouteBodyFile {
+	if in == nil {
+		return nil
+	}
+	out := new(HttpRouteBodyFile)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *HttpRouteCORS) DeepCopyInto(out *HttpRouteCORS) {
 	*out = *in
@@ -1291,27 +1729,106 @@ func (in *HttpRouteDestinationStatus) DeepCopyInto(out *HttpRouteDestinationStat
 	*out = *in
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HttpRouteDestinationStatus.
-func (in *HttpRouteDestinationStatus) DeepCopy() *HttpRouteDestinationStatus {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HttpRouteDestinationStatus.
+func (in *HttpRouteDestinationStatus) DeepCopy() *HttpRouteDestinationStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(HttpRouteDestinationStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HttpRouteDirectResponse) DeepCopyInto(out *HttpRouteDirectResponse) {
+	*out = *in
+	if in.BodyFile != nil {
+		in, out := &in.BodyFile, &out.BodyFile
+		*out = new(HttpRouteBodyFile)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HttpRouteDirectResponse.
+func (in *HttpRouteDirectResponse) DeepCopy() *HttpRouteDirectResponse {
+	if in == nil {
+		return nil
+	}
+	out := new(HttpRouteDirectResponse)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HttpRouteFault) DeepCopyInto(out *HttpRouteFault) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HttpRouteFault.
+func (in *HttpRouteFault) DeepCopy() *HttpRouteFault {
+	if in == nil {
+		return nil
+	}
+	out := new(HttpRouteFault)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HttpRouteHeaderOperations) DeepCopyInto(out *HttpRouteHeaderOperations) {
+	*out = *in
+	if in.Set != nil {
+		in, out := &in.Set, &out.Set
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Add != nil {
+		in, out := &in.Add, &out.Add
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Remove != nil {
+		in, out := &in.Remove, &out.Remove
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HttpRouteHeaderOperations.
+func (in *HttpRouteHeaderOperations) DeepCopy() *HttpRouteHeaderOperations {
 	if in == nil {
 		return nil
 	}
-	out := new(HttpRouteDestinationStatus)
+	out := new(HttpRouteHeaderOperations)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *HttpRouteFault) DeepCopyInto(out *HttpRouteFault) {
+func (in *HttpRouteHeaders) DeepCopyInto(out *HttpRouteHeaders) {
 	*out = *in
+	if in.Request != nil {
+		in, out := &in.Request, &out.Request
+		*out = new(HttpRouteHeaderOperations)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Response != nil {
+		in, out := &in.Response, &out.Response
+		*out = new(HttpRouteHeaderOperations)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HttpRouteFault.
-func (in *HttpRouteFault) DeepCopy() *HttpRouteFault {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HttpRouteHeaders.
+func (in *HttpRouteHeaders) DeepCopy() *HttpRouteHeaders {
 	if in == nil {
 		return nil
 	}
-	out := new(HttpRouteFault)
+	out := new(HttpRouteHeaders)
 	in.DeepCopyInto(out)
 	return out
 }
@@ -1364,6 +1881,51 @@ func (in *HttpRouteMirror) DeepCopy() *HttpRouteMirror {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HttpRouteRateLimit) DeepCopyInto(out *HttpRouteRateLimit) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HttpRouteRateLimit.
+func (in *HttpRouteRateLimit) DeepCopy() *HttpRouteRateLimit {
+	if in == nil {
+		return nil
+	}
+	out := new(HttpRouteRateLimit)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HttpRouteRedirect) DeepCopyInto(out *HttpRouteRedirect) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HttpRouteRedirect.
+func (in *HttpRouteRedirect) DeepCopy() *HttpRouteRedirect {
+	if in == nil {
+		return nil
+	}
+	out := new(HttpRouteRedirect)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HttpRouteRegexRewrite) DeepCopyInto(out *HttpRouteRegexRewrite) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HttpRouteRegexRewrite.
+func (in *HttpRouteRegexRewrite) DeepCopy() *HttpRouteRegexRewrite {
+	if in == nil {
+		return nil
+	}
+	out := new(HttpRouteRegexRewrite)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *HttpRouteRetries) DeepCopyInto(out *HttpRouteRetries) {
 	*out = *in
@@ -1384,6 +1946,26 @@ func (in *HttpRouteRetries) DeepCopy() *HttpRouteRetries {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HttpRouteRewrite) DeepCopyInto(out *HttpRouteRewrite) {
+	*out = *in
+	if in.UriRegex != nil {
+		in, out := &in.UriRegex, &out.UriRegex
+		*out = new(HttpRouteRegexRewrite)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HttpRouteRewrite.
+func (in *HttpRouteRewrite) DeepCopy() *HttpRouteRewrite {
+	if in == nil {
+		return nil
+	}
+	out := new(HttpRouteRewrite)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *HttpRouteSpec) DeepCopyInto(out *HttpRouteSpec) {
 	*out = *in
@@ -1447,6 +2029,36 @@ func (in *HttpRouteSpec) DeepCopyInto(out *HttpRouteSpec) {
 		*out = new(HttpRouteCORS)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.RateLimit != nil {
+		in, out := &in.RateLimit, &out.RateLimit
+		*out = new(HttpRouteRateLimit)
+		**out = **in
+	}
+	if in.Headers != nil {
+		in, out := &in.Headers, &out.Headers
+		*out = new(HttpRouteHeaders)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Rewrite != nil {
+		in, out := &in.Rewrite, &out.Rewrite
+		*out = new(HttpRouteRewrite)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Redirect != nil {
+		in, out := &in.Redirect, &out.Redirect
+		*out = new(HttpRouteRedirect)
+		**out = **in
+	}
+	if in.DirectResponse != nil {
+		in, out := &in.DirectResponse, &out.DirectResponse
+		*out = new(HttpRouteDirectResponse)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.AccessControl != nil {
+		in, out := &in.AccessControl, &out.AccessControl
+		*out = new(HttpRouteAccessControl)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HttpRouteSpec.
@@ -1716,6 +2328,66 @@ func (in *HttpsCertStatus) DeepCopy() *HttpsCertStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *JobConfig) DeepCopyInto(out *JobConfig) {
+	*out = *in
+	if in.Completions != nil {
+		in, out := &in.Completions, &out.Completions
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Parallelism != nil {
+		in, out := &in.Parallelism, &out.Parallelism
+		*out = new(int32)
+		**out = **in
+	}
+	if in.BackoffLimit != nil {
+		in, out := &in.BackoffLimit, &out.BackoffLimit
+		*out = new(int32)
+		**out = **in
+	}
+	if in.TTLSecondsAfterFinished != nil {
+		in, out := &in.TTLSecondsAfterFinished, &out.TTLSecondsAfterFinished
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new JobConfig.
+func (in *JobConfig) DeepCopy() *JobConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(JobConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *JobRunRequest) DeepCopyInto(out *JobRunRequest) {
+	*out = *in
+	if in.Env != nil {
+		in, out := &in.Env, &out.Env
+		*out = make([]EnvVar, len(*in))
+		copy(*out, *in)
+	}
+	if in.Args != nil {
+		in, out := &in.Args, &out.Args
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new JobRunRequest.
+func (in *JobRunRequest) DeepCopy() *JobRunRequest {
+	if in == nil {
+		return nil
+	}
+	out := new(JobRunRequest)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *KalmValidateError) DeepCopyInto(out *KalmValidateError) {
 	*out = *in
@@ -1874,6 +2546,35 @@ func (in *LokiConfig) DeepCopy() *LokiConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeAffinity) DeepCopyInto(out *NodeAffinity) {
+	*out = *in
+	if in.Required != nil {
+		in, out := &in.Required, &out.Required
+		*out = make([]corev1.NodeSelectorRequirement, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Preferred != nil {
+		in, out := &in.Preferred, &out.Preferred
+		*out = make([]PreferredNodeAffinity, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeAffinity.
+func (in *NodeAffinity) DeepCopy() *NodeAffinity {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeAffinity)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PLGConfig) DeepCopyInto(out *PLGConfig) {
 	*out = *in
@@ -1957,6 +2658,11 @@ func (in *Port) DeepCopy() *Port {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PreInjectFile) DeepCopyInto(out *PreInjectFile) {
 	*out = *in
+	if in.SecretRef != nil {
+		in, out := &in.SecretRef, &out.SecretRef
+		*out = new(SecretKeyReference)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PreInjectFile.
@@ -1969,6 +2675,28 @@ func (in *PreInjectFile) DeepCopy() *PreInjectFile {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PreferredNodeAffinity) DeepCopyInto(out *PreferredNodeAffinity) {
+	*out = *in
+	if in.Expressions != nil {
+		in, out := &in.Expressions, &out.Expressions
+		*out = make([]corev1.NodeSelectorRequirement, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PreferredNodeAffinity.
+func (in *PreferredNodeAffinity) DeepCopy() *PreferredNodeAffinity {
+	if in == nil {
+		return nil
+	}
+	out := new(PreferredNodeAffinity)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PromtailConfig) DeepCopyInto(out *PromtailConfig) {
 	*out = *in
@@ -2118,6 +2846,45 @@ func (in *RepositoryTag) DeepCopy() *RepositoryTag {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ResourceDefaults) DeepCopyInto(out *ResourceDefaults) {
+	*out = *in
+	if in.Container != nil {
+		in, out := &in.Container, &out.Container
+		*out = new(corev1.ResourceRequirements)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Istio != nil {
+		in, out := &in.Istio, &out.Istio
+		*out = new(corev1.ResourceRequirements)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.MaxContainerLimits != nil {
+		in, out := &in.MaxContainerLimits, &out.MaxContainerLimits
+		*out = make(corev1.ResourceList, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val.DeepCopy()
+		}
+	}
+	if in.MaxIstioLimits != nil {
+		in, out := &in.MaxIstioLimits, &out.MaxIstioLimits
+		*out = make(corev1.ResourceList, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val.DeepCopy()
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResourceDefaults.
+func (in *ResourceDefaults) DeepCopy() *ResourceDefaults {
+	if in == nil {
+		return nil
+	}
+	out := new(ResourceDefaults)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RoleBinding) DeepCopyInto(out *RoleBinding) {
 	*out = *in
@@ -2211,6 +2978,59 @@ func (in *RoleBindingStatus) DeepCopy() *RoleBindingStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RolloutStepStatus) DeepCopyInto(out *RolloutStepStatus) {
+	*out = *in
+	if in.StartedAt != nil {
+		in, out := &in.StartedAt, &out.StartedAt
+		*out = (*in).DeepCopy()
+	}
+	if in.FinishedAt != nil {
+		in, out := &in.FinishedAt, &out.FinishedAt
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RolloutStepStatus.
+func (in *RolloutStepStatus) DeepCopy() *RolloutStepStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(RolloutStepStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RolloutStrategy) DeepCopyInto(out *RolloutStrategy) {
+	*out = *in
+	if in.Steps != nil {
+		in, out := &in.Steps, &out.Steps
+		*out = make([]int32, len(*in))
+		copy(*out, *in)
+	}
+	if in.MaxErrorRatePercentage != nil {
+		in, out := &in.MaxErrorRatePercentage, &out.MaxErrorRatePercentage
+		*out = new(int32)
+		**out = **in
+	}
+	if in.MaxLatencyMilliseconds != nil {
+		in, out := &in.MaxLatencyMilliseconds, &out.MaxLatencyMilliseconds
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RolloutStrategy.
+func (in *RolloutStrategy) DeepCopy() *RolloutStrategy {
+	if in == nil {
+		return nil
+	}
+	out := new(RolloutStrategy)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RunnerPermission) DeepCopyInto(out *RunnerPermission) {
 	*out = *in
@@ -2233,6 +3053,51 @@ func (in *RunnerPermission) DeepCopy() *RunnerPermission {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ScaleToZeroConfig) DeepCopyInto(out *ScaleToZeroConfig) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScaleToZeroConfig.
+func (in *ScaleToZeroConfig) DeepCopy() *ScaleToZeroConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(ScaleToZeroConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ScalingSchedule) DeepCopyInto(out *ScalingSchedule) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScalingSchedule.
+func (in *ScalingSchedule) DeepCopy() *ScalingSchedule {
+	if in == nil {
+		return nil
+	}
+	out := new(ScalingSchedule)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SecretKeyReference) DeepCopyInto(out *SecretKeyReference) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SecretKeyReference.
+func (in *SecretKeyReference) DeepCopy() *SecretKeyReference {
+	if in == nil {
+		return nil
+	}
+	out := new(SecretKeyReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SingleSignOnConfig) DeepCopyInto(out *SingleSignOnConfig) {
 	*out = *in
@@ -2364,6 +3229,21 @@ func (in *TemporaryDexUser) DeepCopy() *TemporaryDexUser {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TopologySpread) DeepCopyInto(out *TopologySpread) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TopologySpread.
+func (in *TopologySpread) DeepCopy() *TopologySpread {
+	if in == nil {
+		return nil
+	}
+	out := new(TopologySpread)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Volume) DeepCopyInto(out *Volume) {
 	*out = *in
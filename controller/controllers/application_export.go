@@ -0,0 +1,271 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	cmv1alpha2 "github.com/jetstack/cert-manager/pkg/apis/certmanager/v1alpha2"
+	"github.com/kalmhq/kalm/controller/api/v1alpha1"
+	"istio.io/client-go/pkg/apis/networking/v1alpha3"
+	"istio.io/client-go/pkg/apis/networking/v1beta1"
+	istioScheme "istio.io/client-go/pkg/clientset/versioned/scheme"
+	appsV1 "k8s.io/api/apps/v1"
+	autoscalingV2beta2 "k8s.io/api/autoscaling/v2beta2"
+	batchV1 "k8s.io/api/batch/v1"
+	batchV1Beta1 "k8s.io/api/batch/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	policyV1beta1 "k8s.io/api/policy/v1beta1"
+	rbacV1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+)
+
+// exportedTypes are kinds of objects in an export, in the order they should be applied
+var exportedTypes = []runtime.Object{
+	&corev1.Namespace{},
+	&corev1.ServiceAccount{},
+	&rbacV1.ClusterRole{},
+	&rbacV1.ClusterRoleBinding{},
+	&rbacV1.Role{},
+	&rbacV1.RoleBinding{},
+	&corev1.PersistentVolumeClaim{},
+	&corev1.Service{},
+	&appsV1.Deployment{},
+	&appsV1.StatefulSet{},
+	&appsV1.DaemonSet{},
+	&batchV1Beta1.CronJob{},
+	&batchV1.Job{},
+	&autoscalingV2beta2.HorizontalPodAutoscaler{},
+	&policyV1beta1.PodDisruptionBudget{},
+	&v1alpha3.DestinationRule{},
+	&v1beta1.VirtualService{},
+	&v1alpha3.EnvoyFilter{},
+	&cmv1alpha2.Certificate{},
+}
+
+// renderedOnlyTypes are rendered as well, but they only make sense in kalm
+var renderedOnlyTypes = []runtime.Object{
+	&v1alpha1.Component{},
+	&v1alpha1.ComponentPluginBinding{},
+	&v1alpha1.HttpRoute{},
+	&appsV1.ControllerRevision{},
+	&corev1.Pod{},
+}
+
+func newExportScheme() *runtime.Scheme {
+	scheme := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(scheme)
+	_ = v1alpha1.AddToScheme(scheme)
+	_ = cmv1alpha2.AddToScheme(scheme)
+	_ = istioScheme.AddToScheme(scheme)
+
+	return scheme
+}
+
+// ExportApplication returns objects kalm creates for components of the application and routes to them.
+// The component and route reconciler tasks run against a renderClient, so objects are built by the same code
+// as in the cluster, without changing anything. Only inputs of components, e.g. secrets, are read from the cluster.
+// Features which require kalm to run, e.g. scaling to zero, scaling schedules and dependencies, are not exported.
+func ExportApplication(ctx context.Context, reader client.Reader, namespace string) ([]runtime.Object, error) {
+	scheme := newExportScheme()
+
+	c, err := newRenderClient(reader, scheme, append(append([]runtime.Object{}, exportedTypes...), renderedOnlyTypes...)...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var ns corev1.Namespace
+	if err := reader.Get(ctx, types.NamespacedName{Name: namespace}, &ns); err != nil {
+		return nil, err
+	}
+
+	delete(ns.Annotations, v1alpha1.KalmAnnoApplicationSuspendedAt)
+	delete(ns.Annotations, v1alpha1.KalmAnnoApplicationMaintenance)
+
+	if err := c.save(&ns); err != nil {
+		return nil, err
+	}
+
+	var components v1alpha1.ComponentList
+	if err := reader.List(ctx, &components, client.InNamespace(namespace)); err != nil {
+		return nil, err
+	}
+
+	for i := range components.Items {
+		if err := c.save(getExportedComponent(&components.Items[i])); err != nil {
+			return nil, err
+		}
+	}
+
+	var routes v1alpha1.HttpRouteList
+	if err := reader.List(ctx, &routes); err != nil {
+		return nil, err
+	}
+
+	var hosts []string
+
+	for i := range routes.Items {
+		route := &routes.Items[i]
+
+		if !isRouteOfApplication(route, namespace) {
+			continue
+		}
+
+		hosts = append(hosts, route.Spec.Hosts...)
+
+		if err := c.save(route); err != nil {
+			return nil, err
+		}
+	}
+
+	base := &BaseReconciler{
+		Client:   c,
+		Reader:   c,
+		Log:      ctrl.Log.WithName("controllers").WithName("Export"),
+		Scheme:   scheme,
+		Recorder: &record.FakeRecorder{},
+	}
+
+	sort.Slice(components.Items, func(i, j int) bool {
+		return components.Items[i].Name < components.Items[j].Name
+	})
+
+	for _, component := range components.Items {
+		task := &ComponentReconcilerTask{
+			ComponentReconciler: &ComponentReconciler{base},
+			ctx:                 ctx,
+			sharedEnvSets:       make(map[string]*sharedEnvSet),
+		}
+
+		if err := task.Run(ctrl.Request{NamespacedName: types.NamespacedName{Namespace: namespace, Name: component.Name}}); err != nil {
+			return nil, fmt.Errorf("unable to export component %s: %s", component.Name, err)
+		}
+	}
+
+	routeTask := &HttpRouteReconcilerTask{
+		HttpRouteReconciler: &HttpRouteReconciler{base},
+		ctx:                 ctx,
+	}
+
+	if err := routeTask.Run(ctrl.Request{}); err != nil {
+		return nil, fmt.Errorf("unable to export routes: %s", err)
+	}
+
+	var httpsCerts v1alpha1.HttpsCertList
+	if err := reader.List(ctx, &httpsCerts); err != nil {
+		return nil, err
+	}
+
+	for _, httpsCert := range httpsCerts.Items {
+		if httpsCert.Spec.IsSelfManaged || !isCertUsedByHosts(httpsCert, hosts) {
+			continue
+		}
+
+		cert := buildCertificate(httpsCert)
+
+		if err := c.save(&cert); err != nil {
+			return nil, err
+		}
+	}
+
+	var res []runtime.Object
+
+	for _, obj := range exportedTypes {
+		gvk, err := apiutil.GVKForObject(obj, scheme)
+
+		if err != nil {
+			return nil, err
+		}
+
+		for _, key := range c.sortedKeys(gvk) {
+			exported := c.objects[gvk][key].DeepCopyObject()
+
+			if err := cleanExportedObject(exported); err != nil {
+				return nil, err
+			}
+
+			res = append(res, exported)
+		}
+	}
+
+	return res, nil
+}
+
+// getExportedComponent removes the state kept by kalm, so all objects are rendered as if the component is just created.
+func getExportedComponent(component *v1alpha1.Component) *v1alpha1.Component {
+	copied := component.DeepCopy()
+
+	delete(copied.Labels, v1alpha1.KalmLabelKeyExceedingQuota)
+	delete(copied.Labels, v1alpha1.KalmLabelKeySuspended)
+	delete(copied.Annotations, v1alpha1.KalmAnnoAdoptedGeneration)
+	delete(copied.Annotations, v1alpha1.KalmAnnoScaledToZeroAt)
+	delete(copied.Annotations, v1alpha1.KalmAnnoWakingAt)
+
+	copied.Spec.ScaleToZero = nil
+	copied.Spec.ScalingSchedules = nil
+	copied.Spec.StartAfterComponents = nil
+	copied.Status = v1alpha1.ComponentStatus{}
+
+	return copied
+}
+
+// isRouteOfApplication returns true if the route sends requests to services in the namespace
+func isRouteOfApplication(route *v1alpha1.HttpRoute, namespace string) bool {
+	for _, destination := range route.Spec.Destinations {
+		if getDestinationNamespace(destination.Host) == namespace {
+			return true
+		}
+	}
+
+	return false
+}
+
+// getDestinationNamespace returns the namespace of service hosts, e.g. web.shop.svc.cluster.local:80
+func getDestinationNamespace(host string) string {
+	parts := strings.Split(strings.Split(host, ":")[0], ".")
+
+	if len(parts) < 2 {
+		return ""
+	}
+
+	return parts[1]
+}
+
+func isCertUsedByHosts(httpsCert v1alpha1.HttpsCert, hosts []string) bool {
+	for _, host := range hosts {
+		if certCanBeUsedOnDomain(getDNSNames(httpsCert), host) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// cleanExportedObject removes fields set by the cluster and owner references to components
+func cleanExportedObject(obj runtime.Object) error {
+	objMeta, err := meta.Accessor(obj)
+
+	if err != nil {
+		return err
+	}
+
+	objMeta.SetOwnerReferences(nil)
+	objMeta.SetResourceVersion("")
+	objMeta.SetUID("")
+	objMeta.SetGeneration(0)
+	objMeta.SetSelfLink("")
+	objMeta.SetCreationTimestamp(metaV1.Time{})
+	objMeta.SetManagedFields(nil)
+
+	return nil
+}
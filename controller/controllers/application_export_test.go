@@ -0,0 +1,132 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kalmhq/kalm/controller/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"istio.io/client-go/pkg/apis/networking/v1beta1"
+	appsV1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestExportApplication(t *testing.T) {
+	replicas := int32(2)
+
+	objects := []runtime.Object{
+		&corev1.Namespace{
+			ObjectMeta: metaV1.ObjectMeta{
+				Name:        "shop",
+				Labels:      map[string]string{KalmEnableLabelName: KalmEnableLabelValue},
+				Annotations: map[string]string{v1alpha1.KalmAnnoApplicationSuspendedAt: "2020-10-01T00:00:00Z"},
+			},
+		},
+		&v1alpha1.Component{
+			ObjectMeta: metaV1.ObjectMeta{
+				Name:      "web",
+				Namespace: "shop",
+				UID:       "web-uid",
+				Labels:    map[string]string{v1alpha1.KalmLabelKeySuspended: "true"},
+			},
+			Spec: v1alpha1.ComponentSpec{
+				Image:    "nginx:1.19",
+				Replicas: &replicas,
+				Ports: []v1alpha1.Port{
+					{ContainerPort: 80, ServicePort: 80, Protocol: v1alpha1.PortProtocolHTTP},
+				},
+				Volumes: []v1alpha1.Volume{
+					{Path: "/data", Type: v1alpha1.VolumeTypePersistentVolumeClaim, PVC: "web-data", Size: resource.MustParse("1Gi")},
+				},
+				StartAfterComponents: []string{"db"},
+			},
+			Status: v1alpha1.ComponentStatus{
+				Conditions: []v1alpha1.ComponentCondition{
+					{Type: v1alpha1.ComponentConditionAvailable, Status: corev1.ConditionTrue},
+				},
+			},
+		},
+		&v1alpha1.HttpRoute{
+			ObjectMeta: metaV1.ObjectMeta{Name: "shop-web"},
+			Spec: v1alpha1.HttpRouteSpec{
+				Hosts:   []string{"shop.example.com"},
+				Paths:   []string{"/"},
+				Methods: []v1alpha1.HttpRouteMethod{"GET"},
+				Schemes: []v1alpha1.HttpRouteScheme{"http"},
+				Destinations: []v1alpha1.HttpRouteDestination{
+					{Host: "web.shop.svc.cluster.local:80", Weight: 1},
+				},
+			},
+		},
+		&v1alpha1.HttpRoute{
+			ObjectMeta: metaV1.ObjectMeta{Name: "other"},
+			Spec: v1alpha1.HttpRouteSpec{
+				Hosts:   []string{"other.example.com"},
+				Paths:   []string{"/"},
+				Methods: []v1alpha1.HttpRouteMethod{"GET"},
+				Schemes: []v1alpha1.HttpRouteScheme{"http"},
+				Destinations: []v1alpha1.HttpRouteDestination{
+					{Host: "other.other.svc.cluster.local:80", Weight: 1},
+				},
+			},
+		},
+		&v1alpha1.HttpsCert{
+			ObjectMeta: metaV1.ObjectMeta{Name: "wildcard"},
+			Spec: v1alpha1.HttpsCertSpec{
+				HttpsCertIssuer: v1alpha1.DefaultDNS01IssuerName,
+				Domains:         []string{"*.example.com"},
+			},
+		},
+	}
+
+	reader := fake.NewFakeClientWithScheme(newExportScheme(), objects...)
+	res, err := ExportApplication(context.Background(), reader, "shop")
+
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	var kinds []string
+	var deployment *appsV1.Deployment
+	var virtualService *v1beta1.VirtualService
+
+	for _, obj := range res {
+		kind := obj.GetObjectKind().GroupVersionKind().Kind
+		kinds = append(kinds, kind)
+
+		switch o := obj.(type) {
+		case *appsV1.Deployment:
+			deployment = o
+		case *v1beta1.VirtualService:
+			virtualService = o
+		}
+	}
+
+	assert.Equal(t, []string{
+		"Namespace",
+		"ServiceAccount",
+		"RoleBinding",
+		"PersistentVolumeClaim",
+		"Service",
+		"Deployment",
+		"DestinationRule",
+		"VirtualService",
+		"Certificate",
+	}, kinds)
+
+	// suspension and dependencies are not exported
+	if assert.NotNil(t, deployment) {
+		assert.Equal(t, int32(2), *deployment.Spec.Replicas)
+		assert.Equal(t, "nginx:1.19", getMainContainerImage(deployment.Spec.Template, "web"))
+		assert.Empty(t, deployment.OwnerReferences)
+	}
+
+	// only routes to the application are exported
+	if assert.NotNil(t, virtualService) {
+		assert.Equal(t, []string{"shop.example.com"}, virtualService.Spec.Hosts)
+	}
+}
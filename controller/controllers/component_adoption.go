@@ -0,0 +1,121 @@
+package controllers
+
+import (
+	"github.com/kalmhq/kalm/controller/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ComponentReasonAdopted is the reason of the event emitted when the component takes ownership of an existing resource
+const ComponentReasonAdopted = "Adopted"
+
+// adoptResources sets the component as the controller of the existing workload and service of the same name.
+// Nothing else is changed, resources controlled by others are left alone.
+func (r *ComponentReconcilerTask) adoptResources() error {
+	objects := []interface {
+		runtime.Object
+		metaV1.Object
+	}{}
+
+	if r.service != nil {
+		objects = append(objects, r.service)
+	}
+
+	if r.headlessService != nil {
+		objects = append(objects, r.headlessService)
+	}
+
+	switch r.component.Spec.WorkloadType {
+	case v1alpha1.WorkloadTypeServer, "":
+		if r.deployment != nil {
+			objects = append(objects, r.deployment)
+		}
+	case v1alpha1.WorkloadTypeStatefulSet:
+		if r.statefulSet != nil {
+			objects = append(objects, r.statefulSet)
+		}
+	case v1alpha1.WorkloadTypeDaemonSet:
+		if r.daemonSet != nil {
+			objects = append(objects, r.daemonSet)
+		}
+	case v1alpha1.WorkloadTypeCronjob:
+		if r.cronJob != nil {
+			objects = append(objects, r.cronJob)
+		}
+	}
+
+	for _, obj := range objects {
+		if owner := metaV1.GetControllerOf(obj); owner != nil {
+			if owner.UID != r.component.UID {
+				r.Log.Info("resource is controlled by others, not adopted", "name", obj.GetName(), "owner", owner.Name)
+			}
+
+			continue
+		}
+
+		copied := obj.DeepCopyObject()
+
+		if err := ctrl.SetControllerReference(r.component, copied.(metaV1.Object), r.Scheme); err != nil {
+			r.WarningEvent(err, "unable to set owner for %s", obj.GetName())
+			return err
+		}
+
+		if err := r.Patch(r.ctx, copied, client.MergeFrom(obj)); err != nil {
+			r.WarningEvent(err, "unable to adopt %s", obj.GetName())
+			return err
+		}
+
+		r.NormalEvent(ComponentReasonAdopted, "%s is adopted.", obj.GetName())
+	}
+
+	return nil
+}
+
+// getAdoptedSelector returns the selector of the adopted workload, nil if the workload is created by kalm.
+// Selectors are immutable, so pods and services of the component keep using the original one.
+func (r *ComponentReconcilerTask) getAdoptedSelector() map[string]string {
+	if !v1alpha1.IsComponentAdopted(r.component) {
+		return nil
+	}
+
+	var selector *metaV1.LabelSelector
+
+	switch r.component.Spec.WorkloadType {
+	case v1alpha1.WorkloadTypeServer, "":
+		if r.deployment != nil {
+			selector = r.deployment.Spec.Selector
+		}
+	case v1alpha1.WorkloadTypeStatefulSet:
+		if r.statefulSet != nil {
+			selector = r.statefulSet.Spec.Selector
+		}
+	case v1alpha1.WorkloadTypeDaemonSet:
+		if r.daemonSet != nil {
+			selector = r.daemonSet.Spec.Selector
+		}
+	}
+
+	if selector == nil {
+		return nil
+	}
+
+	return selector.MatchLabels
+}
+
+// addSelectorLabels makes sure pods still match the immutable selector of an existing workload.
+func addSelectorLabels(selector *metaV1.LabelSelector, template *corev1.PodTemplateSpec) {
+	if selector == nil {
+		return
+	}
+
+	if template.Labels == nil {
+		template.Labels = make(map[string]string)
+	}
+
+	for k, v := range selector.MatchLabels {
+		template.Labels[k] = v
+	}
+}
@@ -0,0 +1,46 @@
+package controllers
+
+import (
+	"testing"
+
+	"github.com/kalmhq/kalm/controller/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	appsV1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestGetAdoptedSelector(t *testing.T) {
+	task := newScaleToZeroTestTask(metaV1.Now().Time)
+	task.deployment = &appsV1.Deployment{
+		Spec: appsV1.DeploymentSpec{
+			Selector: &metaV1.LabelSelector{MatchLabels: map[string]string{"app": "web"}},
+		},
+	}
+
+	assert.Nil(t, task.getAdoptedSelector())
+
+	task.component.Generation = 1
+	task.component.Annotations = map[string]string{v1alpha1.KalmAnnoAdoptedGeneration: "1"}
+	assert.True(t, v1alpha1.IsComponentAdoptionPending(task.component))
+	assert.Equal(t, map[string]string{"app": "web"}, task.getAdoptedSelector())
+
+	// the component is changed, the selector is still the original one
+	task.component.Generation = 2
+	assert.False(t, v1alpha1.IsComponentAdoptionPending(task.component))
+	assert.Equal(t, map[string]string{"app": "web"}, task.getAdoptedSelector())
+}
+
+func TestAddSelectorLabels(t *testing.T) {
+	template := &corev1.PodTemplateSpec{
+		ObjectMeta: metaV1.ObjectMeta{
+			Labels: map[string]string{v1alpha1.KalmLabelComponentKey: "web"},
+		},
+	}
+
+	addSelectorLabels(nil, template)
+	assert.Len(t, template.Labels, 1)
+
+	addSelectorLabels(&metaV1.LabelSelector{MatchLabels: map[string]string{"app": "web"}}, template)
+	assert.Equal(t, map[string]string{v1alpha1.KalmLabelComponentKey: "web", "app": "web"}, template.Labels)
+}
@@ -26,17 +26,21 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	js "github.com/dop251/goja"
 	"github.com/kalmhq/kalm/controller/vm"
 	"github.com/xeipuuv/gojsonschema"
 	v1alpha32 "istio.io/api/networking/v1alpha3"
 	"istio.io/client-go/pkg/apis/networking/v1alpha3"
+	"istio.io/client-go/pkg/apis/networking/v1beta1"
 	appsV1 "k8s.io/api/apps/v1"
+	autoscalingV2beta2 "k8s.io/api/autoscaling/v2beta2"
 	batchV1 "k8s.io/api/batch/v1"
 	v1 "k8s.io/api/batch/v1"
 	batchV1Beta1 "k8s.io/api/batch/v1beta1"
 	corev1 "k8s.io/api/core/v1"
+	policyV1beta1 "k8s.io/api/policy/v1beta1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -79,7 +83,39 @@ type ComponentReconcilerTask struct {
 	deployment      *appsV1.Deployment
 	daemonSet       *appsV1.DaemonSet
 	statefulSet     *appsV1.StatefulSet
+	hpa             *autoscalingV2beta2.HorizontalPodAutoscaler
+	pdb             *policyV1beta1.PodDisruptionBudget
 	pluginBindings  *v1alpha1.ComponentPluginBindingList
+
+	// resources of a progressive rollout, only for server workload
+	canaryDeployment      *appsV1.Deployment
+	rolloutVirtualService *v1beta1.VirtualService
+	// the rollout status which will be saved in component status
+	rolloutStatus *v1alpha1.ComponentRolloutStatus
+
+	// the id of the last created run, only for job workload
+	lastJobRunID string
+
+	// replicas decided by the scaling schedule in effect, only for server and statefulset workload
+	scheduledReplicas     *int32
+	scalingScheduleStatus *v1alpha1.ComponentScalingScheduleStatus
+
+	// set if the component needs to be reconciled again later, e.g. for the next rollout step
+	requeueAfter time.Duration
+
+	// the error of the last failed plugin, it will be reported in component status
+	pluginErr error
+
+	// reasons why the workload is held back by startAfterComponents
+	blockingDependencies []string
+
+	// shared env sets used by env vars of type external, keyed by name
+	sharedEnvSets        map[string]*sharedEnvSet
+	sharedEnvHashSources []string
+
+	// the kalm-files config map, loaded when the component has volumes of type file
+	kalmFiles        *corev1.ConfigMap
+	filesHashSources []string
 }
 
 // +kubebuilder:rbac:groups=core.kalm.dev,resources=components,verbs=get;list;watch;create;update;patch;delete
@@ -96,6 +132,8 @@ type ComponentReconcilerTask struct {
 // +kubebuilder:rbac:groups=batch,resources=cronjobs,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=apps,resources=deployments,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=networking.istio.io,resources=destinationrules,verbs=*
+// +kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch
 
 func (r *ComponentReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 	r.Log.Info("reconciling component", "req", req)
@@ -103,9 +141,12 @@ func (r *ComponentReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 	task := &ComponentReconcilerTask{
 		ComponentReconciler: r,
 		ctx:                 context.Background(),
+		sharedEnvSets:       make(map[string]*sharedEnvSet),
 	}
 
-	return ctrl.Result{}, task.Run(req)
+	err := task.Run(req)
+
+	return ctrl.Result{RequeueAfter: task.requeueAfter}, err
 }
 
 func (r *ComponentReconcilerTask) WarningEvent(err error, msg string, args ...interface{}) {
@@ -216,11 +257,25 @@ func (r *ComponentReconciler) SetupWithManager(mgr ctrl.Manager) error {
 		Watches(&source.Kind{Type: &v1alpha1.ComponentPluginBinding{}}, &handler.EnqueueRequestsFromMapFunc{
 			ToRequests: &ComponentPluginBindingsMapper{r.BaseReconciler},
 		}).
+		Watches(&source.Kind{Type: &v1alpha1.Component{}}, &handler.EnqueueRequestsFromMapFunc{
+			ToRequests: &DependentComponentsMapper{r.BaseReconciler},
+		}).
+		Watches(&source.Kind{Type: &corev1.ConfigMap{}}, &handler.EnqueueRequestsFromMapFunc{
+			ToRequests: &SharedEnvMapper{r.BaseReconciler},
+		}).
+		Watches(&source.Kind{Type: &corev1.Secret{}}, &handler.EnqueueRequestsFromMapFunc{
+			ToRequests: &SharedEnvMapper{r.BaseReconciler},
+		}).
+		Watches(&source.Kind{Type: &corev1.ConfigMap{}}, &handler.EnqueueRequestsFromMapFunc{
+			ToRequests: &KalmFilesMapper{r.BaseReconciler},
+		}).
 		Owns(&appsV1.Deployment{}).
 		Owns(&batchV1Beta1.CronJob{}).
 		Owns(&appsV1.DaemonSet{}).
 		Owns(&appsV1.StatefulSet{}).
 		Owns(&corev1.Service{}).
+		Owns(&autoscalingV2beta2.HorizontalPodAutoscaler{}).
+		Owns(&policyV1beta1.PodDisruptionBudget{}).
 		Complete(r)
 }
 func (r *ComponentReconcilerTask) Run(req ctrl.Request) error {
@@ -240,6 +295,21 @@ func (r *ComponentReconcilerTask) Run(req ctrl.Request) error {
 		return nil
 	}
 
+	if err := r.ReconcileRevisions(); err != nil {
+		return err
+	}
+
+	// nothing is changed until the adopted component is changed, so the pods are not restarted
+	if v1alpha1.IsComponentAdoptionPending(r.component) {
+		err := r.adoptResources()
+
+		if statusErr := r.UpdateStatus(err); statusErr != nil && err == nil {
+			return statusErr
+		}
+
+		return err
+	}
+
 	if err := r.ReconcileService(); err != nil {
 		return err
 	}
@@ -248,11 +318,13 @@ func (r *ComponentReconcilerTask) Run(req ctrl.Request) error {
 		return err
 	}
 
-	if err := r.ReconcileWorkload(); err != nil {
-		return err
+	err := r.ReconcileWorkload()
+
+	if statusErr := r.UpdateStatus(err); statusErr != nil && err == nil {
+		return statusErr
 	}
 
-	return nil
+	return err
 }
 
 func (r *ComponentReconcilerTask) GetLabels() map[string]string {
@@ -377,6 +449,12 @@ func (r *ComponentReconcilerTask) ReconcileService() (err error) {
 		return nil
 	}
 
+	selector := labels
+
+	if adoptedSelector := r.getAdoptedSelector(); adoptedSelector != nil {
+		selector = adoptedSelector
+	}
+
 	if len(r.component.Spec.Ports) > 0 {
 		newService := false
 		newHeadlessService := false
@@ -390,11 +468,11 @@ func (r *ComponentReconcilerTask) ReconcileService() (err error) {
 					Labels:    labels,
 				},
 				Spec: corev1.ServiceSpec{
-					Selector: labels,
+					Selector: selector,
 				},
 			}
 		} else {
-			r.service.Spec.Selector = labels
+			r.service.Spec.Selector = selector
 		}
 
 		if r.component.Spec.WorkloadType == v1alpha1.WorkloadTypeStatefulSet {
@@ -411,12 +489,12 @@ func (r *ComponentReconcilerTask) ReconcileService() (err error) {
 						Labels:    labels,
 					},
 					Spec: corev1.ServiceSpec{
-						Selector:  labels,
+						Selector:  selector,
 						ClusterIP: "None",
 					},
 				}
 			} else {
-				r.headlessService.Spec.Selector = labels
+				r.headlessService.Spec.Selector = selector
 				r.headlessService.Spec.ClusterIP = "None"
 			}
 		}
@@ -543,6 +621,10 @@ func (r *ComponentReconcilerTask) ReconcileService() (err error) {
 			destinationRule.Spec.TrafficPolicy.PortLevelSettings[i] = policy
 		}
 
+		if r.component.Spec.RolloutStrategy != nil {
+			destinationRule.Spec.Subsets = buildRolloutSubsets()
+		}
+
 		if r.destinationRule == nil {
 			if err := ctrl.SetControllerReference(r.component, destinationRule, r.Scheme); err != nil {
 				r.WarningEvent(err, "unable to set owner for DestinationRule")
@@ -641,23 +723,52 @@ func (r *ComponentReconcilerTask) ReconcileWorkload() (err error) {
 				return err
 			}
 		}
+		if err := r.DeleteHorizontalPodAutoscaler(); err != nil {
+			return err
+		}
+		if err := r.DeletePodDisruptionBudget(); err != nil {
+			return err
+		}
+		if err := r.cleanupRollout(); err != nil {
+			return err
+		}
 
 		return
 	}
 
-	if isComponentLabeledAsExceedingQuota(r.component) &&
+	// components created after the application is suspended are suspended as well,
+	// the namespace controller resumes all of them together
+	if v1alpha1.IsApplicationSuspended(&r.namespace) && !isComponentLabeledAsSuspended(r.component) {
+		copied := r.component.DeepCopy()
+
+		if copied.Labels == nil {
+			copied.Labels = make(map[string]string)
+		}
+
+		copied.Labels[v1alpha1.KalmLabelKeySuspended] = "true"
+
+		return r.Update(r.ctx, copied)
+	}
+
+	if isComponentForcedToScaleDown(r.component) &&
 		(r.component.Spec.Replicas == nil || *r.component.Spec.Replicas > 0) {
 
-		err := r.forceScaleDownExceedingQuotaComponent()
+		err := r.forceScaleDownComponent()
 		if err == nil {
-			r.Log.Info("succeed force scale down exceeding quota comp", "comp name", r.component.Name)
+			r.Log.Info("succeed force scale down comp", "comp name", r.component.Name)
 		} else {
-			r.Log.Error(err, "fail force scale down exceeding quota comp", "comp name", r.component.Name)
+			r.Log.Error(err, "fail force scale down comp", "comp name", r.component.Name)
 		}
 
 		return err
 	}
 
+	if heldBack, err := r.isHeldBackByDependencies(); err != nil {
+		return err
+	} else if heldBack {
+		return nil
+	}
+
 	if err := r.reconcilePermission(); err != nil {
 		return err
 	}
@@ -666,6 +777,10 @@ func (r *ComponentReconcilerTask) ReconcileWorkload() (err error) {
 		return err
 	}
 
+	if err := r.ReconcilePodDisruptionBudget(); err != nil {
+		return err
+	}
+
 	template, err := r.GetPodTemplateWithoutVols()
 	if err != nil {
 		return err
@@ -677,7 +792,17 @@ func (r *ComponentReconcilerTask) ReconcileWorkload() (err error) {
 			return err
 		}
 
-		return r.ReconcileDeployment(template)
+		r.applyScalingSchedules()
+
+		if err := r.ReconcileScaleToZero(); err != nil {
+			return err
+		}
+
+		if err := r.ReconcileRollout(template); err != nil {
+			return err
+		}
+
+		return r.ReconcileHorizontalPodAutoscaler()
 	case v1alpha1.WorkloadTypeCronjob:
 		if err := r.prepareVolsForSimpleWorkload(template); err != nil {
 			return err
@@ -696,7 +821,15 @@ func (r *ComponentReconcilerTask) ReconcileWorkload() (err error) {
 			return err
 		}
 
+		r.applyScalingSchedules()
+
 		return r.ReconcileStatefulSet(template, volClaimTemplates)
+	case v1alpha1.WorkloadTypeJob:
+		if err := r.prepareVolsForSimpleWorkload(template); err != nil {
+			return err
+		}
+
+		return r.ReconcileJob(template)
 	default:
 		return fmt.Errorf("unknown workload type: %s", string(r.component.Spec.WorkloadType))
 	}
@@ -706,32 +839,59 @@ func isComponentLabeledAsExceedingQuota(comp *v1alpha1.Component) bool {
 	return comp.Labels[v1alpha1.KalmLabelKeyExceedingQuota] == "true"
 }
 
-func (r *ComponentReconcilerTask) forceScaleDownExceedingQuotaComponent() (err error) {
+func isComponentLabeledAsSuspended(comp *v1alpha1.Component) bool {
+	return comp.Labels[v1alpha1.KalmLabelKeySuspended] == "true"
+}
+
+// isComponentForcedToScaleDown returns true if the component is scaled down by its application,
+// because the application is exceeding quota or suspended.
+func isComponentForcedToScaleDown(comp *v1alpha1.Component) bool {
+	return isComponentLabeledAsExceedingQuota(comp) || isComponentLabeledAsSuspended(comp)
+}
+
+func (r *ComponentReconcilerTask) forceScaleDownComponent() (err error) {
 	comp := r.component
-	// if comp already marked exceedingQuota and spec.replicas == 0
+	// if comp already marked and spec.replicas == 0
 	// we need do nothing more
-	if comp.Labels[v1alpha1.KalmLabelKeyExceedingQuota] == "true" &&
+	if isComponentForcedToScaleDown(comp) &&
 		comp.Spec.Replicas != nil && *comp.Spec.Replicas == 0 {
 		return nil
 	}
 
-	msg := "force scale down exceeding quota component"
-	r.EmitNormalEvent(r.component, v1alpha1.ReasonExceedingQuota, msg)
+	if isComponentLabeledAsExceedingQuota(comp) {
+		r.EmitNormalEvent(r.component, v1alpha1.ReasonExceedingQuota, "force scale down exceeding quota component")
+	} else {
+		r.EmitNormalEvent(r.component, v1alpha1.ReasonSuspended, "force scale down component of suspended application")
+	}
 
 	copy := comp.DeepCopy()
 
 	// remember original replicas for recovery
 	var originalReplica int32
-	if copy.Spec.Replicas == nil {
+	if isAutoScalingEnabled(comp) && r.deployment != nil && r.deployment.Spec.Replicas != nil {
+		// replicas is decided by the HorizontalPodAutoscaler
+		originalReplica = *r.deployment.Spec.Replicas
+	} else if copy.Spec.Replicas == nil {
 		originalReplica = 1
 	} else {
 		originalReplica = *copy.Spec.Replicas
 	}
+
+	// HPA will be re-created once the component is scaled up again
+	if err := r.DeleteHorizontalPodAutoscaler(); err != nil {
+		return err
+	}
 	copy.Labels[v1alpha1.KalmLabelKeyOriginalReplicas] = fmt.Sprintf("%d", originalReplica)
 
 	zero := int32(0)
 	copy.Spec.Replicas = &zero
 
+	if copy.Annotations == nil {
+		copy.Annotations = make(map[string]string)
+	}
+	copy.Annotations[v1alpha1.KalmAnnoComponentChangedBy] = ControllerComponent
+	copy.Annotations[v1alpha1.KalmAnnoComponentChangedVia] = v1alpha1.ComponentChangedViaController
+
 	return r.Update(r.ctx, copy)
 }
 
@@ -756,11 +916,12 @@ func (r *ComponentReconcilerTask) ReconcileDeployment(podTemplateSpec *corev1.Po
 			Spec: appsV1.DeploymentSpec{
 				Template: *podTemplateSpec,
 				Selector: &metaV1.LabelSelector{
-					MatchLabels: labelMap,
+					MatchLabels: r.getVersionSelector(StableVersion),
 				},
 			},
 		}
 	} else {
+		addSelectorLabels(deployment.Spec.Selector, podTemplateSpec)
 		deployment.Spec.Template = *podTemplateSpec
 
 		// deployment selector(dp.Spec.Selector) is immutable, so no update here
@@ -769,6 +930,10 @@ func (r *ComponentReconcilerTask) ReconcileDeployment(podTemplateSpec *corev1.Po
 
 	// inherit annotation: AnnoLastUpdatedByWebhook
 	if v, exist := component.Annotations[AnnoLastUpdatedByWebhook]; exist {
+		if deployment.Annotations == nil {
+			deployment.Annotations = make(map[string]string)
+		}
+
 		deployment.Annotations[AnnoLastUpdatedByWebhook] = v
 	}
 
@@ -783,7 +948,19 @@ func (r *ComponentReconcilerTask) ReconcileDeployment(podTemplateSpec *corev1.Po
 	}
 
 	// TODO consider to move to plugin
-	if component.Spec.Replicas != nil {
+	if v1alpha1.IsComponentScaledToZero(component) {
+		zero := int32(0)
+		deployment.Spec.Replicas = &zero
+	} else if r.scheduledReplicas != nil {
+		deployment.Spec.Replicas = r.scheduledReplicas
+	} else if isAutoScalingEnabled(component) {
+		// replicas is managed by the HorizontalPodAutoscaler, only set it if HPA can't take over,
+		// which is the case for a new deployment or a deployment scaled down to 0.
+		if deployment.Spec.Replicas == nil || *deployment.Spec.Replicas == 0 {
+			minReplicas := component.Spec.AutoScaling.MinReplicas
+			deployment.Spec.Replicas = &minReplicas
+		}
+	} else if component.Spec.Replicas != nil {
 		deployment.Spec.Replicas = component.Spec.Replicas
 	} else {
 		deployment.Spec.Replicas = nil
@@ -815,10 +992,16 @@ func (r *ComponentReconcilerTask) ReconcileDeployment(podTemplateSpec *corev1.Po
 		r.NormalEvent("DeploymentUpdated", deployment.Name+" is updated.")
 	}
 
+	r.deployment = deployment
+
 	return nil
 }
 
 func (r *ComponentReconcilerTask) ReconcileDaemonSet(podTemplateSpec *corev1.PodTemplateSpec) error {
+	if isComponentLabeledAsSuspended(r.component) {
+		return r.suspendDaemonSet()
+	}
+
 	labelMap := r.GetLabels()
 	annotations := r.GetAnnotations()
 
@@ -843,6 +1026,7 @@ func (r *ComponentReconcilerTask) ReconcileDaemonSet(podTemplateSpec *corev1.Pod
 			},
 		}
 	} else {
+		addSelectorLabels(daemonSet.Spec.Selector, podTemplateSpec)
 		daemonSet.Spec.Template = *podTemplateSpec
 	}
 
@@ -867,6 +1051,8 @@ func (r *ComponentReconcilerTask) ReconcileDaemonSet(podTemplateSpec *corev1.Pod
 		r.NormalEvent("DaemonSetUpdated", daemonSet.Name+" is updated.")
 	}
 
+	r.daemonSet = daemonSet
+
 	return nil
 }
 
@@ -952,18 +1138,15 @@ func (r *ComponentReconcilerTask) ReconcileCronJob(podTemplateSpec *corev1.PodTe
 
 	}
 
-	successJobHistoryLimit := int32(3)
-	failJobHistoryLimit := int32(5)
-
-	desiredCJSpec := batchV1Beta1.CronJobSpec{
-		Schedule: component.Spec.Schedule,
-		JobTemplate: batchV1Beta1.JobTemplateSpec{
-			Spec: batchV1.JobSpec{
-				Template: *template,
-			},
+	desiredCJSpec := buildCronJobSpec(component)
+	desiredCJSpec.JobTemplate = batchV1Beta1.JobTemplateSpec{
+		// jobs created by the cronjob are found by labels, same as triggered jobs
+		ObjectMeta: metaV1.ObjectMeta{
+			Labels: labelMap,
+		},
+		Spec: batchV1.JobSpec{
+			Template: *template,
 		},
-		SuccessfulJobsHistoryLimit: &successJobHistoryLimit,
-		FailedJobsHistoryLimit:     &failJobHistoryLimit,
 	}
 
 	var isNewCJ bool
@@ -1004,6 +1187,13 @@ func (r *ComponentReconcilerTask) ReconcileCronJob(podTemplateSpec *corev1.PodTe
 		r.NormalEvent("CronJobUpdated", cj.Name+" is updated.")
 	}
 
+	r.cronJob = cj
+
+	// the cronjob is suspended, jobs it created already are stopped as well
+	if isComponentLabeledAsSuspended(component) {
+		return r.deleteUnfinishedJobs()
+	}
+
 	if r.component.Spec.ImmediateTrigger {
 		return r.ReconcileImmediateJob(template)
 	}
@@ -1049,10 +1239,13 @@ func (r *ComponentReconcilerTask) ReconcileStatefulSet(
 	} else {
 		// for sts, only 'replicas', 'template', and 'updateStrategy' are mutable
 		// so no update for volClaimTemplate here
+		addSelectorLabels(sts.Spec.Selector, spec)
 		sts.Spec.Template = *spec
 	}
 
-	if r.component.Spec.Replicas != nil {
+	if r.scheduledReplicas != nil {
+		sts.Spec.Replicas = r.scheduledReplicas
+	} else if r.component.Spec.Replicas != nil {
 		sts.Spec.Replicas = r.component.Spec.Replicas
 	}
 
@@ -1077,6 +1270,8 @@ func (r *ComponentReconcilerTask) ReconcileStatefulSet(
 		r.NormalEvent("StatefulSetUpdated", sts.Name+" is updated.")
 	}
 
+	r.statefulSet = sts
+
 	return nil
 }
 
@@ -1099,7 +1294,7 @@ func (r *ComponentReconcilerTask) GetPodTemplateWithoutVols() (template *corev1.
 
 	labels := r.GetLabels()
 	labels["app"] = component.Name
-	labels["version"] = "v1" // TODO
+	labels["version"] = StableVersion // TODO
 
 	annotations := r.GetAnnotations()
 
@@ -1169,6 +1364,10 @@ func (r *ComponentReconcilerTask) GetPodTemplateWithoutVols() (template *corev1.
 		}
 	}
 
+	if len(component.Spec.Args) > 0 && len(mainContainer.Args) == 0 {
+		mainContainer.Args = component.Spec.Args
+	}
+
 	var pullImageSecrets corev1.SecretList
 	if err := r.Client.List(
 		r.ctx,
@@ -1200,6 +1399,10 @@ func (r *ComponentReconcilerTask) GetPodTemplateWithoutVols() (template *corev1.
 		template.Spec.Affinity = affinity
 	}
 
+	template.Spec.TopologySpreadConstraints = r.buildTopologySpreadConstraints()
+	template.Spec.Tolerations = component.Spec.Tolerations
+	template.Spec.PriorityClassName = component.Spec.PriorityClassName
+
 	if r.component.Namespace != KalmSystemNamespace {
 		if component.Spec.RunnerPermission != nil {
 			template.Spec.ServiceAccountName = r.getNameForPermission()
@@ -1215,8 +1418,62 @@ func (r *ComponentReconcilerTask) GetPodTemplateWithoutVols() (template *corev1.
 	}
 
 	// apply envs
-	var envs []corev1.EnvVar
-	for _, env := range component.Spec.Env {
+	if mainContainer.Env, err = r.buildContainerEnvs(component.Spec.Env); err != nil {
+		return nil, err
+	}
+
+	if hash := r.getSharedEnvHash(); hash != "" {
+		template.ObjectMeta.Annotations[AnnoSharedEnvHash] = hash
+	}
+
+	envFromCommonCM := corev1.EnvFromSource{
+		ConfigMapRef: &corev1.ConfigMapEnvSource{
+			LocalObjectReference: corev1.LocalObjectReference{
+				Name: NSScopeSharedConfigMapName,
+			},
+		},
+	}
+
+	// envFromCommonSec := corev1.EnvFromSource{
+	// 	SecretRef: &corev1.SecretEnvSource{
+	// 		LocalObjectReference: corev1.LocalObjectReference{
+	// 			Name: NSScopeSharedConfigMapName,
+	// 		},
+	// 	},
+	// }
+
+	mainContainer.EnvFrom = append(mainContainer.EnvFrom, envFromCommonCM)
+
+	// volumes of init containers and sidecars are mounted when volumes are prepared
+	for _, c := range component.Spec.InitContainers {
+		container, err := r.buildExtraContainer(c)
+		if err != nil {
+			return nil, err
+		}
+
+		template.Spec.InitContainers = append(template.Spec.InitContainers, *container)
+	}
+
+	for _, c := range component.Spec.Sidecars {
+		container, err := r.buildExtraContainer(c)
+		if err != nil {
+			return nil, err
+		}
+
+		template.Spec.Containers = append(template.Spec.Containers, *container)
+	}
+
+	err = r.runPlugins(ComponentPluginMethodAfterPodTemplateGeneration, component, template, template)
+	if err != nil {
+		r.WarningEvent(err, "run "+ComponentPluginMethodAfterPodTemplateGeneration+" save plugin error")
+		return nil, err
+	}
+
+	return template, nil
+}
+
+func (r *ComponentReconcilerTask) buildContainerEnvs(envVars []v1alpha1.EnvVar) (envs []corev1.EnvVar, err error) {
+	for _, env := range envVars {
 		var value string
 		var valueFrom *corev1.EnvVarSource
 
@@ -1224,12 +1481,26 @@ func (r *ComponentReconcilerTask) GetPodTemplateWithoutVols() (template *corev1.
 		case "", v1alpha1.EnvVarTypeStatic:
 			value = env.Value
 		case v1alpha1.EnvVarTypeExternal:
-			//value, err = r.FindShareEnvValue(env.Value)
-			//
-			////  if the env can't be found in sharedEnv, ignore it
-			//if err != nil {
-			//	continue
-			//}
+			value, valueFrom, err = r.getValueOfExternalEnv(env)
+			if err != nil {
+				r.WarningEvent(err, "resolve shared env failed")
+				return nil, err
+			}
+
+			if valueFrom != nil && (env.Prefix != "" || env.Suffix != "") {
+				var secretEnv corev1.EnvVar
+				secretEnv, value = buildAffixedSecretEnv(env, valueFrom)
+				envs = append(envs, secretEnv)
+				valueFrom = nil
+			}
+		case v1alpha1.EnvVarTypeSecret:
+			secretName, key := v1alpha1.ParseSecretEnvValue(env)
+			valueFrom = &corev1.EnvVarSource{
+				SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: secretName},
+					Key:                  key,
+				},
+			}
 		case v1alpha1.EnvVarTypeLinked:
 			value, err = r.getValueOfLinkedEnv(env)
 			if err != nil {
@@ -1273,33 +1544,78 @@ func (r *ComponentReconcilerTask) GetPodTemplateWithoutVols() (template *corev1.
 			ValueFrom: valueFrom,
 		})
 	}
-	mainContainer.Env = envs
 
-	envFromCommonCM := corev1.EnvFromSource{
-		ConfigMapRef: &corev1.ConfigMapEnvSource{
-			LocalObjectReference: corev1.LocalObjectReference{
-				Name: NSScopeSharedConfigMapName,
-			},
-		},
+	return envs, nil
+}
+
+func (r *ComponentReconcilerTask) buildExtraContainer(c v1alpha1.ComponentContainer) (*corev1.Container, error) {
+	envs, err := r.buildContainerEnvs(c.Env)
+	if err != nil {
+		return nil, err
 	}
 
-	// envFromCommonSec := corev1.EnvFromSource{
-	// 	SecretRef: &corev1.SecretEnvSource{
-	// 		LocalObjectReference: corev1.LocalObjectReference{
-	// 			Name: NSScopeSharedConfigMapName,
-	// 		},
-	// 	},
-	// }
+	container := &corev1.Container{
+		Name:    c.Name,
+		Image:   c.Image,
+		Command: c.Command,
+		Args:    c.Args,
+		Env:     envs,
+	}
 
-	mainContainer.EnvFrom = append(mainContainer.EnvFrom, envFromCommonCM)
+	if c.ResourceRequirements != nil {
+		container.Resources = *c.ResourceRequirements
+	}
 
-	err = r.runPlugins(ComponentPluginMethodAfterPodTemplateGeneration, component, template, template)
-	if err != nil {
-		r.WarningEvent(err, "run "+ComponentPluginMethodAfterPodTemplateGeneration+" save plugin error")
-		return nil, err
+	return container, nil
+}
+
+// mountVolumesIntoExtraContainers mounts volumes shared with the main container into init containers and sidecars.
+// volNames maps the path of a volume in the component to its name in the pod template.
+func (r *ComponentReconcilerTask) mountVolumesIntoExtraContainers(template *corev1.PodTemplateSpec, volNames map[string]string) error {
+	mount := func(containers []corev1.Container, specs []v1alpha1.ComponentContainer) error {
+		for _, spec := range specs {
+			var container *corev1.Container
+
+			for i := range containers {
+				if containers[i].Name == spec.Name {
+					container = &containers[i]
+					break
+				}
+			}
+
+			if container == nil {
+				continue
+			}
+
+			container.VolumeMounts = nil
+
+			for _, volumeMount := range spec.VolumeMounts {
+				volName, exist := volNames[volumeMount.Volume]
+				if !exist {
+					return fmt.Errorf("volume %s used by container %s is not found", volumeMount.Volume, spec.Name)
+				}
+
+				mountPath := volumeMount.MountPath
+				if mountPath == "" {
+					mountPath = volumeMount.Volume
+				}
+
+				container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{
+					Name:      volName,
+					MountPath: mountPath,
+					ReadOnly:  volumeMount.ReadOnly,
+				})
+			}
+		}
+
+		return nil
 	}
 
-	return template, nil
+	if err := mount(template.Spec.InitContainers, r.component.Spec.InitContainers); err != nil {
+		return err
+	}
+
+	return mount(template.Spec.Containers[1:], r.component.Spec.Sidecars)
 }
 
 func getVolName(componentName, diskPath string) string {
@@ -1381,6 +1697,7 @@ func (r *ComponentReconcilerTask) runPlugins(methodName string, component *v1alp
 		pluginProgram, config, err := findPluginAndValidateConfigNew(&binding, methodName, component)
 
 		if err != nil {
+			r.pluginErr = err
 			return err
 		}
 
@@ -1425,6 +1742,7 @@ func (r *ComponentReconcilerTask) runPlugins(methodName string, component *v1alp
 
 		if err != nil {
 			r.WarningEvent(err, fmt.Sprintf("Run plugin error. methodName: %s, componentName: %s, pluginName: %s", methodName, component.Name, binding.Spec.PluginName))
+			r.pluginErr = fmt.Errorf("plugin %s failed in %s: %s", binding.Spec.PluginName, methodName, err.Error())
 			return err
 		}
 	}
@@ -1515,12 +1833,43 @@ func (r *ComponentReconcilerTask) decideAffinity() (*corev1.Affinity, bool) {
 		})
 	}
 
+	var preferredTerms []corev1.PreferredSchedulingTerm
+
+	if component.NodeAffinity != nil {
+		// terms are ORed, required expressions should be met in each of them
+		if len(component.NodeAffinity.Required) > 0 {
+			if len(nodeSelectorTerms) == 0 {
+				nodeSelectorTerms = append(nodeSelectorTerms, corev1.NodeSelectorTerm{})
+			}
+
+			for i := range nodeSelectorTerms {
+				nodeSelectorTerms[i].MatchExpressions = append(
+					nodeSelectorTerms[i].MatchExpressions,
+					component.NodeAffinity.Required...,
+				)
+			}
+		}
+
+		for _, preferred := range component.NodeAffinity.Preferred {
+			preferredTerms = append(preferredTerms, corev1.PreferredSchedulingTerm{
+				Weight: preferred.Weight,
+				Preference: corev1.NodeSelectorTerm{
+					MatchExpressions: preferred.Expressions,
+				},
+			})
+		}
+	}
+
 	var nodeAffinity *corev1.NodeAffinity
-	if len(nodeSelectorTerms) > 0 {
+	if len(nodeSelectorTerms) > 0 || len(preferredTerms) > 0 {
 		nodeAffinity = &corev1.NodeAffinity{
-			RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{
+			PreferredDuringSchedulingIgnoredDuringExecution: preferredTerms,
+		}
+
+		if len(nodeSelectorTerms) > 0 {
+			nodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution = &corev1.NodeSelector{
 				NodeSelectorTerms: nodeSelectorTerms,
-			},
+			}
 		}
 	}
 
@@ -1553,6 +1902,41 @@ func (r *ComponentReconcilerTask) decideAffinity() (*corev1.Affinity, bool) {
 	}, true
 }
 
+func getTopologyKey(topology v1alpha1.TopologySpreadType) string {
+	if topology == v1alpha1.TopologySpreadHost {
+		return corev1.LabelHostname
+	}
+
+	return corev1.LabelZoneFailureDomainStable
+}
+
+func (r *ComponentReconcilerTask) buildTopologySpreadConstraints() []corev1.TopologySpreadConstraint {
+	var constraints []corev1.TopologySpreadConstraint
+
+	for _, spread := range r.component.Spec.TopologySpread {
+		maxSkew := spread.MaxSkew
+		if maxSkew < 1 {
+			maxSkew = 1
+		}
+
+		whenUnsatisfiable := corev1.DoNotSchedule
+		if spread.ScheduleAnyway {
+			whenUnsatisfiable = corev1.ScheduleAnyway
+		}
+
+		constraints = append(constraints, corev1.TopologySpreadConstraint{
+			MaxSkew:           maxSkew,
+			TopologyKey:       getTopologyKey(spread.Topology),
+			WhenUnsatisfiable: whenUnsatisfiable,
+			LabelSelector: &metaV1.LabelSelector{
+				MatchLabels: r.getVersionSelector(StableVersion),
+			},
+		})
+	}
+
+	return constraints
+}
+
 func (r *ComponentReconcilerTask) SetupAttributes(req ctrl.Request) (err error) {
 	var component v1alpha1.Component
 	err = r.Reader.Get(r.ctx, req.NamespacedName, &component)
@@ -1648,8 +2032,26 @@ func (r *ComponentReconcilerTask) LoadResources() (err error) {
 		return err
 	}
 
+	if err := r.LoadPodDisruptionBudget(); err != nil {
+		return err
+	}
+
 	switch r.component.Spec.WorkloadType {
 	case v1alpha1.WorkloadTypeServer, "":
+		if err := r.LoadHorizontalPodAutoscaler(); err != nil {
+			return err
+		}
+
+		if err := r.LoadCanaryDeployment(); err != nil {
+			return err
+		}
+
+		if err := r.LoadRolloutVirtualService(); err != nil {
+			return err
+		}
+
+		r.rolloutStatus = r.component.Status.Rollout.DeepCopy()
+
 		return r.LoadDeployment()
 	case v1alpha1.WorkloadTypeCronjob:
 		return r.LoadCronJob()
@@ -1657,6 +2059,9 @@ func (r *ComponentReconcilerTask) LoadResources() (err error) {
 		return r.LoadDaemonSet()
 	case v1alpha1.WorkloadTypeStatefulSet:
 		return r.LoadStatefulSet()
+	case v1alpha1.WorkloadTypeJob:
+		// runs are not loaded, jobs may be deleted after they are finished
+		r.lastJobRunID = r.component.Status.LastJobRunID
 	}
 
 	return nil
@@ -1801,7 +2206,18 @@ func (r *ComponentReconcilerTask) preparePreInjectedFiles(
 ) error {
 	component := r.component
 
-	if len(component.Spec.PreInjectedFiles) <= 0 {
+	var files []v1alpha1.PreInjectFile
+
+	for _, file := range component.Spec.PreInjectedFiles {
+		if file.SecretRef != nil {
+			prepareSecretPreInjectedFile(file, volumes, volumeMounts)
+			continue
+		}
+
+		files = append(files, file)
+	}
+
+	if len(files) <= 0 {
 		return nil
 	}
 
@@ -1817,7 +2233,7 @@ func (r *ComponentReconcilerTask) preparePreInjectedFiles(
 	}
 
 	var injectCommands []string
-	for _, file := range component.Spec.PreInjectedFiles {
+	for _, file := range files {
 		content := file.Content
 
 		if !file.Base64 {
@@ -1852,6 +2268,42 @@ func (r *ComponentReconcilerTask) preparePreInjectedFiles(
 	return nil
 }
 
+// Secret backed files are mounted from a secret volume directly,
+// so the content never shows up in the component or the pod template.
+func prepareSecretPreInjectedFile(file v1alpha1.PreInjectFile, volumes *[]corev1.Volume, volumeMounts *[]corev1.VolumeMount) {
+	volumeName := fmt.Sprintf("pre-injected-secret-%x", md5.Sum([]byte(file.MountPath)))
+	baseName := path.Base(file.MountPath)
+
+	var mode *int32
+	if file.Runnable {
+		runnableMode := int32(0755)
+		mode = &runnableMode
+	}
+
+	*volumes = append(*volumes, corev1.Volume{
+		Name: volumeName,
+		VolumeSource: corev1.VolumeSource{
+			Secret: &corev1.SecretVolumeSource{
+				SecretName: file.SecretRef.Name,
+				Items: []corev1.KeyToPath{
+					{
+						Key:  file.SecretRef.Key,
+						Path: baseName,
+						Mode: mode,
+					},
+				},
+			},
+		},
+	})
+
+	*volumeMounts = append(*volumeMounts, corev1.VolumeMount{
+		Name:      volumeName,
+		MountPath: file.MountPath,
+		SubPath:   baseName,
+		ReadOnly:  file.Readonly,
+	})
+}
+
 // STS has 2 kinds of volumes:
 //
 // - temp vol as podTemplate.volumes
@@ -1862,6 +2314,7 @@ func (r *ComponentReconcilerTask) prepareVolsForSTS(podTemplate *corev1.PodTempl
 	var volumes []corev1.Volume
 	var volumeMounts []corev1.VolumeMount
 	var volClaimTemplates []corev1.PersistentVolumeClaim
+	volNames := make(map[string]string)
 
 	if err := r.preparePreInjectedFiles(podTemplate, &volumes, &volumeMounts); err != nil {
 		return nil, err
@@ -1891,6 +2344,16 @@ func (r *ComponentReconcilerTask) prepareVolsForSTS(podTemplate *corev1.PodTempl
 					},
 				},
 			})
+		} else if disk.Type == v1alpha1.VolumeTypeFile {
+			volume, volumeMount, err := r.buildFileVolume(volName, disk)
+			if err != nil {
+				return nil, err
+			}
+
+			volumes = append(volumes, *volume)
+			volumeMounts = append(volumeMounts, *volumeMount)
+			volNames[disk.Path] = volName
+			continue
 		} else if disk.Type == v1alpha1.VolumeTypePersistentVolumeClaim ||
 			disk.Type == v1alpha1.VolumeTypePersistentVolumeClaimTemplate {
 
@@ -1930,15 +2393,21 @@ func (r *ComponentReconcilerTask) prepareVolsForSTS(podTemplate *corev1.PodTempl
 			Name:      volName,
 			MountPath: disk.Path,
 		})
+		volNames[disk.Path] = volName
 	}
 
 	// set volumes & volMounts for podTemplate of STS
 	podTemplate.Spec.Volumes = volumes
+	r.setFilesHashAnnotation(podTemplate)
 
 	// mount vols into container
 	mainContainer := &podTemplate.Spec.Containers[0]
 	mainContainer.VolumeMounts = volumeMounts
 
+	if err := r.mountVolumesIntoExtraContainers(podTemplate, volNames); err != nil {
+		return nil, err
+	}
+
 	// for STS, pvc is not in podTemplate but in volumeClaimTemplate
 	return volClaimTemplates, nil
 }
@@ -1950,6 +2419,7 @@ func (r *ComponentReconcilerTask) prepareVolsForSimpleWorkload(template *corev1.
 
 	var volumes []corev1.Volume
 	var volumeMounts []corev1.VolumeMount
+	volNames := make(map[string]string)
 
 	if err := r.preparePreInjectedFiles(template, &volumes, &volumeMounts); err != nil {
 		return err
@@ -1990,6 +2460,16 @@ func (r *ComponentReconcilerTask) prepareVolsForSimpleWorkload(template *corev1.
 					},
 				},
 			})
+		case v1alpha1.VolumeTypeFile:
+			volume, volumeMount, err := r.buildFileVolume(volName, disk)
+			if err != nil {
+				return err
+			}
+
+			volumes = append(volumes, *volume)
+			volumeMounts = append(volumeMounts, *volumeMount)
+			volNames[disk.Path] = volName
+			continue
 		case v1alpha1.VolumeTypePersistentVolumeClaim:
 			pvcName := disk.PVC
 			volName = pvcName
@@ -2069,14 +2549,16 @@ func (r *ComponentReconcilerTask) prepareVolsForSimpleWorkload(template *corev1.
 			Name:      volName,
 			MountPath: disk.Path,
 		})
+		volNames[disk.Path] = volName
 	}
 
 	template.Spec.Volumes = volumes
+	r.setFilesHashAnnotation(template)
 
 	mainContainer := &template.Spec.Containers[0]
 	mainContainer.VolumeMounts = volumeMounts
 
-	return nil
+	return r.mountVolumesIntoExtraContainers(template, volNames)
 }
 
 // 2. diff ns pv reuse, remove old pvc, clean ref in pv
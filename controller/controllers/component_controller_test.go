@@ -9,12 +9,17 @@ import (
 	"github.com/kalmhq/kalm/controller/api/v1alpha1"
 	"github.com/stretchr/testify/suite"
 	appsV1 "k8s.io/api/apps/v1"
+	autoscalingV2beta2 "k8s.io/api/autoscaling/v2beta2"
+	batchV1 "k8s.io/api/batch/v1"
+	batchV1Beta1 "k8s.io/api/batch/v1beta1"
 	coreV1 "k8s.io/api/core/v1"
+	policyV1beta1 "k8s.io/api/policy/v1beta1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
@@ -236,6 +241,560 @@ func (suite *ComponentControllerSuite) TestOnlyStaticEnvs() {
 	}, "the second value should be updated")
 }
 
+func (suite *ComponentControllerSuite) TestExternalEnvs() {
+	configMap := coreV1.ConfigMap{
+		ObjectMeta: metaV1.ObjectMeta{
+			Name:      "database",
+			Namespace: suite.ns.Name,
+			Labels:    map[string]string{v1alpha1.KalmLabelSharedEnvKey: "true"},
+		},
+		Data: map[string]string{"DATABASE_URL": "postgres://db:5432"},
+	}
+	suite.createObject(&configMap)
+
+	secret := coreV1.Secret{
+		ObjectMeta: metaV1.ObjectMeta{
+			Name:      "credentials",
+			Namespace: suite.ns.Name,
+			Labels:    map[string]string{v1alpha1.KalmLabelSharedEnvKey: "true"},
+		},
+		Data: map[string][]byte{"password": []byte("foo")},
+	}
+	suite.createObject(&secret)
+
+	component := generateEmptyComponent(suite.ns.Name)
+	component.Spec.Env = []v1alpha1.EnvVar{
+		{Name: "DATABASE_URL", Value: "database", Type: v1alpha1.EnvVarTypeExternal},
+		{Name: "DATABASE_PASSWORD", Value: "credentials/password", Type: v1alpha1.EnvVarTypeExternal},
+	}
+	suite.createComponent(component)
+
+	key := types.NamespacedName{
+		Namespace: component.Namespace,
+		Name:      component.Name,
+	}
+
+	var deployment appsV1.Deployment
+	var hash string
+	suite.Eventually(func() bool {
+		if err := suite.K8sClient.Get(context.Background(), key, &deployment); err != nil {
+			return false
+		}
+
+		envs := deployment.Spec.Template.Spec.Containers[0].Env
+		hash = deployment.Spec.Template.Annotations[AnnoSharedEnvHash]
+
+		return len(envs) == 2 &&
+			envs[0].Value == "postgres://db:5432" &&
+			envs[1].ValueFrom != nil && envs[1].ValueFrom.SecretKeyRef.Name == "credentials" &&
+			hash != ""
+	}, "external envs are not resolved")
+
+	configMap.Data["DATABASE_URL"] = "postgres://new-db:5432"
+	suite.updateObject(&configMap)
+
+	secret.Data["password"] = []byte("bar")
+	suite.updateObject(&secret)
+
+	suite.Eventually(func() bool {
+		if err := suite.K8sClient.Get(context.Background(), key, &deployment); err != nil {
+			return false
+		}
+
+		return deployment.Spec.Template.Spec.Containers[0].Env[0].Value == "postgres://new-db:5432" &&
+			deployment.Spec.Template.Annotations[AnnoSharedEnvHash] != hash
+	}, "shared env changes should re-roll the component")
+}
+
+func (suite *ComponentControllerSuite) TestSecretEnvsAndFiles() {
+	component := generateEmptyComponent(suite.ns.Name)
+	component.Spec.Env = []v1alpha1.EnvVar{
+		{Name: "PASSWORD", Value: "db-credentials/password", Type: v1alpha1.EnvVarTypeSecret},
+	}
+	component.Spec.PreInjectedFiles = []v1alpha1.PreInjectFile{
+		{
+			MountPath: "/etc/tls/tls.key",
+			Readonly:  true,
+			SecretRef: &v1alpha1.SecretKeyReference{Name: "tls", Key: "tls.key"},
+		},
+	}
+	suite.createComponent(component)
+
+	key := types.NamespacedName{
+		Namespace: component.Namespace,
+		Name:      component.Name,
+	}
+
+	var deployment appsV1.Deployment
+	suite.Eventually(func() bool {
+		return suite.K8sClient.Get(context.Background(), key, &deployment) == nil
+	}, "can't get deployment")
+
+	podSpec := deployment.Spec.Template.Spec
+	env := podSpec.Containers[0].Env[0]
+	suite.Equal("", env.Value)
+	suite.Equal("db-credentials", env.ValueFrom.SecretKeyRef.Name)
+	suite.Equal("password", env.ValueFrom.SecretKeyRef.Key)
+
+	// secret files don't need the inject-files init container
+	suite.Len(podSpec.InitContainers, 0)
+	suite.Len(podSpec.Volumes, 1)
+	suite.Equal("tls", podSpec.Volumes[0].Secret.SecretName)
+	suite.Equal("tls.key", podSpec.Volumes[0].Secret.Items[0].Key)
+
+	mount := podSpec.Containers[0].VolumeMounts[0]
+	suite.Equal("/etc/tls/tls.key", mount.MountPath)
+	suite.Equal("tls.key", mount.SubPath)
+	suite.True(mount.ReadOnly)
+}
+
+func (suite *ComponentControllerSuite) TestInitContainersAndSidecars() {
+	component := generateEmptyComponent(suite.ns.Name, v1alpha1.WorkloadTypeStatefulSet)
+	component.Spec.Volumes = []v1alpha1.Volume{
+		{
+			Path: "/var/log/app",
+			Size: resource.MustParse("1Gi"),
+			Type: v1alpha1.VolumeTypeTemporaryDisk,
+		},
+	}
+	component.Spec.InitContainers = []v1alpha1.ComponentContainer{
+		{Name: "migrate", Image: "foo:bar", Command: []string{"./migrate"}},
+	}
+	component.Spec.Sidecars = []v1alpha1.ComponentContainer{
+		{
+			Name:  "log-shipper",
+			Image: "fluent-bit",
+			Env: []v1alpha1.EnvVar{
+				{Name: "FOO", Value: "bar"},
+			},
+			VolumeMounts: []v1alpha1.ContainerVolumeMount{
+				{Volume: "/var/log/app", MountPath: "/logs", ReadOnly: true},
+			},
+		},
+	}
+	suite.createComponent(component)
+
+	key := types.NamespacedName{
+		Namespace: component.Namespace,
+		Name:      component.Name,
+	}
+
+	var sts appsV1.StatefulSet
+	suite.Eventually(func() bool {
+		return suite.K8sClient.Get(context.Background(), key, &sts) == nil
+	}, "can't get statefulset")
+
+	podSpec := sts.Spec.Template.Spec
+	suite.Len(podSpec.InitContainers, 1)
+	suite.Equal([]string{"./migrate"}, podSpec.InitContainers[0].Command)
+
+	suite.Len(podSpec.Containers, 2)
+	sidecar := podSpec.Containers[1]
+	suite.Equal("log-shipper", sidecar.Name)
+	suite.Equal("bar", sidecar.Env[0].Value)
+
+	// the volume is shared with the main container
+	suite.Len(sidecar.VolumeMounts, 1)
+	suite.Equal(podSpec.Containers[0].VolumeMounts[0].Name, sidecar.VolumeMounts[0].Name)
+	suite.Equal("/logs", sidecar.VolumeMounts[0].MountPath)
+	suite.True(sidecar.VolumeMounts[0].ReadOnly)
+}
+
+func (suite *ComponentControllerSuite) TestDisruptionBudgetAndTopologySpread() {
+	replicas := int32(3)
+	minAvailable := intstr.FromInt(2)
+
+	component := generateEmptyComponent(suite.ns.Name)
+	component.Spec.Replicas = &replicas
+	component.Spec.MinAvailable = &minAvailable
+	component.Spec.TopologySpread = []v1alpha1.TopologySpread{
+		{Topology: v1alpha1.TopologySpreadZone},
+	}
+	suite.createComponent(component)
+
+	key := types.NamespacedName{
+		Namespace: component.Namespace,
+		Name:      component.Name,
+	}
+
+	var pdb policyV1beta1.PodDisruptionBudget
+	suite.Eventually(func() bool {
+		return suite.K8sClient.Get(context.Background(), key, &pdb) == nil
+	}, "can't get pdb")
+
+	suite.Equal(minAvailable, *pdb.Spec.MinAvailable)
+	suite.Equal(component.Name, pdb.Spec.Selector.MatchLabels[v1alpha1.KalmLabelComponentKey])
+
+	var deployment appsV1.Deployment
+	suite.Eventually(func() bool {
+		return suite.K8sClient.Get(context.Background(), key, &deployment) == nil
+	}, "can't get deployment")
+
+	constraints := deployment.Spec.Template.Spec.TopologySpreadConstraints
+	suite.Len(constraints, 1)
+	suite.Equal(coreV1.LabelZoneFailureDomainStable, constraints[0].TopologyKey)
+	suite.Equal(int32(1), constraints[0].MaxSkew)
+	suite.Equal(coreV1.DoNotSchedule, constraints[0].WhenUnsatisfiable)
+
+	suite.reloadComponent(component)
+	component.Spec.MinAvailable = nil
+	suite.updateComponent(component)
+
+	suite.Eventually(func() bool {
+		return errors.IsNotFound(suite.K8sClient.Get(context.Background(), key, &pdb))
+	}, "pdb should be deleted")
+}
+
+func (suite *ComponentControllerSuite) TestSchedulingOptions() {
+	component := generateEmptyComponent(suite.ns.Name, v1alpha1.WorkloadTypeCronjob)
+	component.Spec.Schedule = "*/5 * * * *"
+	component.Spec.NodeSelectorLabels = map[string]string{"pool": "batch"}
+	component.Spec.NodeAffinity = &v1alpha1.NodeAffinity{
+		Required: []coreV1.NodeSelectorRequirement{
+			{Key: "gpu", Operator: coreV1.NodeSelectorOpDoesNotExist},
+		},
+		Preferred: []v1alpha1.PreferredNodeAffinity{
+			{
+				Weight: 10,
+				Expressions: []coreV1.NodeSelectorRequirement{
+					{Key: "lifecycle", Operator: coreV1.NodeSelectorOpIn, Values: []string{"spot"}},
+				},
+			},
+		},
+	}
+	component.Spec.Tolerations = []coreV1.Toleration{
+		{Key: "lifecycle", Operator: coreV1.TolerationOpEqual, Value: "spot", Effect: coreV1.TaintEffectNoSchedule},
+	}
+	component.Spec.PriorityClassName = "batch-low"
+	suite.createComponent(component)
+
+	key := types.NamespacedName{
+		Namespace: component.Namespace,
+		Name:      component.Name,
+	}
+
+	var cronJob batchV1Beta1.CronJob
+	suite.Eventually(func() bool {
+		return suite.K8sClient.Get(context.Background(), key, &cronJob) == nil
+	}, "can't get cronjob")
+
+	podSpec := cronJob.Spec.JobTemplate.Spec.Template.Spec
+	suite.Equal(component.Spec.Tolerations, podSpec.Tolerations)
+	suite.Equal("batch-low", podSpec.PriorityClassName)
+
+	nodeAffinity := podSpec.Affinity.NodeAffinity
+	terms := nodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms
+	suite.Len(terms, 1)
+	suite.Len(terms[0].MatchExpressions, 2)
+	suite.Equal("pool", terms[0].MatchExpressions[0].Key)
+	suite.Equal("gpu", terms[0].MatchExpressions[1].Key)
+
+	suite.Len(nodeAffinity.PreferredDuringSchedulingIgnoredDuringExecution, 1)
+	suite.Equal(int32(10), nodeAffinity.PreferredDuringSchedulingIgnoredDuringExecution[0].Weight)
+}
+
+func (suite *ComponentControllerSuite) TestJobWorkload() {
+	component := generateEmptyComponent(suite.ns.Name, v1alpha1.WorkloadTypeJob)
+	suite.createComponent(component)
+
+	var job batchV1.Job
+	suite.Eventually(func() bool {
+		return suite.K8sClient.Get(context.Background(), types.NamespacedName{
+			Namespace: component.Namespace,
+			Name:      JobRunName(component.Name, InitialJobRunID),
+		}, &job) == nil
+	}, "can't get the initial job run")
+
+	suite.Eventually(func() bool {
+		suite.reloadComponent(component)
+		return component.Status.LastJobRunID == InitialJobRunID
+	}, "last job run id is not updated")
+
+	component.Spec.PendingJobRuns = []v1alpha1.JobRunRequest{
+		{ID: "abc", Env: []v1alpha1.EnvVar{{Name: "foo", Value: "baz"}}},
+	}
+	suite.updateComponent(component)
+
+	suite.Eventually(func() bool {
+		return suite.K8sClient.Get(context.Background(), types.NamespacedName{
+			Namespace: component.Namespace,
+			Name:      JobRunName(component.Name, "abc"),
+		}, &job) == nil
+	}, "can't get the triggered job run")
+
+	suite.Equal("abc", job.Labels[v1alpha1.KalmLabelJobRunIDKey])
+	suite.Equal("baz", job.Spec.Template.Spec.Containers[0].Env[0].Value)
+
+	suite.Eventually(func() bool {
+		suite.reloadComponent(component)
+		return len(component.Spec.PendingJobRuns) == 0 && component.Status.LastJobRunID == "abc"
+	}, "pending job runs are not cleaned")
+}
+
+func (suite *ComponentControllerSuite) TestCronJobConfig() {
+	component := generateEmptyComponent(suite.ns.Name, v1alpha1.WorkloadTypeCronjob)
+	component.Spec.Schedule = "0 2 * * *"
+	component.Spec.CronJob = &v1alpha1.CronJobConfig{
+		ConcurrencyPolicy: batchV1Beta1.ForbidConcurrent,
+		Suspend:           true,
+		TimeZone:          "Asia/Shanghai",
+	}
+	suite.createComponent(component)
+
+	key := types.NamespacedName{
+		Namespace: component.Namespace,
+		Name:      component.Name,
+	}
+
+	var cronJob batchV1Beta1.CronJob
+	suite.Eventually(func() bool {
+		return suite.K8sClient.Get(context.Background(), key, &cronJob) == nil
+	}, "can't get cronjob")
+
+	suite.Equal("CRON_TZ=Asia/Shanghai 0 2 * * *", cronJob.Spec.Schedule)
+	suite.Equal(batchV1Beta1.ForbidConcurrent, cronJob.Spec.ConcurrencyPolicy)
+	suite.True(*cronJob.Spec.Suspend)
+	suite.Equal(component.Name, cronJob.Spec.JobTemplate.Labels[v1alpha1.KalmLabelComponentKey])
+
+	suite.reloadComponent(component)
+	component.Spec.CronJob.Suspend = false
+	suite.updateComponent(component)
+
+	suite.Eventually(func() bool {
+		if err := suite.K8sClient.Get(context.Background(), key, &cronJob); err != nil {
+			return false
+		}
+
+		return !*cronJob.Spec.Suspend
+	}, "cronjob is not resumed")
+}
+
+func (suite *ComponentControllerSuite) TestAutoScaling() {
+	targetCPU := int32(80)
+
+	component := generateEmptyComponent(suite.ns.Name)
+	component.Spec.AutoScaling = &v1alpha1.AutoScalingConfig{
+		MinReplicas:                    2,
+		MaxReplicas:                    4,
+		TargetCPUUtilizationPercentage: &targetCPU,
+	}
+	suite.createComponent(component)
+
+	key := types.NamespacedName{
+		Namespace: component.Namespace,
+		Name:      component.Name,
+	}
+
+	var hpa autoscalingV2beta2.HorizontalPodAutoscaler
+	suite.Eventually(func() bool {
+		return suite.K8sClient.Get(context.Background(), key, &hpa) == nil
+	}, "can't get hpa")
+
+	suite.Equal(int32(2), *hpa.Spec.MinReplicas)
+	suite.Equal(int32(4), hpa.Spec.MaxReplicas)
+	suite.Equal(component.Name, hpa.Spec.ScaleTargetRef.Name)
+
+	var deployment appsV1.Deployment
+	suite.Eventually(func() bool {
+		return suite.K8sClient.Get(context.Background(), key, &deployment) == nil
+	}, "can't get deployment")
+	suite.Equal(int32(2), *deployment.Spec.Replicas)
+
+	// replicas changed by hpa should be kept
+	three := int32(3)
+	deployment.Spec.Replicas = &three
+	suite.updateObject(&deployment)
+
+	suite.reloadComponent(component)
+	component.Spec.AutoScaling.MaxReplicas = 5
+	suite.updateComponent(component)
+
+	suite.Eventually(func() bool {
+		if err := suite.K8sClient.Get(context.Background(), key, &hpa); err != nil {
+			return false
+		}
+
+		return hpa.Spec.MaxReplicas == 5
+	}, "hpa is not updated")
+
+	suite.Nil(suite.K8sClient.Get(context.Background(), key, &deployment))
+	suite.Equal(int32(3), *deployment.Spec.Replicas)
+
+	// scale down to 0 disables the hpa
+	zero := int32(0)
+	suite.reloadComponent(component)
+	component.Spec.Replicas = &zero
+	suite.updateComponent(component)
+
+	suite.Eventually(func() bool {
+		return errors.IsNotFound(suite.K8sClient.Get(context.Background(), key, &hpa))
+	}, "hpa should be deleted")
+}
+
+func (suite *ComponentControllerSuite) TestCanaryRollout() {
+	component := generateEmptyComponent(suite.ns.Name)
+	component.Spec.RolloutStrategy = &v1alpha1.RolloutStrategy{
+		Type:                v1alpha1.RolloutStrategyCanary,
+		Steps:               []int32{20, 50},
+		StepDurationSeconds: 60,
+	}
+	suite.createComponent(component)
+
+	key := types.NamespacedName{Namespace: component.Namespace, Name: component.Name}
+	canaryKey := types.NamespacedName{Namespace: component.Namespace, Name: getCanaryDeploymentName(component.Name)}
+
+	// the first version is deployed directly
+	var deployment appsV1.Deployment
+	suite.Eventually(func() bool {
+		return suite.K8sClient.Get(context.Background(), key, &deployment) == nil
+	}, "can't get deployment")
+
+	originalImage := component.Spec.Image
+
+	suite.reloadComponent(component)
+	component.Spec.Image = "foo:v2"
+	suite.updateComponent(component)
+
+	var canary appsV1.Deployment
+	suite.Eventually(func() bool {
+		return suite.K8sClient.Get(context.Background(), canaryKey, &canary) == nil
+	}, "can't get canary deployment")
+
+	suite.Equal("foo:v2", canary.Spec.Template.Spec.Containers[0].Image)
+	suite.Equal(CanaryVersion, canary.Spec.Template.Labels["version"])
+
+	// the stable deployment keeps running the old image
+	suite.Nil(suite.K8sClient.Get(context.Background(), key, &deployment))
+	suite.Equal(originalImage, deployment.Spec.Template.Spec.Containers[0].Image)
+
+	suite.Eventually(func() bool {
+		suite.reloadComponent(component)
+		rollout := component.Status.Rollout
+		return rollout != nil &&
+			rollout.Phase == v1alpha1.RolloutPhaseProgressing &&
+			rollout.CanaryImage == "foo:v2" &&
+			rollout.CanaryWeight == 0 &&
+			len(rollout.Steps) == 2
+	}, "rollout status is not reported")
+
+	// rolled back before the rollout is finished
+	component.Spec.Image = originalImage
+	suite.updateComponent(component)
+
+	suite.Eventually(func() bool {
+		return errors.IsNotFound(suite.K8sClient.Get(context.Background(), canaryKey, &canary))
+	}, "canary deployment should be deleted")
+
+	suite.Eventually(func() bool {
+		suite.reloadComponent(component)
+		return component.Status.Rollout.Phase == v1alpha1.RolloutPhaseAborted
+	}, "rollout should be aborted")
+}
+
+func (suite *ComponentControllerSuite) TestRevisions() {
+	limit := int32(1)
+
+	component := generateEmptyComponent(suite.ns.Name)
+	component.Annotations = map[string]string{
+		v1alpha1.KalmAnnoComponentChangedBy:  "foo@example.com",
+		v1alpha1.KalmAnnoComponentChangedVia: v1alpha1.ComponentChangedViaUI,
+	}
+	component.Spec.RevisionHistoryLimit = &limit
+	suite.createComponent(component)
+
+	listRevisions := func() []appsV1.ControllerRevision {
+		var revisionList appsV1.ControllerRevisionList
+		suite.Nil(suite.K8sClient.List(
+			context.Background(),
+			&revisionList,
+			client.InNamespace(component.Namespace),
+			client.MatchingLabels{v1alpha1.KalmLabelComponentKey: component.Name},
+		))
+
+		return revisionList.Items
+	}
+
+	suite.Eventually(func() bool {
+		revisions := listRevisions()
+		return len(revisions) == 1 && revisions[0].Revision == 1
+	}, "first revision is not created")
+
+	revisions := listRevisions()
+	suite.Equal("foo@example.com", revisions[0].Annotations[v1alpha1.KalmAnnoComponentChangedBy])
+	firstRevisionName := revisions[0].Name
+
+	suite.reloadComponent(component)
+	originalImage := component.Spec.Image
+	component.Spec.Image = "foo:v2"
+	component.Annotations[v1alpha1.KalmAnnoComponentChangedVia] = v1alpha1.ComponentChangedViaWebhook
+	suite.updateComponent(component)
+
+	suite.Eventually(func() bool {
+		return len(listRevisions()) == 2
+	}, "second revision is not created")
+
+	// rollback
+	suite.reloadComponent(component)
+	component.Spec.Image = originalImage
+	suite.updateComponent(component)
+
+	suite.Eventually(func() bool {
+		for _, revision := range listRevisions() {
+			if revision.Name == firstRevisionName && revision.Revision == 3 {
+				return true
+			}
+		}
+
+		return false
+	}, "first revision should be restored as revision 3")
+
+	suite.reloadComponent(component)
+	component.Spec.Image = "foo:v3"
+	suite.updateComponent(component)
+
+	// revision 2 is removed because of the limit
+	suite.Eventually(func() bool {
+		revisions := listRevisions()
+
+		for _, revision := range revisions {
+			if revision.Revision == 2 {
+				return false
+			}
+		}
+
+		return len(revisions) == 2
+	}, "old revisions should be removed")
+}
+
+func (suite *ComponentControllerSuite) TestStartAfterComponents() {
+	// cronjobs are available once scheduled, which doesn't need a running deployment controller
+	dependency := generateEmptyComponent(suite.ns.Name, v1alpha1.WorkloadTypeCronjob)
+	dependency.Spec.Schedule = "*/5 * * * *"
+
+	component := generateEmptyComponent(suite.ns.Name)
+	component.Spec.StartAfterComponents = []string{dependency.Name}
+	suite.createComponent(component)
+
+	suite.Eventually(func() bool {
+		suite.reloadComponent(component)
+		cond := v1alpha1.GetComponentCondition(component.Status, v1alpha1.ComponentConditionDependenciesReady)
+		return cond != nil && cond.Status == coreV1.ConditionFalse
+	}, "component should wait for dependencies")
+
+	var deployment appsV1.Deployment
+	key := types.NamespacedName{Namespace: component.Namespace, Name: component.Name}
+	suite.True(errors.IsNotFound(suite.K8sClient.Get(context.Background(), key, &deployment)))
+
+	suite.createComponent(dependency)
+
+	suite.Eventually(func() bool {
+		return suite.K8sClient.Get(context.Background(), key, &deployment) == nil
+	}, "deployment should be created after dependencies are available")
+
+	suite.reloadComponent(component)
+	suite.True(isComponentConditionTrue(component.Status, v1alpha1.ComponentConditionDependenciesReady))
+}
+
 func (suite *ComponentControllerSuite) TestVolumeTemporaryDisk() {
 	component := generateEmptyComponent(suite.ns.Name)
 	component.Spec.Volumes = []v1alpha1.Volume{
@@ -319,6 +878,25 @@ func (suite *ComponentControllerSuite) TestKalmEnabled() {
 	}, "deployment should be delete when ns is not active")
 }
 
+func (suite *ComponentControllerSuite) TestComponentStatus() {
+	component := generateEmptyComponent(suite.ns.Name)
+	suite.createComponent(component)
+
+	// there is no deployment controller in test env, so the rollout will never complete
+	suite.Eventually(func() bool {
+		suite.reloadComponent(component)
+
+		progressing := v1alpha1.GetComponentCondition(component.Status, v1alpha1.ComponentConditionProgressing)
+		available := v1alpha1.GetComponentCondition(component.Status, v1alpha1.ComponentConditionAvailable)
+
+		return component.Status.ObservedGeneration == component.Generation &&
+			component.Status.Replicas == 1 &&
+			component.Status.AvailableReplicas == 0 &&
+			progressing != nil && progressing.Status == coreV1.ConditionTrue &&
+			available != nil && available.Status == coreV1.ConditionFalse
+	}, "component status is not reported")
+}
+
 func (suite *ComponentControllerSuite) TestPorts() {
 	component := generateEmptyComponent(suite.ns.Name)
 	suite.createComponent(component)
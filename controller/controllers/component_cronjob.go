@@ -0,0 +1,62 @@
+package controllers
+
+import (
+	"fmt"
+
+	"github.com/kalmhq/kalm/controller/api/v1alpha1"
+	batchV1Beta1 "k8s.io/api/batch/v1beta1"
+)
+
+const (
+	DefaultSuccessfulJobsHistoryLimit = int32(3)
+	DefaultFailedJobsHistoryLimit     = int32(5)
+)
+
+// GetCronJobSchedule returns the schedule with the time zone of the component,
+// e.g. "CRON_TZ=Asia/Shanghai 0 2 * * *".
+func GetCronJobSchedule(component *v1alpha1.Component) string {
+	if component.Spec.CronJob == nil || component.Spec.CronJob.TimeZone == "" {
+		return component.Spec.Schedule
+	}
+
+	return fmt.Sprintf("CRON_TZ=%s %s", component.Spec.CronJob.TimeZone, component.Spec.Schedule)
+}
+
+// buildCronJobSpec builds the cronjob spec without the job template.
+func buildCronJobSpec(component *v1alpha1.Component) batchV1Beta1.CronJobSpec {
+	successfulJobsHistoryLimit := DefaultSuccessfulJobsHistoryLimit
+	failedJobsHistoryLimit := DefaultFailedJobsHistoryLimit
+
+	spec := batchV1Beta1.CronJobSpec{
+		Schedule:                   GetCronJobSchedule(component),
+		ConcurrencyPolicy:          batchV1Beta1.AllowConcurrent,
+		SuccessfulJobsHistoryLimit: &successfulJobsHistoryLimit,
+		FailedJobsHistoryLimit:     &failedJobsHistoryLimit,
+	}
+
+	if config := component.Spec.CronJob; config != nil {
+		if config.ConcurrencyPolicy != "" {
+			spec.ConcurrencyPolicy = config.ConcurrencyPolicy
+		}
+
+		suspend := config.Suspend
+		spec.Suspend = &suspend
+		spec.StartingDeadlineSeconds = config.StartingDeadlineSeconds
+
+		if config.SuccessfulJobsHistoryLimit != nil {
+			spec.SuccessfulJobsHistoryLimit = config.SuccessfulJobsHistoryLimit
+		}
+
+		if config.FailedJobsHistoryLimit != nil {
+			spec.FailedJobsHistoryLimit = config.FailedJobsHistoryLimit
+		}
+	}
+
+	// cronjobs of a suspended application don't create jobs until it's resumed
+	if isComponentLabeledAsSuspended(component) {
+		suspend := true
+		spec.Suspend = &suspend
+	}
+
+	return spec
+}
@@ -0,0 +1,48 @@
+package controllers
+
+import (
+	"testing"
+
+	"github.com/kalmhq/kalm/controller/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	batchV1Beta1 "k8s.io/api/batch/v1beta1"
+)
+
+func TestBuildCronJobSpec(t *testing.T) {
+	component := &v1alpha1.Component{
+		Spec: v1alpha1.ComponentSpec{
+			WorkloadType: v1alpha1.WorkloadTypeCronjob,
+			Schedule:     "0 2 * * *",
+		},
+	}
+
+	spec := buildCronJobSpec(component)
+	assert.Equal(t, "0 2 * * *", spec.Schedule)
+	assert.Equal(t, batchV1Beta1.AllowConcurrent, spec.ConcurrencyPolicy)
+	assert.Equal(t, int32(3), *spec.SuccessfulJobsHistoryLimit)
+	assert.Equal(t, int32(5), *spec.FailedJobsHistoryLimit)
+	assert.Nil(t, spec.Suspend)
+
+	deadline := int64(120)
+	failedLimit := int32(1)
+	component.Spec.CronJob = &v1alpha1.CronJobConfig{
+		ConcurrencyPolicy:       batchV1Beta1.ReplaceConcurrent,
+		Suspend:                 true,
+		StartingDeadlineSeconds: &deadline,
+		FailedJobsHistoryLimit:  &failedLimit,
+		TimeZone:                "Asia/Shanghai",
+	}
+
+	spec = buildCronJobSpec(component)
+	assert.Equal(t, "CRON_TZ=Asia/Shanghai 0 2 * * *", spec.Schedule)
+	assert.Equal(t, batchV1Beta1.ReplaceConcurrent, spec.ConcurrencyPolicy)
+	assert.True(t, *spec.Suspend)
+	assert.Equal(t, &deadline, spec.StartingDeadlineSeconds)
+	assert.Equal(t, int32(3), *spec.SuccessfulJobsHistoryLimit)
+	assert.Equal(t, int32(1), *spec.FailedJobsHistoryLimit)
+
+	// cronjobs of a suspended application are suspended whatever the config is
+	component.Spec.CronJob.Suspend = false
+	component.Labels = map[string]string{v1alpha1.KalmLabelKeySuspended: "true"}
+	assert.True(t, *buildCronJobSpec(component).Suspend)
+}
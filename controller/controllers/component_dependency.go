@@ -0,0 +1,144 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/kalmhq/kalm/controller/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	ComponentReasonWaitingForDependencies = "WaitingForDependencies"
+	ComponentReasonDependenciesAvailable  = "DependenciesAvailable"
+)
+
+// loadBlockingDependencies finds components in startAfterComponents which are not available yet.
+func (r *ComponentReconcilerTask) loadBlockingDependencies() error {
+	r.blockingDependencies = nil
+
+	for _, name := range r.component.Spec.StartAfterComponents {
+		var dep v1alpha1.Component
+
+		if err := r.Reader.Get(r.ctx, types.NamespacedName{Namespace: r.component.Namespace, Name: name}, &dep); err != nil {
+			if !errors.IsNotFound(err) {
+				return err
+			}
+
+			r.blockingDependencies = append(r.blockingDependencies, fmt.Sprintf("component %s not found", name))
+			continue
+		}
+
+		if !v1alpha1.IsComponentAvailable(dep) {
+			r.blockingDependencies = append(r.blockingDependencies, fmt.Sprintf("component %s is not available", name))
+		}
+	}
+
+	return nil
+}
+
+func (r *ComponentReconcilerTask) hasWorkload() bool {
+	switch r.component.Spec.WorkloadType {
+	case v1alpha1.WorkloadTypeServer, "":
+		return r.deployment != nil
+	case v1alpha1.WorkloadTypeCronjob:
+		return r.cronJob != nil
+	case v1alpha1.WorkloadTypeDaemonSet:
+		return r.daemonSet != nil
+	case v1alpha1.WorkloadTypeStatefulSet:
+		return r.statefulSet != nil
+	case v1alpha1.WorkloadTypeJob:
+		return r.lastJobRunID != ""
+	}
+
+	return false
+}
+
+func (r *ComponentReconcilerTask) dependenciesMessage() string {
+	return "waiting for dependencies: " + strings.Join(r.blockingDependencies, ", ")
+}
+
+// isHeldBackByDependencies returns true if the workload should not be created yet.
+func (r *ComponentReconcilerTask) isHeldBackByDependencies() (bool, error) {
+	if len(r.component.Spec.StartAfterComponents) == 0 || r.hasWorkload() {
+		return false, nil
+	}
+
+	if err := r.loadBlockingDependencies(); err != nil {
+		return false, err
+	}
+
+	if len(r.blockingDependencies) == 0 {
+		return false, nil
+	}
+
+	// only emit the event when the reason changes
+	cond := v1alpha1.GetComponentCondition(r.component.Status, v1alpha1.ComponentConditionDependenciesReady)
+	if cond == nil || cond.Status != corev1.ConditionFalse || cond.Message != r.dependenciesMessage() {
+		r.NormalEvent(ComponentReasonWaitingForDependencies, r.dependenciesMessage())
+	}
+
+	return true, nil
+}
+
+func (r *ComponentReconcilerTask) getDependenciesCondition() *v1alpha1.ComponentCondition {
+	if len(r.blockingDependencies) > 0 {
+		return &v1alpha1.ComponentCondition{
+			Type:    v1alpha1.ComponentConditionDependenciesReady,
+			Status:  corev1.ConditionFalse,
+			Reason:  ComponentReasonWaitingForDependencies,
+			Message: r.dependenciesMessage(),
+		}
+	}
+
+	if len(r.component.Spec.StartAfterComponents) > 0 ||
+		v1alpha1.GetComponentCondition(r.component.Status, v1alpha1.ComponentConditionDependenciesReady) != nil {
+		return &v1alpha1.ComponentCondition{
+			Type:   v1alpha1.ComponentConditionDependenciesReady,
+			Status: corev1.ConditionTrue,
+			Reason: ComponentReasonDependenciesAvailable,
+		}
+	}
+
+	return nil
+}
+
+// DependentComponentsMapper enqueues components which start after the changed component.
+type DependentComponentsMapper struct {
+	*BaseReconciler
+}
+
+func (r *DependentComponentsMapper) Map(object handler.MapObject) []reconcile.Request {
+	var componentList v1alpha1.ComponentList
+	if err := r.Reader.List(context.Background(), &componentList, client.InNamespace(object.Meta.GetNamespace())); err != nil {
+		r.Log.Error(err, "Can't list components in dependent components mapper.")
+		return nil
+	}
+
+	var res []reconcile.Request
+
+	for _, component := range componentList.Items {
+		for _, dep := range component.Spec.StartAfterComponents {
+			if dep != object.Meta.GetName() {
+				continue
+			}
+
+			res = append(res, reconcile.Request{
+				NamespacedName: types.NamespacedName{
+					Name:      component.Name,
+					Namespace: component.Namespace,
+				},
+			})
+
+			break
+		}
+	}
+
+	return res
+}
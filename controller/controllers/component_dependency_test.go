@@ -0,0 +1,51 @@
+package controllers
+
+import (
+	"testing"
+
+	"github.com/kalmhq/kalm/controller/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	appsV1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestGetDependenciesCondition(t *testing.T) {
+	task := &ComponentReconcilerTask{
+		component: &v1alpha1.Component{},
+	}
+
+	assert.Nil(t, task.getDependenciesCondition())
+
+	task.component.Spec.StartAfterComponents = []string{"db", "cache"}
+	task.blockingDependencies = []string{"component db not found", "component cache is not available"}
+
+	cond := task.getDependenciesCondition()
+	assert.Equal(t, corev1.ConditionFalse, cond.Status)
+	assert.Equal(t, ComponentReasonWaitingForDependencies, cond.Reason)
+	assert.Equal(t, "waiting for dependencies: component db not found, component cache is not available", cond.Message)
+
+	task.blockingDependencies = nil
+	cond = task.getDependenciesCondition()
+	assert.Equal(t, corev1.ConditionTrue, cond.Status)
+
+	// the condition is kept after dependencies are removed
+	task.component.Spec.StartAfterComponents = nil
+	task.component.Status.Conditions = []v1alpha1.ComponentCondition{*cond}
+	assert.Equal(t, corev1.ConditionTrue, task.getDependenciesCondition().Status)
+}
+
+func TestHasWorkload(t *testing.T) {
+	task := &ComponentReconcilerTask{
+		component: &v1alpha1.Component{
+			Spec: v1alpha1.ComponentSpec{WorkloadType: v1alpha1.WorkloadTypeServer},
+		},
+	}
+
+	assert.False(t, task.hasWorkload())
+
+	task.deployment = &appsV1.Deployment{}
+	assert.True(t, task.hasWorkload())
+
+	task.component.Spec.WorkloadType = v1alpha1.WorkloadTypeStatefulSet
+	assert.False(t, task.hasWorkload())
+}
@@ -0,0 +1,177 @@
+package controllers
+
+import (
+	"context"
+	"crypto/md5"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/kalmhq/kalm/controller/api/v1alpha1"
+	"github.com/kalmhq/kalm/controller/lib/files"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// pods will be restarted when the value of this annotation is changed
+const AnnoFilesHash = "core.kalm.dev/files-hash"
+
+func (r *ComponentReconcilerTask) loadKalmFiles() (*corev1.ConfigMap, error) {
+	if r.kalmFiles != nil {
+		return r.kalmFiles, nil
+	}
+
+	var configMap corev1.ConfigMap
+	key := types.NamespacedName{Namespace: r.component.Namespace, Name: files.KALM_CONFIG_MAP_NAME}
+
+	if err := r.Reader.Get(r.ctx, key, &configMap); err != nil {
+		if errors.IsNotFound(err) {
+			return nil, fmt.Errorf("config map %s not found in namespace %s", files.KALM_CONFIG_MAP_NAME, r.component.Namespace)
+		}
+
+		return nil, err
+	}
+
+	r.kalmFiles = &configMap
+
+	return r.kalmFiles, nil
+}
+
+// buildFileVolume mounts a file or a dir of the kalm-files config map.
+// A file is mounted with subPath, all files under a dir are projected with their relative paths.
+func (r *ComponentReconcilerTask) buildFileVolume(volName string, disk v1alpha1.Volume) (*corev1.Volume, *corev1.VolumeMount, error) {
+	configMap, err := r.loadKalmFiles()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	root, err := files.GetFileItemTree(configMap, disk.FilePath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("file %s not found in %s", disk.FilePath, files.KALM_CONFIG_MAP_NAME)
+	}
+
+	volumeMount := &corev1.VolumeMount{
+		Name:      volName,
+		MountPath: disk.Path,
+		ReadOnly:  true,
+	}
+
+	var items []corev1.KeyToPath
+
+	for _, file := range files.ListFiles(root) {
+		relativePath := path.Base(file.AbsPath)
+
+		if root.IsDir {
+			relativePath = strings.TrimPrefix(file.AbsPath, strings.TrimSuffix(root.AbsPath, "/")+"/")
+		}
+
+		items = append(items, corev1.KeyToPath{
+			Key:  files.EncodeFilePath(file.AbsPath),
+			Path: relativePath,
+		})
+
+		r.filesHashSources = append(r.filesHashSources, fmt.Sprintf("%s=%x", file.AbsPath, md5.Sum([]byte(file.Content))))
+	}
+
+	if !root.IsDir {
+		volumeMount.SubPath = items[0].Path
+	}
+
+	// a config map volume without items projects all keys, use an empty dir instead
+	if len(items) == 0 {
+		return &corev1.Volume{
+			Name: volName,
+			VolumeSource: corev1.VolumeSource{
+				EmptyDir: &corev1.EmptyDirVolumeSource{},
+			},
+		}, volumeMount, nil
+	}
+
+	return &corev1.Volume{
+		Name: volName,
+		VolumeSource: corev1.VolumeSource{
+			ConfigMap: &corev1.ConfigMapVolumeSource{
+				LocalObjectReference: corev1.LocalObjectReference{Name: configMap.Name},
+				Items:                items,
+			},
+		},
+	}, volumeMount, nil
+}
+
+// getFilesHash returns a digest of all mounted files, or empty string if no file is mounted.
+func (r *ComponentReconcilerTask) getFilesHash() string {
+	if len(r.filesHashSources) == 0 {
+		return ""
+	}
+
+	sources := append([]string{}, r.filesHashSources...)
+	sort.Strings(sources)
+
+	return fmt.Sprintf("%x", md5.Sum([]byte(strings.Join(sources, ";"))))
+}
+
+func (r *ComponentReconcilerTask) setFilesHashAnnotation(template *corev1.PodTemplateSpec) {
+	hash := r.getFilesHash()
+
+	if hash == "" {
+		return
+	}
+
+	if template.ObjectMeta.Annotations == nil {
+		template.ObjectMeta.Annotations = make(map[string]string)
+	}
+
+	template.ObjectMeta.Annotations[AnnoFilesHash] = hash
+}
+
+func isComponentMountingFiles(component *v1alpha1.Component) bool {
+	for _, vol := range component.Spec.Volumes {
+		if vol.Type == v1alpha1.VolumeTypeFile {
+			return true
+		}
+	}
+
+	return false
+}
+
+// KalmFilesMapper enqueues all components mounting files when the kalm-files config map is changed.
+// Only components whose mounted files are changed will be restarted, as the hash of other components stays the same.
+type KalmFilesMapper struct {
+	*BaseReconciler
+}
+
+func (r *KalmFilesMapper) Map(object handler.MapObject) []reconcile.Request {
+	if object.Meta.GetName() != files.KALM_CONFIG_MAP_NAME {
+		return nil
+	}
+
+	var componentList v1alpha1.ComponentList
+	if err := r.Reader.List(context.Background(), &componentList, client.InNamespace(object.Meta.GetNamespace())); err != nil {
+		r.Log.Error(err, "Can't list components in kalm files mapper.")
+		return nil
+	}
+
+	var res []reconcile.Request
+
+	for i := range componentList.Items {
+		component := &componentList.Items[i]
+
+		if !isComponentMountingFiles(component) {
+			continue
+		}
+
+		res = append(res, reconcile.Request{
+			NamespacedName: types.NamespacedName{
+				Name:      component.Name,
+				Namespace: component.Namespace,
+			},
+		})
+	}
+
+	return res
+}
@@ -0,0 +1,85 @@
+package controllers
+
+import (
+	"testing"
+
+	"github.com/kalmhq/kalm/controller/api/v1alpha1"
+	"github.com/kalmhq/kalm/controller/lib/files"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newFilesTestTask() *ComponentReconcilerTask {
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metaV1.ObjectMeta{Name: files.KALM_CONFIG_MAP_NAME, Namespace: "default"},
+		Data: map[string]string{
+			files.KALM_SLASH_REPLACER: files.KALM_PERSISTENT_DIR_PLACEHOLDER,
+		},
+	}
+
+	_ = files.AddFile(configMap, &files.File{Path: "/nginx/nginx.conf", Content: "nginx"})
+	_ = files.AddFile(configMap, &files.File{Path: "/nginx/conf.d/default.conf", Content: "default"})
+	_ = files.AddFile(configMap, &files.File{Path: "/empty", IsDir: true})
+
+	return &ComponentReconcilerTask{
+		component: &v1alpha1.Component{ObjectMeta: metaV1.ObjectMeta{Name: "web", Namespace: "default"}},
+		kalmFiles: configMap,
+	}
+}
+
+func TestBuildFileVolume(t *testing.T) {
+	task := newFilesTestTask()
+
+	volume, volumeMount, err := task.buildFileVolume("nginx", v1alpha1.Volume{
+		Type:     v1alpha1.VolumeTypeFile,
+		Path:     "/etc/nginx",
+		FilePath: "/nginx",
+	})
+
+	assert.Nil(t, err)
+	assert.Equal(t, "/etc/nginx", volumeMount.MountPath)
+	assert.Equal(t, "", volumeMount.SubPath)
+	assert.Equal(t, files.KALM_CONFIG_MAP_NAME, volume.ConfigMap.Name)
+	assert.Equal(t, []corev1.KeyToPath{
+		{Key: "__D__nginx__D__conf.d__D__default.conf", Path: "conf.d/default.conf"},
+		{Key: "__D__nginx__D__nginx.conf", Path: "nginx.conf"},
+	}, volume.ConfigMap.Items)
+
+	volume, volumeMount, err = task.buildFileVolume("nginx-conf", v1alpha1.Volume{
+		Type:     v1alpha1.VolumeTypeFile,
+		Path:     "/etc/nginx/nginx.conf",
+		FilePath: "/nginx/nginx.conf",
+	})
+
+	assert.Nil(t, err)
+	assert.Equal(t, "nginx.conf", volumeMount.SubPath)
+	assert.Equal(t, []corev1.KeyToPath{{Key: "__D__nginx__D__nginx.conf", Path: "nginx.conf"}}, volume.ConfigMap.Items)
+
+	volume, _, err = task.buildFileVolume("empty", v1alpha1.Volume{Type: v1alpha1.VolumeTypeFile, Path: "/empty", FilePath: "/empty"})
+	assert.Nil(t, err)
+	assert.NotNil(t, volume.EmptyDir)
+
+	_, _, err = task.buildFileVolume("missing", v1alpha1.Volume{Type: v1alpha1.VolumeTypeFile, Path: "/missing", FilePath: "/missing"})
+	assert.NotNil(t, err)
+}
+
+func TestFilesHashChangesWithContent(t *testing.T) {
+	vol := v1alpha1.Volume{Type: v1alpha1.VolumeTypeFile, Path: "/etc/nginx", FilePath: "/nginx"}
+
+	task := newFilesTestTask()
+	_, _, _ = task.buildFileVolume("nginx", vol)
+	hash := task.getFilesHash()
+	assert.NotEqual(t, "", hash)
+
+	task = newFilesTestTask()
+	_ = files.UpdateFile(task.kalmFiles, &files.File{Path: "/nginx/nginx.conf", Content: "changed"})
+	_, _, _ = task.buildFileVolume("nginx", vol)
+	assert.NotEqual(t, hash, task.getFilesHash())
+
+	// files out of the mounted dir don't affect the hash
+	task = newFilesTestTask()
+	_ = files.AddFile(task.kalmFiles, &files.File{Path: "/other", Content: "other"})
+	_, _, _ = task.buildFileVolume("nginx", vol)
+	assert.Equal(t, hash, task.getFilesHash())
+}
@@ -0,0 +1,177 @@
+package controllers
+
+import (
+	"github.com/kalmhq/kalm/controller/api/v1alpha1"
+	autoscalingV2beta2 "k8s.io/api/autoscaling/v2beta2"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// +kubebuilder:rbac:groups=autoscaling,resources=horizontalpodautoscalers,verbs=get;list;watch;create;update;patch;delete
+
+// pod metric exposed by a custom metrics adapter (e.g. prometheus-adapter) from istio_requests_total
+const IstioRequestsPerSecondMetricName = "istio_requests_per_second"
+
+// isAutoScalingEnabled returns false if the component is scaled down to 0 on purpose,
+// e.g. by the exceeding quota logic or because it's idle. HPA doesn't work with 0 replicas anyway.
+func isAutoScalingEnabled(component *v1alpha1.Component) bool {
+	if component.Spec.AutoScaling == nil || v1alpha1.IsComponentScaledToZero(component) {
+		return false
+	}
+
+	if component.Spec.WorkloadType != v1alpha1.WorkloadTypeServer && component.Spec.WorkloadType != "" {
+		return false
+	}
+
+	return component.Spec.Replicas == nil || *component.Spec.Replicas > 0
+}
+
+func buildHPAMetrics(config *v1alpha1.AutoScalingConfig) []autoscalingV2beta2.MetricSpec {
+	var metrics []autoscalingV2beta2.MetricSpec
+
+	if config.TargetCPUUtilizationPercentage != nil {
+		metrics = append(metrics, autoscalingV2beta2.MetricSpec{
+			Type: autoscalingV2beta2.ResourceMetricSourceType,
+			Resource: &autoscalingV2beta2.ResourceMetricSource{
+				Name: corev1.ResourceCPU,
+				Target: autoscalingV2beta2.MetricTarget{
+					Type:               autoscalingV2beta2.UtilizationMetricType,
+					AverageUtilization: config.TargetCPUUtilizationPercentage,
+				},
+			},
+		})
+	}
+
+	if config.TargetMemoryUtilizationPercentage != nil {
+		metrics = append(metrics, autoscalingV2beta2.MetricSpec{
+			Type: autoscalingV2beta2.ResourceMetricSourceType,
+			Resource: &autoscalingV2beta2.ResourceMetricSource{
+				Name: corev1.ResourceMemory,
+				Target: autoscalingV2beta2.MetricTarget{
+					Type:               autoscalingV2beta2.UtilizationMetricType,
+					AverageUtilization: config.TargetMemoryUtilizationPercentage,
+				},
+			},
+		})
+	}
+
+	if config.TargetIstioRequestsPerSecond != nil {
+		averageValue := resource.NewQuantity(int64(*config.TargetIstioRequestsPerSecond), resource.DecimalSI)
+
+		metrics = append(metrics, autoscalingV2beta2.MetricSpec{
+			Type: autoscalingV2beta2.PodsMetricSourceType,
+			Pods: &autoscalingV2beta2.PodsMetricSource{
+				Metric: autoscalingV2beta2.MetricIdentifier{
+					Name: IstioRequestsPerSecondMetricName,
+				},
+				Target: autoscalingV2beta2.MetricTarget{
+					Type:         autoscalingV2beta2.AverageValueMetricType,
+					AverageValue: averageValue,
+				},
+			},
+		})
+	}
+
+	return metrics
+}
+
+func (r *ComponentReconcilerTask) LoadHorizontalPodAutoscaler() error {
+	var hpa autoscalingV2beta2.HorizontalPodAutoscaler
+	err := r.LoadItem(&hpa)
+	if err != nil {
+		return client.IgnoreNotFound(err)
+	}
+	r.hpa = &hpa
+	return nil
+}
+
+func (r *ComponentReconcilerTask) DeleteHorizontalPodAutoscaler() error {
+	if r.hpa == nil {
+		return nil
+	}
+
+	if err := r.Delete(r.ctx, r.hpa); client.IgnoreNotFound(err) != nil {
+		r.WarningEvent(err, "unable to delete HorizontalPodAutoscaler")
+		return err
+	}
+
+	r.hpa = nil
+
+	return nil
+}
+
+func (r *ComponentReconcilerTask) ReconcileHorizontalPodAutoscaler() error {
+	component := r.component
+
+	// replicas is overridden by the scaling schedule in effect, the HPA is created again once the window ends
+	if !isAutoScalingEnabled(component) || r.scheduledReplicas != nil {
+		return r.DeleteHorizontalPodAutoscaler()
+	}
+
+	minReplicas := component.Spec.AutoScaling.MinReplicas
+
+	spec := autoscalingV2beta2.HorizontalPodAutoscalerSpec{
+		ScaleTargetRef: autoscalingV2beta2.CrossVersionObjectReference{
+			APIVersion: "apps/v1",
+			Kind:       "Deployment",
+			Name:       component.Name,
+		},
+		MinReplicas: &minReplicas,
+		MaxReplicas: component.Spec.AutoScaling.MaxReplicas,
+		Metrics:     buildHPAMetrics(component.Spec.AutoScaling),
+	}
+
+	hpa := r.hpa
+
+	if hpa == nil {
+		hpa = &autoscalingV2beta2.HorizontalPodAutoscaler{
+			ObjectMeta: metaV1.ObjectMeta{
+				Name:      component.Name,
+				Namespace: component.Namespace,
+				Labels:    r.GetLabels(),
+			},
+			Spec: spec,
+		}
+
+		if err := ctrl.SetControllerReference(component, hpa, r.Scheme); err != nil {
+			r.WarningEvent(err, "unable to set owner for HorizontalPodAutoscaler")
+			return err
+		}
+
+		if err := r.Create(r.ctx, hpa); err != nil {
+			r.WarningEvent(err, "unable to create HorizontalPodAutoscaler")
+			return err
+		}
+
+		r.NormalEvent("HorizontalPodAutoscalerCreated", hpa.Name+" is created.")
+		r.hpa = hpa
+
+		return nil
+	}
+
+	// fields not managed by kalm (e.g. behavior) are kept
+	if equality.Semantic.DeepEqual(hpa.Spec.ScaleTargetRef, spec.ScaleTargetRef) &&
+		equality.Semantic.DeepEqual(hpa.Spec.MinReplicas, spec.MinReplicas) &&
+		hpa.Spec.MaxReplicas == spec.MaxReplicas &&
+		equality.Semantic.DeepEqual(hpa.Spec.Metrics, spec.Metrics) {
+		return nil
+	}
+
+	hpa.Spec.ScaleTargetRef = spec.ScaleTargetRef
+	hpa.Spec.MinReplicas = spec.MinReplicas
+	hpa.Spec.MaxReplicas = spec.MaxReplicas
+	hpa.Spec.Metrics = spec.Metrics
+
+	if err := r.Update(r.ctx, hpa); err != nil {
+		r.WarningEvent(err, "unable to update HorizontalPodAutoscaler")
+		return err
+	}
+
+	r.NormalEvent("HorizontalPodAutoscalerUpdated", hpa.Name+" is updated.")
+
+	return nil
+}
@@ -0,0 +1,61 @@
+package controllers
+
+import (
+	"testing"
+
+	"github.com/kalmhq/kalm/controller/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	autoscalingV2beta2 "k8s.io/api/autoscaling/v2beta2"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestIsAutoScalingEnabled(t *testing.T) {
+	one := int32(1)
+	zero := int32(0)
+
+	component := &v1alpha1.Component{
+		Spec: v1alpha1.ComponentSpec{
+			WorkloadType: v1alpha1.WorkloadTypeServer,
+			Replicas:     &one,
+		},
+	}
+	assert.False(t, isAutoScalingEnabled(component))
+
+	component.Spec.AutoScaling = &v1alpha1.AutoScalingConfig{MinReplicas: 1, MaxReplicas: 3}
+	assert.True(t, isAutoScalingEnabled(component))
+
+	// scaled down, e.g. exceeding quota
+	component.Spec.Replicas = &zero
+	assert.False(t, isAutoScalingEnabled(component))
+
+	// scaled to zero because it's idle
+	component.Spec.Replicas = &one
+	component.Spec.ScaleToZero = &v1alpha1.ScaleToZeroConfig{IdleSeconds: 600}
+	component.Annotations = map[string]string{v1alpha1.KalmAnnoScaledToZeroAt: "2020-10-01T00:00:00Z"}
+	assert.False(t, isAutoScalingEnabled(component))
+
+	component.Annotations = nil
+	component.Spec.WorkloadType = v1alpha1.WorkloadTypeStatefulSet
+	assert.False(t, isAutoScalingEnabled(component))
+}
+
+func TestBuildHPAMetrics(t *testing.T) {
+	cpu := int32(70)
+	rps := int32(100)
+
+	metrics := buildHPAMetrics(&v1alpha1.AutoScalingConfig{
+		MinReplicas:                    1,
+		MaxReplicas:                    3,
+		TargetCPUUtilizationPercentage: &cpu,
+		TargetIstioRequestsPerSecond:   &rps,
+	})
+
+	assert.Len(t, metrics, 2)
+	assert.Equal(t, autoscalingV2beta2.ResourceMetricSourceType, metrics[0].Type)
+	assert.Equal(t, corev1.ResourceCPU, metrics[0].Resource.Name)
+	assert.Equal(t, cpu, *metrics[0].Resource.Target.AverageUtilization)
+
+	assert.Equal(t, autoscalingV2beta2.PodsMetricSourceType, metrics[1].Type)
+	assert.Equal(t, IstioRequestsPerSecondMetricName, metrics[1].Pods.Metric.Name)
+	assert.Equal(t, int64(100), metrics[1].Pods.Target.AverageValue.Value())
+}
@@ -0,0 +1,158 @@
+package controllers
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/kalmhq/kalm/controller/api/v1alpha1"
+	batchV1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// +kubebuilder:rbac:groups=batch,resources=jobs,verbs=get;list;watch;create;update;patch;delete
+
+// the run created once a job workload is created
+const InitialJobRunID = "initial"
+
+func JobRunName(componentName, runID string) string {
+	return fmt.Sprintf("%s-%s", componentName, runID)
+}
+
+// applyJobRunOverrides merges envs and args of the run into the main container.
+func applyJobRunOverrides(template *corev1.PodTemplateSpec, componentName string, envs []corev1.EnvVar, args []string) {
+	for i := range template.Spec.Containers {
+		container := &template.Spec.Containers[i]
+
+		if container.Name != componentName {
+			continue
+		}
+
+		for _, env := range envs {
+			overridden := false
+
+			for j := range container.Env {
+				if container.Env[j].Name == env.Name {
+					container.Env[j] = env
+					overridden = true
+					break
+				}
+			}
+
+			if overridden {
+				continue
+			}
+
+			// secret values of shared envs are expanded by later env vars, which may be overridden in place
+			if strings.HasPrefix(env.Name, sharedEnvSecretEnvPrefix) {
+				container.Env = append([]corev1.EnvVar{env}, container.Env...)
+			} else {
+				container.Env = append(container.Env, env)
+			}
+		}
+
+		if len(args) > 0 {
+			container.Args = args
+		}
+	}
+}
+
+func (r *ComponentReconcilerTask) buildJobRun(podTemplateSpec *corev1.PodTemplateSpec, run v1alpha1.JobRunRequest) (*batchV1.Job, error) {
+	component := r.component
+	template := podTemplateSpec.DeepCopy()
+
+	// same as cronjob, the istio sidecar keeps the pod running after the process is completed
+	template.ObjectMeta.Annotations["sidecar.istio.io/inject"] = "false"
+	template.ObjectMeta.Labels[v1alpha1.KalmLabelJobRunIDKey] = run.ID
+
+	if template.Spec.RestartPolicy == corev1.RestartPolicyAlways || template.Spec.RestartPolicy == "" {
+		template.Spec.RestartPolicy = corev1.RestartPolicyOnFailure
+	}
+
+	envs, err := r.buildContainerEnvs(run.Env)
+	if err != nil {
+		return nil, err
+	}
+
+	applyJobRunOverrides(template, component.Name, envs, run.Args)
+
+	labels := r.GetLabels()
+	labels[v1alpha1.KalmLabelJobRunIDKey] = run.ID
+
+	job := &batchV1.Job{
+		ObjectMeta: metaV1.ObjectMeta{
+			Name:        JobRunName(component.Name, run.ID),
+			Namespace:   component.Namespace,
+			Labels:      labels,
+			Annotations: r.GetAnnotations(),
+		},
+		Spec: batchV1.JobSpec{
+			Template: *template,
+		},
+	}
+
+	if config := component.Spec.Job; config != nil {
+		job.Spec.Completions = config.Completions
+		job.Spec.Parallelism = config.Parallelism
+		job.Spec.BackoffLimit = config.BackoffLimit
+		job.Spec.TTLSecondsAfterFinished = config.TTLSecondsAfterFinished
+	}
+
+	return job, nil
+}
+
+// ReconcileJob creates a job for each pending run, or the initial run if the job workload never ran.
+// Jobs are immutable, changes of the component only affect later runs.
+func (r *ComponentReconcilerTask) ReconcileJob(podTemplateSpec *corev1.PodTemplateSpec) error {
+	// pending runs are kept until the application is resumed
+	if isComponentLabeledAsSuspended(r.component) {
+		return r.deleteUnfinishedJobs()
+	}
+
+	pendingRuns := r.component.Spec.PendingJobRuns
+
+	runs := pendingRuns
+	if r.lastJobRunID == "" && len(runs) == 0 {
+		runs = []v1alpha1.JobRunRequest{{ID: InitialJobRunID}}
+	}
+
+	for _, run := range runs {
+		job, err := r.buildJobRun(podTemplateSpec, run)
+		if err != nil {
+			return err
+		}
+
+		if err := ctrl.SetControllerReference(r.component, job, r.Scheme); err != nil {
+			r.WarningEvent(err, "unable to set owner for job")
+			return err
+		}
+
+		// the run is created already if the pending runs failed to be cleaned last time
+		if err := r.Create(r.ctx, job); err != nil && !errors.IsAlreadyExists(err) {
+			r.WarningEvent(err, "unable to create job for run %s", run.ID)
+			return err
+		} else if err == nil {
+			r.NormalEvent("JobRunCreated", job.Name+" is created.")
+		}
+
+		r.lastJobRunID = run.ID
+	}
+
+	if len(pendingRuns) == 0 {
+		return nil
+	}
+
+	copied := r.component.DeepCopy()
+	copied.Spec.PendingJobRuns = nil
+
+	// runs added in the meantime are kept by the conflict
+	if err := r.Patch(r.ctx, copied, client.MergeFromWithOptions(r.component, client.MergeFromWithOptimisticLock{})); err != nil {
+		r.WarningEvent(err, "unable to clean pending job runs")
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,100 @@
+package controllers
+
+import (
+	"testing"
+
+	"github.com/kalmhq/kalm/controller/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestApplyJobRunOverrides(t *testing.T) {
+	template := &corev1.PodTemplateSpec{
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{
+				{
+					Name: "migrate",
+					Args: []string{"up"},
+					Env:  []corev1.EnvVar{{Name: "FOO", Value: "foo"}, {Name: "BAR", Value: "bar"}},
+				},
+				{Name: "log-shipper"},
+			},
+		},
+	}
+
+	applyJobRunOverrides(template, "migrate", []corev1.EnvVar{{Name: "BAR", Value: "baz"}, {Name: "NEW", Value: "new"}}, nil)
+
+	main := template.Spec.Containers[0]
+	assert.Equal(t, []string{"up"}, main.Args)
+	assert.Equal(t, []corev1.EnvVar{
+		{Name: "FOO", Value: "foo"},
+		{Name: "BAR", Value: "baz"},
+		{Name: "NEW", Value: "new"},
+	}, main.Env)
+
+	// the secret value is defined before the overridden env var expanding it
+	applyJobRunOverrides(template, "migrate", []corev1.EnvVar{
+		{Name: "KALM_SHARED_ENV_BAR", ValueFrom: &corev1.EnvVarSource{SecretKeyRef: &corev1.SecretKeySelector{Key: "bar"}}},
+		{Name: "BAR", Value: "$(KALM_SHARED_ENV_BAR)"},
+	}, nil)
+	assert.Equal(t, "KALM_SHARED_ENV_BAR", template.Spec.Containers[0].Env[0].Name)
+	assert.Equal(t, corev1.EnvVar{Name: "BAR", Value: "$(KALM_SHARED_ENV_BAR)"}, template.Spec.Containers[0].Env[2])
+
+	applyJobRunOverrides(template, "migrate", nil, []string{"down", "1"})
+	assert.Equal(t, []string{"down", "1"}, template.Spec.Containers[0].Args)
+	assert.Empty(t, template.Spec.Containers[1].Env)
+}
+
+func TestBuildJobRun(t *testing.T) {
+	completions := int32(3)
+	ttl := int32(600)
+
+	task := &ComponentReconcilerTask{
+		ComponentReconciler: &ComponentReconciler{
+			BaseReconciler: &BaseReconciler{Recorder: record.NewFakeRecorder(100)},
+		},
+		component: &v1alpha1.Component{
+			ObjectMeta: metaV1.ObjectMeta{Name: "migrate", Namespace: "default"},
+			Spec: v1alpha1.ComponentSpec{
+				Image:        "migrate:v1",
+				WorkloadType: v1alpha1.WorkloadTypeJob,
+				Job: &v1alpha1.JobConfig{
+					Completions:             &completions,
+					TTLSecondsAfterFinished: &ttl,
+				},
+			},
+		},
+	}
+
+	template := &corev1.PodTemplateSpec{
+		ObjectMeta: metaV1.ObjectMeta{
+			Labels:      map[string]string{},
+			Annotations: map[string]string{},
+		},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{Name: "migrate", Image: "migrate:v1"}},
+		},
+	}
+
+	job, err := task.buildJobRun(template, v1alpha1.JobRunRequest{
+		ID:   "abc",
+		Env:  []v1alpha1.EnvVar{{Name: "FOO", Value: "bar"}},
+		Args: []string{"--dry-run"},
+	})
+
+	assert.Nil(t, err)
+	assert.Equal(t, "migrate-abc", job.Name)
+	assert.Equal(t, "abc", job.Labels[v1alpha1.KalmLabelJobRunIDKey])
+	assert.Equal(t, "abc", job.Spec.Template.Labels[v1alpha1.KalmLabelJobRunIDKey])
+	assert.Equal(t, &completions, job.Spec.Completions)
+	assert.Equal(t, &ttl, job.Spec.TTLSecondsAfterFinished)
+	assert.Nil(t, job.Spec.Parallelism)
+	assert.Equal(t, corev1.RestartPolicyOnFailure, job.Spec.Template.Spec.RestartPolicy)
+	assert.Equal(t, []string{"--dry-run"}, job.Spec.Template.Spec.Containers[0].Args)
+	assert.Equal(t, []corev1.EnvVar{{Name: "FOO", Value: "bar"}}, job.Spec.Template.Spec.Containers[0].Env)
+
+	// the template is not changed
+	assert.Empty(t, template.Labels)
+}
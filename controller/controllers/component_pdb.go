@@ -0,0 +1,117 @@
+package controllers
+
+import (
+	"github.com/kalmhq/kalm/controller/api/v1alpha1"
+	policyV1beta1 "k8s.io/api/policy/v1beta1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// +kubebuilder:rbac:groups=policy,resources=poddisruptionbudgets,verbs=get;list;watch;create;update;patch;delete
+
+func isDisruptionBudgetEnabled(component *v1alpha1.Component) bool {
+	if component.Spec.MinAvailable == nil {
+		return false
+	}
+
+	switch component.Spec.WorkloadType {
+	case v1alpha1.WorkloadTypeServer, v1alpha1.WorkloadTypeStatefulSet, "":
+		return true
+	default:
+		return false
+	}
+}
+
+func (r *ComponentReconcilerTask) buildPodDisruptionBudgetSpec() policyV1beta1.PodDisruptionBudgetSpec {
+	minAvailable := *r.component.Spec.MinAvailable
+
+	return policyV1beta1.PodDisruptionBudgetSpec{
+		MinAvailable: &minAvailable,
+		Selector: &metaV1.LabelSelector{
+			MatchLabels: r.getVersionSelector(StableVersion),
+		},
+	}
+}
+
+func (r *ComponentReconcilerTask) LoadPodDisruptionBudget() error {
+	var pdb policyV1beta1.PodDisruptionBudget
+	err := r.LoadItem(&pdb)
+	if err != nil {
+		return client.IgnoreNotFound(err)
+	}
+	r.pdb = &pdb
+	return nil
+}
+
+func (r *ComponentReconcilerTask) DeletePodDisruptionBudget() error {
+	if r.pdb == nil {
+		return nil
+	}
+
+	if err := r.Delete(r.ctx, r.pdb); client.IgnoreNotFound(err) != nil {
+		r.WarningEvent(err, "unable to delete PodDisruptionBudget")
+		return err
+	}
+
+	r.pdb = nil
+
+	return nil
+}
+
+func (r *ComponentReconcilerTask) ReconcilePodDisruptionBudget() error {
+	component := r.component
+
+	if !isDisruptionBudgetEnabled(component) {
+		return r.DeletePodDisruptionBudget()
+	}
+
+	spec := r.buildPodDisruptionBudgetSpec()
+	pdb := r.pdb
+
+	if pdb == nil {
+		pdb = &policyV1beta1.PodDisruptionBudget{
+			ObjectMeta: metaV1.ObjectMeta{
+				Name:      component.Name,
+				Namespace: component.Namespace,
+				Labels:    r.GetLabels(),
+			},
+			Spec: spec,
+		}
+
+		if err := ctrl.SetControllerReference(component, pdb, r.Scheme); err != nil {
+			r.WarningEvent(err, "unable to set owner for PodDisruptionBudget")
+			return err
+		}
+
+		if err := r.Create(r.ctx, pdb); err != nil {
+			r.WarningEvent(err, "unable to create PodDisruptionBudget")
+			return err
+		}
+
+		r.NormalEvent("PodDisruptionBudgetCreated", pdb.Name+" is created.")
+		r.pdb = pdb
+
+		return nil
+	}
+
+	if equality.Semantic.DeepEqual(pdb.Spec.MinAvailable, spec.MinAvailable) &&
+		equality.Semantic.DeepEqual(pdb.Spec.Selector, spec.Selector) &&
+		pdb.Spec.MaxUnavailable == nil {
+		return nil
+	}
+
+	pdb.Spec.MinAvailable = spec.MinAvailable
+	pdb.Spec.MaxUnavailable = nil
+	pdb.Spec.Selector = spec.Selector
+
+	if err := r.Update(r.ctx, pdb); err != nil {
+		r.WarningEvent(err, "unable to update PodDisruptionBudget")
+		return err
+	}
+
+	r.NormalEvent("PodDisruptionBudgetUpdated", pdb.Name+" is updated.")
+
+	return nil
+}
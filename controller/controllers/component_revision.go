@@ -0,0 +1,181 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"sort"
+
+	"github.com/kalmhq/kalm/controller/api/v1alpha1"
+	appsV1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/rand"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// +kubebuilder:rbac:groups=apps,resources=controllerrevisions,verbs=get;list;watch;create;update;patch;delete
+
+// Revisions of a component are stored as ControllerRevisions, the data is the json of the component spec.
+// A rollback reuses the revision with the same spec and bumps its revision number, same as deployments do.
+
+// ComponentRevisionData returns the spec which is recorded in revisions.
+// Fields that trigger one-off actions are excluded.
+func ComponentRevisionData(spec *v1alpha1.ComponentSpec) ([]byte, error) {
+	copied := spec.DeepCopy()
+	copied.ImmediateTrigger = false
+	copied.PendingJobRuns = nil
+
+	return json.Marshal(copied)
+}
+
+func hashComponentRevisionData(data []byte) string {
+	hasher := fnv.New32a()
+	_, _ = hasher.Write(data)
+	return rand.SafeEncodeString(fmt.Sprint(hasher.Sum32()))
+}
+
+func ComponentRevisionName(componentName string, data []byte) string {
+	return fmt.Sprintf("%s-%s", componentName, hashComponentRevisionData(data))
+}
+
+func (r *ComponentReconcilerTask) listRevisions() ([]*appsV1.ControllerRevision, error) {
+	var revisionList appsV1.ControllerRevisionList
+
+	if err := r.Reader.List(
+		r.ctx,
+		&revisionList,
+		client.InNamespace(r.component.Namespace),
+		client.MatchingLabels{v1alpha1.KalmLabelComponentKey: r.component.Name},
+	); err != nil {
+		return nil, err
+	}
+
+	var revisions []*appsV1.ControllerRevision
+
+	for i := range revisionList.Items {
+		revision := &revisionList.Items[i]
+
+		// revisions of a deleted component with the same name
+		if !metaV1.IsControlledBy(revision, r.component) {
+			continue
+		}
+
+		revisions = append(revisions, revision)
+	}
+
+	sort.Slice(revisions, func(i, j int) bool {
+		return revisions[i].Revision < revisions[j].Revision
+	})
+
+	return revisions, nil
+}
+
+func (r *ComponentReconcilerTask) setRevisionChangeCause(revision *appsV1.ControllerRevision) {
+	if revision.Annotations == nil {
+		revision.Annotations = make(map[string]string)
+	}
+
+	for _, key := range []string{v1alpha1.KalmAnnoComponentChangedBy, v1alpha1.KalmAnnoComponentChangedVia} {
+		if v, exist := r.component.Annotations[key]; exist {
+			revision.Annotations[key] = v
+		} else {
+			delete(revision.Annotations, key)
+		}
+	}
+}
+
+// ReconcileRevisions records the current spec of the component as the latest revision,
+// and removes the oldest revisions exceeding the history limit.
+func (r *ComponentReconcilerTask) ReconcileRevisions() error {
+	data, err := ComponentRevisionData(&r.component.Spec)
+	if err != nil {
+		return err
+	}
+
+	name := ComponentRevisionName(r.component.Name, data)
+
+	revisions, err := r.listRevisions()
+	if err != nil {
+		r.WarningEvent(err, "unable to list revisions")
+		return err
+	}
+
+	var latestRevision int64
+	var current *appsV1.ControllerRevision
+
+	for _, revision := range revisions {
+		latestRevision = revision.Revision
+
+		if revision.Name == name {
+			current = revision
+		}
+	}
+
+	if current == nil {
+		current = &appsV1.ControllerRevision{
+			ObjectMeta: metaV1.ObjectMeta{
+				Name:      name,
+				Namespace: r.component.Namespace,
+				Labels: map[string]string{
+					v1alpha1.KalmLabelComponentKey: r.component.Name,
+				},
+			},
+			Data:     runtime.RawExtension{Raw: data},
+			Revision: latestRevision + 1,
+		}
+
+		r.setRevisionChangeCause(current)
+
+		if err := ctrl.SetControllerReference(r.component, current, r.Scheme); err != nil {
+			r.WarningEvent(err, "unable to set owner for revision")
+			return err
+		}
+
+		if err := r.Create(r.ctx, current); err != nil {
+			// created in a previous reconcile, but the cache is not synced yet
+			if errors.IsAlreadyExists(err) {
+				return nil
+			}
+
+			r.WarningEvent(err, "unable to create revision")
+			return err
+		}
+
+		r.NormalEvent("RevisionCreated", fmt.Sprintf("revision %d is created.", current.Revision))
+		revisions = append(revisions, current)
+	} else if current.Revision != latestRevision {
+		// rolled back to an old revision
+		oldRevision := current.Revision
+		current.Revision = latestRevision + 1
+		r.setRevisionChangeCause(current)
+
+		if err := r.Update(r.ctx, current); err != nil {
+			r.WarningEvent(err, "unable to update revision")
+			return err
+		}
+
+		r.NormalEvent("RevisionUpdated", fmt.Sprintf("revision %d is restored as %d.", oldRevision, current.Revision))
+
+		sort.Slice(revisions, func(i, j int) bool {
+			return revisions[i].Revision < revisions[j].Revision
+		})
+	}
+
+	limit := v1alpha1.DefaultComponentRevisionHistoryLimit
+	if r.component.Spec.RevisionHistoryLimit != nil {
+		limit = *r.component.Spec.RevisionHistoryLimit
+	}
+
+	// the current revision is always kept
+	for i := 0; i < len(revisions)-1-int(limit); i++ {
+		if err := r.Delete(r.ctx, revisions[i]); client.IgnoreNotFound(err) != nil {
+			r.WarningEvent(err, "unable to delete old revision")
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,29 @@
+package controllers
+
+import (
+	"testing"
+
+	"github.com/kalmhq/kalm/controller/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestComponentRevisionName(t *testing.T) {
+	spec := v1alpha1.ComponentSpec{Image: "foo:v1"}
+
+	data, err := ComponentRevisionData(&spec)
+	assert.Nil(t, err)
+	name := ComponentRevisionName("foo", data)
+
+	// one-off actions are not part of the revision
+	spec.ImmediateTrigger = true
+	spec.PendingJobRuns = []v1alpha1.JobRunRequest{{ID: "foo"}}
+	data, err = ComponentRevisionData(&spec)
+	assert.Nil(t, err)
+	assert.Equal(t, name, ComponentRevisionName("foo", data))
+	assert.True(t, spec.ImmediateTrigger)
+
+	spec.Image = "foo:v2"
+	data, err = ComponentRevisionData(&spec)
+	assert.Nil(t, err)
+	assert.NotEqual(t, name, ComponentRevisionName("foo", data))
+}
@@ -0,0 +1,553 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/kalmhq/kalm/controller/api/v1alpha1"
+	"github.com/kalmhq/kalm/controller/lib/istiometric"
+	v1alpha32 "istio.io/api/networking/v1alpha3"
+	istioNetworkingV1Beta1 "istio.io/api/networking/v1beta1"
+	"istio.io/client-go/pkg/apis/networking/v1beta1"
+	appsV1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// +kubebuilder:rbac:groups=networking.istio.io,resources=virtualservices,verbs=*
+
+// A progressive rollout runs the new image in a second deployment, named <component>-canary.
+// Pods of both deployments are behind the component service, they are told apart by the version label,
+// which is used by subsets of the component DestinationRule. Traffic in the mesh is split by a VirtualService
+// of the component service, traffic from gateways is split by the HttpRoute controller.
+
+const (
+	StableVersion = "v1"
+	CanaryVersion = "canary"
+
+	RolloutSubsetStable = "stable"
+	RolloutSubsetCanary = "canary"
+
+	ComponentReasonProgressiveRolloutInProgress = "ProgressiveRolloutInProgress"
+
+	// how long to wait before retrying a failed metrics query
+	rolloutMetricsRetryInterval = 30 * time.Second
+)
+
+// metrics of the canary version of a component service, replaced in tests
+var getCanaryMetrics = func(ctx context.Context, serviceHost string, window time.Duration) (*istiometric.ServiceVersionMetrics, error) {
+	return istiometric.GetServiceVersionMetrics(ctx, serviceHost, CanaryVersion, window)
+}
+
+func getCanaryDeploymentName(componentName string) string {
+	return componentName + "-canary"
+}
+
+func getRolloutVirtualServiceName(componentName string) string {
+	return componentName + "-rollout"
+}
+
+func getComponentServiceHost(component *v1alpha1.Component) string {
+	return fmt.Sprintf("%s.%s.svc.cluster.local", component.Name, component.Namespace)
+}
+
+func buildRolloutSubsets() []*v1alpha32.Subset {
+	return []*v1alpha32.Subset{
+		{
+			Name:   RolloutSubsetStable,
+			Labels: map[string]string{"version": StableVersion},
+		},
+		{
+			Name:   RolloutSubsetCanary,
+			Labels: map[string]string{"version": CanaryVersion},
+		},
+	}
+}
+
+// withMainContainerImage returns a copy of the template running the image in the main container
+func withMainContainerImage(template *corev1.PodTemplateSpec, componentName, image string) *corev1.PodTemplateSpec {
+	copied := template.DeepCopy()
+
+	for i := range copied.Spec.Containers {
+		if copied.Spec.Containers[i].Name == componentName {
+			copied.Spec.Containers[i].Image = image
+		}
+	}
+
+	return copied
+}
+
+// getCanaryReplicas returns replicas of the canary deployment which receives weight percent of the traffic
+func getCanaryReplicas(stableReplicas, weight int32) int32 {
+	if stableReplicas <= 0 {
+		return 0
+	}
+
+	replicas := int32(math.Ceil(float64(stableReplicas) * float64(weight) / 100))
+
+	if replicas < 1 {
+		replicas = 1
+	}
+
+	return replicas
+}
+
+// analyzeRolloutStep decides whether the new version passes a step according to its metrics
+func analyzeRolloutStep(strategy *v1alpha1.RolloutStrategy, metrics *istiometric.ServiceVersionMetrics) (bool, string) {
+	if !metrics.HasTraffic {
+		return true, "no traffic to the new version during the step"
+	}
+
+	if strategy.MaxErrorRatePercentage != nil && metrics.ErrorRatePercentage > float64(*strategy.MaxErrorRatePercentage) {
+		return false, fmt.Sprintf(
+			"error rate %.2f%% is higher than %d%%",
+			metrics.ErrorRatePercentage,
+			*strategy.MaxErrorRatePercentage,
+		)
+	}
+
+	if strategy.MaxLatencyMilliseconds != nil && metrics.P99LatencyMilliseconds > float64(*strategy.MaxLatencyMilliseconds) {
+		return false, fmt.Sprintf(
+			"P99 latency %.2fms is higher than %dms",
+			metrics.P99LatencyMilliseconds,
+			*strategy.MaxLatencyMilliseconds,
+		)
+	}
+
+	return true, ""
+}
+
+func newRolloutStatus(strategy *v1alpha1.RolloutStrategy, stableImage, canaryImage string) *v1alpha1.ComponentRolloutStatus {
+	now := metaV1.Now()
+
+	status := &v1alpha1.ComponentRolloutStatus{
+		Type:        strategy.Type,
+		Phase:       v1alpha1.RolloutPhaseProgressing,
+		StableImage: stableImage,
+		CanaryImage: canaryImage,
+		StartedAt:   &now,
+	}
+
+	for _, weight := range strategy.GetSteps() {
+		status.Steps = append(status.Steps, v1alpha1.RolloutStepStatus{
+			Weight: weight,
+			Phase:  v1alpha1.RolloutStepPhasePending,
+		})
+	}
+
+	return status
+}
+
+func (r *ComponentReconcilerTask) LoadCanaryDeployment() error {
+	var deployment appsV1.Deployment
+
+	if err := r.Reader.Get(
+		r.ctx,
+		types.NamespacedName{Namespace: r.component.Namespace, Name: getCanaryDeploymentName(r.component.Name)},
+		&deployment,
+	); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+
+	r.canaryDeployment = &deployment
+
+	return nil
+}
+
+func (r *ComponentReconcilerTask) LoadRolloutVirtualService() error {
+	var vs v1beta1.VirtualService
+
+	if err := r.Reader.Get(
+		r.ctx,
+		types.NamespacedName{Namespace: r.component.Namespace, Name: getRolloutVirtualServiceName(r.component.Name)},
+		&vs,
+	); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+
+	r.rolloutVirtualService = &vs
+
+	return nil
+}
+
+// ReconcileRollout is used instead of ReconcileDeployment for server components.
+// Without a rollout strategy, the deployment is updated directly.
+func (r *ComponentReconcilerTask) ReconcileRollout(template *corev1.PodTemplateSpec) error {
+	component := r.component
+	strategy := component.Spec.RolloutStrategy
+
+	// the first version is always deployed directly
+	if strategy == nil || r.deployment == nil {
+		if strategy == nil {
+			r.rolloutStatus = nil
+		}
+
+		if err := r.ReconcileDeployment(template); err != nil {
+			return err
+		}
+
+		return r.cleanupRollout()
+	}
+
+	status := r.rolloutStatus
+	stableImage := getMainContainerImage(r.deployment.Spec.Template, component.Name)
+
+	if v1alpha1.IsRolloutActive(status) {
+		if status.CanaryImage != component.Spec.Image {
+			if component.Spec.Image == status.StableImage {
+				r.abortRollout("the image is changed back to the stable image")
+			} else {
+				r.NormalEvent("RolloutRestarted", "rollout of %s is replaced by %s.", status.CanaryImage, component.Spec.Image)
+				r.rolloutStatus = newRolloutStatus(strategy, status.StableImage, component.Spec.Image)
+			}
+		}
+	} else if stableImage != component.Spec.Image &&
+		!(status != nil && status.Phase == v1alpha1.RolloutPhaseAborted && status.CanaryImage == component.Spec.Image) {
+		r.NormalEvent("RolloutStarted", "%s rollout of %s is started.", strategy.Type, component.Spec.Image)
+		r.rolloutStatus = newRolloutStatus(strategy, stableImage, component.Spec.Image)
+	}
+
+	if err := r.progressRollout(); err != nil {
+		return err
+	}
+
+	status = r.rolloutStatus
+
+	switch {
+	case status != nil && status.Phase == v1alpha1.RolloutPhaseProgressing:
+		if err := r.ReconcileDeployment(withMainContainerImage(template, component.Name, status.StableImage)); err != nil {
+			return err
+		}
+
+		step := status.Steps[status.CurrentStep]
+		if err := r.ReconcileCanaryDeployment(template, getCanaryReplicas(r.getStableReplicas(), step.Weight)); err != nil {
+			return err
+		}
+
+		return r.ReconcileRolloutVirtualService()
+	case status != nil && status.Phase == v1alpha1.RolloutPhasePromoting:
+		// the canary keeps serving until the stable deployment is updated to the new image
+		if err := r.ReconcileDeployment(template); err != nil {
+			return err
+		}
+
+		return r.ReconcileRolloutVirtualService()
+	case status != nil && status.Phase == v1alpha1.RolloutPhaseAborted && status.CanaryImage == component.Spec.Image:
+		if err := r.ReconcileDeployment(withMainContainerImage(template, component.Name, status.StableImage)); err != nil {
+			return err
+		}
+
+		return r.cleanupRollout()
+	default:
+		if err := r.ReconcileDeployment(template); err != nil {
+			return err
+		}
+
+		return r.cleanupRollout()
+	}
+}
+
+func (r *ComponentReconcilerTask) getStableReplicas() int32 {
+	if r.deployment == nil || r.deployment.Spec.Replicas == nil {
+		return 1
+	}
+
+	return *r.deployment.Spec.Replicas
+}
+
+func (r *ComponentReconcilerTask) isCanaryDeploymentReady() bool {
+	if r.canaryDeployment == nil {
+		return false
+	}
+
+	rollout := getDeploymentRolloutStatus(r.canaryDeployment, r.component.Name)
+
+	return rollout.complete && rollout.image == r.rolloutStatus.CanaryImage
+}
+
+func (r *ComponentReconcilerTask) abortRollout(message string) {
+	status := r.rolloutStatus
+	now := metaV1.Now()
+
+	status.Phase = v1alpha1.RolloutPhaseAborted
+	status.CanaryWeight = 0
+	status.FinishedAt = &now
+	status.Message = message
+
+	r.WarningEvent(fmt.Errorf("%s", message), "rollout of %s is aborted", status.CanaryImage)
+}
+
+// progressRollout moves the rollout forward according to the state of workloads and metrics of the new version.
+func (r *ComponentReconcilerTask) progressRollout() error {
+	status := r.rolloutStatus
+
+	if status == nil {
+		return nil
+	}
+
+	now := metaV1.Now()
+
+	switch status.Phase {
+	case v1alpha1.RolloutPhasePromoting:
+		rollout := getDeploymentRolloutStatus(r.deployment, r.component.Name)
+
+		if rollout.complete && rollout.image == status.CanaryImage {
+			status.Phase = v1alpha1.RolloutPhaseSucceeded
+			status.StableImage = status.CanaryImage
+			status.CanaryWeight = 0
+			status.FinishedAt = &now
+			status.Message = ""
+
+			r.NormalEvent("RolloutSucceeded", "%s is promoted.", status.CanaryImage)
+		}
+
+		return nil
+	case v1alpha1.RolloutPhaseProgressing:
+	default:
+		return nil
+	}
+
+	strategy := r.component.Spec.RolloutStrategy
+	stepDuration := time.Duration(strategy.StepDurationSeconds) * time.Second
+	step := &status.Steps[status.CurrentStep]
+
+	switch step.Phase {
+	case v1alpha1.RolloutStepPhasePending:
+		// traffic is shifted after pods of the new version are available
+		if !r.isCanaryDeploymentReady() {
+			return nil
+		}
+
+		step.Phase = v1alpha1.RolloutStepPhaseRunning
+		step.StartedAt = &now
+		status.CanaryWeight = step.Weight
+		r.requeueAfter = stepDuration
+
+		r.NormalEvent("RolloutStepStarted", "%d%% of traffic is sent to %s.", step.Weight, status.CanaryImage)
+	case v1alpha1.RolloutStepPhaseRunning:
+		if elapsed := now.Sub(step.StartedAt.Time); elapsed < stepDuration {
+			r.requeueAfter = stepDuration - elapsed
+			return nil
+		}
+
+		metrics, err := getCanaryMetrics(r.ctx, getComponentServiceHost(r.component), stepDuration)
+
+		if err != nil {
+			r.WarningEvent(err, "unable to get metrics of the new version")
+			step.Message = "unable to get metrics: " + err.Error()
+			r.requeueAfter = rolloutMetricsRetryInterval
+			return nil
+		}
+
+		passed, message := analyzeRolloutStep(strategy, metrics)
+
+		step.FinishedAt = &now
+		step.Message = message
+
+		if metrics.HasTraffic {
+			step.ErrorRatePercentage = fmt.Sprintf("%.2f", metrics.ErrorRatePercentage)
+			step.LatencyMilliseconds = fmt.Sprintf("%.2f", metrics.P99LatencyMilliseconds)
+		}
+
+		if !passed {
+			step.Phase = v1alpha1.RolloutStepPhaseFailed
+			r.abortRollout(fmt.Sprintf("step %d failed: %s", status.CurrentStep+1, message))
+			return nil
+		}
+
+		step.Phase = v1alpha1.RolloutStepPhasePassed
+
+		if int(status.CurrentStep)+1 < len(status.Steps) {
+			status.CurrentStep++
+		} else {
+			status.Phase = v1alpha1.RolloutPhasePromoting
+			r.NormalEvent("RolloutPromoting", "all steps passed, promoting %s.", status.CanaryImage)
+		}
+	}
+
+	return nil
+}
+
+// getVersionSelector selects pods of a version of the component.
+// It doesn't include labels of the component spec, which may change while selectors of workloads are immutable.
+func (r *ComponentReconcilerTask) getVersionSelector(version string) map[string]string {
+	return map[string]string{
+		v1alpha1.KalmLabelNamespaceKey: r.component.Namespace,
+		v1alpha1.KalmLabelComponentKey: r.component.Name,
+		"app":                          r.component.Name,
+		"version":                      version,
+	}
+}
+
+// buildCanaryPodTemplate copies the stable template, pods and their topology spread constraints are of the canary version.
+func buildCanaryPodTemplate(template *corev1.PodTemplateSpec) *corev1.PodTemplateSpec {
+	canaryTemplate := template.DeepCopy()
+	canaryTemplate.Labels["version"] = CanaryVersion
+
+	for _, constraint := range canaryTemplate.Spec.TopologySpreadConstraints {
+		if constraint.LabelSelector != nil && constraint.LabelSelector.MatchLabels["version"] != "" {
+			constraint.LabelSelector.MatchLabels["version"] = CanaryVersion
+		}
+	}
+
+	return canaryTemplate
+}
+
+func (r *ComponentReconcilerTask) ReconcileCanaryDeployment(template *corev1.PodTemplateSpec, replicas int32) error {
+	component := r.component
+
+	canaryTemplate := buildCanaryPodTemplate(template)
+
+	deployment := r.canaryDeployment
+	isNew := deployment == nil
+
+	if isNew {
+		deployment = &appsV1.Deployment{
+			ObjectMeta: metaV1.ObjectMeta{
+				Name:        getCanaryDeploymentName(component.Name),
+				Namespace:   component.Namespace,
+				Labels:      canaryTemplate.Labels,
+				Annotations: r.GetAnnotations(),
+			},
+			Spec: appsV1.DeploymentSpec{
+				Selector: &metaV1.LabelSelector{
+					MatchLabels: r.getVersionSelector(CanaryVersion),
+				},
+			},
+		}
+	} else {
+		addSelectorLabels(deployment.Spec.Selector, canaryTemplate)
+	}
+
+	deployment.Spec.Template = *canaryTemplate
+	deployment.Spec.Replicas = &replicas
+
+	if component.Spec.RestartStrategy != "" {
+		deployment.Spec.Strategy = appsV1.DeploymentStrategy{Type: component.Spec.RestartStrategy}
+	} else {
+		deployment.Spec.Strategy = appsV1.DeploymentStrategy{Type: appsV1.RollingUpdateDeploymentStrategyType}
+	}
+
+	if err := ctrl.SetControllerReference(component, deployment, r.Scheme); err != nil {
+		r.WarningEvent(err, "unable to set owner for canary deployment")
+		return err
+	}
+
+	if err := r.runPlugins(ComponentPluginMethodBeforeDeploymentSave, component, deployment, deployment); err != nil {
+		r.WarningEvent(err, "run before deployment save error.")
+		return err
+	}
+
+	if isNew {
+		if err := r.Create(r.ctx, deployment); err != nil {
+			r.WarningEvent(err, "unable to create canary Deployment")
+			return err
+		}
+
+		r.NormalEvent("CanaryDeploymentCreated", deployment.Name+" is created.")
+	} else if err := r.Update(r.ctx, deployment); err != nil {
+		r.WarningEvent(err, "unable to update canary Deployment")
+		return err
+	}
+
+	r.canaryDeployment = deployment
+
+	return nil
+}
+
+// ReconcileRolloutVirtualService splits traffic in the mesh between the stable and canary subsets.
+func (r *ComponentReconcilerTask) ReconcileRolloutVirtualService() error {
+	component := r.component
+	host := getComponentServiceHost(component)
+	weight := r.rolloutStatus.CanaryWeight
+
+	route := &istioNetworkingV1Beta1.HTTPRoute{
+		Name: "rollout",
+		Route: []*istioNetworkingV1Beta1.HTTPRouteDestination{
+			{
+				Destination: &istioNetworkingV1Beta1.Destination{Host: host, Subset: RolloutSubsetStable},
+				Weight:      100 - weight,
+			},
+			{
+				Destination: &istioNetworkingV1Beta1.Destination{Host: host, Subset: RolloutSubsetCanary},
+				Weight:      weight,
+			},
+		},
+	}
+
+	spec := istioNetworkingV1Beta1.VirtualService{
+		Hosts: []string{host},
+		Http:  []*istioNetworkingV1Beta1.HTTPRoute{route},
+	}
+
+	if r.rolloutVirtualService == nil {
+		vs := &v1beta1.VirtualService{
+			ObjectMeta: metaV1.ObjectMeta{
+				Name:      getRolloutVirtualServiceName(component.Name),
+				Namespace: component.Namespace,
+				Labels:    r.GetLabels(),
+			},
+			Spec: spec,
+		}
+
+		if err := ctrl.SetControllerReference(component, vs, r.Scheme); err != nil {
+			r.WarningEvent(err, "unable to set owner for rollout VirtualService")
+			return err
+		}
+
+		if err := r.Create(r.ctx, vs); err != nil {
+			r.WarningEvent(err, "unable to create rollout VirtualService")
+			return err
+		}
+
+		r.rolloutVirtualService = vs
+
+		return nil
+	}
+
+	if equality.Semantic.DeepEqual(r.rolloutVirtualService.Spec, spec) {
+		return nil
+	}
+
+	copied := r.rolloutVirtualService.DeepCopy()
+	copied.Spec = spec
+
+	if err := r.Patch(r.ctx, copied, client.MergeFrom(r.rolloutVirtualService)); err != nil {
+		r.WarningEvent(err, "unable to patch rollout VirtualService")
+		return err
+	}
+
+	r.rolloutVirtualService = copied
+
+	return nil
+}
+
+// cleanupRollout deletes resources that are only needed during a rollout
+func (r *ComponentReconcilerTask) cleanupRollout() error {
+	if r.rolloutVirtualService != nil {
+		if err := r.Delete(r.ctx, r.rolloutVirtualService); client.IgnoreNotFound(err) != nil {
+			r.WarningEvent(err, "unable to delete rollout VirtualService")
+			return err
+		}
+
+		r.rolloutVirtualService = nil
+	}
+
+	if r.canaryDeployment != nil {
+		if err := r.Delete(r.ctx, r.canaryDeployment); client.IgnoreNotFound(err) != nil {
+			r.WarningEvent(err, "unable to delete canary Deployment")
+			return err
+		}
+
+		r.NormalEvent("CanaryDeploymentDeleted", r.canaryDeployment.Name+" is deleted.")
+		r.canaryDeployment = nil
+	}
+
+	return nil
+}
@@ -0,0 +1,213 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kalmhq/kalm/controller/api/v1alpha1"
+	"github.com/kalmhq/kalm/controller/lib/istiometric"
+	"github.com/stretchr/testify/assert"
+	istioNetworkingV1Beta1 "istio.io/api/networking/v1beta1"
+	appsV1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestGetCanaryReplicas(t *testing.T) {
+	assert.Equal(t, int32(1), getCanaryReplicas(3, 10))
+	assert.Equal(t, int32(2), getCanaryReplicas(3, 50))
+	assert.Equal(t, int32(3), getCanaryReplicas(3, 100))
+	assert.Equal(t, int32(0), getCanaryReplicas(0, 50))
+}
+
+func TestAnalyzeRolloutStep(t *testing.T) {
+	maxErrorRate := int32(5)
+	maxLatency := int32(200)
+
+	strategy := &v1alpha1.RolloutStrategy{
+		Type:                   v1alpha1.RolloutStrategyCanary,
+		Steps:                  []int32{10, 50},
+		MaxErrorRatePercentage: &maxErrorRate,
+		MaxLatencyMilliseconds: &maxLatency,
+	}
+
+	passed, _ := analyzeRolloutStep(strategy, &istiometric.ServiceVersionMetrics{})
+	assert.True(t, passed)
+
+	passed, _ = analyzeRolloutStep(strategy, &istiometric.ServiceVersionMetrics{
+		HasTraffic:             true,
+		ErrorRatePercentage:    1,
+		P99LatencyMilliseconds: 100,
+	})
+	assert.True(t, passed)
+
+	passed, message := analyzeRolloutStep(strategy, &istiometric.ServiceVersionMetrics{
+		HasTraffic:             true,
+		ErrorRatePercentage:    10,
+		P99LatencyMilliseconds: 100,
+	})
+	assert.False(t, passed)
+	assert.Equal(t, "error rate 10.00% is higher than 5%", message)
+
+	passed, _ = analyzeRolloutStep(strategy, &istiometric.ServiceVersionMetrics{
+		HasTraffic:             true,
+		P99LatencyMilliseconds: 300,
+	})
+	assert.False(t, passed)
+}
+
+func TestSplitCanaryDestinations(t *testing.T) {
+	destinations := []*istioNetworkingV1Beta1.HTTPRouteDestination{
+		{Destination: &istioNetworkingV1Beta1.Destination{Host: "foo.default.svc.cluster.local"}, Weight: 50},
+		{Destination: &istioNetworkingV1Beta1.Destination{Host: "bar.default.svc.cluster.local"}, Weight: 50},
+	}
+
+	assert.Equal(t, destinations, splitCanaryDestinations(destinations, nil))
+
+	res := splitCanaryDestinations(destinations, map[string]int32{"foo.default.svc.cluster.local": 20})
+	assert.Len(t, res, 3)
+	assert.Equal(t, RolloutSubsetStable, res[0].Destination.Subset)
+	assert.Equal(t, int32(40), res[0].Weight)
+	assert.Equal(t, RolloutSubsetCanary, res[1].Destination.Subset)
+	assert.Equal(t, int32(10), res[1].Weight)
+	assert.Equal(t, "", res[2].Destination.Subset)
+	assert.Equal(t, int32(50), res[2].Weight)
+
+	// the canary doesn't receive traffic before the first step starts
+	res = splitCanaryDestinations(destinations, map[string]int32{"foo.default.svc.cluster.local": 0})
+	assert.Len(t, res, 2)
+	assert.Equal(t, RolloutSubsetStable, res[0].Destination.Subset)
+	assert.Equal(t, int32(50), res[0].Weight)
+}
+
+func newRolloutTestTask(strategy *v1alpha1.RolloutStrategy) *ComponentReconcilerTask {
+	return &ComponentReconcilerTask{
+		ComponentReconciler: &ComponentReconciler{
+			BaseReconciler: &BaseReconciler{Recorder: record.NewFakeRecorder(100)},
+		},
+		component: &v1alpha1.Component{
+			ObjectMeta: metaV1.ObjectMeta{Name: "web", Namespace: "default"},
+			Spec: v1alpha1.ComponentSpec{
+				Image:           "web:v2",
+				WorkloadType:    v1alpha1.WorkloadTypeServer,
+				RolloutStrategy: strategy,
+			},
+		},
+	}
+}
+
+func newRolloutTestDeployment(image string, replicas int32) *appsV1.Deployment {
+	return &appsV1.Deployment{
+		Spec: appsV1.DeploymentSpec{
+			Replicas: &replicas,
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{Name: "web", Image: image}},
+				},
+			},
+		},
+		Status: appsV1.DeploymentStatus{
+			Replicas:          replicas,
+			UpdatedReplicas:   replicas,
+			AvailableReplicas: replicas,
+		},
+	}
+}
+
+func TestProgressRollout(t *testing.T) {
+	originalGetCanaryMetrics := getCanaryMetrics
+	defer func() { getCanaryMetrics = originalGetCanaryMetrics }()
+
+	errorRate := 10.0
+	getCanaryMetrics = func(ctx context.Context, serviceHost string, window time.Duration) (*istiometric.ServiceVersionMetrics, error) {
+		assert.Equal(t, "web.default.svc.cluster.local", serviceHost)
+		return &istiometric.ServiceVersionMetrics{HasTraffic: true, ErrorRatePercentage: errorRate}, nil
+	}
+
+	maxErrorRate := int32(5)
+	strategy := &v1alpha1.RolloutStrategy{
+		Type:                   v1alpha1.RolloutStrategyCanary,
+		Steps:                  []int32{10, 50},
+		StepDurationSeconds:    60,
+		MaxErrorRatePercentage: &maxErrorRate,
+	}
+
+	task := newRolloutTestTask(strategy)
+	task.deployment = newRolloutTestDeployment("web:v1", 2)
+	task.rolloutStatus = newRolloutStatus(strategy, "web:v1", "web:v2")
+
+	// waiting for the canary deployment
+	assert.Nil(t, task.progressRollout())
+	assert.Equal(t, v1alpha1.RolloutStepPhasePending, task.rolloutStatus.Steps[0].Phase)
+
+	task.canaryDeployment = newRolloutTestDeployment("web:v2", 1)
+	assert.Nil(t, task.progressRollout())
+	assert.Equal(t, v1alpha1.RolloutStepPhaseRunning, task.rolloutStatus.Steps[0].Phase)
+	assert.Equal(t, int32(10), task.rolloutStatus.CanaryWeight)
+	assert.Equal(t, time.Minute, task.requeueAfter)
+
+	// the step is still running
+	assert.Nil(t, task.progressRollout())
+	assert.Equal(t, v1alpha1.RolloutStepPhaseRunning, task.rolloutStatus.Steps[0].Phase)
+
+	// the step is finished, but the error rate is too high
+	startedAt := metaV1.NewTime(time.Now().Add(-2 * time.Minute))
+	task.rolloutStatus.Steps[0].StartedAt = &startedAt
+	assert.Nil(t, task.progressRollout())
+	assert.Equal(t, v1alpha1.RolloutStepPhaseFailed, task.rolloutStatus.Steps[0].Phase)
+	assert.Equal(t, "10.00", task.rolloutStatus.Steps[0].ErrorRatePercentage)
+	assert.Equal(t, v1alpha1.RolloutPhaseAborted, task.rolloutStatus.Phase)
+	assert.Equal(t, int32(0), task.rolloutStatus.CanaryWeight)
+
+	// passing all steps
+	errorRate = 1
+	task.rolloutStatus = newRolloutStatus(strategy, "web:v1", "web:v2")
+
+	for i := range task.rolloutStatus.Steps {
+		assert.Nil(t, task.progressRollout())
+		task.rolloutStatus.Steps[i].StartedAt = &startedAt
+		assert.Nil(t, task.progressRollout())
+		assert.Equal(t, v1alpha1.RolloutStepPhasePassed, task.rolloutStatus.Steps[i].Phase)
+	}
+
+	assert.Equal(t, v1alpha1.RolloutPhasePromoting, task.rolloutStatus.Phase)
+	assert.Equal(t, int32(50), task.rolloutStatus.CanaryWeight)
+
+	// promoted once the stable deployment is running the new image
+	task.deployment = newRolloutTestDeployment("web:v2", 2)
+	assert.Nil(t, task.progressRollout())
+	assert.Equal(t, v1alpha1.RolloutPhaseSucceeded, task.rolloutStatus.Phase)
+	assert.Equal(t, "web:v2", task.rolloutStatus.StableImage)
+	assert.Equal(t, int32(0), task.rolloutStatus.CanaryWeight)
+}
+
+func TestCanaryPodTemplate(t *testing.T) {
+	task := newRolloutTestTask(&v1alpha1.RolloutStrategy{Type: v1alpha1.RolloutStrategyCanary})
+	task.component.Spec.Labels = map[string]string{"team": "shop"}
+	task.component.Spec.TopologySpread = []v1alpha1.TopologySpread{{Topology: v1alpha1.TopologySpreadHost}}
+
+	labels := task.GetLabels()
+	labels["app"] = "web"
+	labels["version"] = StableVersion
+
+	template := &corev1.PodTemplateSpec{
+		ObjectMeta: metaV1.ObjectMeta{Labels: labels},
+		Spec:       corev1.PodSpec{TopologySpreadConstraints: task.buildTopologySpreadConstraints()},
+	}
+
+	canaryTemplate := buildCanaryPodTemplate(template)
+	canarySelector := task.getVersionSelector(CanaryVersion)
+
+	// selectors of both versions don't include labels of the spec and don't match pods of the other version
+	assert.Equal(t, "", canarySelector["team"])
+	assert.Equal(t, "shop", canaryTemplate.Labels["team"])
+
+	for k, v := range canarySelector {
+		assert.Equal(t, v, canaryTemplate.Labels[k])
+	}
+
+	assert.Equal(t, task.getVersionSelector(StableVersion), template.Spec.TopologySpreadConstraints[0].LabelSelector.MatchLabels)
+	assert.Equal(t, canarySelector, canaryTemplate.Spec.TopologySpreadConstraints[0].LabelSelector.MatchLabels)
+}
@@ -0,0 +1,233 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/kalmhq/kalm/controller/api/v1alpha1"
+	"github.com/kalmhq/kalm/controller/lib/istiometric"
+	corev1 "k8s.io/api/core/v1"
+	rbacV1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// +kubebuilder:rbac:groups="",resources=endpoints,verbs=get;list;watch
+
+// A component with scaleToZero is scaled to zero once istio reports no requests to it in the idle window.
+// The HttpRoute controller then sends requests to the component to the activator in kalm-system instead,
+// with the original destination in the activator target header. The activator wakes the component up,
+// waits until it's ready and forwards the request. Routes keep sending requests to the activator while the component
+// is waking, until its deployment has available replicas. Until the next idle window is over, it's not scaled to zero again.
+
+const (
+	KALM_ACTIVATOR_NAME          = "activator"
+	KALM_ACTIVATOR_TARGET_HEADER = v1alpha1.KalmActivatorTargetHeader
+
+	activatorContainerPort = 3003
+
+	scaleToZeroMetricsRetryInterval = time.Minute
+)
+
+// requests to a component service in a time window, replaced in tests
+var getComponentRequestCount = func(ctx context.Context, serviceHost string, window time.Duration) (float64, error) {
+	return istiometric.GetServiceRequestCount(ctx, serviceHost, window)
+}
+
+func getActivatorHost() string {
+	return fmt.Sprintf("%s.%s.svc.cluster.local", KALM_ACTIVATOR_NAME, v1alpha1.KalmSystemNamespace)
+}
+
+// getComponentActiveSince returns the time since when the component is expected to serve requests,
+// which is the last time it's woken up, or the time it's created.
+func getComponentActiveSince(component *v1alpha1.Component) time.Time {
+	since := component.CreationTimestamp.Time
+
+	if wokenAt, err := time.Parse(time.RFC3339, component.Annotations[v1alpha1.KalmAnnoWokenAt]); err == nil && wokenAt.After(since) {
+		since = wokenAt
+	}
+
+	return since
+}
+
+// setRequeueAfter keeps the earliest time the component needs to be reconciled again.
+func (r *ComponentReconcilerTask) setRequeueAfter(duration time.Duration) {
+	if r.requeueAfter == 0 || duration < r.requeueAfter {
+		r.requeueAfter = duration
+	}
+}
+
+func (r *ComponentReconcilerTask) ReconcileScaleToZero() error {
+	component := r.component
+	_, scaledToZero := component.Annotations[v1alpha1.KalmAnnoScaledToZeroAt]
+	_, waking := component.Annotations[v1alpha1.KalmAnnoWakingAt]
+
+	if component.Spec.ScaleToZero == nil {
+		if scaledToZero || waking {
+			return r.setScaledToZero(false)
+		}
+
+		return nil
+	}
+
+	if err := r.reconcileActivator(); err != nil {
+		return err
+	}
+
+	// routes are switched back to the component once it's able to serve requests,
+	// the deployment status change triggers another reconcile until then.
+	if waking {
+		if r.deployment == nil || r.deployment.Status.AvailableReplicas == 0 {
+			return nil
+		}
+
+		return r.setAwake()
+	}
+
+	if scaledToZero || !r.isComponentIdle() {
+		return nil
+	}
+
+	r.NormalEvent(ComponentReasonScaledToZero, "no requests in the last %ds, scaled to zero.", component.Spec.ScaleToZero.IdleSeconds)
+
+	return r.setScaledToZero(true)
+}
+
+// isComponentIdle returns true if the component has no requests in the idle window,
+// otherwise the component is reconciled again when it might be idle.
+func (r *ComponentReconcilerTask) isComponentIdle() bool {
+	component := r.component
+
+	// both versions keep running during a progressive rollout
+	if v1alpha1.IsRolloutActive(component.Status.Rollout) {
+		return false
+	}
+
+	window := time.Duration(component.Spec.ScaleToZero.IdleSeconds) * time.Second
+
+	if elapsed := time.Since(getComponentActiveSince(component)); elapsed < window {
+		r.setRequeueAfter(window - elapsed)
+		return false
+	}
+
+	count, err := getComponentRequestCount(r.ctx, getComponentServiceHost(component), window)
+
+	if err != nil {
+		r.WarningEvent(err, "unable to get requests of the component, it's not scaled to zero")
+		r.setRequeueAfter(scaleToZeroMetricsRetryInterval)
+		return false
+	}
+
+	if count > 0 {
+		r.setRequeueAfter(window)
+		return false
+	}
+
+	return true
+}
+
+func (r *ComponentReconcilerTask) setScaledToZero(scaledToZero bool) error {
+	copied := r.component.DeepCopy()
+
+	if scaledToZero {
+		if copied.Annotations == nil {
+			copied.Annotations = make(map[string]string)
+		}
+
+		copied.Annotations[v1alpha1.KalmAnnoScaledToZeroAt] = time.Now().UTC().Format(time.RFC3339)
+	} else {
+		delete(copied.Annotations, v1alpha1.KalmAnnoScaledToZeroAt)
+		delete(copied.Annotations, v1alpha1.KalmAnnoWakingAt)
+	}
+
+	if err := r.Patch(r.ctx, copied, client.MergeFrom(r.component)); err != nil {
+		return err
+	}
+
+	r.component = copied
+
+	return nil
+}
+
+// setAwake removes the waking state, so routes send requests to the component instead of the activator.
+func (r *ComponentReconcilerTask) setAwake() error {
+	copied := r.component.DeepCopy()
+	delete(copied.Annotations, v1alpha1.KalmAnnoWakingAt)
+
+	if err := r.Patch(r.ctx, copied, client.MergeFrom(r.component)); err != nil {
+		return err
+	}
+
+	r.component = copied
+
+	return nil
+}
+
+func buildActivatorComponent() *v1alpha1.Component {
+	imgTag := getKalmVersionFromEnv()
+
+	if imgTag == "" {
+		imgTag = DefaultAuthProxyImgTag
+	}
+
+	return &v1alpha1.Component{
+		ObjectMeta: metaV1.ObjectMeta{
+			Name:      KALM_ACTIVATOR_NAME,
+			Namespace: v1alpha1.KalmSystemNamespace,
+		},
+		Spec: v1alpha1.ComponentSpec{
+			WorkloadType: v1alpha1.WorkloadTypeServer,
+			Image:        fmt.Sprintf("kalmhq/kalm:%s", imgTag),
+			Command:      "./activator",
+			Ports: []v1alpha1.Port{
+				{
+					ContainerPort: activatorContainerPort,
+					ServicePort:   80,
+					Protocol:      v1alpha1.PortProtocolHTTP,
+				},
+			},
+			RunnerPermission: &v1alpha1.RunnerPermission{
+				RoleType: "clusterRole",
+				Rules: []rbacV1.PolicyRule{
+					{
+						APIGroups: []string{v1alpha1.GroupVersion.Group},
+						Resources: []string{"components"},
+						Verbs:     []string{"get", "patch"},
+					},
+					{
+						APIGroups: []string{""},
+						Resources: []string{"endpoints"},
+						Verbs:     []string{"get"},
+					},
+				},
+			},
+			ResourceRequirements: &corev1.ResourceRequirements{
+				Requests: map[corev1.ResourceName]resource.Quantity{
+					corev1.ResourceCPU:    resource.MustParse("10m"),
+					corev1.ResourceMemory: resource.MustParse("10Mi"),
+				},
+			},
+		},
+	}
+}
+
+// reconcileActivator creates the activator once any component can be scaled to zero.
+func (r *ComponentReconcilerTask) reconcileActivator() error {
+	var activator v1alpha1.Component
+
+	err := r.Get(r.ctx, client.ObjectKey{Namespace: v1alpha1.KalmSystemNamespace, Name: KALM_ACTIVATOR_NAME}, &activator)
+
+	if !errors.IsNotFound(err) {
+		return err
+	}
+
+	if err := r.Create(r.ctx, buildActivatorComponent()); err != nil && !errors.IsAlreadyExists(err) {
+		r.WarningEvent(err, "unable to create the activator")
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,169 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/kalmhq/kalm/controller/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	istioNetworkingV1Beta1 "istio.io/api/networking/v1beta1"
+	appsV1 "k8s.io/api/apps/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func newScaleToZeroTestTask(createdAt time.Time) *ComponentReconcilerTask {
+	return &ComponentReconcilerTask{
+		ComponentReconciler: &ComponentReconciler{
+			BaseReconciler: &BaseReconciler{Recorder: record.NewFakeRecorder(100)},
+		},
+		component: &v1alpha1.Component{
+			ObjectMeta: metaV1.ObjectMeta{
+				Name:              "web",
+				Namespace:         "default",
+				CreationTimestamp: metaV1.NewTime(createdAt),
+			},
+			Spec: v1alpha1.ComponentSpec{
+				Image:        "web:v1",
+				WorkloadType: v1alpha1.WorkloadTypeServer,
+				ScaleToZero:  &v1alpha1.ScaleToZeroConfig{IdleSeconds: 600},
+			},
+		},
+	}
+}
+
+func TestGetComponentActiveSince(t *testing.T) {
+	createdAt := time.Date(2020, 10, 1, 0, 0, 0, 0, time.UTC)
+	task := newScaleToZeroTestTask(createdAt)
+
+	assert.Equal(t, createdAt, getComponentActiveSince(task.component))
+
+	task.component.Annotations = map[string]string{v1alpha1.KalmAnnoWokenAt: "2020-10-02T00:00:00Z"}
+	assert.Equal(t, createdAt.Add(24*time.Hour), getComponentActiveSince(task.component))
+
+	task.component.Annotations[v1alpha1.KalmAnnoWokenAt] = "invalid"
+	assert.Equal(t, createdAt, getComponentActiveSince(task.component))
+}
+
+func TestIsComponentIdle(t *testing.T) {
+	originalGetComponentRequestCount := getComponentRequestCount
+	defer func() { getComponentRequestCount = originalGetComponentRequestCount }()
+
+	var count float64
+	var countErr error
+	getComponentRequestCount = func(ctx context.Context, serviceHost string, window time.Duration) (float64, error) {
+		assert.Equal(t, "web.default.svc.cluster.local", serviceHost)
+		assert.Equal(t, 10*time.Minute, window)
+		return count, countErr
+	}
+
+	// the component is just created
+	task := newScaleToZeroTestTask(time.Now().Add(-time.Minute))
+	assert.False(t, task.isComponentIdle())
+	assert.True(t, task.requeueAfter > 8*time.Minute && task.requeueAfter <= 9*time.Minute)
+
+	task = newScaleToZeroTestTask(time.Now().Add(-time.Hour))
+	count = 3
+	assert.False(t, task.isComponentIdle())
+	assert.Equal(t, 10*time.Minute, task.requeueAfter)
+
+	// metrics are not available
+	task = newScaleToZeroTestTask(time.Now().Add(-time.Hour))
+	count, countErr = 0, fmt.Errorf("prometheus is down")
+	assert.False(t, task.isComponentIdle())
+	assert.Equal(t, scaleToZeroMetricsRetryInterval, task.requeueAfter)
+
+	countErr = nil
+	assert.True(t, task.isComponentIdle())
+
+	// the component is woken up recently
+	task.component.Annotations = map[string]string{
+		v1alpha1.KalmAnnoWokenAt: time.Now().Add(-time.Minute).UTC().Format(time.RFC3339),
+	}
+	assert.False(t, task.isComponentIdle())
+
+	// during a progressive rollout
+	task = newScaleToZeroTestTask(time.Now().Add(-time.Hour))
+	task.component.Status.Rollout = &v1alpha1.ComponentRolloutStatus{Phase: v1alpha1.RolloutPhaseProgressing}
+	assert.False(t, task.isComponentIdle())
+}
+
+func TestSendScaledToZeroDestinationsToActivator(t *testing.T) {
+	destinations := []*istioNetworkingV1Beta1.HTTPRouteDestination{
+		{
+			Destination: &istioNetworkingV1Beta1.Destination{
+				Host: "foo.default.svc.cluster.local",
+				Port: &istioNetworkingV1Beta1.PortSelector{Number: 8080},
+			},
+			Weight: 50,
+		},
+		{Destination: &istioNetworkingV1Beta1.Destination{Host: "bar.default.svc.cluster.local"}, Weight: 50},
+	}
+
+	res := sendScaledToZeroDestinationsToActivator(destinations, map[string]bool{"foo.default.svc.cluster.local": true})
+	assert.Len(t, res, 2)
+	assert.Equal(t, "activator.kalm-system.svc.cluster.local", res[0].Destination.Host)
+	assert.Equal(t, uint32(80), res[0].Destination.Port.Number)
+	assert.Equal(t, int32(50), res[0].Weight)
+	assert.Equal(t, "foo.default.svc.cluster.local:8080", res[0].Headers.Request.Set[KALM_ACTIVATOR_TARGET_HEADER])
+	assert.Equal(t, "bar.default.svc.cluster.local", res[1].Destination.Host)
+	assert.Nil(t, res[1].Headers)
+}
+
+func TestRemoveActivatorTargetHeaderOnDestinations(t *testing.T) {
+	httpRoute := &istioNetworkingV1Beta1.HTTPRoute{
+		Headers: buildIstioHeaders(nil),
+		Route: sendScaledToZeroDestinationsToActivator([]*istioNetworkingV1Beta1.HTTPRouteDestination{
+			{Destination: &istioNetworkingV1Beta1.Destination{Host: "foo.default.svc.cluster.local"}, Weight: 50},
+			{Destination: &istioNetworkingV1Beta1.Destination{Host: "bar.default.svc.cluster.local"}, Weight: 50},
+		}, map[string]bool{"foo.default.svc.cluster.local": true}),
+	}
+
+	removeActivatorTargetHeaderOnDestinations(httpRoute)
+
+	assert.NotContains(t, httpRoute.Headers.Request.Remove, KALM_ACTIVATOR_TARGET_HEADER)
+	assert.Nil(t, httpRoute.Route[0].Headers.Request.Remove)
+	assert.Equal(t, []string{KALM_ACTIVATOR_TARGET_HEADER}, httpRoute.Route[1].Headers.Request.Remove)
+
+	// the header of the other routes is still removed
+	assert.Contains(t, DANGEROUS_HEADERS, KALM_ACTIVATOR_TARGET_HEADER)
+
+	httpRoute = &istioNetworkingV1Beta1.HTTPRoute{
+		Headers: buildIstioHeaders(nil),
+		Route: []*istioNetworkingV1Beta1.HTTPRouteDestination{
+			{Destination: &istioNetworkingV1Beta1.Destination{Host: "bar.default.svc.cluster.local"}, Weight: 100},
+		},
+	}
+
+	removeActivatorTargetHeaderOnDestinations(httpRoute)
+
+	assert.Contains(t, httpRoute.Headers.Request.Remove, KALM_ACTIVATOR_TARGET_HEADER)
+	assert.Nil(t, httpRoute.Route[0].Headers)
+}
+
+func TestReconcileScaleToZeroWaking(t *testing.T) {
+	task := newScaleToZeroTestTask(time.Now().Add(-time.Hour))
+	task.ctx = context.Background()
+	task.component.Annotations = map[string]string{v1alpha1.KalmAnnoWakingAt: "2020-10-02T00:00:00Z"}
+	task.Client = fake.NewFakeClientWithScheme(newExportScheme(), task.component.DeepCopy(), buildActivatorComponent())
+
+	assert.False(t, v1alpha1.IsComponentScaledToZero(task.component))
+	assert.True(t, v1alpha1.IsComponentWaking(task.component))
+
+	// routes keep sending requests to the activator until replicas are available
+	task.deployment = &appsV1.Deployment{}
+	assert.Nil(t, task.ReconcileScaleToZero())
+	assert.True(t, v1alpha1.IsComponentWaking(task.component))
+
+	task.deployment.Status.AvailableReplicas = 1
+	assert.Nil(t, task.ReconcileScaleToZero())
+	assert.False(t, v1alpha1.IsComponentWaking(task.component))
+
+	var component v1alpha1.Component
+	assert.Nil(t, task.Get(task.ctx, client.ObjectKey{Namespace: "default", Name: "web"}, &component))
+	assert.NotContains(t, component.Annotations, v1alpha1.KalmAnnoWakingAt)
+}
@@ -0,0 +1,122 @@
+package controllers
+
+import (
+	"time"
+
+	"github.com/kalmhq/kalm/controller/api/v1alpha1"
+	"github.com/robfig/cron"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// The last start and end of a scaling schedule are searched in a lookback window, which is doubled until
+// a fire time is found. Cron schedules fire at least once in 5 years, robfig/cron gives up after that as well.
+const (
+	scalingScheduleMinLookback = time.Hour
+	scalingScheduleMaxLookback = 5 * 366 * 24 * time.Hour
+)
+
+// activeScalingSchedule is the scaling schedule in effect and when its window ends.
+type activeScalingSchedule struct {
+	schedule *v1alpha1.ScalingSchedule
+	until    time.Time
+}
+
+// getLastFireTime returns the last time the schedule fires at or before now, zero if it doesn't fire in the max lookback.
+func getLastFireTime(schedule cron.Schedule, now time.Time) time.Time {
+	for lookback := scalingScheduleMinLookback; ; lookback *= 2 {
+		if lookback > scalingScheduleMaxLookback {
+			lookback = scalingScheduleMaxLookback
+		}
+
+		var last time.Time
+
+		for t := schedule.Next(now.Add(-lookback)); !t.IsZero() && !t.After(now); t = schedule.Next(t) {
+			last = t
+		}
+
+		if !last.IsZero() || lookback == scalingScheduleMaxLookback {
+			return last
+		}
+	}
+}
+
+// getActiveScalingSchedule returns the first scaling schedule in effect at the time,
+// and the next time when a window of any schedule starts or ends.
+func getActiveScalingSchedule(schedules []v1alpha1.ScalingSchedule, now time.Time) (*activeScalingSchedule, time.Time) {
+	var active *activeScalingSchedule
+	var next time.Time
+
+	updateNext := func(t time.Time) {
+		if !t.IsZero() && (next.IsZero() || t.Before(next)) {
+			next = t
+		}
+	}
+
+	for i := range schedules {
+		schedule := &schedules[i]
+
+		// invalid schedules are refused by the webhook
+		start, err := cron.ParseStandard(schedule.Start)
+		if err != nil {
+			continue
+		}
+
+		end, err := cron.ParseStandard(schedule.End)
+		if err != nil {
+			continue
+		}
+
+		loc, err := time.LoadLocation(schedule.TimeZone)
+		if err != nil {
+			continue
+		}
+
+		localNow := now.In(loc)
+		nextEnd := end.Next(localNow)
+
+		updateNext(start.Next(localNow))
+		updateNext(nextEnd)
+
+		lastStart := getLastFireTime(start, localNow)
+
+		if active == nil && !lastStart.IsZero() && lastStart.After(getLastFireTime(end, localNow)) {
+			active = &activeScalingSchedule{schedule: schedule, until: nextEnd}
+		}
+	}
+
+	return active, next
+}
+
+// applyScalingSchedules decides replicas of the workload by scaling schedules of the component,
+// the component is reconciled again when a window starts or ends.
+func (r *ComponentReconcilerTask) applyScalingSchedules() {
+	r.scheduledReplicas = nil
+	r.scalingScheduleStatus = nil
+
+	// components scaled down by the application are not scaled up by schedules
+	if isComponentForcedToScaleDown(r.component) {
+		return
+	}
+
+	active, next := getActiveScalingSchedule(r.component.Spec.ScalingSchedules, time.Now())
+
+	if !next.IsZero() {
+		r.setRequeueAfter(time.Until(next))
+	}
+
+	if active == nil {
+		return
+	}
+
+	replicas := active.schedule.Replicas
+	r.scheduledReplicas = &replicas
+
+	status := &v1alpha1.ComponentScalingScheduleStatus{Replicas: replicas}
+
+	if !active.until.IsZero() {
+		until := metaV1.NewTime(active.until)
+		status.Until = &until
+	}
+
+	r.scalingScheduleStatus = status
+}
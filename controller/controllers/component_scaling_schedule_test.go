@@ -0,0 +1,84 @@
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kalmhq/kalm/controller/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestGetActiveScalingSchedule(t *testing.T) {
+	nightly := v1alpha1.ScalingSchedule{Start: "0 20 * * *", End: "0 7 * * *", Replicas: 0}
+	weekend := v1alpha1.ScalingSchedule{Start: "0 0 * * 6", End: "0 0 * * 1", Replicas: 1}
+
+	// Wednesday
+	day := time.Date(2020, 10, 14, 12, 0, 0, 0, time.UTC)
+	active, next := getActiveScalingSchedule([]v1alpha1.ScalingSchedule{nightly, weekend}, day)
+	assert.Nil(t, active)
+	assert.Equal(t, time.Date(2020, 10, 14, 20, 0, 0, 0, time.UTC), next)
+
+	night := time.Date(2020, 10, 14, 23, 0, 0, 0, time.UTC)
+	active, next = getActiveScalingSchedule([]v1alpha1.ScalingSchedule{nightly, weekend}, night)
+	assert.Equal(t, int32(0), active.schedule.Replicas)
+	assert.Equal(t, time.Date(2020, 10, 15, 7, 0, 0, 0, time.UTC), active.until)
+	assert.Equal(t, time.Date(2020, 10, 15, 7, 0, 0, 0, time.UTC), next)
+
+	// Sunday noon, only the weekend schedule is in effect
+	sunday := time.Date(2020, 10, 18, 12, 0, 0, 0, time.UTC)
+	active, next = getActiveScalingSchedule([]v1alpha1.ScalingSchedule{nightly, weekend}, sunday)
+	assert.Equal(t, int32(1), active.schedule.Replicas)
+	assert.Equal(t, time.Date(2020, 10, 19, 0, 0, 0, 0, time.UTC), active.until)
+	assert.Equal(t, time.Date(2020, 10, 18, 20, 0, 0, 0, time.UTC), next)
+
+	// the first schedule in effect wins
+	active, _ = getActiveScalingSchedule([]v1alpha1.ScalingSchedule{weekend, nightly}, time.Date(2020, 10, 18, 22, 0, 0, 0, time.UTC))
+	assert.Equal(t, int32(1), active.schedule.Replicas)
+
+	// 20:00 in Shanghai is 12:00 in UTC
+	nightly.TimeZone = "Asia/Shanghai"
+	active, _ = getActiveScalingSchedule([]v1alpha1.ScalingSchedule{nightly}, time.Date(2020, 10, 14, 13, 0, 0, 0, time.UTC))
+	assert.NotNil(t, active)
+	active, _ = getActiveScalingSchedule([]v1alpha1.ScalingSchedule{nightly}, time.Date(2020, 10, 14, 11, 0, 0, 0, time.UTC))
+	assert.Nil(t, active)
+}
+
+func TestGetActiveMonthlyScalingSchedule(t *testing.T) {
+	// scaled up for the last 10 days of each month
+	monthEnd := v1alpha1.ScalingSchedule{Start: "0 0 21 * *", End: "0 0 1 * *", Replicas: 5}
+
+	active, next := getActiveScalingSchedule([]v1alpha1.ScalingSchedule{monthEnd}, time.Date(2020, 10, 30, 12, 0, 0, 0, time.UTC))
+	if assert.NotNil(t, active) {
+		assert.Equal(t, time.Date(2020, 11, 1, 0, 0, 0, 0, time.UTC), active.until)
+	}
+	assert.Equal(t, time.Date(2020, 11, 1, 0, 0, 0, 0, time.UTC), next)
+
+	active, _ = getActiveScalingSchedule([]v1alpha1.ScalingSchedule{monthEnd}, time.Date(2020, 10, 14, 12, 0, 0, 0, time.UTC))
+	assert.Nil(t, active)
+
+	// once a year
+	yearly := v1alpha1.ScalingSchedule{Start: "0 0 1 12 *", End: "0 0 1 1 *", Replicas: 5}
+	active, _ = getActiveScalingSchedule([]v1alpha1.ScalingSchedule{yearly}, time.Date(2020, 12, 24, 0, 0, 0, 0, time.UTC))
+	assert.NotNil(t, active)
+	active, _ = getActiveScalingSchedule([]v1alpha1.ScalingSchedule{yearly}, time.Date(2020, 10, 14, 0, 0, 0, 0, time.UTC))
+	assert.Nil(t, active)
+}
+
+func TestApplyScalingSchedules(t *testing.T) {
+	task := newScaleToZeroTestTask(time.Now())
+	task.component.Spec.ScalingSchedules = []v1alpha1.ScalingSchedule{
+		{Start: "* * * * *", End: "0 0 1 1 *", Replicas: 2},
+	}
+
+	task.applyScalingSchedules()
+	assert.Equal(t, int32(2), *task.scheduledReplicas)
+	assert.Equal(t, int32(2), task.scalingScheduleStatus.Replicas)
+	assert.True(t, task.requeueAfter > 0 && task.requeueAfter <= time.Minute)
+
+	// components scaled down by the application are not scaled up by schedules
+	task.component.ObjectMeta = metaV1.ObjectMeta{Labels: map[string]string{v1alpha1.KalmLabelKeySuspended: "true"}}
+	task.applyScalingSchedules()
+	assert.Nil(t, task.scheduledReplicas)
+	assert.Nil(t, task.scalingScheduleStatus)
+}
@@ -0,0 +1,378 @@
+package controllers
+
+import (
+	"fmt"
+
+	"github.com/kalmhq/kalm/controller/api/v1alpha1"
+	appsV1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	ComponentReasonRolloutInProgress       = "RolloutInProgress"
+	ComponentReasonRolloutComplete         = "RolloutComplete"
+	ComponentReasonReconcileError          = "ReconcileError"
+	ComponentReasonWorkloadNotFound        = "WorkloadNotFound"
+	ComponentReasonNamespaceNotKalmEnabled = "NamespaceNotKalmEnabled"
+	ComponentReasonMinimumReplicasReady    = "MinimumReplicasAvailable"
+	ComponentReasonReplicasUnavailable     = "ReplicasUnavailable"
+	ComponentReasonScaledToZero            = "ScaledToZero"
+	ComponentReasonCronJobScheduled        = "CronJobScheduled"
+	ComponentReasonCronJobSuspended        = "CronJobSuspended"
+	ComponentReasonJobRunCreated           = "JobRunCreated"
+	ComponentReasonPluginFailed            = "PluginFailed"
+	ComponentReasonPluginsSucceeded        = "PluginsSucceeded"
+)
+
+// workloadRolloutStatus is the common shape of status of all kinds of workloads a component can own.
+type workloadRolloutStatus struct {
+	replicas          int32
+	readyReplicas     int32
+	updatedReplicas   int32
+	availableReplicas int32
+	image             string
+	complete          bool
+}
+
+func getMainContainerImage(template corev1.PodTemplateSpec, componentName string) string {
+	for _, container := range template.Spec.Containers {
+		if container.Name == componentName {
+			return container.Image
+		}
+	}
+
+	if len(template.Spec.Containers) > 0 {
+		return template.Spec.Containers[0].Image
+	}
+
+	return ""
+}
+
+func getDeploymentRolloutStatus(deployment *appsV1.Deployment, componentName string) workloadRolloutStatus {
+	desired := int32(1)
+	if deployment.Spec.Replicas != nil {
+		desired = *deployment.Spec.Replicas
+	}
+
+	status := deployment.Status
+
+	return workloadRolloutStatus{
+		replicas:          desired,
+		readyReplicas:     status.ReadyReplicas,
+		updatedReplicas:   status.UpdatedReplicas,
+		availableReplicas: status.AvailableReplicas,
+		image:             getMainContainerImage(deployment.Spec.Template, componentName),
+		complete: status.ObservedGeneration >= deployment.Generation &&
+			status.UpdatedReplicas == desired &&
+			status.Replicas == status.UpdatedReplicas &&
+			status.AvailableReplicas == status.UpdatedReplicas,
+	}
+}
+
+func getStatefulSetRolloutStatus(sts *appsV1.StatefulSet, componentName string) workloadRolloutStatus {
+	desired := int32(1)
+	if sts.Spec.Replicas != nil {
+		desired = *sts.Spec.Replicas
+	}
+
+	status := sts.Status
+
+	// StatefulSet doesn't report available replicas, ready pods are regarded as available.
+	return workloadRolloutStatus{
+		replicas:          desired,
+		readyReplicas:     status.ReadyReplicas,
+		updatedReplicas:   status.UpdatedReplicas,
+		availableReplicas: status.ReadyReplicas,
+		image:             getMainContainerImage(sts.Spec.Template, componentName),
+		complete: status.ObservedGeneration >= sts.Generation &&
+			status.UpdatedReplicas == desired &&
+			status.ReadyReplicas == desired &&
+			status.CurrentRevision == status.UpdateRevision,
+	}
+}
+
+func getDaemonSetRolloutStatus(ds *appsV1.DaemonSet, componentName string) workloadRolloutStatus {
+	status := ds.Status
+
+	return workloadRolloutStatus{
+		replicas:          status.DesiredNumberScheduled,
+		readyReplicas:     status.NumberReady,
+		updatedReplicas:   status.UpdatedNumberScheduled,
+		availableReplicas: status.NumberAvailable,
+		image:             getMainContainerImage(ds.Spec.Template, componentName),
+		complete: status.ObservedGeneration >= ds.Generation &&
+			status.UpdatedNumberScheduled == status.DesiredNumberScheduled &&
+			status.NumberAvailable == status.DesiredNumberScheduled,
+	}
+}
+
+// setComponentCondition adds or updates the condition, the transition time is only changed when status changes.
+func setComponentCondition(status *v1alpha1.ComponentStatus, cond v1alpha1.ComponentCondition) {
+	existing := v1alpha1.GetComponentCondition(*status, cond.Type)
+
+	if existing == nil {
+		cond.LastTransitionTime = metaV1.Now()
+		status.Conditions = append(status.Conditions, cond)
+		return
+	}
+
+	if existing.Status != cond.Status {
+		existing.LastTransitionTime = metaV1.Now()
+	}
+
+	existing.Status = cond.Status
+	existing.Reason = cond.Reason
+	existing.Message = cond.Message
+}
+
+func (r *ComponentReconcilerTask) getWorkloadRolloutStatus() (*workloadRolloutStatus, bool) {
+	name := r.component.Name
+
+	switch r.component.Spec.WorkloadType {
+	case v1alpha1.WorkloadTypeServer, "":
+		if r.deployment == nil {
+			return nil, false
+		}
+
+		status := getDeploymentRolloutStatus(r.deployment, name)
+		return &status, true
+	case v1alpha1.WorkloadTypeStatefulSet:
+		if r.statefulSet == nil {
+			return nil, false
+		}
+
+		status := getStatefulSetRolloutStatus(r.statefulSet, name)
+		return &status, true
+	case v1alpha1.WorkloadTypeDaemonSet:
+		if r.daemonSet == nil {
+			return nil, false
+		}
+
+		status := getDaemonSetRolloutStatus(r.daemonSet, name)
+		return &status, true
+	case v1alpha1.WorkloadTypeCronjob:
+		if r.cronJob == nil {
+			return nil, false
+		}
+
+		// a cronjob has no long running pods, it's rolled out once the cronjob is saved.
+		return &workloadRolloutStatus{
+			image:    getMainContainerImage(r.cronJob.Spec.JobTemplate.Spec.Template, name),
+			complete: true,
+		}, true
+	case v1alpha1.WorkloadTypeJob:
+		if r.lastJobRunID == "" {
+			return nil, false
+		}
+
+		// same as cronjob, later runs use the latest spec
+		return &workloadRolloutStatus{
+			image:    r.component.Spec.Image,
+			complete: true,
+		}, true
+	}
+
+	return nil, false
+}
+
+// UpdateStatus writes the observed state of the component workload into the status subresource.
+// reconcileErr is the error of this round of reconcile, if any.
+func (r *ComponentReconcilerTask) UpdateStatus(reconcileErr error) error {
+	status := r.component.Status.DeepCopy()
+	status.ObservedGeneration = r.component.Generation
+
+	if isComponentLabeledAsExceedingQuota(r.component) {
+		setComponentCondition(status, v1alpha1.ComponentCondition{
+			Type:    v1alpha1.ComponentConditionExceedingQuota,
+			Status:  corev1.ConditionTrue,
+			Reason:  v1alpha1.ReasonExceedingQuota,
+			Message: "component is scaled down because the application is exceeding quota",
+		})
+	} else if v1alpha1.GetComponentCondition(*status, v1alpha1.ComponentConditionExceedingQuota) != nil {
+		setComponentCondition(status, v1alpha1.ComponentCondition{
+			Type:   v1alpha1.ComponentConditionExceedingQuota,
+			Status: corev1.ConditionFalse,
+		})
+	}
+
+	if isComponentLabeledAsSuspended(r.component) {
+		setComponentCondition(status, v1alpha1.ComponentCondition{
+			Type:    v1alpha1.ComponentConditionSuspended,
+			Status:  corev1.ConditionTrue,
+			Reason:  v1alpha1.ReasonSuspended,
+			Message: "component is scaled down because the application is suspended",
+		})
+	} else if v1alpha1.GetComponentCondition(*status, v1alpha1.ComponentConditionSuspended) != nil {
+		setComponentCondition(status, v1alpha1.ComponentCondition{
+			Type:   v1alpha1.ComponentConditionSuspended,
+			Status: corev1.ConditionFalse,
+		})
+	}
+
+	status.ScalingSchedule = r.scalingScheduleStatus
+
+	if r.pluginErr != nil {
+		setComponentCondition(status, v1alpha1.ComponentCondition{
+			Type:    v1alpha1.ComponentConditionPluginError,
+			Status:  corev1.ConditionTrue,
+			Reason:  ComponentReasonPluginFailed,
+			Message: r.pluginErr.Error(),
+		})
+	} else if v1alpha1.GetComponentCondition(*status, v1alpha1.ComponentConditionPluginError) != nil {
+		setComponentCondition(status, v1alpha1.ComponentCondition{
+			Type:   v1alpha1.ComponentConditionPluginError,
+			Status: corev1.ConditionFalse,
+			Reason: ComponentReasonPluginsSucceeded,
+		})
+	}
+
+	if cond := r.getDependenciesCondition(); cond != nil {
+		setComponentCondition(status, *cond)
+	}
+
+	if r.component.Spec.WorkloadType == v1alpha1.WorkloadTypeServer || r.component.Spec.WorkloadType == "" {
+		status.Rollout = r.rolloutStatus
+	}
+
+	if r.component.Spec.WorkloadType == v1alpha1.WorkloadTypeJob {
+		status.LastJobRunID = r.lastJobRunID
+	}
+
+	rollout, exist := r.getWorkloadRolloutStatus()
+
+	switch {
+	case !IsNamespaceKalmEnabled(r.namespace):
+		status.Replicas, status.ReadyReplicas, status.UpdatedReplicas, status.AvailableReplicas = 0, 0, 0, 0
+
+		setComponentCondition(status, v1alpha1.ComponentCondition{
+			Type:   v1alpha1.ComponentConditionProgressing,
+			Status: corev1.ConditionFalse,
+			Reason: ComponentReasonNamespaceNotKalmEnabled,
+		})
+		setComponentCondition(status, v1alpha1.ComponentCondition{
+			Type:   v1alpha1.ComponentConditionAvailable,
+			Status: corev1.ConditionFalse,
+			Reason: ComponentReasonNamespaceNotKalmEnabled,
+		})
+	case !exist:
+		status.Replicas, status.ReadyReplicas, status.UpdatedReplicas, status.AvailableReplicas = 0, 0, 0, 0
+
+		cond := v1alpha1.ComponentCondition{
+			Type:   v1alpha1.ComponentConditionProgressing,
+			Status: corev1.ConditionTrue,
+			Reason: ComponentReasonWorkloadNotFound,
+		}
+
+		if reconcileErr != nil {
+			cond.Status = corev1.ConditionFalse
+			cond.Reason = ComponentReasonReconcileError
+			cond.Message = reconcileErr.Error()
+		} else if len(r.blockingDependencies) > 0 {
+			cond.Status = corev1.ConditionFalse
+			cond.Reason = ComponentReasonWaitingForDependencies
+			cond.Message = r.dependenciesMessage()
+		}
+
+		setComponentCondition(status, cond)
+		setComponentCondition(status, v1alpha1.ComponentCondition{
+			Type:   v1alpha1.ComponentConditionAvailable,
+			Status: corev1.ConditionFalse,
+			Reason: cond.Reason,
+		})
+	default:
+		status.Replicas = rollout.replicas
+		status.ReadyReplicas = rollout.readyReplicas
+		status.UpdatedReplicas = rollout.updatedReplicas
+		status.AvailableReplicas = rollout.availableReplicas
+
+		progressing := v1alpha1.ComponentCondition{
+			Type:   v1alpha1.ComponentConditionProgressing,
+			Status: corev1.ConditionTrue,
+			Reason: ComponentReasonRolloutInProgress,
+			Message: fmt.Sprintf(
+				"%d of %d updated replicas are available",
+				rollout.availableReplicas,
+				rollout.replicas,
+			),
+		}
+
+		if reconcileErr != nil {
+			progressing.Status = corev1.ConditionFalse
+			progressing.Reason = ComponentReasonReconcileError
+			progressing.Message = reconcileErr.Error()
+		} else if rolloutStatus := r.rolloutStatus; v1alpha1.IsRolloutActive(rolloutStatus) {
+			progressing.Reason = ComponentReasonProgressiveRolloutInProgress
+			progressing.Message = fmt.Sprintf(
+				"%s rollout of %s: step %d of %d, %d%% of traffic",
+				rolloutStatus.Type,
+				rolloutStatus.CanaryImage,
+				rolloutStatus.CurrentStep+1,
+				len(rolloutStatus.Steps),
+				rolloutStatus.CanaryWeight,
+			)
+		} else if rollout.complete {
+			progressing.Status = corev1.ConditionFalse
+			progressing.Reason = ComponentReasonRolloutComplete
+			progressing.Message = ""
+
+			if status.Image != rollout.image || status.LastSuccessfulRolloutTime == nil ||
+				isComponentConditionTrue(r.component.Status, v1alpha1.ComponentConditionProgressing) {
+				now := metaV1.Now()
+				status.LastSuccessfulRolloutTime = &now
+			}
+
+			status.Image = rollout.image
+		}
+
+		setComponentCondition(status, progressing)
+
+		available := v1alpha1.ComponentCondition{
+			Type:   v1alpha1.ComponentConditionAvailable,
+			Status: corev1.ConditionFalse,
+			Reason: ComponentReasonReplicasUnavailable,
+		}
+
+		if r.component.Spec.WorkloadType == v1alpha1.WorkloadTypeCronjob {
+			available.Status = corev1.ConditionTrue
+			available.Reason = ComponentReasonCronJobScheduled
+
+			if r.component.Spec.CronJob != nil && r.component.Spec.CronJob.Suspend {
+				available.Reason = ComponentReasonCronJobSuspended
+			}
+		} else if r.component.Spec.WorkloadType == v1alpha1.WorkloadTypeJob {
+			available.Status = corev1.ConditionTrue
+			available.Reason = ComponentReasonJobRunCreated
+		} else if rollout.replicas == 0 {
+			available.Reason = ComponentReasonScaledToZero
+		} else if rollout.availableReplicas >= rollout.replicas {
+			available.Status = corev1.ConditionTrue
+			available.Reason = ComponentReasonMinimumReplicasReady
+		}
+
+		setComponentCondition(status, available)
+	}
+
+	if equality.Semantic.DeepEqual(*status, r.component.Status) {
+		return nil
+	}
+
+	copied := r.component.DeepCopy()
+	copied.Status = *status
+
+	if err := r.Status().Patch(r.ctx, copied, client.MergeFrom(r.component)); err != nil {
+		r.WarningEvent(err, "Patch component status error.")
+		return err
+	}
+
+	r.component = copied
+
+	return nil
+}
+
+func isComponentConditionTrue(status v1alpha1.ComponentStatus, conditionType v1alpha1.ComponentConditionType) bool {
+	cond := v1alpha1.GetComponentCondition(status, conditionType)
+	return cond != nil && cond.Status == corev1.ConditionTrue
+}
@@ -0,0 +1,110 @@
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kalmhq/kalm/controller/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	appsV1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestGetDeploymentRolloutStatus(t *testing.T) {
+	replicas := int32(2)
+
+	deployment := &appsV1.Deployment{
+		ObjectMeta: metaV1.ObjectMeta{
+			Generation: 3,
+		},
+		Spec: appsV1.DeploymentSpec{
+			Replicas: &replicas,
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{Name: "sidecar", Image: "sidecar:v1"},
+						{Name: "foo", Image: "foo:v2"},
+					},
+				},
+			},
+		},
+		Status: appsV1.DeploymentStatus{
+			ObservedGeneration: 3,
+			Replicas:           3,
+			UpdatedReplicas:    2,
+			ReadyReplicas:      3,
+			AvailableReplicas:  3,
+		},
+	}
+
+	// an old pod is still running
+	status := getDeploymentRolloutStatus(deployment, "foo")
+	assert.False(t, status.complete)
+	assert.Equal(t, int32(2), status.replicas)
+	assert.Equal(t, "foo:v2", status.image)
+
+	deployment.Status.Replicas = 2
+	deployment.Status.AvailableReplicas = 2
+	status = getDeploymentRolloutStatus(deployment, "foo")
+	assert.True(t, status.complete)
+
+	// spec is changed but not observed by deployment controller yet
+	deployment.Generation = 4
+	status = getDeploymentRolloutStatus(deployment, "foo")
+	assert.False(t, status.complete)
+}
+
+func TestGetStatefulSetRolloutStatus(t *testing.T) {
+	sts := &appsV1.StatefulSet{
+		Status: appsV1.StatefulSetStatus{
+			UpdatedReplicas: 1,
+			ReadyReplicas:   1,
+			CurrentRevision: "foo-1",
+			UpdateRevision:  "foo-2",
+		},
+	}
+
+	status := getStatefulSetRolloutStatus(sts, "foo")
+	assert.False(t, status.complete)
+	assert.Equal(t, int32(1), status.availableReplicas)
+
+	sts.Status.CurrentRevision = "foo-2"
+	status = getStatefulSetRolloutStatus(sts, "foo")
+	assert.True(t, status.complete)
+}
+
+func TestSetComponentCondition(t *testing.T) {
+	var status v1alpha1.ComponentStatus
+
+	setComponentCondition(&status, v1alpha1.ComponentCondition{
+		Type:   v1alpha1.ComponentConditionAvailable,
+		Status: corev1.ConditionFalse,
+		Reason: ComponentReasonReplicasUnavailable,
+	})
+
+	assert.Len(t, status.Conditions, 1)
+	transitionTime := metaV1.NewTime(status.Conditions[0].LastTransitionTime.Add(-time.Hour))
+	status.Conditions[0].LastTransitionTime = transitionTime
+
+	// same status, transition time should be kept
+	setComponentCondition(&status, v1alpha1.ComponentCondition{
+		Type:    v1alpha1.ComponentConditionAvailable,
+		Status:  corev1.ConditionFalse,
+		Reason:  ComponentReasonScaledToZero,
+		Message: "foo",
+	})
+
+	assert.Len(t, status.Conditions, 1)
+	assert.Equal(t, ComponentReasonScaledToZero, status.Conditions[0].Reason)
+	assert.Equal(t, transitionTime, status.Conditions[0].LastTransitionTime)
+
+	setComponentCondition(&status, v1alpha1.ComponentCondition{
+		Type:   v1alpha1.ComponentConditionAvailable,
+		Status: corev1.ConditionTrue,
+		Reason: ComponentReasonMinimumReplicasReady,
+	})
+
+	assert.True(t, status.Conditions[0].LastTransitionTime.After(transitionTime.Time))
+	assert.True(t, v1alpha1.IsComponentAvailable(v1alpha1.Component{Status: status}))
+}
@@ -0,0 +1,71 @@
+package controllers
+
+import (
+	"github.com/kalmhq/kalm/controller/api/v1alpha1"
+	batchV1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Components of a suspended application keep no pods running. Deployments and statefulsets are scaled to zero
+// by forceScaleDownComponent. Other workloads have no replicas, so daemonsets are deleted and unfinished jobs
+// of cronjobs and job workloads are deleted. They are created again once the application is resumed.
+
+// suspendDaemonSet deletes the daemonset of a suspended component.
+func (r *ComponentReconcilerTask) suspendDaemonSet() error {
+	if r.daemonSet == nil {
+		return nil
+	}
+
+	if err := r.Delete(r.ctx, r.daemonSet); client.IgnoreNotFound(err) != nil {
+		r.WarningEvent(err, "unable to delete daemonSet of suspended component")
+		return err
+	}
+
+	r.NormalEvent("DaemonSetDeleted", r.daemonSet.Name+" is deleted because the application is suspended.")
+	r.daemonSet = nil
+
+	return nil
+}
+
+// deleteUnfinishedJobs deletes jobs of a suspended component which are still running, finished jobs are kept.
+func (r *ComponentReconcilerTask) deleteUnfinishedJobs() error {
+	var jobs batchV1.JobList
+
+	if err := r.Reader.List(
+		r.ctx,
+		&jobs,
+		client.InNamespace(r.component.Namespace),
+		client.MatchingLabels{v1alpha1.KalmLabelComponentKey: r.component.Name},
+	); err != nil {
+		return err
+	}
+
+	for i := range jobs.Items {
+		job := &jobs.Items[i]
+
+		if isJobFinished(job) {
+			continue
+		}
+
+		if err := r.Delete(r.ctx, job, client.PropagationPolicy(metaV1.DeletePropagationBackground)); client.IgnoreNotFound(err) != nil {
+			r.WarningEvent(err, "unable to delete job %s of suspended component", job.Name)
+			return err
+		}
+
+		r.NormalEvent("JobDeleted", job.Name+" is deleted because the application is suspended.")
+	}
+
+	return nil
+}
+
+func isJobFinished(job *batchV1.Job) bool {
+	for _, condition := range job.Status.Conditions {
+		if (condition.Type == batchV1.JobComplete || condition.Type == batchV1.JobFailed) && condition.Status == corev1.ConditionTrue {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,113 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kalmhq/kalm/controller/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	appsV1 "k8s.io/api/apps/v1"
+	batchV1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func newSuspendedTestTask(workloadType v1alpha1.WorkloadType, objects ...runtime.Object) *ComponentReconcilerTask {
+	c := fake.NewFakeClientWithScheme(newExportScheme(), objects...)
+
+	return &ComponentReconcilerTask{
+		ComponentReconciler: &ComponentReconciler{
+			BaseReconciler: &BaseReconciler{Client: c, Reader: c, Recorder: record.NewFakeRecorder(100)},
+		},
+		ctx: context.Background(),
+		component: &v1alpha1.Component{
+			ObjectMeta: metaV1.ObjectMeta{
+				Name:      "worker",
+				Namespace: "default",
+				Labels:    map[string]string{v1alpha1.KalmLabelKeySuspended: "true"},
+			},
+			Spec: v1alpha1.ComponentSpec{
+				Image:        "worker:v1",
+				WorkloadType: workloadType,
+			},
+		},
+	}
+}
+
+func newSuspendTestJob(name string, finished bool) *batchV1.Job {
+	job := &batchV1.Job{
+		ObjectMeta: metaV1.ObjectMeta{
+			Name:      name,
+			Namespace: "default",
+			Labels:    map[string]string{v1alpha1.KalmLabelComponentKey: "worker"},
+		},
+	}
+
+	if finished {
+		job.Status.Conditions = []batchV1.JobCondition{{Type: batchV1.JobComplete, Status: corev1.ConditionTrue}}
+	}
+
+	return job
+}
+
+func TestSuspendDaemonSet(t *testing.T) {
+	daemonSet := &appsV1.DaemonSet{ObjectMeta: metaV1.ObjectMeta{Name: "worker", Namespace: "default"}}
+	task := newSuspendedTestTask(v1alpha1.WorkloadTypeDaemonSet, daemonSet)
+	task.daemonSet = daemonSet.DeepCopy()
+
+	assert.Nil(t, task.ReconcileDaemonSet(&corev1.PodTemplateSpec{}))
+	assert.Nil(t, task.daemonSet)
+
+	err := task.Get(task.ctx, client.ObjectKey{Namespace: "default", Name: "worker"}, &appsV1.DaemonSet{})
+	assert.True(t, errors.IsNotFound(err))
+
+	// nothing to do once it's deleted
+	assert.Nil(t, task.ReconcileDaemonSet(&corev1.PodTemplateSpec{}))
+}
+
+func TestSuspendJobs(t *testing.T) {
+	task := newSuspendedTestTask(
+		v1alpha1.WorkloadTypeJob,
+		newSuspendTestJob("worker-running", false),
+		newSuspendTestJob("worker-done", true),
+	)
+
+	task.component.Spec.PendingJobRuns = []v1alpha1.JobRunRequest{{ID: "later"}}
+
+	assert.Nil(t, task.ReconcileJob(&corev1.PodTemplateSpec{}))
+
+	var jobs batchV1.JobList
+	assert.Nil(t, task.List(task.ctx, &jobs))
+
+	if assert.Len(t, jobs.Items, 1) {
+		assert.Equal(t, "worker-done", jobs.Items[0].Name)
+	}
+
+	// pending runs are created after the application is resumed
+	assert.Len(t, task.component.Spec.PendingJobRuns, 1)
+}
+
+func TestSuspendCronJob(t *testing.T) {
+	task := newSuspendedTestTask(
+		v1alpha1.WorkloadTypeCronjob,
+		newSuspendTestJob("worker-1602720000", false),
+	)
+
+	task.Scheme = newExportScheme()
+	task.namespace.Name = "default"
+	task.component.Spec.Schedule = "0 * * * *"
+
+	template := &corev1.PodTemplateSpec{ObjectMeta: metaV1.ObjectMeta{Annotations: map[string]string{}}}
+	assert.Nil(t, task.ReconcileCronJob(template))
+	assert.True(t, *task.cronJob.Spec.Suspend)
+
+	// jobs already created by the cronjob are stopped as well
+	var jobs batchV1.JobList
+	assert.Nil(t, task.List(task.ctx, &jobs))
+	assert.Empty(t, jobs.Items)
+}
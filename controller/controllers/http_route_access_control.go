@@ -0,0 +1,352 @@
+package controllers
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/kalmhq/kalm/controller/api/v1alpha1"
+	"istio.io/api/networking/v1alpha3"
+	v1alpha32 "istio.io/client-go/pkg/apis/networking/v1alpha3"
+	corev1 "k8s.io/api/core/v1"
+	rbacV1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Access control of routes is enforced by http filters of the ingress gateway, which are inserted once.
+// The rbac filter checks client IPs with the rbac config of the route, it allows all requests without route configs.
+// The lua filter responds with the maintenance response of the application unless the rbac shadow rules of the route
+// allow the request to bypass it. Then it asks the basic auth component in kalm-system to check credentials of routes
+// with basic auth, the secret of the route is passed by route metadata. Other routes are skipped.
+
+const (
+	accessControlEnvoyFilterName = "kalm-access-control"
+	rbacHttpFilterName           = "envoy.filters.http.rbac"
+	luaHttpFilterName            = "envoy.filters.http.lua"
+
+	KALM_BASIC_AUTH_NAME = "basic-auth"
+
+	basicAuthContainerPort = 3004
+
+	basicAuthSecretMetadataKey = "kalm_basic_auth_secret"
+	basicAuthRealmMetadataKey  = "kalm_basic_auth_realm"
+)
+
+const accessControlLuaCode = `function envoy_on_request(request_handle)
+  local metadata = request_handle:metadata()
+  local maintenanceStatus = metadata:get("` + maintenanceStatusMetadataKey + `")
+
+  if maintenanceStatus ~= nil then
+    local rbac = request_handle:streamInfo():dynamicMetadata():get("` + rbacHttpFilterName + `")
+
+    if rbac == nil or rbac["shadow_engine_result"] ~= "allowed" then
+      local headers = {[":status"] = maintenanceStatus}
+      local contentType = metadata:get("` + maintenanceContentTypeMetadataKey + `")
+
+      if contentType ~= nil then
+        headers["content-type"] = contentType
+      end
+
+      request_handle:respond(headers, metadata:get("` + maintenanceBodyMetadataKey + `") or "")
+      return
+    end
+  end
+
+  local secret = metadata:get("` + basicAuthSecretMetadataKey + `")
+
+  if secret == nil then
+    return
+  end
+
+  local authorization = request_handle:headers():get("authorization")
+
+  if authorization ~= nil then
+    local headers, _ = request_handle:httpCall(
+      "%s",
+      {
+        [":method"] = "GET",
+        [":path"] = "/check/" .. secret,
+        [":authority"] = "%s",
+        ["authorization"] = authorization
+      },
+      "",
+      5000)
+
+    if headers[":status"] == "200" then
+      -- credentials of the route are not passed to destinations
+      request_handle:headers():remove("authorization")
+      return
+    end
+  end
+
+  local realm = metadata:get("` + basicAuthRealmMetadataKey + `") or "kalm"
+  request_handle:respond({[":status"] = "401", ["www-authenticate"] = "Basic realm=\"" .. realm .. "\""}, "Unauthorized")
+end
+`
+
+func getBasicAuthHost() string {
+	return fmt.Sprintf("%s.%s.svc.cluster.local", KALM_BASIC_AUTH_NAME, v1alpha1.KalmSystemNamespace)
+}
+
+func getAccessControlEnvoyFilterName(route *v1alpha1.HttpRoute) string {
+	return fmt.Sprintf("access-control-%s", route.Name)
+}
+
+func buildGatewayHttpFilterPatch(value map[string]interface{}) *v1alpha3.EnvoyFilter_EnvoyConfigObjectPatch {
+	return &v1alpha3.EnvoyFilter_EnvoyConfigObjectPatch{
+		ApplyTo: v1alpha3.EnvoyFilter_HTTP_FILTER,
+		Match: &v1alpha3.EnvoyFilter_EnvoyConfigObjectMatch{
+			Context: v1alpha3.EnvoyFilter_GATEWAY,
+			ObjectTypes: &v1alpha3.EnvoyFilter_EnvoyConfigObjectMatch_Listener{
+				Listener: &v1alpha3.EnvoyFilter_ListenerMatch{
+					FilterChain: &v1alpha3.EnvoyFilter_ListenerMatch_FilterChainMatch{
+						Filter: &v1alpha3.EnvoyFilter_ListenerMatch_FilterMatch{
+							Name: "envoy.http_connection_manager",
+							SubFilter: &v1alpha3.EnvoyFilter_ListenerMatch_SubFilterMatch{
+								Name: "envoy.router",
+							},
+						},
+					},
+				},
+			},
+		},
+		Patch: &v1alpha3.EnvoyFilter_Patch{
+			Operation: v1alpha3.EnvoyFilter_Patch_INSERT_BEFORE,
+			Value:     golangMapToProtoStruct(value),
+		},
+	}
+}
+
+func buildGatewayAccessControlEnvoyFilter() *v1alpha32.EnvoyFilter {
+	return &v1alpha32.EnvoyFilter{
+		ObjectMeta: metaV1.ObjectMeta{
+			Namespace: istioNamespace,
+			Name:      accessControlEnvoyFilterName,
+			Labels: map[string]string{
+				KALM_ROUTE_LABEL: "true",
+			},
+		},
+		Spec: v1alpha3.EnvoyFilter{
+			WorkloadSelector: &v1alpha3.WorkloadSelector{
+				Labels: map[string]string{
+					"app": "istio-ingressgateway",
+				},
+			},
+			ConfigPatches: []*v1alpha3.EnvoyFilter_EnvoyConfigObjectPatch{
+				buildGatewayHttpFilterPatch(map[string]interface{}{
+					"name": rbacHttpFilterName,
+					"typed_config": map[string]interface{}{
+						"@type": "type.googleapis.com/envoy.extensions.filters.http.rbac.v3.RBAC",
+					},
+				}),
+				buildGatewayHttpFilterPatch(map[string]interface{}{
+					"name": luaHttpFilterName,
+					"typed_config": map[string]interface{}{
+						"@type":       "type.googleapis.com/envoy.extensions.filters.http.lua.v3.Lua",
+						"inline_code": fmt.Sprintf(accessControlLuaCode, fmt.Sprintf("outbound|80||%s", getBasicAuthHost()), getBasicAuthHost()),
+					},
+				}),
+			},
+		},
+	}
+}
+
+// buildAccessControlEnvoyFilter sets the rbac config and the lua metadata on routes of the http route,
+// for access control of the route and the maintenance of the application it sends requests to.
+func (r *HttpRouteReconcilerTask) buildAccessControlEnvoyFilter(route *v1alpha1.HttpRoute) *v1alpha32.EnvoyFilter {
+	rbac := make(map[string]interface{})
+	metadata := make(map[string]interface{})
+
+	if accessControl := route.Spec.AccessControl; accessControl != nil {
+		if principal := buildAccessControlPrincipal(accessControl); principal != nil {
+			rbac["rules"] = buildAllowRBACRules("kalm-route-access-control", principal)
+		}
+
+		if basicAuth := accessControl.BasicAuth; basicAuth != nil {
+			realm := basicAuth.Realm
+
+			if realm == "" {
+				realm = route.Name
+			}
+
+			metadata[basicAuthSecretMetadataKey] = basicAuth.SecretNamespace + "/" + basicAuth.SecretName
+			metadata[basicAuthRealmMetadataKey] = realm
+		}
+	}
+
+	if maintenance, ok := r.routeMaintenances[route.Name]; ok {
+		if principal := buildMaintenanceBypassPrincipal(maintenance.ApplicationMaintenance); principal != nil {
+			rbac["shadow_rules"] = buildAllowRBACRules(maintenanceBypassPolicyName, principal)
+		}
+
+		for key, value := range r.buildMaintenanceMetadata(route, maintenance) {
+			metadata[key] = value
+		}
+	}
+
+	value := make(map[string]interface{})
+
+	if len(rbac) > 0 {
+		value["typed_per_filter_config"] = map[string]interface{}{
+			rbacHttpFilterName: map[string]interface{}{
+				"@type": "type.googleapis.com/envoy.extensions.filters.http.rbac.v3.RBACPerRoute",
+				"rbac":  rbac,
+			},
+		}
+	}
+
+	if len(metadata) > 0 {
+		value["metadata"] = map[string]interface{}{
+			"filter_metadata": map[string]interface{}{
+				luaHttpFilterName: metadata,
+			},
+		}
+	}
+
+	return buildRouteMergeEnvoyFilter(getAccessControlEnvoyFilterName(route), getIstioHttpRouteName(route), value)
+}
+
+func buildAllowRBACRules(policyName string, principal map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"action": "ALLOW",
+		"policies": map[string]interface{}{
+			policyName: map[string]interface{}{
+				"permissions": []interface{}{
+					map[string]interface{}{"any": true},
+				},
+				"principals": []interface{}{principal},
+			},
+		},
+	}
+}
+
+// buildAccessControlPrincipal matches clients which are allowed and not denied, nil if IPs are not limited
+func buildAccessControlPrincipal(accessControl *v1alpha1.HttpRouteAccessControl) map[string]interface{} {
+	allowed := buildRemoteIPsPrincipal(accessControl.AllowedIPs)
+	denied := buildRemoteIPsPrincipal(accessControl.DeniedIPs)
+
+	if denied != nil {
+		denied = map[string]interface{}{"not_id": denied}
+	}
+
+	switch {
+	case allowed != nil && denied != nil:
+		return map[string]interface{}{
+			"and_ids": map[string]interface{}{
+				"ids": []interface{}{allowed, denied},
+			},
+		}
+	case allowed != nil:
+		return allowed
+	default:
+		return denied
+	}
+}
+
+func buildRemoteIPsPrincipal(ips []string) map[string]interface{} {
+	if len(ips) == 0 {
+		return nil
+	}
+
+	ids := make([]interface{}, 0, len(ips))
+
+	for _, ip := range ips {
+		address, prefixLen := parseIPOrCIDR(ip)
+
+		ids = append(ids, map[string]interface{}{
+			"remote_ip": map[string]interface{}{
+				"address_prefix": address,
+				"prefix_len":     prefixLen,
+			},
+		})
+	}
+
+	return map[string]interface{}{
+		"or_ids": map[string]interface{}{
+			"ids": ids,
+		},
+	}
+}
+
+// parseIPOrCIDR returns the address and the prefix length, IPs are CIDRs of a single address
+func parseIPOrCIDR(ip string) (string, int) {
+	if _, ipNet, err := net.ParseCIDR(ip); err == nil {
+		prefixLen, _ := ipNet.Mask.Size()
+		return ipNet.IP.String(), prefixLen
+	}
+
+	if strings.Contains(ip, ":") {
+		return ip, 128
+	}
+
+	return ip, 32
+}
+
+func buildBasicAuthComponent() *v1alpha1.Component {
+	imgTag := getKalmVersionFromEnv()
+
+	if imgTag == "" {
+		imgTag = DefaultAuthProxyImgTag
+	}
+
+	return &v1alpha1.Component{
+		ObjectMeta: metaV1.ObjectMeta{
+			Name:      KALM_BASIC_AUTH_NAME,
+			Namespace: v1alpha1.KalmSystemNamespace,
+		},
+		Spec: v1alpha1.ComponentSpec{
+			WorkloadType: v1alpha1.WorkloadTypeServer,
+			Image:        fmt.Sprintf("kalmhq/kalm:%s", imgTag),
+			Command:      "./basic-auth",
+			Ports: []v1alpha1.Port{
+				{
+					ContainerPort: basicAuthContainerPort,
+					ServicePort:   80,
+					Protocol:      v1alpha1.PortProtocolHTTP,
+				},
+			},
+			RunnerPermission: &v1alpha1.RunnerPermission{
+				RoleType: "clusterRole",
+				Rules: []rbacV1.PolicyRule{
+					{
+						APIGroups: []string{v1alpha1.GroupVersion.Group},
+						Resources: []string{"httproutes"},
+						Verbs:     []string{"list"},
+					},
+					{
+						APIGroups: []string{""},
+						Resources: []string{"secrets"},
+						Verbs:     []string{"get"},
+					},
+				},
+			},
+			ResourceRequirements: &corev1.ResourceRequirements{
+				Requests: map[corev1.ResourceName]resource.Quantity{
+					corev1.ResourceCPU:    resource.MustParse("10m"),
+					corev1.ResourceMemory: resource.MustParse("10Mi"),
+				},
+			},
+		},
+	}
+}
+
+// reconcileBasicAuth creates the basic auth component once any route requires basic auth.
+func (r *HttpRouteReconcilerTask) reconcileBasicAuth() error {
+	var basicAuth v1alpha1.Component
+
+	err := r.Get(r.ctx, client.ObjectKey{Namespace: v1alpha1.KalmSystemNamespace, Name: KALM_BASIC_AUTH_NAME}, &basicAuth)
+
+	if !errors.IsNotFound(err) {
+		return err
+	}
+
+	if err := r.Create(r.ctx, buildBasicAuthComponent()); err != nil && !errors.IsAlreadyExists(err) {
+		r.Log.Error(err, "unable to create the basic auth component")
+		return err
+	}
+
+	return nil
+}
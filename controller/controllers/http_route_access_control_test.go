@@ -0,0 +1,73 @@
+package controllers
+
+import (
+	"strings"
+	"testing"
+
+	corev1alpha1 "github.com/kalmhq/kalm/controller/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildAccessControlPrincipal(t *testing.T) {
+	assert.Nil(t, buildAccessControlPrincipal(&corev1alpha1.HttpRouteAccessControl{}))
+
+	principal := buildAccessControlPrincipal(&corev1alpha1.HttpRouteAccessControl{
+		AllowedIPs: []string{"10.0.0.0/8"},
+	})
+
+	assert.Equal(t, map[string]interface{}{
+		"or_ids": map[string]interface{}{
+			"ids": []interface{}{
+				map[string]interface{}{
+					"remote_ip": map[string]interface{}{"address_prefix": "10.0.0.0", "prefix_len": 8},
+				},
+			},
+		},
+	}, principal)
+
+	principal = buildAccessControlPrincipal(&corev1alpha1.HttpRouteAccessControl{
+		DeniedIPs: []string{"10.0.0.1", "2001:db8::1"},
+	})
+
+	ids := principal["not_id"].(map[string]interface{})["or_ids"].(map[string]interface{})["ids"].([]interface{})
+	if assert.Len(t, ids, 2) {
+		assert.Equal(t, 32, ids[0].(map[string]interface{})["remote_ip"].(map[string]interface{})["prefix_len"])
+		assert.Equal(t, 128, ids[1].(map[string]interface{})["remote_ip"].(map[string]interface{})["prefix_len"])
+	}
+
+	principal = buildAccessControlPrincipal(&corev1alpha1.HttpRouteAccessControl{
+		AllowedIPs: []string{"10.0.0.0/8"},
+		DeniedIPs:  []string{"10.0.0.1"},
+	})
+
+	assert.Len(t, principal["and_ids"].(map[string]interface{})["ids"], 2)
+}
+
+func TestBuildAccessControlEnvoyFilter(t *testing.T) {
+	route := &corev1alpha1.HttpRoute{}
+	route.Name = "staging"
+	route.Spec.AccessControl = &corev1alpha1.HttpRouteAccessControl{
+		BasicAuth: &corev1alpha1.HttpRouteBasicAuth{
+			SecretNamespace: "staging",
+			SecretName:      "users",
+		},
+	}
+
+	filter := (&HttpRouteReconcilerTask{}).buildAccessControlEnvoyFilter(route)
+
+	assert.Equal(t, "access-control-staging", filter.Name)
+
+	value := filter.Spec.ConfigPatches[0].Patch.Value.Fields
+	assert.NotContains(t, value, "typed_per_filter_config")
+
+	metadata := value["metadata"].GetStructValue().Fields["filter_metadata"].GetStructValue().Fields[luaHttpFilterName].GetStructValue().Fields
+	assert.Equal(t, "staging/users", metadata[basicAuthSecretMetadataKey].GetStringValue())
+	assert.Equal(t, "staging", metadata[basicAuthRealmMetadataKey].GetStringValue())
+
+	gatewayFilter := buildGatewayAccessControlEnvoyFilter()
+	if assert.Len(t, gatewayFilter.Spec.ConfigPatches, 2) {
+		lua := gatewayFilter.Spec.ConfigPatches[1].Patch.Value.Fields["typed_config"].GetStructValue().Fields["inline_code"].GetStringValue()
+		assert.True(t, strings.Contains(lua, "outbound|80||basic-auth.kalm-system.svc.cluster.local"))
+		assert.False(t, strings.Contains(lua, "%!"))
+	}
+}
@@ -24,6 +24,7 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/gogo/protobuf/proto"
 	protoTypes "github.com/gogo/protobuf/types"
 	"istio.io/api/networking/v1alpha3"
 	istioNetworkingV1Beta1 "istio.io/api/networking/v1beta1"
@@ -38,6 +39,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	corev1alpha1 "github.com/kalmhq/kalm/controller/api/v1alpha1"
+	"github.com/kalmhq/kalm/controller/lib/files"
 	ctrl "sigs.k8s.io/controller-runtime"
 )
 
@@ -47,20 +49,15 @@ const (
 
 const KALM_SSO_GRANTED_GROUPS_HEADER = "kalm-sso-granted-groups"
 const KALM_SSO_GRANTED_EMAILS_HEADER = "kalm-sso-granted-emails"
-const KALM_SSO_USERINFO_HEADER = "kalm-sso-userinfo"
-const KALM_SSO_SET_COOKIE_PAYLOAD_HEADER = "kalm-set-cookie"
-const KALM_ROUTE_HEADER = "kalm-route"
-const KALM_ALLOW_TO_PASS_IF_HAS_BEARER_TOKEN_HEADER = "allow-to-pass-if-has-bearer-token"
+const KALM_SSO_USERINFO_HEADER = corev1alpha1.KalmSSOUserinfoHeader
+const KALM_SSO_SET_COOKIE_PAYLOAD_HEADER = corev1alpha1.KalmSSOSetCookiePayloadHeader
+const KALM_ROUTE_HEADER = corev1alpha1.KalmRouteHeader
+const KALM_ALLOW_TO_PASS_IF_HAS_BEARER_TOKEN_HEADER = corev1alpha1.KalmAllowToPassIfHasBearerTokenHeader
 
-const KALM_AUTH_EMAIL = "kalm-auth-email"
+const KALM_AUTH_EMAIL = corev1alpha1.KalmAuthEmailHeader
 
-var DANGEROUS_HEADERS = []string{
-	KALM_SSO_USERINFO_HEADER,
-	KALM_ALLOW_TO_PASS_IF_HAS_BEARER_TOKEN_HEADER,
-	KALM_ROUTE_HEADER,
-	KALM_SSO_SET_COOKIE_PAYLOAD_HEADER,
-	KALM_AUTH_EMAIL,
-}
+// the list is shared with the webhook of routes, which prevents routes from changing these headers
+var DANGEROUS_HEADERS = corev1alpha1.DangerousHeaders
 
 type HttpRouteReconcilerTask struct {
 	*HttpRouteReconciler
@@ -69,6 +66,18 @@ type HttpRouteReconcilerTask struct {
 	gateways                  []v1beta1.Gateway
 	virtualServices           []v1beta1.VirtualService
 	httpsRedirectEnvoyFilters []v1alpha32.EnvoyFilter
+
+	// component service host -> traffic weight of the canary version, for components during a progressive rollout
+	canaryWeights map[string]int32
+
+	// service hosts of components scaled to zero or waking up, requests to them are sent to the activator
+	scaledToZeroHosts map[string]bool
+
+	// route name -> maintenance of the application the route sends requests to
+	routeMaintenances map[string]*routeMaintenance
+
+	// route name -> filter of the direct response, routes with direct responses are skipped without filters
+	directResponseFilters map[string]*v1alpha32.EnvoyFilter
 }
 
 func getIstioHttpRouteName(route *corev1alpha1.HttpRoute) string {
@@ -83,24 +92,34 @@ func getHttpsRedirectEnvoyFilterName(route *corev1alpha1.HttpRoute) string {
 func (r *HttpRouteReconcilerTask) buildIstioHttpRoute(route *corev1alpha1.HttpRoute) *istioNetworkingV1Beta1.HTTPRoute {
 	spec := &route.Spec
 	httpRoute := &istioNetworkingV1Beta1.HTTPRoute{
-		Name:  getIstioHttpRouteName(route),
-		Route: r.BuildDestinations(route),
-		Headers: &istioNetworkingV1Beta1.Headers{
-			Request: &istioNetworkingV1Beta1.Headers_HeaderOperations{
-				Remove: DANGEROUS_HEADERS,
-				Set: map[string]string{
-					KALM_ROUTE_HEADER: "true",
-				},
-			},
-		},
+		Name:    getIstioHttpRouteName(route),
+		Route:   r.BuildDestinations(route),
+		Headers: buildIstioHeaders(spec.Headers),
 	}
 
+	removeActivatorTargetHeaderOnDestinations(httpRoute)
+
 	if spec.StripPath {
 		httpRoute.Rewrite = &istioNetworkingV1Beta1.HTTPRewrite{
 			Uri: "/",
 		}
 	}
 
+	if spec.Rewrite != nil {
+		httpRoute.Rewrite = buildIstioRewrite(spec.Rewrite)
+	}
+
+	// redirect routes have no destinations
+	if spec.Redirect != nil {
+		httpRoute.Route = nil
+		httpRoute.Redirect = buildIstioRedirect(spec.Redirect)
+	}
+
+	if spec.DirectResponse != nil {
+		httpRoute.Route = nil
+		httpRoute.Redirect = directResponsePlaceholder()
+	}
+
 	// Disable 5s timeout bug
 	// if spec.Timeout != nil {
 	// 	httpRoute.Timeout = &protoTypes.Duration{
@@ -198,6 +217,43 @@ func (r *HttpRouteReconcilerTask) buildIstioHttpRoute(route *corev1alpha1.HttpRo
 
 }
 
+// buildIstioHeaders returns header operations of the route,
+// kalm headers are always removed from requests and can't be changed by routes.
+func buildIstioHeaders(headers *corev1alpha1.HttpRouteHeaders) *istioNetworkingV1Beta1.Headers {
+	request := &istioNetworkingV1Beta1.Headers_HeaderOperations{
+		Remove: DANGEROUS_HEADERS,
+		Set: map[string]string{
+			KALM_ROUTE_HEADER: "true",
+		},
+	}
+
+	if headers == nil {
+		return &istioNetworkingV1Beta1.Headers{Request: request}
+	}
+
+	if headers.Request != nil {
+		for header, value := range headers.Request.Set {
+			request.Set[header] = value
+		}
+
+		request.Set[KALM_ROUTE_HEADER] = "true"
+		request.Add = headers.Request.Add
+		request.Remove = append(append([]string{}, DANGEROUS_HEADERS...), headers.Request.Remove...)
+	}
+
+	istioHeaders := &istioNetworkingV1Beta1.Headers{Request: request}
+
+	if headers.Response != nil {
+		istioHeaders.Response = &istioNetworkingV1Beta1.Headers_HeaderOperations{
+			Set:    headers.Response.Set,
+			Add:    headers.Response.Add,
+			Remove: headers.Response.Remove,
+		}
+	}
+
+	return istioHeaders
+}
+
 // Kalm route level http to https redirect is achieved by adding envoy filter for istio ingress gateway
 //
 func (r *HttpRouteReconcilerTask) buildHttpsRedirectEnvoyFilter(route *corev1alpha1.HttpRoute) (*v1alpha32.EnvoyFilter, error) {
@@ -256,6 +312,68 @@ func (r *HttpRouteReconcilerTask) buildHttpsRedirectEnvoyFilter(route *corev1alp
 	return filter, nil
 }
 
+// buildRouteMergeEnvoyFilter merges the value into envoy routes with the name on the ingress gateway,
+// the routes are matched by name as the https redirect filter does.
+func buildRouteMergeEnvoyFilter(name, istioRouteName string, value map[string]interface{}) *v1alpha32.EnvoyFilter {
+	return &v1alpha32.EnvoyFilter{
+		ObjectMeta: metaV1.ObjectMeta{
+			Namespace: istioNamespace,
+			Name:      name,
+			Labels: map[string]string{
+				KALM_ROUTE_LABEL: "true",
+			},
+		},
+		Spec: v1alpha3.EnvoyFilter{
+			WorkloadSelector: &v1alpha3.WorkloadSelector{
+				Labels: map[string]string{
+					"app": "istio-ingressgateway",
+				},
+			},
+			ConfigPatches: []*v1alpha3.EnvoyFilter_EnvoyConfigObjectPatch{
+				{
+					ApplyTo: v1alpha3.EnvoyFilter_HTTP_ROUTE,
+					Match: &v1alpha3.EnvoyFilter_EnvoyConfigObjectMatch{
+						Context: v1alpha3.EnvoyFilter_GATEWAY,
+						ObjectTypes: &v1alpha3.EnvoyFilter_EnvoyConfigObjectMatch_RouteConfiguration{
+							RouteConfiguration: &v1alpha3.EnvoyFilter_RouteConfigurationMatch{
+								Vhost: &v1alpha3.EnvoyFilter_RouteConfigurationMatch_VirtualHostMatch{
+									Route: &v1alpha3.EnvoyFilter_RouteConfigurationMatch_RouteMatch{
+										Name: istioRouteName,
+									},
+								},
+							},
+						},
+					},
+					Patch: &v1alpha3.EnvoyFilter_Patch{
+						Operation: v1alpha3.EnvoyFilter_Patch_MERGE,
+						Value:     golangMapToProtoStruct(value),
+					},
+				},
+			},
+		},
+	}
+}
+
+// saveRouteEnvoyFilter creates the filter or updates the existing one in filters if its spec is changed,
+// the filter is removed from filters so it's not cleaned.
+func (r *HttpRouteReconcilerTask) saveRouteEnvoyFilter(filters map[string]*v1alpha32.EnvoyFilter, filter *v1alpha32.EnvoyFilter) error {
+	existing, ok := filters[filter.Name]
+
+	if !ok {
+		return r.Create(r.ctx, filter)
+	}
+
+	delete(filters, filter.Name)
+
+	if proto.Equal(&existing.Spec, &filter.Spec) {
+		return nil
+	}
+
+	existing.Spec = filter.Spec
+
+	return r.Update(r.ctx, existing)
+}
+
 func (r *HttpRouteReconcilerTask) buildIstioHttpRoutes(route *corev1alpha1.HttpRoute) []*istioNetworkingV1Beta1.HTTPRoute {
 	matches := r.BuildMatches(route)
 	res := make([]*istioNetworkingV1Beta1.HTTPRoute, 0)
@@ -277,6 +395,10 @@ func sortRoutes(a, b *istioNetworkingV1Beta1.HTTPRoute) bool {
 	aUriIsNil := aUri == nil
 	bUriIsNil := bUri == nil
 
+	if aUriIsNil && bUriIsNil {
+		return sortRoutesWithSameUri(a, b)
+	}
+
 	if aUriIsNil {
 		return false
 	}
@@ -297,6 +419,10 @@ func sortRoutes(a, b *istioNetworkingV1Beta1.HTTPRoute) bool {
 	}
 
 	if aUriIsRegexp && bUriIsRegexp {
+		if aRegexp.Regex == bRegexp.Regex {
+			return sortRoutesWithSameUri(a, b)
+		}
+
 		// TODO this is temporary solution
 		// will use regexp priority later
 		return aRegexp.Regex > bRegexp.Regex
@@ -309,10 +435,27 @@ func sortRoutes(a, b *istioNetworkingV1Beta1.HTTPRoute) bool {
 		panic("uri is neither a regexp or a prefix")
 	}
 
+	if aPrefix.Prefix == bPrefix.Prefix {
+		return sortRoutesWithSameUri(a, b)
+	}
+
 	// Long prefix should be nearer to the front
 	return aPrefix.Prefix > bPrefix.Prefix
 }
 
+// Routes with more conditions are more specific.
+// Redirects and direct responses go before other routes with the same match, otherwise they would never be used.
+func sortRoutesWithSameUri(a, b *istioNetworkingV1Beta1.HTTPRoute) bool {
+	aConditions := len(a.Match[0].Headers) + len(a.Match[0].QueryParams) + len(a.Match[0].WithoutHeaders)
+	bConditions := len(b.Match[0].Headers) + len(b.Match[0].QueryParams) + len(b.Match[0].WithoutHeaders)
+
+	if aConditions != bConditions {
+		return aConditions > bConditions
+	}
+
+	return a.Redirect != nil && b.Redirect == nil
+}
+
 func (r *HttpRouteReconcilerTask) Run(ctrl.Request) error {
 	var routes corev1alpha1.HttpRouteList
 	if err := r.Reader.List(r.ctx, &routes); err != nil {
@@ -320,6 +463,12 @@ func (r *HttpRouteReconcilerTask) Run(ctrl.Request) error {
 	}
 	r.routes = routes.Items
 
+	if err := r.loadRouteMaintenances(); err != nil {
+		return err
+	}
+
+	r.loadDirectResponseFilters()
+
 	var virtualServices v1beta1.VirtualServiceList
 	if err := r.Reader.List(r.ctx, &virtualServices, client.MatchingLabels{KALM_ROUTE_LABEL: "true"}); err != nil {
 		return err
@@ -332,6 +481,25 @@ func (r *HttpRouteReconcilerTask) Run(ctrl.Request) error {
 	}
 	r.httpsRedirectEnvoyFilters = httpsRedirectEnvoyFilters.Items
 
+	var components corev1alpha1.ComponentList
+	if err := r.Reader.List(r.ctx, &components); err != nil {
+		return err
+	}
+
+	r.canaryWeights = make(map[string]int32)
+	r.scaledToZeroHosts = make(map[string]bool)
+	for i := range components.Items {
+		component := &components.Items[i]
+
+		if corev1alpha1.IsRolloutActive(component.Status.Rollout) {
+			r.canaryWeights[getComponentServiceHost(component)] = component.Status.Rollout.CanaryWeight
+		}
+
+		if corev1alpha1.IsComponentScaledToZero(component) || corev1alpha1.IsComponentWaking(component) {
+			r.scaledToZeroHosts[getComponentServiceHost(component)] = true
+		}
+	}
+
 	// Each host will has a virtual service
 	// Kalm will order http route rules, and set them in the virtual service http field.
 	hostVirtualService := make(map[string][]*istioNetworkingV1Beta1.HTTPRoute)
@@ -339,6 +507,10 @@ func (r *HttpRouteReconcilerTask) Run(ctrl.Request) error {
 	for i := range r.routes {
 		route := r.routes[i]
 
+		if route.Spec.DirectResponse != nil && r.directResponseFilters[route.Name] == nil {
+			continue
+		}
+
 		for j := range route.Spec.Hosts {
 			host := route.Spec.Hosts[j]
 
@@ -393,6 +565,25 @@ func (r *HttpRouteReconcilerTask) Run(ctrl.Request) error {
 		r.Status().Update(r.ctx, &route)
 	}
 
+	httpsRedirectFilterMap := make(map[string]*v1alpha32.EnvoyFilter)
+
+	for i := range r.httpsRedirectEnvoyFilters {
+		filter := r.httpsRedirectEnvoyFilters[i]
+		httpsRedirectFilterMap[filter.Name] = &filter
+	}
+
+	// direct responses replace the placeholder redirects of virtual service routes, so they are saved first
+	for i := range r.routes {
+		route := r.routes[i]
+
+		if filter := r.directResponseFilters[route.Name]; filter != nil {
+			if err := r.saveRouteEnvoyFilter(httpsRedirectFilterMap, filter); err != nil {
+				r.EmitWarningEvent(&route, err, "Save Direct Response filter Error")
+				return err
+			}
+		}
+	}
+
 	for host, routes := range hostVirtualService {
 		// Less reports whether the element with
 		// index i should sort before the element with index j.
@@ -403,12 +594,9 @@ func (r *HttpRouteReconcilerTask) Run(ctrl.Request) error {
 		}
 	}
 
-	httpsRedirectFilterMap := make(map[string]*v1alpha32.EnvoyFilter)
-
-	for i := range r.httpsRedirectEnvoyFilters {
-		filter := r.httpsRedirectEnvoyFilters[i]
-		httpsRedirectFilterMap[filter.Name] = &filter
-	}
+	hasRateLimit := false
+	hasAccessControl := false
+	hasBasicAuth := false
 
 	// Create or delete envoy filter on gateway for routes
 	for i := range r.routes {
@@ -431,6 +619,51 @@ func (r *HttpRouteReconcilerTask) Run(ctrl.Request) error {
 				delete(httpsRedirectFilterMap, filterName)
 			}
 		}
+
+		if filter := r.buildRewriteEnvoyFilter(&route); filter != nil {
+			if err := r.saveRouteEnvoyFilter(httpsRedirectFilterMap, filter); err != nil {
+				r.EmitWarningEvent(&route, err, "Save Rewrite filter Error")
+				return err
+			}
+		}
+
+		// the maintenance of the application is checked by the access control filters as well
+		if _, inMaintenance := r.routeMaintenances[route.Name]; route.Spec.AccessControl != nil || inMaintenance {
+			hasAccessControl = true
+			hasBasicAuth = hasBasicAuth || (route.Spec.AccessControl != nil && route.Spec.AccessControl.BasicAuth != nil)
+
+			if err := r.saveRouteEnvoyFilter(httpsRedirectFilterMap, r.buildAccessControlEnvoyFilter(&route)); err != nil {
+				r.EmitWarningEvent(&route, err, "Save Access Control filter Error")
+				return err
+			}
+		}
+
+		if route.Spec.RateLimit != nil {
+			hasRateLimit = true
+
+			if err := r.saveRouteEnvoyFilter(httpsRedirectFilterMap, r.buildRateLimitEnvoyFilter(&route)); err != nil {
+				r.EmitWarningEvent(&route, err, "Save Rate Limit filter Error")
+				return err
+			}
+		}
+	}
+
+	if hasRateLimit {
+		if err := r.saveRouteEnvoyFilter(httpsRedirectFilterMap, buildLocalRateLimitEnvoyFilter()); err != nil {
+			return err
+		}
+	}
+
+	if hasAccessControl {
+		if err := r.saveRouteEnvoyFilter(httpsRedirectFilterMap, buildGatewayAccessControlEnvoyFilter()); err != nil {
+			return err
+		}
+	}
+
+	if hasBasicAuth {
+		if err := r.reconcileBasicAuth(); err != nil {
+			return err
+		}
 	}
 
 	// clean left unused envoy filters
@@ -708,6 +941,137 @@ func (r *HttpRouteReconcilerTask) BuildDestinations(route *corev1alpha1.HttpRout
 		res = append(res, toHttpRouteDestination(destination, weight))
 	}
 
+	res = splitCanaryDestinations(res, r.canaryWeights)
+
+	return sendScaledToZeroDestinationsToActivator(res, r.scaledToZeroHosts)
+}
+
+// sendScaledToZeroDestinationsToActivator replaces destinations of components scaled to zero with the activator,
+// the original destination is passed to the activator in a header.
+func sendScaledToZeroDestinationsToActivator(
+	destinations []*istioNetworkingV1Beta1.HTTPRouteDestination,
+	scaledToZeroHosts map[string]bool,
+) []*istioNetworkingV1Beta1.HTTPRouteDestination {
+	for i, dest := range destinations {
+		if !scaledToZeroHosts[dest.Destination.Host] {
+			continue
+		}
+
+		target := dest.Destination.Host
+		if dest.Destination.Port != nil {
+			target = fmt.Sprintf("%s:%d", target, dest.Destination.Port.Number)
+		}
+
+		destinations[i] = &istioNetworkingV1Beta1.HTTPRouteDestination{
+			Destination: &istioNetworkingV1Beta1.Destination{
+				Host: getActivatorHost(),
+				Port: &istioNetworkingV1Beta1.PortSelector{
+					Number: 80,
+				},
+			},
+			Weight: dest.Weight,
+			Headers: &istioNetworkingV1Beta1.Headers{
+				Request: &istioNetworkingV1Beta1.Headers_HeaderOperations{
+					Set: map[string]string{
+						KALM_ACTIVATOR_TARGET_HEADER: target,
+					},
+				},
+			},
+		}
+	}
+
+	return destinations
+}
+
+// removeActivatorTargetHeaderOnDestinations keeps the activator target header set on destinations sent to the activator.
+// Envoy applies headers of weighted destinations before headers of the route, so if any destination of the route
+// is the activator, the header is removed from requests to the other destinations instead of the whole route.
+func removeActivatorTargetHeaderOnDestinations(httpRoute *istioNetworkingV1Beta1.HTTPRoute) {
+	isActivator := func(dest *istioNetworkingV1Beta1.HTTPRouteDestination) bool {
+		return dest.Headers != nil && dest.Headers.Request != nil && dest.Headers.Request.Set[KALM_ACTIVATOR_TARGET_HEADER] != ""
+	}
+
+	hasActivator := false
+
+	for _, dest := range httpRoute.Route {
+		if isActivator(dest) {
+			hasActivator = true
+		}
+	}
+
+	if !hasActivator {
+		return
+	}
+
+	var remove []string
+
+	for _, header := range httpRoute.Headers.Request.Remove {
+		if header != KALM_ACTIVATOR_TARGET_HEADER {
+			remove = append(remove, header)
+		}
+	}
+
+	httpRoute.Headers.Request.Remove = remove
+
+	for _, dest := range httpRoute.Route {
+		if isActivator(dest) {
+			continue
+		}
+
+		if dest.Headers == nil {
+			dest.Headers = &istioNetworkingV1Beta1.Headers{}
+		}
+
+		if dest.Headers.Request == nil {
+			dest.Headers.Request = &istioNetworkingV1Beta1.Headers_HeaderOperations{}
+		}
+
+		dest.Headers.Request.Remove = append(dest.Headers.Request.Remove, KALM_ACTIVATOR_TARGET_HEADER)
+	}
+}
+
+// splitCanaryDestinations splits destinations of components during a progressive rollout
+// into the stable and canary subsets.
+func splitCanaryDestinations(
+	destinations []*istioNetworkingV1Beta1.HTTPRouteDestination,
+	canaryWeights map[string]int32,
+) []*istioNetworkingV1Beta1.HTTPRouteDestination {
+	var res []*istioNetworkingV1Beta1.HTTPRouteDestination
+	var originWeights []int
+	split := false
+
+	for _, dest := range destinations {
+		canaryWeight, exist := canaryWeights[dest.Destination.Host]
+
+		if !exist {
+			res = append(res, dest)
+			originWeights = append(originWeights, int(dest.Weight)*100)
+			continue
+		}
+
+		split = true
+
+		stable := dest.DeepCopy()
+		stable.Destination.Subset = RolloutSubsetStable
+		res = append(res, stable)
+		originWeights = append(originWeights, int(dest.Weight*(100-canaryWeight)))
+
+		if canaryWeight > 0 {
+			canary := dest.DeepCopy()
+			canary.Destination.Subset = RolloutSubsetCanary
+			res = append(res, canary)
+			originWeights = append(originWeights, int(dest.Weight*canaryWeight))
+		}
+	}
+
+	if !split {
+		return destinations
+	}
+
+	for i, weight := range adjustWeightToSumTo100(originWeights) {
+		res[i].Weight = weight
+	}
+
 	return res
 }
 
@@ -778,6 +1142,8 @@ type HttpRouteReconciler struct {
 // +kubebuilder:rbac:groups=core.kalm.dev,resources=httproutes/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=networking.istio.io,resources=virtualservices,verbs=*
 // +kubebuilder:rbac:groups=networking.istio.io,resources=gateways,verbs=*
+// +kubebuilder:rbac:groups="",resources=namespaces,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch
 
 func (r *HttpRouteReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 	task := &HttpRouteReconcilerTask{
@@ -796,6 +1162,9 @@ type WatchAllKalmGateway struct{}
 type WatchAllKalmVirtualService struct{}
 type WatchAllKalmEnvoyFilter struct{}
 type WatchAllService struct{}
+type WatchAllComponentsAffectingRoutes struct{}
+type WatchAllApplicationsInMaintenance struct{}
+type WatchAllKalmFiles struct{}
 
 func (*WatchAllKalmGateway) Map(object handler.MapObject) []reconcile.Request {
 	gateway, ok := object.Object.(*v1beta1.Gateway)
@@ -830,6 +1199,44 @@ func (*WatchAllService) Map(object handler.MapObject) []reconcile.Request {
 	return []reconcile.Request{{NamespacedName: types.NamespacedName{}}}
 }
 
+// components with a progressive rollout or scale to zero change destinations of routes
+func (*WatchAllComponentsAffectingRoutes) Map(object handler.MapObject) []reconcile.Request {
+	component, ok := object.Object.(*corev1alpha1.Component)
+	if !ok {
+		return nil
+	}
+
+	_, scaledToZero := component.Annotations[corev1alpha1.KalmAnnoScaledToZeroAt]
+	_, waking := component.Annotations[corev1alpha1.KalmAnnoWakingAt]
+
+	if component.Spec.RolloutStrategy == nil && component.Status.Rollout == nil && !scaledToZero && !waking && component.Spec.ScaleToZero == nil {
+		return nil
+	}
+	return []reconcile.Request{{NamespacedName: types.NamespacedName{}}}
+}
+
+// updates of namespaces are mapped for both the old and the new object, so the end of maintenance is seen as well
+func (*WatchAllApplicationsInMaintenance) Map(object handler.MapObject) []reconcile.Request {
+	namespace, ok := object.Object.(*corev1.Namespace)
+	if !ok {
+		return nil
+	}
+
+	if _, exist := namespace.Annotations[corev1alpha1.KalmAnnoApplicationMaintenance]; !exist {
+		return nil
+	}
+	return []reconcile.Request{{NamespacedName: types.NamespacedName{}}}
+}
+
+// bodies of direct responses can be read from kalm files
+func (*WatchAllKalmFiles) Map(object handler.MapObject) []reconcile.Request {
+	configMap, ok := object.Object.(*corev1.ConfigMap)
+	if !ok || configMap.Name != files.KALM_CONFIG_MAP_NAME {
+		return nil
+	}
+	return []reconcile.Request{{NamespacedName: types.NamespacedName{}}}
+}
+
 func (r *HttpRouteReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&corev1alpha1.HttpRoute{}).
@@ -857,5 +1264,23 @@ func (r *HttpRouteReconciler) SetupWithManager(mgr ctrl.Manager) error {
 				ToRequests: &WatchAllService{},
 			},
 		).
+		Watches(
+			&source.Kind{Type: &corev1alpha1.Component{}},
+			&handler.EnqueueRequestsFromMapFunc{
+				ToRequests: &WatchAllComponentsAffectingRoutes{},
+			},
+		).
+		Watches(
+			&source.Kind{Type: &corev1.Namespace{}},
+			&handler.EnqueueRequestsFromMapFunc{
+				ToRequests: &WatchAllApplicationsInMaintenance{},
+			},
+		).
+		Watches(
+			&source.Kind{Type: &corev1.ConfigMap{}},
+			&handler.EnqueueRequestsFromMapFunc{
+				ToRequests: &WatchAllKalmFiles{},
+			},
+		).
 		Complete(r)
 }
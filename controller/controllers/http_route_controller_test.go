@@ -170,3 +170,27 @@ func TestAdjustWeightToSumTo100(t *testing.T) {
 		assert.True(t, 100 == sum(rst))
 	}
 }
+
+func TestBuildIstioHeaders(t *testing.T) {
+	headers := buildIstioHeaders(nil)
+	assert.Equal(t, DANGEROUS_HEADERS, headers.Request.Remove)
+	assert.Equal(t, map[string]string{KALM_ROUTE_HEADER: "true"}, headers.Request.Set)
+	assert.Nil(t, headers.Response)
+
+	headers = buildIstioHeaders(&v1alpha1.HttpRouteHeaders{
+		Request: &v1alpha1.HttpRouteHeaderOperations{
+			Set:    map[string]string{"x-forwarded-prefix": "/api"},
+			Remove: []string{"x-internal"},
+		},
+		Response: &v1alpha1.HttpRouteHeaderOperations{
+			Set: map[string]string{"strict-transport-security": "max-age=31536000"},
+		},
+	})
+
+	assert.Equal(t, map[string]string{KALM_ROUTE_HEADER: "true", "x-forwarded-prefix": "/api"}, headers.Request.Set)
+	assert.Equal(t, append(append([]string{}, DANGEROUS_HEADERS...), "x-internal"), headers.Request.Remove)
+	assert.Equal(t, "max-age=31536000", headers.Response.Set["strict-transport-security"])
+
+	// kalm headers are not changed
+	assert.Len(t, DANGEROUS_HEADERS, 6)
+}
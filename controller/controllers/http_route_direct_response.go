@@ -0,0 +1,241 @@
+package controllers
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	corev1alpha1 "github.com/kalmhq/kalm/controller/api/v1alpha1"
+	"github.com/kalmhq/kalm/controller/lib/files"
+	istioNetworkingV1Beta1 "istio.io/api/networking/v1beta1"
+	v1alpha32 "istio.io/client-go/pkg/apis/networking/v1alpha3"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// The maintenance response of a route is passed to the lua filter of the ingress gateway by route metadata.
+// Bypass IPs and headers are shadow rules of the rbac filter, the lua filter responds with the maintenance response
+// unless the shadow rules allow the request, so client IPs are checked the same way as access control of routes.
+const (
+	maintenanceBypassPolicyName = "kalm-maintenance-bypass"
+
+	maintenanceStatusMetadataKey      = "kalm_maintenance_status"
+	maintenanceContentTypeMetadataKey = "kalm_maintenance_content_type"
+	maintenanceBodyMetadataKey        = "kalm_maintenance_body"
+)
+
+// routeMaintenance is the maintenance of the application a route sends requests to
+type routeMaintenance struct {
+	*corev1alpha1.ApplicationMaintenance
+	namespace string
+}
+
+func getDirectResponseEnvoyFilterName(route *corev1alpha1.HttpRoute) string {
+	return fmt.Sprintf("direct-response-%s", route.Name)
+}
+
+// Virtual services don't support direct responses, a route requires destinations or a redirect.
+// The placeholder redirect is replaced with the direct response by an envoy filter, which is saved before the route.
+func directResponsePlaceholder() *istioNetworkingV1Beta1.HTTPRedirect {
+	return &istioNetworkingV1Beta1.HTTPRedirect{
+		Uri: "/",
+	}
+}
+
+func isForwardingRoute(route *corev1alpha1.HttpRoute) bool {
+	return route.Spec.Redirect == nil && route.Spec.DirectResponse == nil
+}
+
+// loadRouteMaintenances finds routes to applications in maintenance
+func (r *HttpRouteReconcilerTask) loadRouteMaintenances() error {
+	var namespaces corev1.NamespaceList
+	if err := r.Reader.List(r.ctx, &namespaces); err != nil {
+		return err
+	}
+
+	maintenances := make(map[string]*corev1alpha1.ApplicationMaintenance)
+
+	for i := range namespaces.Items {
+		maintenance, err := corev1alpha1.GetApplicationMaintenance(&namespaces.Items[i])
+
+		if err != nil {
+			r.Log.Error(err, "ignore maintenance")
+			continue
+		}
+
+		if maintenance != nil {
+			maintenances[namespaces.Items[i].Name] = maintenance
+		}
+	}
+
+	r.routeMaintenances = make(map[string]*routeMaintenance)
+
+	for i := range r.routes {
+		route := &r.routes[i]
+
+		if !isForwardingRoute(route) {
+			continue
+		}
+
+		for _, destination := range route.Spec.Destinations {
+			namespace := getDestinationNamespace(destination.Host)
+
+			if maintenance, ok := maintenances[namespace]; ok {
+				r.routeMaintenances[route.Name] = &routeMaintenance{maintenance, namespace}
+				break
+			}
+		}
+	}
+
+	return nil
+}
+
+// buildMaintenanceBypassPrincipal matches requests from bypass IPs or with any of the bypass headers,
+// nil if nothing bypasses the maintenance.
+func buildMaintenanceBypassPrincipal(maintenance *corev1alpha1.ApplicationMaintenance) map[string]interface{} {
+	var ids []interface{}
+
+	if principal := buildRemoteIPsPrincipal(maintenance.BypassIPs); principal != nil {
+		ids = append(ids, principal)
+	}
+
+	headers := make([]string, 0, len(maintenance.BypassHeaders))
+	for header := range maintenance.BypassHeaders {
+		headers = append(headers, header)
+	}
+
+	sort.Strings(headers)
+
+	for _, header := range headers {
+		ids = append(ids, map[string]interface{}{
+			"header": map[string]interface{}{
+				"name":        strings.ToLower(header),
+				"exact_match": maintenance.BypassHeaders[header],
+			},
+		})
+	}
+
+	if len(ids) == 0 {
+		return nil
+	}
+
+	return map[string]interface{}{
+		"or_ids": map[string]interface{}{
+			"ids": ids,
+		},
+	}
+}
+
+// buildMaintenanceMetadata returns the lua metadata of the maintenance response.
+// If the body file can't be read, an empty body is returned with the status.
+func (r *HttpRouteReconcilerTask) buildMaintenanceMetadata(route *corev1alpha1.HttpRoute, maintenance *routeMaintenance) map[string]interface{} {
+	// files are always read from the application in maintenance
+	body, err := r.getDirectResponseBody(&maintenance.Response, maintenance.namespace)
+
+	if err != nil {
+		r.EmitWarningEvent(route, err, "unable to read body of the maintenance response")
+	}
+
+	metadata := map[string]interface{}{
+		maintenanceStatusMetadataKey: strconv.Itoa(maintenance.Response.Status),
+		maintenanceBodyMetadataKey:   body,
+	}
+
+	if maintenance.Response.ContentType != "" {
+		metadata[maintenanceContentTypeMetadataKey] = maintenance.Response.ContentType
+	}
+
+	return metadata
+}
+
+// getDirectResponseBody returns the body of the response, the body file is read from kalm files of the namespace.
+func (r *HttpRouteReconcilerTask) getDirectResponseBody(response *corev1alpha1.HttpRouteDirectResponse, namespace string) (string, error) {
+	if response.BodyFile == nil {
+		return response.Body, nil
+	}
+
+	var configMap corev1.ConfigMap
+	if err := r.Reader.Get(r.ctx, types.NamespacedName{Namespace: namespace, Name: files.KALM_CONFIG_MAP_NAME}, &configMap); err != nil {
+		return "", err
+	}
+
+	content, exist := configMap.Data[files.EncodeFilePath(response.BodyFile.Path)]
+
+	if !exist || content == files.KALM_DIR_PLACEHOLDER || content == files.KALM_PERSISTENT_DIR_PLACEHOLDER {
+		return "", fmt.Errorf("file %s doesn't exist in application %s", response.BodyFile.Path, namespace)
+	}
+
+	if len(content) > corev1alpha1.MaxDirectResponseBodySize {
+		return "", fmt.Errorf("file %s is larger than %d bytes", response.BodyFile.Path, corev1alpha1.MaxDirectResponseBodySize)
+	}
+
+	return content, nil
+}
+
+func buildDirectResponseEnvoyFilter(name, istioRouteName string, response *corev1alpha1.HttpRouteDirectResponse, body string) *v1alpha32.EnvoyFilter {
+	value := map[string]interface{}{
+		"direct_response": map[string]interface{}{
+			"status": response.Status,
+			"body": map[string]interface{}{
+				"inline_string": body,
+			},
+		},
+	}
+
+	if response.ContentType != "" {
+		value["response_headers_to_add"] = []interface{}{
+			map[string]interface{}{
+				"header": map[string]interface{}{
+					"key":   "content-type",
+					"value": response.ContentType,
+				},
+				"append": false,
+			},
+		}
+	}
+
+	return buildRouteMergeEnvoyFilter(name, istioRouteName, value)
+}
+
+// buildDirectResponseRouteEnvoyFilter returns the filter of the direct response of the route, nil if the route has none.
+func (r *HttpRouteReconcilerTask) buildDirectResponseRouteEnvoyFilter(route *corev1alpha1.HttpRoute) (*v1alpha32.EnvoyFilter, error) {
+	response := route.Spec.DirectResponse
+
+	if response == nil {
+		return nil, nil
+	}
+
+	namespace := ""
+	if response.BodyFile != nil {
+		namespace = response.BodyFile.Namespace
+	}
+
+	body, err := r.getDirectResponseBody(response, namespace)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return buildDirectResponseEnvoyFilter(getDirectResponseEnvoyFilterName(route), getIstioHttpRouteName(route), response, body), nil
+}
+
+// loadDirectResponseFilters builds filters of routes with direct responses.
+// Routes whose filters can't be built are skipped, so the placeholder redirect is never served alone.
+func (r *HttpRouteReconcilerTask) loadDirectResponseFilters() {
+	r.directResponseFilters = make(map[string]*v1alpha32.EnvoyFilter)
+
+	for i := range r.routes {
+		route := &r.routes[i]
+		filter, err := r.buildDirectResponseRouteEnvoyFilter(route)
+
+		if err != nil {
+			r.EmitWarningEvent(route, err, "unable to build the direct response, the route is skipped")
+			continue
+		}
+
+		if filter != nil {
+			r.directResponseFilters[route.Name] = filter
+		}
+	}
+}
@@ -0,0 +1,183 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	corev1alpha1 "github.com/kalmhq/kalm/controller/api/v1alpha1"
+	"github.com/kalmhq/kalm/controller/lib/files"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestMaintenanceRoutes(t *testing.T) {
+	namespace := &corev1.Namespace{ObjectMeta: metaV1.ObjectMeta{Name: "shop"}}
+
+	_ = corev1alpha1.SetApplicationMaintenance(namespace, &corev1alpha1.ApplicationMaintenance{
+		Response:      corev1alpha1.HttpRouteDirectResponse{Status: 503, BodyFile: &corev1alpha1.HttpRouteBodyFile{Path: "/maintenance.html"}},
+		BypassIPs:     []string{"10.0.0.1"},
+		BypassHeaders: map[string]string{"X-Bypass": "secret"},
+	})
+
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metaV1.ObjectMeta{Namespace: "shop", Name: files.KALM_CONFIG_MAP_NAME},
+		Data:       map[string]string{files.EncodeFilePath("/maintenance.html"): "<h1>maintenance</h1>"},
+	}
+
+	reader := fake.NewFakeClientWithScheme(newExportScheme(), namespace, configMap)
+	task := &HttpRouteReconcilerTask{
+		HttpRouteReconciler: &HttpRouteReconciler{&BaseReconciler{Reader: reader, Recorder: &record.FakeRecorder{}}},
+		ctx:                 context.Background(),
+		routes: []corev1alpha1.HttpRoute{
+			{
+				ObjectMeta: metaV1.ObjectMeta{Name: "web"},
+				Spec: corev1alpha1.HttpRouteSpec{
+					Hosts:        []string{"shop.example.com"},
+					Paths:        []string{"/"},
+					Methods:      []corev1alpha1.HttpRouteMethod{"GET"},
+					Schemes:      []corev1alpha1.HttpRouteScheme{"http"},
+					Destinations: []corev1alpha1.HttpRouteDestination{{Host: "web.shop.svc.cluster.local:80", Weight: 1}},
+				},
+			},
+			{
+				ObjectMeta: metaV1.ObjectMeta{Name: "other"},
+				Spec: corev1alpha1.HttpRouteSpec{
+					Hosts:        []string{"other.example.com"},
+					Paths:        []string{"/"},
+					Methods:      []corev1alpha1.HttpRouteMethod{"GET"},
+					Schemes:      []corev1alpha1.HttpRouteScheme{"http"},
+					Destinations: []corev1alpha1.HttpRouteDestination{{Host: "other.other.svc.cluster.local:80", Weight: 1}},
+				},
+			},
+		},
+	}
+
+	if !assert.Nil(t, task.loadRouteMaintenances()) {
+		return
+	}
+
+	assert.Contains(t, task.routeMaintenances, "web")
+	assert.NotContains(t, task.routeMaintenances, "other")
+
+	// requests in maintenance are sent to the route, the lua filter responds unless the maintenance is bypassed
+	routes := task.buildIstioHttpRoutes(&task.routes[0])
+
+	if assert.Len(t, routes, 1) {
+		assert.Equal(t, "kalm-route-web", routes[0].Name)
+		assert.Nil(t, routes[0].Redirect)
+	}
+
+	filter := task.buildAccessControlEnvoyFilter(&task.routes[0])
+	assert.Equal(t, "access-control-web", filter.Name)
+
+	value := filter.Spec.ConfigPatches[0].Patch.Value.Fields
+
+	metadata := value["metadata"].GetStructValue().Fields["filter_metadata"].GetStructValue().Fields[luaHttpFilterName].GetStructValue().Fields
+	assert.Equal(t, "503", metadata[maintenanceStatusMetadataKey].GetStringValue())
+	assert.Equal(t, "<h1>maintenance</h1>", metadata[maintenanceBodyMetadataKey].GetStringValue())
+	assert.NotContains(t, metadata, basicAuthSecretMetadataKey)
+
+	rbac := value["typed_per_filter_config"].GetStructValue().Fields[rbacHttpFilterName].GetStructValue().Fields["rbac"].GetStructValue().Fields
+	assert.NotContains(t, rbac, "rules")
+
+	policy := rbac["shadow_rules"].GetStructValue().Fields["policies"].GetStructValue().Fields[maintenanceBypassPolicyName].GetStructValue().Fields
+	ids := policy["principals"].GetListValue().Values[0].GetStructValue().Fields["or_ids"].GetStructValue().Fields["ids"].GetListValue().Values
+
+	if assert.Len(t, ids, 2) {
+		assert.Contains(t, ids[0].GetStructValue().Fields, "or_ids")
+		header := ids[1].GetStructValue().Fields["header"].GetStructValue().Fields
+		assert.Equal(t, "x-bypass", header["name"].GetStringValue())
+		assert.Equal(t, "secret", header["exact_match"].GetStringValue())
+	}
+}
+
+func TestMaintenanceBodyFileOfOtherApplication(t *testing.T) {
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metaV1.ObjectMeta{Namespace: "other", Name: files.KALM_CONFIG_MAP_NAME},
+		Data:       map[string]string{files.EncodeFilePath("/secret.html"): "secret"},
+	}
+
+	reader := fake.NewFakeClientWithScheme(newExportScheme(), configMap)
+	task := &HttpRouteReconcilerTask{
+		HttpRouteReconciler: &HttpRouteReconciler{&BaseReconciler{Reader: reader, Recorder: &record.FakeRecorder{}}},
+		ctx:                 context.Background(),
+	}
+
+	maintenance := &routeMaintenance{
+		ApplicationMaintenance: &corev1alpha1.ApplicationMaintenance{
+			Response: corev1alpha1.HttpRouteDirectResponse{
+				Status:   503,
+				BodyFile: &corev1alpha1.HttpRouteBodyFile{Namespace: "other", Path: "/secret.html"},
+			},
+		},
+		namespace: "shop",
+	}
+
+	metadata := task.buildMaintenanceMetadata(&corev1alpha1.HttpRoute{}, maintenance)
+	assert.Equal(t, "", metadata[maintenanceBodyMetadataKey])
+}
+
+func TestDirectResponseRoute(t *testing.T) {
+	task := &HttpRouteReconcilerTask{
+		HttpRouteReconciler: &HttpRouteReconciler{&BaseReconciler{Recorder: &record.FakeRecorder{}}},
+		ctx:                 context.Background(),
+	}
+
+	route := &corev1alpha1.HttpRoute{
+		ObjectMeta: metaV1.ObjectMeta{Name: "status"},
+		Spec: corev1alpha1.HttpRouteSpec{
+			DirectResponse: &corev1alpha1.HttpRouteDirectResponse{
+				Status:      200,
+				ContentType: "application/json",
+				Body:        `{"ok":true}`,
+			},
+		},
+	}
+
+	httpRoute := task.buildIstioHttpRoute(route)
+	assert.Nil(t, httpRoute.Route)
+	assert.NotNil(t, httpRoute.Redirect)
+
+	filter, err := task.buildDirectResponseRouteEnvoyFilter(route)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "direct-response-status", filter.Name)
+	assert.Equal(t, "kalm-route-status", filter.Spec.ConfigPatches[0].Match.GetRouteConfiguration().Vhost.Route.Name)
+	assert.Contains(t, filter.Spec.ConfigPatches[0].Patch.Value.Fields, "response_headers_to_add")
+}
+
+func TestDirectResponseRouteWithoutBodyFile(t *testing.T) {
+	reader := fake.NewFakeClientWithScheme(newExportScheme())
+	task := &HttpRouteReconcilerTask{
+		HttpRouteReconciler: &HttpRouteReconciler{&BaseReconciler{Reader: reader, Recorder: record.NewFakeRecorder(10)}},
+		ctx:                 context.Background(),
+		routes: []corev1alpha1.HttpRoute{
+			{
+				ObjectMeta: metaV1.ObjectMeta{Name: "missing"},
+				Spec: corev1alpha1.HttpRouteSpec{
+					DirectResponse: &corev1alpha1.HttpRouteDirectResponse{
+						Status:   200,
+						BodyFile: &corev1alpha1.HttpRouteBodyFile{Namespace: "shop", Path: "/missing.html"},
+					},
+				},
+			},
+			{
+				ObjectMeta: metaV1.ObjectMeta{Name: "status"},
+				Spec: corev1alpha1.HttpRouteSpec{
+					DirectResponse: &corev1alpha1.HttpRouteDirectResponse{Status: 200, Body: "ok"},
+				},
+			},
+		},
+	}
+
+	_, err := task.buildDirectResponseRouteEnvoyFilter(&task.routes[0])
+	assert.NotNil(t, err)
+
+	// the route without its filter is skipped instead of serving the placeholder redirect
+	task.loadDirectResponseFilters()
+	assert.Len(t, task.directResponseFilters, 1)
+	assert.NotNil(t, task.directResponseFilters["status"])
+}
@@ -0,0 +1,104 @@
+package controllers
+
+import (
+	"fmt"
+
+	corev1alpha1 "github.com/kalmhq/kalm/controller/api/v1alpha1"
+	"istio.io/api/networking/v1alpha3"
+	v1alpha32 "istio.io/client-go/pkg/apis/networking/v1alpha3"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// the local rate limit http filter is inserted into the ingress gateway once, it does nothing without route configs
+	localRateLimitEnvoyFilterName = "kalm-local-rate-limit"
+	localRateLimitHttpFilterName  = "envoy.filters.http.local_ratelimit"
+	localRateLimitTypeUrl         = "type.googleapis.com/envoy.extensions.filters.http.local_ratelimit.v3.LocalRateLimit"
+)
+
+func getRateLimitEnvoyFilterName(route *corev1alpha1.HttpRoute) string {
+	return fmt.Sprintf("rate-limit-%s", route.Name)
+}
+
+func buildLocalRateLimitEnvoyFilter() *v1alpha32.EnvoyFilter {
+	return &v1alpha32.EnvoyFilter{
+		ObjectMeta: metaV1.ObjectMeta{
+			Namespace: istioNamespace,
+			Name:      localRateLimitEnvoyFilterName,
+			Labels: map[string]string{
+				KALM_ROUTE_LABEL: "true",
+			},
+		},
+		Spec: v1alpha3.EnvoyFilter{
+			WorkloadSelector: &v1alpha3.WorkloadSelector{
+				Labels: map[string]string{
+					"app": "istio-ingressgateway",
+				},
+			},
+			ConfigPatches: []*v1alpha3.EnvoyFilter_EnvoyConfigObjectPatch{
+				buildGatewayHttpFilterPatch(map[string]interface{}{
+					"name": localRateLimitHttpFilterName,
+					"typed_config": map[string]interface{}{
+						"@type":    "type.googleapis.com/udpa.type.v1.TypedStruct",
+						"type_url": localRateLimitTypeUrl,
+						"value": map[string]interface{}{
+							"stat_prefix": "http_local_rate_limiter",
+						},
+					},
+				}),
+			},
+		},
+	}
+}
+
+// buildRateLimitEnvoyFilter sets the rate limit on routes of the virtual services for the http route
+func (r *HttpRouteReconcilerTask) buildRateLimitEnvoyFilter(route *corev1alpha1.HttpRoute) *v1alpha32.EnvoyFilter {
+	rateLimit := route.Spec.RateLimit
+
+	value := map[string]interface{}{
+		"typed_per_filter_config": map[string]interface{}{
+			localRateLimitHttpFilterName: map[string]interface{}{
+				"@type":    "type.googleapis.com/udpa.type.v1.TypedStruct",
+				"type_url": localRateLimitTypeUrl,
+				"value":    buildLocalRateLimitConfig(rateLimit),
+			},
+		},
+	}
+
+	return buildRouteMergeEnvoyFilter(getRateLimitEnvoyFilterName(route), getIstioHttpRouteName(route), value)
+}
+
+// buildLocalRateLimitConfig returns the local rate limit config of the route,
+// all requests to the route share the token bucket of each gateway replica.
+func buildLocalRateLimitConfig(rateLimit *corev1alpha1.HttpRouteRateLimit) map[string]interface{} {
+	status := rateLimit.ResponseStatus
+	if status == 0 {
+		status = 429
+	}
+
+	tokenBucket := map[string]interface{}{
+		"max_tokens":      rateLimit.Requests + rateLimit.Burst,
+		"tokens_per_fill": rateLimit.Requests,
+		"fill_interval":   fmt.Sprintf("%ds", rateLimit.IntervalSeconds),
+	}
+
+	enabled := map[string]interface{}{
+		"default_value": map[string]interface{}{
+			"numerator":   100,
+			"denominator": "HUNDRED",
+		},
+		"runtime_key": "local_rate_limit_enabled",
+	}
+
+	config := map[string]interface{}{
+		"stat_prefix":     "http_local_rate_limiter",
+		"token_bucket":    tokenBucket,
+		"filter_enabled":  enabled,
+		"filter_enforced": enabled,
+		"status": map[string]interface{}{
+			"code": status,
+		},
+	}
+
+	return config
+}
@@ -0,0 +1,50 @@
+package controllers
+
+import (
+	"testing"
+
+	corev1alpha1 "github.com/kalmhq/kalm/controller/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildLocalRateLimitConfig(t *testing.T) {
+	rateLimit := &corev1alpha1.HttpRouteRateLimit{
+		Requests:        10,
+		IntervalSeconds: 60,
+		Burst:           5,
+	}
+
+	config := buildLocalRateLimitConfig(rateLimit)
+
+	assert.Equal(t, map[string]interface{}{
+		"max_tokens":      15,
+		"tokens_per_fill": 10,
+		"fill_interval":   "60s",
+	}, config["token_bucket"])
+	assert.Equal(t, map[string]interface{}{"code": 429}, config["status"])
+	assert.Nil(t, config["descriptors"])
+
+	rateLimit.ResponseStatus = 503
+	config = buildLocalRateLimitConfig(rateLimit)
+
+	assert.Equal(t, map[string]interface{}{"code": 503}, config["status"])
+}
+
+func TestBuildRateLimitEnvoyFilter(t *testing.T) {
+	route := &corev1alpha1.HttpRoute{}
+	route.Name = "api"
+	route.Spec.RateLimit = &corev1alpha1.HttpRouteRateLimit{
+		Requests:        100,
+		IntervalSeconds: 1,
+	}
+
+	filter := (&HttpRouteReconcilerTask{}).buildRateLimitEnvoyFilter(route)
+
+	assert.Equal(t, "rate-limit-api", filter.Name)
+	assert.Equal(t, "true", filter.Labels[KALM_ROUTE_LABEL])
+	assert.Equal(t, "kalm-route-api", filter.Spec.ConfigPatches[0].Match.GetRouteConfiguration().Vhost.Route.Name)
+
+	value := filter.Spec.ConfigPatches[0].Patch.Value.Fields
+	assert.NotContains(t, value, "route")
+	assert.Contains(t, value["typed_per_filter_config"].GetStructValue().Fields, localRateLimitHttpFilterName)
+}
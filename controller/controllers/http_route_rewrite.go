@@ -0,0 +1,83 @@
+package controllers
+
+import (
+	"fmt"
+
+	corev1alpha1 "github.com/kalmhq/kalm/controller/api/v1alpha1"
+	istioNetworkingV1Beta1 "istio.io/api/networking/v1beta1"
+	v1alpha32 "istio.io/client-go/pkg/apis/networking/v1alpha3"
+)
+
+func getRewriteEnvoyFilterName(route *corev1alpha1.HttpRoute) string {
+	return fmt.Sprintf("rewrite-%s", route.Name)
+}
+
+func buildIstioRewrite(rewrite *corev1alpha1.HttpRouteRewrite) *istioNetworkingV1Beta1.HTTPRewrite {
+	if rewrite.Uri == "" && rewrite.Authority == "" {
+		return nil
+	}
+
+	return &istioNetworkingV1Beta1.HTTPRewrite{
+		Uri:       rewrite.Uri,
+		Authority: rewrite.Authority,
+	}
+}
+
+// buildIstioRedirect keeps the request path if path is preserved,
+// the path prefix and the query are set by the rewrite envoy filter.
+func buildIstioRedirect(redirect *corev1alpha1.HttpRouteRedirect) *istioNetworkingV1Beta1.HTTPRedirect {
+	res := &istioNetworkingV1Beta1.HTTPRedirect{
+		Authority:    redirect.Host,
+		RedirectCode: uint32(redirect.StatusCode),
+	}
+
+	if res.RedirectCode == 0 {
+		res.RedirectCode = 301
+	}
+
+	if !redirect.PreservePath {
+		res.Uri = redirect.Path
+
+		if res.Uri == "" {
+			res.Uri = "/"
+		}
+	}
+
+	return res
+}
+
+// buildRewriteEnvoyFilter sets options of the route which are not supported by virtual services,
+// nil is returned if the route doesn't need them.
+func (r *HttpRouteReconcilerTask) buildRewriteEnvoyFilter(route *corev1alpha1.HttpRoute) *v1alpha32.EnvoyFilter {
+	value := make(map[string]interface{})
+
+	if rewrite := route.Spec.Rewrite; rewrite != nil && rewrite.UriRegex != nil {
+		value["route"] = map[string]interface{}{
+			"regex_rewrite": map[string]interface{}{
+				"pattern": map[string]interface{}{
+					"google_re2": map[string]interface{}{},
+					"regex":      rewrite.UriRegex.Pattern,
+				},
+				"substitution": rewrite.UriRegex.Substitution,
+			},
+		}
+	}
+
+	if redirect := route.Spec.Redirect; redirect != nil {
+		redirectValue := map[string]interface{}{
+			"strip_query": !redirect.PreserveQuery,
+		}
+
+		if redirect.PreservePath && redirect.Path != "" {
+			redirectValue["prefix_rewrite"] = redirect.Path
+		}
+
+		value["redirect"] = redirectValue
+	}
+
+	if len(value) == 0 {
+		return nil
+	}
+
+	return buildRouteMergeEnvoyFilter(getRewriteEnvoyFilterName(route), getIstioHttpRouteName(route), value)
+}
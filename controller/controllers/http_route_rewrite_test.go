@@ -0,0 +1,95 @@
+package controllers
+
+import (
+	"sort"
+	"testing"
+
+	corev1alpha1 "github.com/kalmhq/kalm/controller/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	istioNetworkingV1Beta1 "istio.io/api/networking/v1beta1"
+)
+
+func TestBuildIstioRedirect(t *testing.T) {
+	redirect := buildIstioRedirect(&corev1alpha1.HttpRouteRedirect{Host: "example.com"})
+	assert.Equal(t, &istioNetworkingV1Beta1.HTTPRedirect{Authority: "example.com", Uri: "/", RedirectCode: 301}, redirect)
+
+	redirect = buildIstioRedirect(&corev1alpha1.HttpRouteRedirect{Path: "/new", StatusCode: 307, PreservePath: true})
+	assert.Equal(t, &istioNetworkingV1Beta1.HTTPRedirect{RedirectCode: 307}, redirect)
+}
+
+func TestBuildRewriteEnvoyFilter(t *testing.T) {
+	task := &HttpRouteReconcilerTask{}
+	route := &corev1alpha1.HttpRoute{}
+	route.Name = "docs"
+	route.Spec.Rewrite = &corev1alpha1.HttpRouteRewrite{Uri: "/v2"}
+
+	assert.Nil(t, task.buildRewriteEnvoyFilter(route))
+
+	route.Spec.Rewrite = &corev1alpha1.HttpRouteRewrite{
+		UriRegex: &corev1alpha1.HttpRouteRegexRewrite{Pattern: "^/docs/(.*)$", Substitution: "/\\1"},
+	}
+
+	filter := task.buildRewriteEnvoyFilter(route)
+	if assert.NotNil(t, filter) {
+		assert.Equal(t, "rewrite-docs", filter.Name)
+
+		value := filter.Spec.ConfigPatches[0].Patch.Value.Fields
+		regexRewrite := value["route"].GetStructValue().Fields["regex_rewrite"].GetStructValue().Fields
+		assert.Equal(t, "/\\1", regexRewrite["substitution"].GetStringValue())
+	}
+
+	route.Spec.Rewrite = nil
+	route.Spec.Redirect = &corev1alpha1.HttpRouteRedirect{Path: "/new", PreservePath: true}
+
+	filter = task.buildRewriteEnvoyFilter(route)
+	if assert.NotNil(t, filter) {
+		redirect := filter.Spec.ConfigPatches[0].Patch.Value.Fields["redirect"].GetStructValue().Fields
+		assert.Equal(t, "/new", redirect["prefix_rewrite"].GetStringValue())
+		assert.True(t, redirect["strip_query"].GetBoolValue())
+	}
+}
+
+func TestSortRedirectRoutes(t *testing.T) {
+	prefix := func(p string) *istioNetworkingV1Beta1.StringMatch {
+		return &istioNetworkingV1Beta1.StringMatch{MatchType: &istioNetworkingV1Beta1.StringMatch_Prefix{Prefix: p}}
+	}
+
+	catchAll := &istioNetworkingV1Beta1.HTTPRoute{
+		Name:  "catch-all",
+		Match: []*istioNetworkingV1Beta1.HTTPMatchRequest{{}},
+		Route: []*istioNetworkingV1Beta1.HTTPRouteDestination{{}},
+	}
+	rootRedirect := &istioNetworkingV1Beta1.HTTPRoute{
+		Name:     "root-redirect",
+		Match:    []*istioNetworkingV1Beta1.HTTPMatchRequest{{}},
+		Redirect: &istioNetworkingV1Beta1.HTTPRedirect{Uri: "/"},
+	}
+	api := &istioNetworkingV1Beta1.HTTPRoute{
+		Name:  "api",
+		Match: []*istioNetworkingV1Beta1.HTTPMatchRequest{{Uri: prefix("/api")}},
+		Route: []*istioNetworkingV1Beta1.HTTPRouteDestination{{}},
+	}
+	apiRedirect := &istioNetworkingV1Beta1.HTTPRoute{
+		Name:     "api-redirect",
+		Match:    []*istioNetworkingV1Beta1.HTTPMatchRequest{{Uri: prefix("/api")}},
+		Redirect: &istioNetworkingV1Beta1.HTTPRedirect{Uri: "/"},
+	}
+	apiWithHeader := &istioNetworkingV1Beta1.HTTPRoute{
+		Name: "api-with-header",
+		Match: []*istioNetworkingV1Beta1.HTTPMatchRequest{{
+			Uri:     prefix("/api"),
+			Headers: map[string]*istioNetworkingV1Beta1.StringMatch{"x-version": prefix("2")},
+		}},
+		Route: []*istioNetworkingV1Beta1.HTTPRouteDestination{{}},
+	}
+
+	routes := []*istioNetworkingV1Beta1.HTTPRoute{catchAll, api, rootRedirect, apiRedirect, apiWithHeader}
+	sort.Slice(routes, func(i, j int) bool { return sortRoutes(routes[i], routes[j]) })
+
+	var names []string
+	for _, route := range routes {
+		names = append(names, route.Name)
+	}
+
+	assert.Equal(t, []string{"api-with-header", "api-redirect", "api", "root-redirect", "catch-all"}, names)
+}
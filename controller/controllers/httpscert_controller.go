@@ -176,13 +176,14 @@ func (r *HttpsCertReconciler) SetupWithManager(mgr ctrl.Manager) error {
 		Complete(r)
 }
 
-func (r *HttpsCertReconciler) reconcileForAutoManagedHttpsCert(ctx context.Context, httpsCert corev1alpha1.HttpsCert) error {
+// buildCertificate returns the cert-manager certificate requested for an auto-managed httpsCert
+func buildCertificate(httpsCert corev1alpha1.HttpsCert) cmv1alpha2.Certificate {
 	certName, certSecretName := getCertAndCertSecretName(httpsCert)
 
 	dnsNames := getDNSNames(httpsCert)
 	commonName := pickCommonName(dnsNames)
 
-	desiredCert := cmv1alpha2.Certificate{
+	return cmv1alpha2.Certificate{
 		ObjectMeta: metav1.ObjectMeta{
 			Namespace: istioNamespace,
 			Name:      certName,
@@ -197,6 +198,11 @@ func (r *HttpsCertReconciler) reconcileForAutoManagedHttpsCert(ctx context.Conte
 			},
 		},
 	}
+}
+
+func (r *HttpsCertReconciler) reconcileForAutoManagedHttpsCert(ctx context.Context, httpsCert corev1alpha1.HttpsCert) error {
+	certName, _ := getCertAndCertSecretName(httpsCert)
+	desiredCert := buildCertificate(httpsCert)
 
 	// reconcile cert
 	var cert cmv1alpha2.Certificate
@@ -27,8 +27,10 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 	"sigs.k8s.io/controller-runtime/pkg/source"
 
@@ -73,9 +75,24 @@ func (r *KalmNSReconciler) SetupWithManager(mgr ctrl.Manager) error {
 		Watches(genSourceForObject(&v1alpha1.HttpsCertIssuer{}), &handler.EnqueueRequestsFromMapFunc{
 			ToRequests: MapperForDefaultHttpsCertIssuer{},
 		}).
+		Watches(genSourceForObject(&v1alpha1.Component{}), &handler.EnqueueRequestsFromMapFunc{
+			ToRequests: ComponentNamespaceMapper{},
+		}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Owns(&v1.ResourceQuota{}).
+		Owns(&v1.LimitRange{}).
 		Complete(r)
 }
 
+// ComponentNamespaceMapper enqueues the namespace of the changed component,
+// so components exceeding the quota are admitted once others are scaled down or deleted.
+type ComponentNamespaceMapper struct{}
+
+func (m ComponentNamespaceMapper) Map(mapObj handler.MapObject) []reconcile.Request {
+	return []reconcile.Request{{NamespacedName: types.NamespacedName{
+		Name: mapObj.Meta.GetNamespace(),
+	}}}
+}
+
 func genSourceForObject(obj runtime.Object) source.Source {
 	return &source.Kind{Type: obj}
 }
@@ -104,6 +121,14 @@ func (r *KalmNSReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 		if err := r.reconcileCommonConfigMap(ns.Name); err != nil {
 			return ctrl.Result{}, err
 		}
+
+		if err := r.reconcileApplicationQuota(&ns); err != nil {
+			return ctrl.Result{}, err
+		}
+
+		if err := r.reconcileApplicationSuspension(&ns); err != nil {
+			return ctrl.Result{}, err
+		}
 	}
 
 	// todo weird logic to process all ns here
@@ -5,9 +5,12 @@ import (
 	"testing"
 	"time"
 
+	"github.com/kalmhq/kalm/controller/api/v1alpha1"
 	"github.com/stretchr/testify/suite"
 	appsV1 "k8s.io/api/apps/v1"
+	coreV1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/apimachinery/pkg/types"
 )
 
@@ -76,3 +79,48 @@ func (suite *KalmNSControllerSuite) TestUpdateOfNSWillAffectComponentWithin() {
 		return suite.K8sClient.Get(context.Background(), key, &deployment) == nil
 	}, "can't get deployment")
 }
+
+func (suite *KalmNSControllerSuite) TestApplicationQuota() {
+	ns := suite.SetupKalmEnabledNs("")
+
+	component := generateEmptyComponent(ns.Name)
+	suite.createComponent(component)
+
+	cpu := resource.MustParse("100m")
+	suite.reloadObject(types.NamespacedName{Name: ns.Name}, &ns)
+	suite.Nil(v1alpha1.SetApplicationQuota(&ns, &v1alpha1.ApplicationQuota{CPU: &cpu}))
+	suite.updateObject(&ns)
+
+	var resourceQuota coreV1.ResourceQuota
+	suite.Eventually(func() bool {
+		err := suite.K8sClient.Get(context.Background(), types.NamespacedName{Namespace: ns.Name, Name: v1alpha1.ApplicationResourceQuotaName}, &resourceQuota)
+		return err == nil && resourceQuota.Spec.Hard.Cpu() != nil
+	}, "resource quota is not created")
+
+	var limitRange coreV1.LimitRange
+	suite.Eventually(func() bool {
+		return suite.K8sClient.Get(context.Background(), types.NamespacedName{Namespace: ns.Name, Name: v1alpha1.ApplicationLimitRangeName}, &limitRange) == nil
+	}, "limit range is not created")
+
+	// the component requires 200m cpu by default
+	key := types.NamespacedName{Namespace: component.Namespace, Name: component.Name}
+	suite.Eventually(func() bool {
+		suite.reloadObject(key, component)
+		return component.Labels[v1alpha1.KalmLabelKeyExceedingQuota] == "true"
+	}, "component is not marked as exceeding quota")
+
+	// the component is scaled up again once the quota is removed
+	suite.reloadObject(types.NamespacedName{Name: ns.Name}, &ns)
+	suite.Nil(v1alpha1.SetApplicationQuota(&ns, nil))
+	suite.updateObject(&ns)
+
+	suite.Eventually(func() bool {
+		suite.reloadObject(key, component)
+		return component.Labels[v1alpha1.KalmLabelKeyExceedingQuota] == "" &&
+			component.Spec.Replicas != nil && *component.Spec.Replicas == 1
+	}, "component is not scaled up")
+
+	suite.Eventually(func() bool {
+		return errors.IsNotFound(suite.K8sClient.Get(context.Background(), types.NamespacedName{Namespace: ns.Name, Name: v1alpha1.ApplicationResourceQuotaName}, &resourceQuota))
+	}, "resource quota is not deleted")
+}
@@ -0,0 +1,256 @@
+package controllers
+
+import (
+	"sort"
+	"strconv"
+
+	"github.com/kalmhq/kalm/controller/api/v1alpha1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// +kubebuilder:rbac:groups="",resources=resourcequotas,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups="",resources=limitranges,verbs=get;list;watch;create;update;patch;delete
+
+// reconcileApplicationQuota turns the quota of the application into a ResourceQuota and a LimitRange,
+// and marks components that don't fit in the quota as exceeding quota, so they are scaled down.
+func (r *KalmNSReconciler) reconcileApplicationQuota(ns *v1.Namespace) error {
+	quota, err := v1alpha1.GetApplicationQuota(ns)
+
+	if err != nil {
+		r.Log.Error(err, "ignore invalid application quota", "ns", ns.Name)
+		return nil
+	}
+
+	if quota == nil {
+		if err := r.deleteQuotaObject(ns.Name, v1alpha1.ApplicationResourceQuotaName, &v1.ResourceQuota{}); err != nil {
+			return err
+		}
+
+		if err := r.deleteQuotaObject(ns.Name, v1alpha1.ApplicationLimitRangeName, &v1.LimitRange{}); err != nil {
+			return err
+		}
+	} else {
+		if err := r.reconcileResourceQuota(ns, quota); err != nil {
+			return err
+		}
+
+		if err := r.reconcileLimitRange(ns, quota); err != nil {
+			return err
+		}
+	}
+
+	return r.reconcileExceedingQuotaComponents(ns.Name, quota)
+}
+
+func (r *KalmNSReconciler) deleteQuotaObject(namespace, name string, obj runtime.Object) error {
+	if err := r.Get(r.ctx, client.ObjectKey{Namespace: namespace, Name: name}, obj); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+
+	return client.IgnoreNotFound(r.Delete(r.ctx, obj))
+}
+
+func (r *KalmNSReconciler) reconcileResourceQuota(ns *v1.Namespace, quota *v1alpha1.ApplicationQuota) error {
+	spec := v1.ResourceQuotaSpec{
+		Hard: quota.ResourceList(),
+	}
+
+	var resourceQuota v1.ResourceQuota
+	err := r.Get(r.ctx, client.ObjectKey{Namespace: ns.Name, Name: v1alpha1.ApplicationResourceQuotaName}, &resourceQuota)
+
+	if errors.IsNotFound(err) {
+		resourceQuota = v1.ResourceQuota{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: ns.Name,
+				Name:      v1alpha1.ApplicationResourceQuotaName,
+			},
+			Spec: spec,
+		}
+
+		if err := ctrl.SetControllerReference(ns, &resourceQuota, r.Scheme); err != nil {
+			return err
+		}
+
+		return r.Create(r.ctx, &resourceQuota)
+	} else if err != nil {
+		return err
+	}
+
+	if equality.Semantic.DeepEqual(resourceQuota.Spec, spec) {
+		return nil
+	}
+
+	resourceQuota.Spec = spec
+
+	return r.Update(r.ctx, &resourceQuota)
+}
+
+// Once cpu or memory is limited by the ResourceQuota, pods without limits can't be created.
+// The LimitRange sets the default limits of these containers.
+func (r *KalmNSReconciler) reconcileLimitRange(ns *v1.Namespace, quota *v1alpha1.ApplicationQuota) error {
+	if quota.CPU == nil && quota.Memory == nil {
+		return r.deleteQuotaObject(ns.Name, v1alpha1.ApplicationLimitRangeName, &v1.LimitRange{})
+	}
+
+	spec := v1.LimitRangeSpec{
+		Limits: []v1.LimitRangeItem{
+			{
+				Type: v1.LimitTypeContainer,
+				Default: v1.ResourceList{
+					v1.ResourceCPU:    v1alpha1.DefaultQuotaContainerCPULimit,
+					v1.ResourceMemory: v1alpha1.DefaultQuotaContainerMemoryLimit,
+				},
+			},
+		},
+	}
+
+	var limitRange v1.LimitRange
+	err := r.Get(r.ctx, client.ObjectKey{Namespace: ns.Name, Name: v1alpha1.ApplicationLimitRangeName}, &limitRange)
+
+	if errors.IsNotFound(err) {
+		limitRange = v1.LimitRange{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: ns.Name,
+				Name:      v1alpha1.ApplicationLimitRangeName,
+			},
+			Spec: spec,
+		}
+
+		if err := ctrl.SetControllerReference(ns, &limitRange, r.Scheme); err != nil {
+			return err
+		}
+
+		return r.Create(r.ctx, &limitRange)
+	} else if err != nil {
+		return err
+	}
+
+	if equality.Semantic.DeepEqual(limitRange.Spec, spec) {
+		return nil
+	}
+
+	limitRange.Spec = spec
+
+	return r.Update(r.ctx, &limitRange)
+}
+
+// restoreComponentScale returns a copy of the component without the label which scales it down,
+// its original replicas are restored once it's not scaled down for any other reason.
+func restoreComponentScale(component *v1alpha1.Component, label string) *v1alpha1.Component {
+	copied := component.DeepCopy()
+	delete(copied.Labels, label)
+
+	if isComponentForcedToScaleDown(copied) {
+		return copied
+	}
+
+	if replicas, err := strconv.ParseInt(copied.Labels[v1alpha1.KalmLabelKeyOriginalReplicas], 10, 32); err == nil {
+		originalReplicas := int32(replicas)
+		copied.Spec.Replicas = &originalReplicas
+	}
+
+	delete(copied.Labels, v1alpha1.KalmLabelKeyOriginalReplicas)
+
+	return copied
+}
+
+// getComponentAtOriginalScale returns a copy of the component as if it's not scaled down because of the quota
+// or the suspension of the application.
+func getComponentAtOriginalScale(component *v1alpha1.Component) *v1alpha1.Component {
+	return restoreComponentScale(restoreComponentScale(component, v1alpha1.KalmLabelKeyExceedingQuota), v1alpha1.KalmLabelKeySuspended)
+}
+
+// decideExceedingQuotaComponents returns names of components that don't fit in the quota.
+// Components are admitted in the order of creation, so the latest components are scaled down first.
+func decideExceedingQuotaComponents(quota *v1alpha1.ApplicationQuota, components []v1alpha1.Component) map[string]bool {
+	exceeding := make(map[string]bool)
+
+	if quota == nil {
+		return exceeding
+	}
+
+	sorted := make([]*v1alpha1.Component, 0, len(components))
+	for i := range components {
+		sorted = append(sorted, &components[i])
+	}
+
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].CreationTimestamp.Equal(&sorted[j].CreationTimestamp) {
+			return sorted[i].Name < sorted[j].Name
+		}
+
+		return sorted[i].CreationTimestamp.Before(&sorted[j].CreationTimestamp)
+	})
+
+	var usage v1alpha1.ApplicationResourceUsage
+
+	for _, component := range sorted {
+		next := usage.DeepCopy()
+		next.Add(getComponentAtOriginalScale(component).GetResourceUsage())
+
+		if len(quota.Check(*next)) > 0 {
+			exceeding[component.Name] = true
+			continue
+		}
+
+		usage = *next
+	}
+
+	return exceeding
+}
+
+func (r *KalmNSReconciler) reconcileExceedingQuotaComponents(namespace string, quota *v1alpha1.ApplicationQuota) error {
+	var compList v1alpha1.ComponentList
+	if err := r.List(r.ctx, &compList, client.InNamespace(namespace)); err != nil {
+		return err
+	}
+
+	exceeding := decideExceedingQuotaComponents(quota, compList.Items)
+
+	for i := range compList.Items {
+		component := &compList.Items[i]
+		labeled := isComponentLabeledAsExceedingQuota(component)
+
+		var updated *v1alpha1.Component
+
+		if exceeding[component.Name] && !labeled {
+			// the component controller will scale it down
+			updated = component.DeepCopy()
+
+			if updated.Labels == nil {
+				updated.Labels = make(map[string]string)
+			}
+
+			updated.Labels[v1alpha1.KalmLabelKeyExceedingQuota] = "true"
+			r.EmitWarningEvent(component, v1alpha1.ExceedingQuotaError, "component doesn't fit in the quota of the application")
+		} else if !exceeding[component.Name] && labeled {
+			updated = restoreComponentScale(component, v1alpha1.KalmLabelKeyExceedingQuota)
+			r.EmitNormalEvent(component, v1alpha1.ReasonExceedingQuota, "scale up component as it fits in the quota of the application")
+		} else {
+			continue
+		}
+
+		if err := r.updateComponentByController(updated); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *KalmNSReconciler) updateComponentByController(component *v1alpha1.Component) error {
+	if component.Annotations == nil {
+		component.Annotations = make(map[string]string)
+	}
+
+	component.Annotations[v1alpha1.KalmAnnoComponentChangedBy] = ControllerComponent
+	component.Annotations[v1alpha1.KalmAnnoComponentChangedVia] = v1alpha1.ComponentChangedViaController
+
+	return r.Update(r.ctx, component)
+}
@@ -0,0 +1,116 @@
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kalmhq/kalm/controller/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+)
+
+func TestDecideExceedingQuotaComponents(t *testing.T) {
+	now := time.Now()
+
+	newComponent := func(name string, createdAt time.Time, replicas int32) v1alpha1.Component {
+		return v1alpha1.Component{
+			ObjectMeta: metaV1.ObjectMeta{
+				Name:              name,
+				Namespace:         "app",
+				CreationTimestamp: metaV1.NewTime(createdAt),
+			},
+			Spec: v1alpha1.ComponentSpec{
+				WorkloadType: v1alpha1.WorkloadTypeServer,
+				Replicas:     &replicas,
+			},
+		}
+	}
+
+	zero := int32(0)
+	scaledDown := newComponent("scaled-down", now.Add(-time.Hour), 0)
+	scaledDown.Spec.Replicas = &zero
+	scaledDown.Labels = map[string]string{
+		v1alpha1.KalmLabelKeyExceedingQuota:   "true",
+		v1alpha1.KalmLabelKeyOriginalReplicas: "2",
+	}
+
+	components := []v1alpha1.Component{
+		newComponent("new", now, 2),
+		scaledDown,
+		newComponent("old", now.Add(-2*time.Hour), 2),
+	}
+
+	assert.Empty(t, decideExceedingQuotaComponents(nil, components))
+
+	// old and scaled-down fit in the quota at their original scale, the newest one is scaled down
+	cpu := resource.MustParse("800m")
+	exceeding := decideExceedingQuotaComponents(&v1alpha1.ApplicationQuota{CPU: &cpu}, components)
+	assert.Equal(t, map[string]bool{"new": true}, exceeding)
+
+	cpu = resource.MustParse("500m")
+	exceeding = decideExceedingQuotaComponents(&v1alpha1.ApplicationQuota{CPU: &cpu}, components)
+	assert.Equal(t, map[string]bool{"new": true, "scaled-down": true}, exceeding)
+}
+
+func TestGetComponentAtOriginalScale(t *testing.T) {
+	zero := int32(0)
+
+	component := &v1alpha1.Component{
+		ObjectMeta: metaV1.ObjectMeta{
+			Name: "web",
+			Labels: map[string]string{
+				v1alpha1.KalmLabelKeyExceedingQuota:   "true",
+				v1alpha1.KalmLabelKeyOriginalReplicas: "3",
+			},
+		},
+		Spec: v1alpha1.ComponentSpec{Replicas: &zero},
+	}
+
+	restored := getComponentAtOriginalScale(component)
+	assert.Equal(t, int32(3), *restored.Spec.Replicas)
+	assert.Empty(t, restored.Labels)
+	assert.Equal(t, int32(0), *component.Spec.Replicas)
+}
+
+func TestRestoreComponentScale(t *testing.T) {
+	zero := int32(0)
+
+	component := &v1alpha1.Component{
+		ObjectMeta: metaV1.ObjectMeta{
+			Name: "web",
+			Labels: map[string]string{
+				v1alpha1.KalmLabelKeyExceedingQuota:   "true",
+				v1alpha1.KalmLabelKeySuspended:        "true",
+				v1alpha1.KalmLabelKeyOriginalReplicas: "3",
+			},
+		},
+		Spec: v1alpha1.ComponentSpec{Replicas: &zero},
+	}
+
+	// still suspended, replicas are kept at 0
+	resumed := restoreComponentScale(component, v1alpha1.KalmLabelKeyExceedingQuota)
+	assert.Equal(t, int32(0), *resumed.Spec.Replicas)
+	assert.Equal(t, map[string]string{
+		v1alpha1.KalmLabelKeySuspended:        "true",
+		v1alpha1.KalmLabelKeyOriginalReplicas: "3",
+	}, resumed.Labels)
+
+	resumed = restoreComponentScale(resumed, v1alpha1.KalmLabelKeySuspended)
+	assert.Equal(t, int32(3), *resumed.Spec.Replicas)
+	assert.Empty(t, resumed.Labels)
+
+	restored := getComponentAtOriginalScale(component)
+	assert.Equal(t, int32(3), *restored.Spec.Replicas)
+	assert.Empty(t, restored.Labels)
+}
+
+func TestComponentNamespaceMapper(t *testing.T) {
+	component := &v1alpha1.Component{ObjectMeta: metaV1.ObjectMeta{Name: "web", Namespace: "app"}}
+
+	requests := ComponentNamespaceMapper{}.Map(handler.MapObject{Meta: component, Object: component})
+	assert.Len(t, requests, 1)
+	assert.Equal(t, types.NamespacedName{Name: "app"}, requests[0].NamespacedName)
+}
@@ -0,0 +1,48 @@
+package controllers
+
+import (
+	"github.com/kalmhq/kalm/controller/api/v1alpha1"
+	v1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// reconcileApplicationSuspension marks all components of a suspended application as suspended,
+// so they are scaled down by the component controller with their replicas remembered.
+// Once the application is resumed, components are scaled back to their original replicas.
+func (r *KalmNSReconciler) reconcileApplicationSuspension(ns *v1.Namespace) error {
+	var compList v1alpha1.ComponentList
+	if err := r.List(r.ctx, &compList, client.InNamespace(ns.Name)); err != nil {
+		return err
+	}
+
+	suspended := v1alpha1.IsApplicationSuspended(ns)
+
+	for i := range compList.Items {
+		component := &compList.Items[i]
+		labeled := isComponentLabeledAsSuspended(component)
+
+		var updated *v1alpha1.Component
+
+		if suspended && !labeled {
+			updated = component.DeepCopy()
+
+			if updated.Labels == nil {
+				updated.Labels = make(map[string]string)
+			}
+
+			updated.Labels[v1alpha1.KalmLabelKeySuspended] = "true"
+			r.EmitNormalEvent(component, v1alpha1.ReasonSuspended, "scale down component as the application is suspended")
+		} else if !suspended && labeled {
+			updated = restoreComponentScale(component, v1alpha1.KalmLabelKeySuspended)
+			r.EmitNormalEvent(component, v1alpha1.ReasonSuspended, "scale up component as the application is resumed")
+		} else {
+			continue
+		}
+
+		if err := r.updateComponentByController(updated); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
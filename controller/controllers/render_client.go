@@ -0,0 +1,207 @@
+package controllers
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+)
+
+// renderClient records objects written by reconciler tasks instead of sending them to the cluster.
+// Objects of rendered kinds are only read from the records, so they are built from scratch
+// instead of copied from the cluster, other objects (e.g. secrets) are read from the cluster.
+type renderClient struct {
+	reader   client.Reader
+	scheme   *runtime.Scheme
+	rendered map[schema.GroupVersionKind]bool
+	objects  map[schema.GroupVersionKind]map[types.NamespacedName]runtime.Object
+}
+
+var _ client.Client = &renderClient{}
+
+func newRenderClient(reader client.Reader, scheme *runtime.Scheme, renderedTypes ...runtime.Object) (*renderClient, error) {
+	c := &renderClient{
+		reader:   reader,
+		scheme:   scheme,
+		rendered: make(map[schema.GroupVersionKind]bool),
+		objects:  make(map[schema.GroupVersionKind]map[types.NamespacedName]runtime.Object),
+	}
+
+	for _, obj := range renderedTypes {
+		gvk, err := apiutil.GVKForObject(obj, scheme)
+
+		if err != nil {
+			return nil, err
+		}
+
+		c.rendered[gvk] = true
+	}
+
+	return c, nil
+}
+
+func (c *renderClient) Get(ctx context.Context, key client.ObjectKey, obj runtime.Object) error {
+	gvk, err := apiutil.GVKForObject(obj, c.scheme)
+
+	if err != nil {
+		return err
+	}
+
+	if !c.rendered[gvk] {
+		return c.reader.Get(ctx, key, obj)
+	}
+
+	stored, exist := c.objects[gvk][key]
+
+	if !exist {
+		return errors.NewNotFound(schema.GroupResource{Group: gvk.Group, Resource: strings.ToLower(gvk.Kind)}, key.Name)
+	}
+
+	reflect.ValueOf(obj).Elem().Set(reflect.ValueOf(stored.DeepCopyObject()).Elem())
+
+	return nil
+}
+
+func (c *renderClient) List(ctx context.Context, list runtime.Object, opts ...client.ListOption) error {
+	listGVK, err := apiutil.GVKForObject(list, c.scheme)
+
+	if err != nil {
+		return err
+	}
+
+	gvk := listGVK.GroupVersion().WithKind(strings.TrimSuffix(listGVK.Kind, "List"))
+
+	if !c.rendered[gvk] {
+		return c.reader.List(ctx, list, opts...)
+	}
+
+	options := (&client.ListOptions{}).ApplyOptions(opts)
+
+	var items []runtime.Object
+
+	for _, key := range c.sortedKeys(gvk) {
+		obj := c.objects[gvk][key]
+
+		if options.Namespace != "" && key.Namespace != options.Namespace {
+			continue
+		}
+
+		if options.LabelSelector != nil {
+			objMeta, err := meta.Accessor(obj)
+
+			if err != nil {
+				return err
+			}
+
+			if !options.LabelSelector.Matches(labels.Set(objMeta.GetLabels())) {
+				continue
+			}
+		}
+
+		items = append(items, obj.DeepCopyObject())
+	}
+
+	return meta.SetList(list, items)
+}
+
+func (c *renderClient) Create(ctx context.Context, obj runtime.Object, opts ...client.CreateOption) error {
+	return c.save(obj)
+}
+
+func (c *renderClient) Update(ctx context.Context, obj runtime.Object, opts ...client.UpdateOption) error {
+	return c.save(obj)
+}
+
+// Patch records the patched object as it is, reconcilers only use merge patches of the full object.
+func (c *renderClient) Patch(ctx context.Context, obj runtime.Object, patch client.Patch, opts ...client.PatchOption) error {
+	return c.save(obj)
+}
+
+func (c *renderClient) Delete(ctx context.Context, obj runtime.Object, opts ...client.DeleteOption) error {
+	gvk, key, err := c.keyOf(obj)
+
+	if err != nil {
+		return err
+	}
+
+	delete(c.objects[gvk], key)
+
+	return nil
+}
+
+func (c *renderClient) DeleteAllOf(ctx context.Context, obj runtime.Object, opts ...client.DeleteAllOfOption) error {
+	return nil
+}
+
+// Status updates are dropped, the status of rendered objects is never exported.
+func (c *renderClient) Status() client.StatusWriter {
+	return &renderStatusWriter{}
+}
+
+func (c *renderClient) save(obj runtime.Object) error {
+	gvk, key, err := c.keyOf(obj)
+
+	if err != nil {
+		return err
+	}
+
+	copied := obj.DeepCopyObject()
+	copied.GetObjectKind().SetGroupVersionKind(gvk)
+
+	if c.objects[gvk] == nil {
+		c.objects[gvk] = make(map[types.NamespacedName]runtime.Object)
+	}
+
+	c.objects[gvk][key] = copied
+
+	return nil
+}
+
+func (c *renderClient) keyOf(obj runtime.Object) (schema.GroupVersionKind, types.NamespacedName, error) {
+	gvk, err := apiutil.GVKForObject(obj, c.scheme)
+
+	if err != nil {
+		return gvk, types.NamespacedName{}, err
+	}
+
+	objMeta, err := meta.Accessor(obj)
+
+	if err != nil {
+		return gvk, types.NamespacedName{}, err
+	}
+
+	return gvk, types.NamespacedName{Namespace: objMeta.GetNamespace(), Name: objMeta.GetName()}, nil
+}
+
+func (c *renderClient) sortedKeys(gvk schema.GroupVersionKind) []types.NamespacedName {
+	var keys []types.NamespacedName
+
+	for key := range c.objects[gvk] {
+		keys = append(keys, key)
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].String() < keys[j].String()
+	})
+
+	return keys
+}
+
+type renderStatusWriter struct{}
+
+func (*renderStatusWriter) Update(ctx context.Context, obj runtime.Object, opts ...client.UpdateOption) error {
+	return nil
+}
+
+func (*renderStatusWriter) Patch(ctx context.Context, obj runtime.Object, patch client.Patch, opts ...client.PatchOption) error {
+	return nil
+}
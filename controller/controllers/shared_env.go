@@ -0,0 +1,178 @@
+package controllers
+
+import (
+	"context"
+	"crypto/md5"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/kalmhq/kalm/controller/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// pods will be restarted when the value of this annotation is changed
+const AnnoSharedEnvHash = "core.kalm.dev/shared-env-hash"
+
+// env vars referencing secret values of shared envs with a prefix or suffix
+const sharedEnvSecretEnvPrefix = "KALM_SHARED_ENV_"
+
+type sharedEnvSet struct {
+	configMap *corev1.ConfigMap
+	secret    *corev1.Secret
+}
+
+func isSharedEnvSet(labels map[string]string) bool {
+	return labels[v1alpha1.KalmLabelSharedEnvKey] == "true"
+}
+
+// loadSharedEnvSet finds the shared env set in the namespace of the component,
+// a ConfigMap takes precedence over a Secret with the same name.
+func (r *ComponentReconcilerTask) loadSharedEnvSet(name string) (*sharedEnvSet, error) {
+	if set, exist := r.sharedEnvSets[name]; exist {
+		return set, nil
+	}
+
+	key := types.NamespacedName{Namespace: r.component.Namespace, Name: name}
+
+	var configMap corev1.ConfigMap
+	if err := r.Reader.Get(r.ctx, key, &configMap); err != nil {
+		if !errors.IsNotFound(err) {
+			return nil, err
+		}
+	} else if isSharedEnvSet(configMap.Labels) {
+		set := &sharedEnvSet{configMap: &configMap}
+		r.sharedEnvSets[name] = set
+		return set, nil
+	}
+
+	var secret corev1.Secret
+	if err := r.Reader.Get(r.ctx, key, &secret); err != nil {
+		if !errors.IsNotFound(err) {
+			return nil, err
+		}
+	} else if isSharedEnvSet(secret.Labels) {
+		set := &sharedEnvSet{secret: &secret}
+		r.sharedEnvSets[name] = set
+		return set, nil
+	}
+
+	return nil, fmt.Errorf("shared env %s not found in namespace %s", name, r.component.Namespace)
+}
+
+// getValueOfExternalEnv resolves an env var of type external.
+// Values of a ConfigMap are inlined, values of a Secret are referenced so they won't appear in the pod template.
+func (r *ComponentReconcilerTask) getValueOfExternalEnv(env v1alpha1.EnvVar) (string, *corev1.EnvVarSource, error) {
+	setName, key := v1alpha1.ParseExternalEnvValue(env)
+
+	set, err := r.loadSharedEnvSet(setName)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if set.configMap != nil {
+		value, exist := set.configMap.Data[key]
+		if !exist {
+			return "", nil, fmt.Errorf("key %s not found in shared env %s", key, setName)
+		}
+
+		return fmt.Sprintf("%s%s%s", env.Prefix, value, env.Suffix), nil, nil
+	}
+
+	value, exist := set.secret.Data[key]
+	if !exist {
+		return "", nil, fmt.Errorf("key %s not found in shared env %s", key, setName)
+	}
+
+	r.sharedEnvHashSources = append(r.sharedEnvHashSources, fmt.Sprintf("%s/%s=%x", setName, key, md5.Sum(value)))
+
+	return "", &corev1.EnvVarSource{
+		SecretKeyRef: &corev1.SecretKeySelector{
+			LocalObjectReference: corev1.LocalObjectReference{Name: set.secret.Name},
+			Key:                  key,
+		},
+	}, nil
+}
+
+// buildAffixedSecretEnv applies the prefix and suffix of an env var backed by a secret.
+// The secret value is referenced by another env var, which is expanded by kubernetes in the returned value.
+func buildAffixedSecretEnv(env v1alpha1.EnvVar, source *corev1.EnvVarSource) (corev1.EnvVar, string) {
+	secretEnv := corev1.EnvVar{
+		Name:      sharedEnvSecretEnvPrefix + env.Name,
+		ValueFrom: source,
+	}
+
+	// "$$" is expanded to "$", so prefix and suffix are kept as they are
+	escape := func(s string) string {
+		return strings.ReplaceAll(s, "$", "$$")
+	}
+
+	return secretEnv, fmt.Sprintf("%s$(%s)%s", escape(env.Prefix), secretEnv.Name, escape(env.Suffix))
+}
+
+// getSharedEnvHash returns a digest of all secret values used by the component, or empty string if none is used.
+func (r *ComponentReconcilerTask) getSharedEnvHash() string {
+	if len(r.sharedEnvHashSources) == 0 {
+		return ""
+	}
+
+	sources := append([]string{}, r.sharedEnvHashSources...)
+	sort.Strings(sources)
+
+	return fmt.Sprintf("%x", md5.Sum([]byte(strings.Join(sources, ";"))))
+}
+
+func isComponentReferringSharedEnvSet(component *v1alpha1.Component, setName string) bool {
+	for _, env := range component.Spec.GetAllEnvs() {
+		if env.Type != v1alpha1.EnvVarTypeExternal {
+			continue
+		}
+
+		if name, _ := v1alpha1.ParseExternalEnvValue(env); name == setName {
+			return true
+		}
+	}
+
+	return false
+}
+
+// SharedEnvMapper enqueues all components referring a shared env set when the set is changed.
+type SharedEnvMapper struct {
+	*BaseReconciler
+}
+
+func (r *SharedEnvMapper) Map(object handler.MapObject) []reconcile.Request {
+	if !isSharedEnvSet(object.Meta.GetLabels()) {
+		return nil
+	}
+
+	var componentList v1alpha1.ComponentList
+	if err := r.Reader.List(context.Background(), &componentList, client.InNamespace(object.Meta.GetNamespace())); err != nil {
+		r.Log.Error(err, "Can't list components in shared env mapper.")
+		return nil
+	}
+
+	var res []reconcile.Request
+
+	for i := range componentList.Items {
+		component := &componentList.Items[i]
+
+		if !isComponentReferringSharedEnvSet(component, object.Meta.GetName()) {
+			continue
+		}
+
+		res = append(res, reconcile.Request{
+			NamespacedName: types.NamespacedName{
+				Name:      component.Name,
+				Namespace: component.Namespace,
+			},
+		})
+	}
+
+	return res
+}
@@ -0,0 +1,62 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kalmhq/kalm/controller/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestBuildContainerEnvsOfSharedEnvs(t *testing.T) {
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metaV1.ObjectMeta{
+			Name:      "database",
+			Namespace: "default",
+			Labels:    map[string]string{v1alpha1.KalmLabelSharedEnvKey: "true"},
+		},
+		Data: map[string]string{"DATABASE_HOST": "db"},
+	}
+
+	secret := &corev1.Secret{
+		ObjectMeta: metaV1.ObjectMeta{
+			Name:      "credentials",
+			Namespace: "default",
+			Labels:    map[string]string{v1alpha1.KalmLabelSharedEnvKey: "true"},
+		},
+		Data: map[string][]byte{"password": []byte("foo")},
+	}
+
+	c := fake.NewFakeClientWithScheme(newExportScheme(), configMap, secret)
+
+	task := &ComponentReconcilerTask{
+		ComponentReconciler: &ComponentReconciler{
+			BaseReconciler: &BaseReconciler{Client: c, Reader: c, Recorder: record.NewFakeRecorder(100)},
+		},
+		ctx:           context.Background(),
+		component:     &v1alpha1.Component{ObjectMeta: metaV1.ObjectMeta{Name: "web", Namespace: "default"}},
+		sharedEnvSets: make(map[string]*sharedEnvSet),
+	}
+
+	envs, err := task.buildContainerEnvs([]v1alpha1.EnvVar{
+		{Name: "DATABASE_HOST", Value: "database", Type: v1alpha1.EnvVarTypeExternal, Prefix: "tcp://", Suffix: ":5432"},
+		{Name: "PASSWORD", Value: "credentials/password", Type: v1alpha1.EnvVarTypeExternal},
+		{Name: "DATABASE_URL", Value: "credentials/password", Type: v1alpha1.EnvVarTypeExternal, Prefix: "postgres://$user:", Suffix: "@db"},
+	})
+
+	assert.Nil(t, err)
+	assert.Len(t, envs, 4)
+	assert.Equal(t, "tcp://db:5432", envs[0].Value)
+	assert.Equal(t, "credentials", envs[1].ValueFrom.SecretKeyRef.Name)
+
+	// the secret value is referenced by another env var and expanded with the prefix and suffix
+	assert.Equal(t, "KALM_SHARED_ENV_DATABASE_URL", envs[2].Name)
+	assert.Equal(t, "password", envs[2].ValueFrom.SecretKeyRef.Key)
+	assert.Equal(t, "DATABASE_URL", envs[3].Name)
+	assert.Equal(t, "postgres://$$user:$(KALM_SHARED_ENV_DATABASE_URL)@db", envs[3].Value)
+	assert.Nil(t, envs[3].ValueFrom)
+}
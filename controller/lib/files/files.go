@@ -2,6 +2,7 @@ package files
 
 import (
 	"fmt"
+	"regexp"
 	"sort"
 	"strings"
 
@@ -14,8 +15,6 @@ type File struct {
 	Content string `json:"content"`
 }
 
-// TODO validate path
-
 // will use this a config-map called kalm-files to store files in each namespace
 const KALM_CONFIG_MAP_NAME = "kalm-files"
 
@@ -28,6 +27,58 @@ const KALM_PERSISTENT_DIR_PLACEHOLDER = "__PDIR__"
 // config map key can't include "/", use this replace "/" in key
 const KALM_SLASH_REPLACER = "__D__"
 
+// the content of a single file can't be larger than this
+const MaxFileSize = 256 * 1024
+
+// a config map can't be larger than 1MiB, leave some room for the metadata
+const MaxTotalSize = 900 * 1024
+
+// same charset as config map keys
+var fileNameRegexp = regexp.MustCompile(`^[-._a-zA-Z0-9]+$`)
+
+// ValidatePath checks if the path can be stored as a config map key.
+// A valid path starts with "/" and each part of it is a valid file name.
+func ValidatePath(path string) error {
+	if !strings.HasPrefix(path, "/") {
+		return fmt.Errorf("path %s must start with /", path)
+	}
+
+	if path == "/" {
+		return nil
+	}
+
+	for _, part := range strings.Split(path[1:], "/") {
+		if part == "" || part == "." || part == ".." {
+			return fmt.Errorf("path %s contains an invalid part \"%s\"", path, part)
+		}
+
+		if !fileNameRegexp.MatchString(part) || strings.Contains(part, KALM_SLASH_REPLACER) {
+			return fmt.Errorf("invalid file name %s in path %s, only alphanumeric characters, '-', '_' or '.' are allowed", part, path)
+		}
+	}
+
+	return nil
+}
+
+// CheckSize makes sure the files in the config map don't exceed the size limits.
+func CheckSize(configMap *coreV1.ConfigMap) error {
+	total := 0
+
+	for key, content := range configMap.Data {
+		if len(content) > MaxFileSize {
+			return fmt.Errorf("file %s is larger than %d bytes", DecodeFilePath(key), MaxFileSize)
+		}
+
+		total += len(key) + len(content)
+	}
+
+	if total > MaxTotalSize {
+		return fmt.Errorf("total size of files is larger than %d bytes", MaxTotalSize)
+	}
+
+	return nil
+}
+
 type FileItem struct {
 	Name     string      `json:"name"`
 	AbsPath  string      `json:"path"`
@@ -200,7 +251,9 @@ func GetFileItemTree(configMap *coreV1.ConfigMap, basePath string) (*FileItem, e
 	}
 
 	for encodedFilePath := range configMap.Data {
-		if strings.HasPrefix(encodedFilePath, encodedBasePath) {
+		// don't match siblings sharing the same prefix, e.g. /ab for /a
+		if encodedFilePath == encodedBasePath ||
+			strings.HasPrefix(encodedFilePath, strings.TrimSuffix(encodedBasePath, KALM_SLASH_REPLACER)+KALM_SLASH_REPLACER) {
 			filePaths = append(filePaths, encodedFilePath)
 		}
 	}
@@ -281,3 +334,18 @@ func ResolveMountPaths(mountPaths map[string]map[string]bool, baseMountPath stri
 		mountPaths[baseMountPath][root.AbsPath] = true
 	}
 }
+
+// ListFiles returns all files under the root, dirs are not included.
+func ListFiles(root *FileItem) []*FileItem {
+	if !root.IsDir {
+		return []*FileItem{root}
+	}
+
+	var res []*FileItem
+
+	for _, child := range root.Children {
+		res = append(res, ListFiles(child)...)
+	}
+
+	return res
+}
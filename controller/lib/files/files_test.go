@@ -4,6 +4,7 @@ import (
 	"github.com/stretchr/testify/suite"
 	coreV1 "k8s.io/api/core/v1"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"strings"
 	"testing"
 )
 
@@ -94,6 +95,38 @@ func (suite *FilesTestSuite) TestResolveMountPaths() {
 	// }
 }
 
+func (suite *FilesTestSuite) TestGetFileItemTreeOfSubDir() {
+	suite.Nil(AddFile(suite.cm, &File{Path: "/a/foo", Content: "foo"}))
+	suite.Nil(AddFile(suite.cm, &File{Path: "/ab/bar", Content: "bar"}))
+
+	root, err := GetFileItemTree(suite.cm, "/a")
+	suite.Nil(err)
+	suite.Equal(1, len(root.Children))
+
+	files := ListFiles(root)
+	suite.Equal(1, len(files))
+	suite.Equal("/a/foo", files[0].AbsPath)
+}
+
+func (suite *FilesTestSuite) TestValidatePath() {
+	suite.Nil(ValidatePath("/"))
+	suite.Nil(ValidatePath("/nginx/conf.d/default.conf"))
+	suite.NotNil(ValidatePath("nginx.conf"))
+	suite.NotNil(ValidatePath("/nginx//default.conf"))
+	suite.NotNil(ValidatePath("/nginx/../default.conf"))
+	suite.NotNil(ValidatePath("/nginx/"))
+	suite.NotNil(ValidatePath("/nginx/default conf"))
+	suite.NotNil(ValidatePath("/nginx/a__D__b"))
+}
+
+func (suite *FilesTestSuite) TestCheckSize() {
+	suite.Nil(AddFile(suite.cm, &File{Path: "/small", Content: "content"}))
+	suite.Nil(CheckSize(suite.cm))
+
+	suite.Nil(AddFile(suite.cm, &File{Path: "/large", Content: strings.Repeat("a", MaxFileSize+1)}))
+	suite.NotNil(CheckSize(suite.cm))
+}
+
 func TestFilesTestSuite(t *testing.T) {
 	suite.Run(t, new(FilesTestSuite))
 }
@@ -0,0 +1,181 @@
+package istiometric
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"time"
+)
+
+// address of the prometheus installed with istio
+var PrometheusAPIAddress = "http://prometheus.istio-system:9090"
+
+// queries run inside reconciles, so they must not block them
+var prometheusClient = &http.Client{Timeout: 10 * time.Second}
+
+func init() {
+	if os.Getenv("KALM_ISTIO_PROMETHEUS_API_ADDRESS") != "" {
+		PrometheusAPIAddress = os.Getenv("KALM_ISTIO_PROMETHEUS_API_ADDRESS")
+	}
+}
+
+type PromResponse struct {
+	Status string `json:"status"`
+	Data   struct {
+		ResultType string             `json:"resultType"`
+		Result     []PromMatrixResult `json:"result"`
+	} `json:"data"`
+}
+
+type PromMatrixResult struct {
+	Metric map[string]string `json:"metric"`
+	Values [][]interface{}   `json:"values,string"`
+
+	// only for instant queries
+	Value []interface{} `json:"value,omitempty"`
+}
+
+func QueryRange(ctx context.Context, query string, start, end int64, step int) (PromResponse, error) {
+	api := fmt.Sprintf("%s/api/v1/query_range?query=%s&start=%d&end=%d&step=%d",
+		PrometheusAPIAddress,
+		url.QueryEscape(query),
+		start,
+		end,
+		step,
+	)
+
+	return queryPrometheusAPI(ctx, api)
+}
+
+func Query(ctx context.Context, query string) (PromResponse, error) {
+	api := fmt.Sprintf("%s/api/v1/query?query=%s", PrometheusAPIAddress, url.QueryEscape(query))
+	return queryPrometheusAPI(ctx, api)
+}
+
+func queryPrometheusAPI(ctx context.Context, api string) (PromResponse, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, api, nil)
+	if err != nil {
+		return PromResponse{}, err
+	}
+
+	resp, err := prometheusClient.Do(req)
+	if err != nil {
+		return PromResponse{}, err
+	}
+
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return PromResponse{}, err
+	}
+
+	var promResp PromResponse
+	if err := json.Unmarshal(body, &promResp); err != nil {
+		return PromResponse{}, fmt.Errorf("fail to parse resp from prometheus: %s", err)
+	}
+
+	if promResp.Status != "success" {
+		return promResp, fmt.Errorf("prometheus query failed, status: %s", promResp.Status)
+	}
+
+	return promResp, nil
+}
+
+// queryScalar returns the value of the first sample of an instant query.
+// The bool is false if the query has no result, e.g. there is no traffic at all.
+func queryScalar(ctx context.Context, query string) (float64, bool, error) {
+	resp, err := Query(ctx, query)
+	if err != nil {
+		return 0, false, err
+	}
+
+	if len(resp.Data.Result) == 0 || len(resp.Data.Result[0].Value) != 2 {
+		return 0, false, nil
+	}
+
+	valInStr, ok := resp.Data.Result[0].Value[1].(string)
+	if !ok {
+		return 0, false, nil
+	}
+
+	val, err := strconv.ParseFloat(valInStr, 64)
+	// NaN is returned when it's divided by zero requests
+	if err != nil || math.IsNaN(val) {
+		return 0, false, nil
+	}
+
+	return val, true, nil
+}
+
+// ServiceVersionMetrics are metrics of pods of a version of a service reported by istio in a time window.
+type ServiceVersionMetrics struct {
+	HasTraffic             bool
+	RequestsPerSecond      float64
+	ErrorRatePercentage    float64
+	P99LatencyMilliseconds float64
+}
+
+// GetServiceVersionMetrics queries metrics of pods with the version label behind the service host,
+// e.g. foo.bar.svc.cluster.local.
+func GetServiceVersionMetrics(ctx context.Context, serviceHost, version string, window time.Duration) (*ServiceVersionMetrics, error) {
+	selector := fmt.Sprintf(`reporter="destination",destination_service="%s",destination_version="%s"`, serviceHost, version)
+	rangeInSeconds := int64(window / time.Second)
+
+	rps, hasTraffic, err := queryScalar(ctx, fmt.Sprintf(
+		`sum(rate(istio_requests_total{%s}[%ds]))`,
+		selector, rangeInSeconds,
+	))
+
+	if err != nil {
+		return nil, err
+	}
+
+	metrics := &ServiceVersionMetrics{
+		HasTraffic:        hasTraffic && rps > 0,
+		RequestsPerSecond: rps,
+	}
+
+	if !metrics.HasTraffic {
+		return metrics, nil
+	}
+
+	errorRate, _, err := queryScalar(ctx, fmt.Sprintf(
+		`sum(rate(istio_requests_total{%s,response_code=~"5.."}[%ds])) / sum(rate(istio_requests_total{%s}[%ds])) * 100`,
+		selector, rangeInSeconds, selector, rangeInSeconds,
+	))
+
+	if err != nil {
+		return nil, err
+	}
+
+	latency, _, err := queryScalar(ctx, fmt.Sprintf(
+		`histogram_quantile(0.99, sum(rate(istio_request_duration_milliseconds_bucket{%s}[%ds])) by (le))`,
+		selector, rangeInSeconds,
+	))
+
+	if err != nil {
+		return nil, err
+	}
+
+	metrics.ErrorRatePercentage = errorRate
+	metrics.P99LatencyMilliseconds = latency
+
+	return metrics, nil
+}
+
+// GetServiceRequestCount returns the number of requests received by pods behind the service host in a time window.
+func GetServiceRequestCount(ctx context.Context, serviceHost string, window time.Duration) (float64, error) {
+	count, _, err := queryScalar(ctx, fmt.Sprintf(
+		`sum(increase(istio_requests_total{reporter="destination",destination_service="%s"}[%ds]))`,
+		serviceHost, int64(window/time.Second),
+	))
+
+	return count, err
+}
@@ -19,6 +19,9 @@ import (
 	"flag"
 	"os"
 
+	// time zones of components are loaded in the distroless image which has no zoneinfo
+	_ "time/tzdata"
+
 	monitoringv1 "github.com/coreos/prometheus-operator/pkg/apis/monitoring/v1"
 	elkv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
 	kibanav1 "github.com/elastic/cloud-on-k8s/pkg/apis/kibana/v1"